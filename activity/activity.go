@@ -0,0 +1,329 @@
+// Package activity records what users do in an application, like "user 1 commented on post 7",
+// in the activities table, and reads it back as paginated feeds. Who did it, what it was done to
+// and where are polymorphic references, a type and an id, so one table holds every kind of model
+package activity
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/database"
+	"github.com/robfig/cron/v3"
+)
+
+// Ref refers to a model of any type, e.g. Ref{Type: "post", ID: 7}
+type Ref = database.Ref
+
+// Referencer is implemented by models that choose how activities refer to them
+type Referencer = database.Referencer
+
+// RefTo returns the reference to v, see database.RefTo
+func RefTo(v interface{}) (Ref, error) {
+	return database.RefTo(v)
+}
+
+// Activity is something an actor did. Subject is what it was done to and Target, if anything,
+// where it happened: "user 1 added photo 4 to album 2" has the actor user 1, the verb added, the
+// subject photo 4 and the target album 2
+type Activity struct {
+	ID         int64                  `json:"id"`
+	Actor      Ref                    `json:"actor"`
+	Verb       string                 `json:"verb"`
+	Subject    Ref                    `json:"subject"`
+	Target     Ref                    `json:"target"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// String describes the activity, e.g. user 1 commented on post 7
+func (a Activity) String() string {
+	s := a.Actor.String() + " " + a.Verb + " " + a.Subject.String()
+	if !a.Target.IsZero() {
+		s += " in " + a.Target.String()
+	}
+
+	return s
+}
+
+// Logger records activities in the table Table, activities when it is empty
+type Logger struct {
+	DB       *sql.DB
+	DataType string
+	Table    string
+	ErrorLog *log.Logger
+}
+
+// New returns a logger for the activities table of db, which is of the given DATABASE_TYPE
+func New(db *sql.DB, dataType string) *Logger {
+	return &Logger{
+		DB:       db,
+		DataType: dataType,
+		ErrorLog: log.New(os.Stderr, "ERROR\t", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+// Entry is an activity being put together, see Logger.Log
+type Entry struct {
+	logger   *Logger
+	activity Activity
+	err      error
+}
+
+// Log starts recording an activity with the verb, finished by Save:
+//
+//	app.Activity.Log("commented").By(user).On(post).With("excerpt", text).Save(ctx)
+func (l *Logger) Log(verb string) *Entry {
+	return &Entry{logger: l, activity: Activity{Verb: verb, Properties: map[string]interface{}{}}}
+}
+
+// By sets who did it, a Ref or a model, see RefTo
+func (e *Entry) By(actor interface{}) *Entry {
+	e.activity.Actor = e.ref(actor)
+	return e
+}
+
+// On sets what it was done to
+func (e *Entry) On(subject interface{}) *Entry {
+	e.activity.Subject = e.ref(subject)
+	return e
+}
+
+// In sets where it happened
+func (e *Entry) In(target interface{}) *Entry {
+	e.activity.Target = e.ref(target)
+	return e
+}
+
+// With adds a property, stored as JSON with the activity
+func (e *Entry) With(key string, value interface{}) *Entry {
+	e.activity.Properties[key] = value
+	return e
+}
+
+// At sets when it happened, now unless it is set
+func (e *Entry) At(t time.Time) *Entry {
+	e.activity.CreatedAt = t
+	return e
+}
+
+func (e *Entry) ref(v interface{}) Ref {
+	ref, err := RefTo(v)
+	if err != nil && e.err == nil {
+		e.err = err
+	}
+
+	return ref
+}
+
+// Save stores the activity and returns it with its ID
+func (e *Entry) Save(ctx context.Context) (Activity, error) {
+	a := e.activity
+	if e.err != nil {
+		return a, e.err
+	}
+
+	if a.Verb == "" || a.Actor.IsZero() || a.Subject.IsZero() {
+		return a, errors.New("an activity needs a verb, an actor and a subject")
+	}
+
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+
+	properties, err := json.Marshal(a.Properties)
+	if err != nil {
+		return a, err
+	}
+
+	l := e.logger
+	query := l.query("INSERT INTO %s (actor_type, actor_id, verb, subject_type, subject_id, target_type, target_id, properties, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	args := []interface{}{a.Actor.Type, a.Actor.ID, a.Verb, a.Subject.Type, a.Subject.ID, a.Target.Type, a.Target.ID, string(properties), a.CreatedAt}
+
+	if database.Postgres(l.DataType) {
+		err = l.DB.QueryRowContext(ctx, query+" RETURNING id", args...).Scan(&a.ID)
+		return a, err
+	}
+
+	res, err := l.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return a, err
+	}
+
+	a.ID, err = res.LastInsertId()
+
+	return a, err
+}
+
+// Filter chooses the activities of a feed. Zero fields match everything, so Filter{Actors:
+// following} is what the followed users did and Filter{Target: group} what happened in a group
+type Filter struct {
+	Actors  []Ref
+	Subject Ref
+	Target  Ref
+	Verbs   []string
+}
+
+// Page is one page of a feed, newest first
+type Page struct {
+	Activities []Activity `json:"activities"`
+	Page       int        `json:"page"`
+	PerPage    int        `json:"per_page"`
+	HasMore    bool       `json:"has_more"`
+}
+
+// DefaultPerPage is the page size of feeds asked for without one
+const DefaultPerPage = 20
+
+// Feed returns the page, counted from 1, of the activities matching the filter, newest first
+func (l *Logger) Feed(ctx context.Context, f Filter, page, perPage int) (*Page, error) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = DefaultPerPage
+	}
+
+	var where []string
+	var args []interface{}
+
+	if len(f.Actors) > 0 {
+		actors := make([]string, len(f.Actors))
+		for i, actor := range f.Actors {
+			actors[i] = "(actor_type = ? AND actor_id = ?)"
+			args = append(args, actor.Type, actor.ID)
+		}
+		where = append(where, "("+strings.Join(actors, " OR ")+")")
+	}
+
+	if !f.Subject.IsZero() {
+		where = append(where, "subject_type = ? AND subject_id = ?")
+		args = append(args, f.Subject.Type, f.Subject.ID)
+	}
+
+	if !f.Target.IsZero() {
+		where = append(where, "target_type = ? AND target_id = ?")
+		args = append(args, f.Target.Type, f.Target.ID)
+	}
+
+	if len(f.Verbs) > 0 {
+		where = append(where, "verb IN (?"+strings.Repeat(", ?", len(f.Verbs)-1)+")")
+		for _, verb := range f.Verbs {
+			args = append(args, verb)
+		}
+	}
+
+	query := "SELECT id, actor_type, actor_id, verb, subject_type, subject_id, target_type, target_id, properties, created_at FROM %s"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	// one row more than the page tells whether there is another page
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT %d OFFSET %d", perPage+1, (page-1)*perPage)
+
+	rows, err := l.DB.QueryContext(ctx, l.query(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	p := &Page{Activities: []Activity{}, Page: page, PerPage: perPage}
+	for rows.Next() {
+		var a Activity
+		var properties string
+
+		err = rows.Scan(&a.ID, &a.Actor.Type, &a.Actor.ID, &a.Verb, &a.Subject.Type, &a.Subject.ID,
+			&a.Target.Type, &a.Target.ID, &properties, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if properties != "" {
+			err = json.Unmarshal([]byte(properties), &a.Properties)
+			if err != nil {
+				return nil, fmt.Errorf("activity %d: %w", a.ID, err)
+			}
+		}
+
+		p.Activities = append(p.Activities, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(p.Activities) > perPage {
+		p.Activities = p.Activities[:perPage]
+		p.HasMore = true
+	}
+
+	return p, nil
+}
+
+// ByActor returns a page of what actor, a Ref or a model, did
+func (l *Logger) ByActor(ctx context.Context, actor interface{}, page, perPage int) (*Page, error) {
+	ref, err := RefTo(actor)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Feed(ctx, Filter{Actors: []Ref{ref}}, page, perPage)
+}
+
+// About returns a page of what was done to subject, a Ref or a model
+func (l *Logger) About(ctx context.Context, subject interface{}, page, perPage int) (*Page, error) {
+	ref, err := RefTo(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Feed(ctx, Filter{Subject: ref}, page, perPage)
+}
+
+// Within returns a page of what happened in target, a Ref or a model
+func (l *Logger) Within(ctx context.Context, target interface{}, page, perPage int) (*Page, error) {
+	ref, err := RefTo(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Feed(ctx, Filter{Target: ref}, page, perPage)
+}
+
+// Prune deletes the activities older than keep, and returns how many it deleted
+func (l *Logger) Prune(ctx context.Context, keep time.Duration) (int64, error) {
+	res, err := l.DB.ExecContext(ctx, l.query("DELETE FROM %s WHERE created_at < ?"), time.Now().Add(-keep))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// PruneOn schedules Prune with the cron spec, e.g. "@daily" on app.Scheduler, logging its errors
+func (l *Logger) PruneOn(scheduler *cron.Cron, spec string, keep time.Duration) error {
+	_, err := scheduler.AddFunc(spec, func() {
+		_, err := l.Prune(context.Background(), keep)
+		if err != nil && l.ErrorLog != nil {
+			l.ErrorLog.Println("pruning activities:", err)
+		}
+	})
+
+	return err
+}
+
+// query puts the table into a query and numbers its placeholders for postgres
+func (l *Logger) query(format string) string {
+	table := l.Table
+	if table == "" {
+		table = "activities"
+	}
+
+	return database.Rebind(l.DataType, fmt.Sprintf(format, table))
+}
@@ -0,0 +1,127 @@
+package activity
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type blogPost struct {
+	ID    int
+	Title string
+}
+
+type member struct{ id int64 }
+
+func (m member) Ref() Ref { return Ref{Type: "user", ID: m.id} }
+
+func newLogger(t *testing.T, dataType string) (*Logger, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return New(db, dataType), mock
+}
+
+func TestRefTo(t *testing.T) {
+	tests := []struct {
+		v        interface{}
+		expected Ref
+	}{
+		{Ref{Type: "post", ID: 7}, Ref{Type: "post", ID: 7}},
+		{&blogPost{ID: 3}, Ref{Type: "blog_post", ID: 3}},
+		{member{id: 1}, Ref{Type: "user", ID: 1}},
+	}
+
+	for _, e := range tests {
+		ref, err := RefTo(e.v)
+		if err != nil || ref != e.expected {
+			t.Errorf("RefTo(%#v): expected %v, got %v, %v", e.v, e.expected, ref, err)
+		}
+	}
+
+	for _, v := range []interface{}{nil, (*blogPost)(nil), "post", struct{ Name string }{}} {
+		if _, err := RefTo(v); err == nil {
+			t.Errorf("RefTo(%#v): expected an error", v)
+		}
+	}
+}
+
+func TestEntry_Save(t *testing.T) {
+	l, mock := newLogger(t, "pgx")
+
+	mock.ExpectQuery(`INSERT INTO activities \(actor_type, actor_id, verb, subject_type, subject_id, target_type, target_id, properties, created_at\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9\) RETURNING id`).
+		WithArgs("user", int64(1), "commented", "blog_post", int64(7), "", int64(0), `{"excerpt":"Nice"}`, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+
+	a, err := l.Log("commented").By(member{id: 1}).On(&blogPost{ID: 7}).With("excerpt", "Nice").Save(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.ID != 12 || a.String() != "user 1 commented blog_post 7" {
+		t.Errorf("unexpected activity %d: %s", a.ID, a)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEntry_Save_Invalid(t *testing.T) {
+	l, _ := newLogger(t, "mysql")
+
+	if _, err := l.Log("commented").By(member{id: 1}).Save(context.Background()); err == nil {
+		t.Error("expected an error without a subject")
+	}
+
+	if _, err := l.Log("commented").By("someone").On(Ref{Type: "post", ID: 1}).Save(context.Background()); err == nil {
+		t.Error("expected an error for an actor that cannot be referred to")
+	}
+}
+
+func TestLogger_Feed(t *testing.T) {
+	l, mock := newLogger(t, "mysql")
+	now := time.Now()
+
+	columns := []string{"id", "actor_type", "actor_id", "verb", "subject_type", "subject_id", "target_type", "target_id", "properties", "created_at"}
+	mock.ExpectQuery(`SELECT .* FROM activities WHERE \(\(actor_type = \? AND actor_id = \?\) OR \(actor_type = \? AND actor_id = \?\)\) AND verb IN \(\?, \?\) ORDER BY created_at DESC, id DESC LIMIT 3 OFFSET 2`).
+		WithArgs("user", int64(1), "user", int64(2), "commented", "liked").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(9, "user", 1, "commented", "post", 7, "", 0, `{"excerpt":"Nice"}`, now).
+			AddRow(8, "user", 2, "liked", "post", 7, "", 0, `{}`, now).
+			AddRow(7, "user", 2, "liked", "post", 6, "", 0, `{}`, now))
+
+	f := Filter{Actors: []Ref{{Type: "user", ID: 1}, {Type: "user", ID: 2}}, Verbs: []string{"commented", "liked"}}
+	page, err := l.Feed(context.Background(), f, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page.Activities) != 2 || !page.HasMore || page.Page != 2 {
+		t.Errorf("expected a full page with more after it, got %+v", page)
+	}
+
+	if page.Activities[0].Properties["excerpt"] != "Nice" {
+		t.Errorf("expected the properties to be read, got %v", page.Activities[0].Properties)
+	}
+}
+
+func TestLogger_Prune(t *testing.T) {
+	l, mock := newLogger(t, "postgres")
+	l.Table = "app_activities"
+
+	mock.ExpectExec(`DELETE FROM app_activities WHERE created_at < \$1`).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	n, err := l.Prune(context.Background(), 24*time.Hour)
+	if err != nil || n != 4 {
+		t.Errorf("expected 4 pruned activities, got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,159 @@
+package gemquick
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RateLimitResetter is a rate limiter whose buckets can be emptied, like the one of a throttling
+// middleware, by the key it counts requests under, e.g. a client's IP or API key
+type RateLimitResetter interface {
+	Reset(key string) error
+}
+
+// AdminRoutes returns endpoints for support staff to fix stuck clients without a deploy:
+//
+//	DELETE /rate-limits/{key}        resets the rate limit bucket of key in app.RateLimiter, app.Guard
+//	                                 unless it is set to another limiter, with the key path escaped
+//	DELETE /cache?prefix=products:   removes the cache entries with the prefix, or ?tag=products for products:*
+//	DELETE /users/{id}/sessions      logs the user out everywhere
+//
+// Every request is refused with 403 Forbidden unless authorize returns true for it. Mount them
+// at ADMIN_ROUTES_PATH, /admin/support when it is empty, behind the app's auth middleware, e.g.
+//
+//	a.App.Routes.With(a.Middleware.Auth).Mount("/admin/support", a.App.AdminRoutes(func(r *http.Request) bool {
+//		return a.App.Policies.Can(currentUser(r), "support", nil)
+//	}))
+//
+// NoSurf lets DELETE requests under ADMIN_ROUTES_PATH through without a CSRF token, so that ops
+// scripts and API clients can call them
+func (g *Gemquick) AdminRoutes(authorize func(r *http.Request) bool) http.Handler {
+	mux := chi.NewRouter()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if authorize == nil || !authorize(r) {
+				g.ErrorForbidden(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	mux.Delete("/rate-limits/{key}", g.adminResetRateLimit)
+	mux.Delete("/cache", g.adminFlushCache)
+	mux.Delete("/users/{id}/sessions", g.adminClearSessions)
+
+	return mux
+}
+
+func (g *Gemquick) adminResetRateLimit(w http.ResponseWriter, r *http.Request) {
+	if g.RateLimiter == nil {
+		g.ErrorStatus(w, http.StatusNotImplemented)
+		return
+	}
+
+	// keys like the ones of ratelimit.Key hold slashes, which come escaped
+	key, err := url.PathUnescape(chi.URLParam(r, "key"))
+	if err != nil {
+		g.ErrorStatus(w, http.StatusBadRequest)
+		return
+	}
+
+	if err := g.RateLimiter.Reset(key); err != nil {
+		g.ErrorLog.Printf("resetting the rate limit of %s: %v", key, err)
+		g.Error500(w, r)
+		return
+	}
+
+	g.InfoLog.Printf("reset the rate limit of %s", key)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (g *Gemquick) adminFlushCache(w http.ResponseWriter, r *http.Request) {
+	if g.Cache == nil {
+		g.ErrorStatus(w, http.StatusNotImplemented)
+		return
+	}
+
+	// a tag is the part of a key before its first colon, like products in products:42
+	pattern := r.URL.Query().Get("prefix")
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		pattern = tag + ":"
+	}
+
+	// flushing the whole cache takes a prefix of *, so that it is never done by accident
+	if pattern == "" {
+		g.ErrorStatus(w, http.StatusBadRequest)
+		return
+	}
+	if pattern != "*" {
+		pattern += "*"
+	}
+
+	if err := g.Cache.EmptyByMatch(pattern); err != nil {
+		g.ErrorLog.Printf("flushing the cache entries %s: %v", pattern, err)
+		g.Error500(w, r)
+		return
+	}
+
+	g.InfoLog.Printf("flushed the cache entries %s", pattern)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (g *Gemquick) adminClearSessions(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		g.Error404(w, r)
+		return
+	}
+
+	if g.Session == nil {
+		g.ErrorStatus(w, http.StatusNotImplemented)
+		return
+	}
+
+	n, err := g.DestroyUserSessions(r.Context(), id)
+	if err != nil {
+		g.ErrorLog.Printf("clearing the sessions of user %d: %v", id, err)
+		g.Error500(w, r)
+		return
+	}
+
+	g.InfoLog.Printf("cleared %d sessions of user %d", n, id)
+	_ = g.WriteJson(w, http.StatusOK, map[string]int{"sessions": n})
+}
+
+// DestroyUserSessions destroys every session logged in as the user, stored under userID as the
+// auth scaffolding does, and returns how many there were
+func (g *Gemquick) DestroyUserSessions(ctx context.Context, userID int) (int, error) {
+	if !sessionsEnabled() {
+		return 0, nil
+	}
+
+	n := 0
+	err := g.Session.Iterate(ctx, func(ctx context.Context) error {
+		if g.Session.GetInt(ctx, "userID") != userID {
+			return nil
+		}
+
+		n++
+		return g.Session.Destroy(ctx)
+	})
+
+	return n, err
+}
+
+// adminRoutesPath is where the app mounts AdminRoutes, which NoSurf asks no token of
+func adminRoutesPath() string {
+	if path := strings.TrimRight(os.Getenv("ADMIN_ROUTES_PATH"), "/"); path != "" {
+		return path
+	}
+
+	return "/admin/support"
+}
@@ -0,0 +1,149 @@
+package gemquick
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/jimmitjoo/gemquick/cache"
+	"github.com/jimmitjoo/gemquick/ratelimit"
+)
+
+type testLimiter struct{ reset []string }
+
+func (l *testLimiter) Reset(key string) error {
+	l.reset = append(l.reset, key)
+	return nil
+}
+
+type testCache struct {
+	cache.Cache
+	emptied []string
+}
+
+func (c *testCache) EmptyByMatch(pattern string) error {
+	c.emptied = append(c.emptied, pattern)
+	return nil
+}
+
+func TestAdminRoutes(t *testing.T) {
+	limiter := &testLimiter{}
+	c := &testCache{}
+	g := &Gemquick{
+		InfoLog:     log.New(io.Discard, "", 0),
+		ErrorLog:    log.New(io.Discard, "", 0),
+		Session:     scs.New(),
+		RateLimiter: limiter,
+		Cache:       c,
+	}
+
+	admin := true
+	mux := g.AdminRoutes(func(r *http.Request) bool { return admin })
+
+	do := func(target string) int {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("DELETE", target, nil))
+		return w.Code
+	}
+
+	if code := do("/rate-limits/10.0.0.1"); code != http.StatusNoContent || len(limiter.reset) != 1 || limiter.reset[0] != "10.0.0.1" {
+		t.Errorf("expected the bucket of 10.0.0.1 to be reset, got %d and %v", code, limiter.reset)
+	}
+
+	if code := do("/cache?tag=products"); code != http.StatusNoContent || c.emptied[0] != "products:*" {
+		t.Errorf("expected the products entries to be flushed, got %d and %v", code, c.emptied)
+	}
+
+	if code := do("/cache"); code != http.StatusBadRequest {
+		t.Errorf("expected flushing without a prefix to be refused, got %d", code)
+	}
+
+	admin = false
+	if code := do("/cache?prefix=*"); code != http.StatusForbidden || len(c.emptied) != 1 {
+		t.Errorf("expected 403 for someone who is not allowed, got %d", code)
+	}
+}
+
+func TestAdminRoutes_Guard(t *testing.T) {
+	g := &Gemquick{InfoLog: log.New(io.Discard, "", 0), ErrorLog: log.New(io.Discard, "", 0)}
+	g.Guard = ratelimit.NewGuard(1, time.Minute, time.Minute)
+	g.RateLimiter = g.Guard
+
+	login := httptest.NewRequest("POST", "/login", nil)
+	login.RemoteAddr = "10.0.0.1:5000"
+	key := ratelimit.Key(login, "bob@example.com")
+
+	g.Guard.Hit(key)
+	if ok, _ := g.Guard.Hit(key); ok {
+		t.Fatal("expected the second attempt to be locked out")
+	}
+
+	w := httptest.NewRecorder()
+	g.AdminRoutes(func(r *http.Request) bool { return true }).ServeHTTP(w, httptest.NewRequest("DELETE", "/rate-limits/"+url.PathEscape(key), nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 No Content, got %d", w.Code)
+	}
+
+	if ok, _ := g.Guard.Hit(key); !ok {
+		t.Error("expected the lockout to be lifted")
+	}
+}
+
+func TestAdminRoutes_NoSurf(t *testing.T) {
+	g := &Gemquick{ErrorLog: log.New(io.Discard, "", 0)}
+	handler := g.NoSurf(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, tt := range []struct {
+		method, target string
+		status         int
+	}{
+		{"DELETE", "/admin/support/cache?tag=products", http.StatusOK},
+		{"POST", "/admin/support/cache", http.StatusBadRequest},
+		{"DELETE", "/admin/supportive", http.StatusBadRequest},
+		{"DELETE", "/orders/1", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.target, tt.status, w.Code)
+		}
+	}
+}
+
+func TestDestroyUserSessions(t *testing.T) {
+	g := &Gemquick{Session: scs.New()}
+
+	login := func(userID int) string {
+		ctx, err := g.Session.Load(context.Background(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.Session.Put(ctx, "userID", userID)
+
+		token, _, err := g.Session.Commit(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	login(7)
+	login(7)
+	other := login(8)
+
+	n, err := g.DestroyUserSessions(context.Background(), 7)
+	if err != nil || n != 2 {
+		t.Fatalf("expected both sessions of user 7 to be destroyed, got %d, %v", n, err)
+	}
+
+	ctx, _ := g.Session.Load(context.Background(), other)
+	if g.Session.GetInt(ctx, "userID") != 8 {
+		t.Error("expected the session of user 8 to be left alone")
+	}
+}
@@ -0,0 +1,42 @@
+package gemquick
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/assetcache"
+)
+
+// createAssetCache returns the store for generated content in ASSET_CACHE_DIR, tmp/assets when it
+// is empty, which instances share when it is on a shared volume. Content not served for
+// ASSET_CACHE_MAX_AGE, 30 days when it is empty, is evicted on ASSET_CACHE_EVICT, hourly when it
+// is empty, and so is the content served longest ago once the store passes ASSET_CACHE_MAX_SIZE
+// megabytes, 1024 when it is empty
+func (g *Gemquick) createAssetCache(rootPath string) (*assetcache.Store, error) {
+	dir := os.Getenv("ASSET_CACHE_DIR")
+	if dir == "" {
+		dir = filepath.Join(rootPath, "tmp", "assets")
+	}
+
+	maxSize, err := strconv.ParseInt(os.Getenv("ASSET_CACHE_MAX_SIZE"), 10, 64)
+	if err != nil || maxSize <= 0 {
+		maxSize = 1024
+	}
+
+	maxAge, err := time.ParseDuration(os.Getenv("ASSET_CACHE_MAX_AGE"))
+	if err != nil || maxAge <= 0 {
+		maxAge = 30 * 24 * time.Hour
+	}
+
+	store := assetcache.New(dir, maxSize<<20, maxAge)
+	store.ErrorLog = g.ErrorLog
+
+	spec := os.Getenv("ASSET_CACHE_EVICT")
+	if spec == "" {
+		spec = "@hourly"
+	}
+
+	return store, store.EvictOn(g.Scheduler, spec)
+}
@@ -0,0 +1,288 @@
+// Package assetcache keeps content that is expensive to derive, like thumbnails, PDFs and exports,
+// in a directory under the hash of what it was derived from. Instances that share the directory
+// generate every piece of content once, and browsers and CDNs are told it never changes
+package assetcache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// tmpPrefix starts the names of files that are still being generated
+const tmpPrefix = ".tmp-"
+
+// Store keeps generated content in Dir. Content that was not served for MaxAge is evicted, and
+// the content served longest ago once Dir is larger than MaxBytes
+type Store struct {
+	Dir      string
+	MaxBytes int64
+	MaxAge   time.Duration
+	ErrorLog *log.Logger
+
+	mu       sync.Mutex
+	inflight map[string]*call
+}
+
+type call struct {
+	done chan struct{}
+	err  error
+}
+
+// New returns a store in dir, which is created once something is stored
+func New(dir string, maxBytes int64, maxAge time.Duration) *Store {
+	return &Store{Dir: dir, MaxBytes: maxBytes, MaxAge: maxAge, inflight: map[string]*call{}}
+}
+
+// Key returns the address of the content derived from parts, like the hash of a source image and
+// the size of its thumbnail. The same parts always give the same key
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		// the length keeps "ab", "c" apart from "a", "bc"
+		_ = binary.Write(h, binary.BigEndian, uint64(len(p)))
+		_, _ = io.WriteString(h, p)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Path returns where the content of key is kept, in a subdirectory so that no directory gets too
+// many files
+func (s *Store) Path(key string) string {
+	return filepath.Join(s.Dir, key[:2], key)
+}
+
+// Open returns the content of key, and generates it with generate when it is not stored yet.
+// Requests for a key that is being generated wait for it rather than generating it again
+func (s *Store) Open(key string, generate func(w io.Writer) error) (*os.File, error) {
+	if !validKey(key) {
+		return nil, errors.New("assetcache: keys are made with Key")
+	}
+
+	if f, err := s.open(key); err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return f, err
+	}
+
+	s.mu.Lock()
+	if s.inflight == nil {
+		s.inflight = map[string]*call{}
+	}
+	if c, ok := s.inflight[key]; ok {
+		s.mu.Unlock()
+		<-c.done
+		if c.err != nil {
+			return nil, c.err
+		}
+		return s.open(key)
+	}
+	c := &call{done: make(chan struct{})}
+	s.inflight[key] = c
+	s.mu.Unlock()
+
+	c.err = s.generate(key, generate)
+
+	s.mu.Lock()
+	delete(s.inflight, key)
+	s.mu.Unlock()
+	close(c.done)
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return s.open(key)
+}
+
+// open opens stored content, and marks it as served now so that it is evicted last
+func (s *Store) open(key string) (*os.File, error) {
+	path := s.Path(key)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	return f, nil
+}
+
+// generate writes the content to a temporary file that is renamed into place once it is
+// complete, so that other instances never serve half of it
+func (s *Store) generate(key string, generate func(w io.Writer) error) error {
+	dir := filepath.Dir(s.Path(key))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, tmpPrefix+key+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := generate(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.Path(key))
+}
+
+// Handler serves the content of the key for the request, generating it the first time. It is
+// served with the key as its ETag and as immutable, so the key must change with whatever the
+// content is derived from. A key of "" is 404 Not Found
+func (s *Store) Handler(contentType string, key func(r *http.Request) (string, error), generate func(w io.Writer, r *http.Request) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		k, err := key(r)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if k == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("ETag", `"`+k+`"`)
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+
+		// the content behind a key never changes, so there is nothing to look up
+		if strings.Contains(r.Header.Get("If-None-Match"), `"`+k+`"`) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		f, err := s.Open(k, func(w io.Writer) error {
+			return generate(w, r)
+		})
+		if err != nil {
+			if s.ErrorLog != nil {
+				s.ErrorLog.Println("assetcache: generating", k, err)
+			}
+			w.Header().Del("ETag")
+			w.Header().Del("Cache-Control")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+
+		stat, err := f.Stat()
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		http.ServeContent(w, r, "", stat.ModTime(), f)
+	})
+}
+
+// Evict removes the content that was not served for MaxAge, and then the content served longest
+// ago until Dir holds at most MaxBytes. It returns the number of files removed
+func (s *Store) Evict() (int, error) {
+	type entry struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var entries []entry
+	var total int64
+	removed := 0
+	now := time.Now()
+
+	err := filepath.WalkDir(s.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		// files being generated are left alone, unless they were left behind by a crash
+		if strings.HasPrefix(d.Name(), tmpPrefix) {
+			if now.Sub(info.ModTime()) > time.Hour && os.Remove(path) == nil {
+				removed++
+			}
+			return nil
+		}
+
+		if s.MaxAge > 0 && now.Sub(info.ModTime()) > s.MaxAge {
+			if os.Remove(path) == nil {
+				removed++
+			}
+			return nil
+		}
+
+		entries = append(entries, entry{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+
+	if s.MaxBytes > 0 && total > s.MaxBytes {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+		for _, e := range entries {
+			if total <= s.MaxBytes {
+				break
+			}
+			if os.Remove(e.path) == nil {
+				removed++
+				total -= e.size
+			}
+		}
+	}
+
+	return removed, nil
+}
+
+// EvictOn evicts content on the cron spec
+func (s *Store) EvictOn(scheduler *cron.Cron, spec string) error {
+	_, err := scheduler.AddFunc(spec, func() {
+		_, err := s.Evict()
+		if err != nil && s.ErrorLog != nil {
+			s.ErrorLog.Println("evicting cached assets:", err)
+		}
+	})
+
+	return err
+}
+
+// validKey reports whether key looks like one of Key, so that it cannot reach outside Dir
+func validKey(key string) bool {
+	if len(key) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(key)
+
+	return err == nil
+}
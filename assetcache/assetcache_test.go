@@ -0,0 +1,142 @@
+package assetcache
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKey(t *testing.T) {
+	if Key("ab", "c") == Key("a", "bc") {
+		t.Error("expected the parts to be kept apart")
+	}
+	if Key("photo", "200x200") != Key("photo", "200x200") {
+		t.Error("expected the same parts to give the same key")
+	}
+}
+
+func TestStore_Handler(t *testing.T) {
+	s := New(t.TempDir(), 0, 0)
+
+	var generated int32
+	handler := s.Handler("text/csv", func(r *http.Request) (string, error) {
+		if r.URL.Query().Get("report") == "" {
+			return "", nil
+		}
+		return Key("report", r.URL.Query().Get("report")), nil
+	}, func(w io.Writer, r *http.Request) error {
+		atomic.AddInt32(&generated, 1)
+		time.Sleep(10 * time.Millisecond)
+		_, err := io.WriteString(w, "id,total\n1,42\n")
+		return err
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "/exports?report=sales", nil))
+			if w.Code != http.StatusOK || w.Body.String() != "id,total\n1,42\n" {
+				t.Errorf("expected the export, got %d %q", w.Code, w.Body)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if generated != 1 {
+		t.Errorf("expected the export to be generated once, got %d", generated)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/exports?report=sales", nil))
+	etag := w.Header().Get("ETag")
+	if w.Header().Get("Cache-Control") != "public, max-age=31536000, immutable" || w.Header().Get("Content-Type") != "text/csv" || etag == "" {
+		t.Errorf("expected immutable csv with an etag, got %v", w.Header())
+	}
+
+	r := httptest.NewRequest("GET", "/exports?report=sales", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected 304 Not Modified, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/exports", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 Not Found without a key, got %d", w.Code)
+	}
+}
+
+func TestStore_OpenFails(t *testing.T) {
+	s := New(t.TempDir(), 0, 0)
+	key := Key("broken")
+
+	_, err := s.Open(key, func(w io.Writer) error {
+		_, _ = io.WriteString(w, "half")
+		return errors.New("out of memory")
+	})
+	if err == nil {
+		t.Fatal("expected the error of the generator")
+	}
+	if _, err := os.Stat(s.Path(key)); !os.IsNotExist(err) {
+		t.Error("expected nothing to be stored")
+	}
+
+	if _, err := s.Open("../../etc/passwd", nil); err == nil {
+		t.Error("expected a key that is not a hash to be refused")
+	}
+}
+
+func TestStore_Evict(t *testing.T) {
+	s := New(t.TempDir(), 10, time.Hour)
+
+	store := func(name, content string, age time.Duration) string {
+		key := Key(name)
+		f, err := s.Open(key, func(w io.Writer) error {
+			_, err := io.WriteString(w, content)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = f.Close()
+
+		when := time.Now().Add(-age)
+		_ = os.Chtimes(s.Path(key), when, when)
+		return s.Path(key)
+	}
+
+	expired := store("expired", "1", 2*time.Hour)
+	oldest := store("oldest", "123456", 30*time.Minute)
+	newest := store("newest", "123456", time.Minute)
+
+	crashed := filepath.Join(filepath.Dir(newest), tmpPrefix+"crashed")
+	_ = os.WriteFile(crashed, []byte("x"), 0644)
+	when := time.Now().Add(-2 * time.Hour)
+	_ = os.Chtimes(crashed, when, when)
+
+	removed, err := s.Evict()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 3 {
+		t.Errorf("expected 3 files to be removed, got %d", removed)
+	}
+
+	for path, kept := range map[string]bool{expired: false, oldest: false, newest: true, crashed: false} {
+		if _, err := os.Stat(path); (err == nil) != kept {
+			t.Errorf("expected %s to be kept: %v", filepath.Base(path), kept)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Package captcha verifies the tokens of solved Google reCAPTCHA v3, Cloudflare Turnstile and
+// hCaptcha challenges
+package captcha
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Provider is a captcha service. Its widget is put in a form, which then posts the token of the
+// solved challenge in FormField, and Verify asks the service whether the token is valid
+type Provider struct {
+	Name      string
+	SiteKey   string
+	Secret    string
+	Script    string
+	Class     string
+	FormField string
+	VerifyURL string
+	Client    *http.Client
+
+	// MinScore is the score from 0 to 1 a token of a scoring service like reCAPTCHA v3 needs,
+	// where bots score low. It is not checked for services without scores
+	MinScore float64
+	// Action is the action the token must have been made for, when it is set
+	Action string
+	// BypassToken passes without asking the service, for tests and local development. Never set
+	// it in production
+	BypassToken string
+}
+
+// Turnstile returns Cloudflare Turnstile with the site key and secret of the site
+func Turnstile(siteKey, secret string) *Provider {
+	return &Provider{
+		Name:      "turnstile",
+		SiteKey:   siteKey,
+		Secret:    secret,
+		Script:    "https://challenges.cloudflare.com/turnstile/v0/api.js",
+		Class:     "cf-turnstile",
+		FormField: "cf-turnstile-response",
+		VerifyURL: "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+	}
+}
+
+// HCaptcha returns hCaptcha with the site key and secret of the site
+func HCaptcha(siteKey, secret string) *Provider {
+	return &Provider{
+		Name:      "hcaptcha",
+		SiteKey:   siteKey,
+		Secret:    secret,
+		Script:    "https://js.hcaptcha.com/1/api.js",
+		Class:     "h-captcha",
+		FormField: "h-captcha-response",
+		VerifyURL: "https://api.hcaptcha.com/siteverify",
+	}
+}
+
+// ReCAPTCHA returns Google reCAPTCHA v3 with the site key and secret of the site. It shows no
+// challenge but scores the user, and tokens scoring below 0.5 fail
+func ReCAPTCHA(siteKey, secret string) *Provider {
+	return &Provider{
+		Name:      "recaptcha",
+		SiteKey:   siteKey,
+		Secret:    secret,
+		Script:    "https://www.google.com/recaptcha/api.js?render=" + url.QueryEscape(siteKey),
+		FormField: "g-recaptcha-response",
+		VerifyURL: "https://www.google.com/recaptcha/api/siteverify",
+		MinScore:  0.5,
+		Action:    "submit",
+	}
+}
+
+// New returns the provider named recaptcha, turnstile or hcaptcha
+func New(name, siteKey, secret string) (*Provider, error) {
+	switch strings.ToLower(name) {
+	case "recaptcha":
+		return ReCAPTCHA(siteKey, secret), nil
+	case "turnstile":
+		return Turnstile(siteKey, secret), nil
+	case "hcaptcha":
+		return HCaptcha(siteKey, secret), nil
+	}
+
+	return nil, fmt.Errorf("unknown captcha provider %s, choose recaptcha, turnstile or hcaptcha", name)
+}
+
+// Widget returns the html that shows the challenge in a form, nothing when p is nil. For reCAPTCHA
+// v3 it is a hidden field that a script keeps filled with a fresh token
+func (p *Provider) Widget() template.HTML {
+	if p == nil {
+		return ""
+	}
+
+	if p.Name == "recaptcha" {
+		// tokens expire after two minutes, so the field gets a fresh one every 90 seconds
+		return template.HTML(fmt.Sprintf(`<script src="%s"></script><input type="hidden" name="%s">`+
+			`<script>grecaptcha.ready(function(){function t(){grecaptcha.execute("%s",{action:"%s"}).then(function(v){document.querySelectorAll('input[name="%s"]').forEach(function(i){i.value=v})})}t();setInterval(t,90000)})</script>`,
+			template.HTMLEscapeString(p.Script), p.FormField,
+			template.JSEscapeString(p.SiteKey), template.JSEscapeString(p.Action), p.FormField))
+	}
+
+	return template.HTML(fmt.Sprintf(`<script src="%s" async defer></script><div class="%s" data-sitekey="%s"></div>`,
+		template.HTMLEscapeString(p.Script), template.HTMLEscapeString(p.Class), template.HTMLEscapeString(p.SiteKey)))
+}
+
+// Verify asks the service whether token is a solved challenge, passing the IP of the user along.
+// Tokens of scoring services must also reach MinScore and be made for Action
+func (p *Provider) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+
+	if p.BypassToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(p.BypassToken)) == 1 {
+		return true, nil
+	}
+
+	form := url.Values{"secret": {p.Secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.VerifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("%s answered %s", p.Name, resp.Status)
+	}
+
+	var result struct {
+		Success    bool     `json:"success"`
+		Score      *float64 `json:"score"`
+		Action     string   `json:"action"`
+		ErrorCodes []string `json:"error-codes"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, err
+	}
+
+	// a wrong secret is the site's fault, not the user's
+	for _, code := range result.ErrorCodes {
+		if code == "invalid-input-secret" || code == "missing-input-secret" {
+			return false, errors.New(p.Name + ": the secret is invalid")
+		}
+	}
+
+	if !result.Success {
+		return false, nil
+	}
+
+	if result.Score != nil && *result.Score < p.MinScore {
+		return false, nil
+	}
+
+	if p.Action != "" && result.Action != "" && result.Action != p.Action {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// VerifyRequest verifies the token posted with r, from the IP r came from
+func (p *Provider) VerifyRequest(r *http.Request, remoteIP string) (bool, error) {
+	return p.Verify(r.Context(), r.FormValue(p.FormField), remoteIP)
+}
+
+// Middleware refuses the requests to next that do not come with a solved captcha with
+// 403 Forbidden, for public forms like a contact form. errorLog gets the errors of the service
+// when it is set
+func (p *Provider) Middleware(errorLog func(v ...interface{})) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				ip = r.RemoteAddr
+			}
+
+			solved, err := p.VerifyRequest(r, ip)
+			if err != nil && errorLog != nil {
+				errorLog("verifying the captcha:", err)
+			}
+			if !solved {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
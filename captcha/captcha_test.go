@@ -0,0 +1,113 @@
+package captcha
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProvider_Verify(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("remoteip") != "10.0.0.1" {
+			t.Errorf("expected the user's IP, got %q", r.Form.Get("remoteip"))
+		}
+
+		switch {
+		case r.Form.Get("secret") != "secret":
+			_, _ = w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-secret"]}`))
+		case r.Form.Get("response") == "solved":
+			_, _ = w.Write([]byte(`{"success": true}`))
+		default:
+			_, _ = w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+		}
+	}))
+	defer srv.Close()
+
+	p := Turnstile("site", "secret")
+	p.VerifyURL = srv.URL
+
+	if ok, err := p.Verify(context.Background(), "solved", "10.0.0.1"); !ok || err != nil {
+		t.Errorf("expected a solved challenge to verify, got %v, %v", ok, err)
+	}
+
+	if ok, err := p.Verify(context.Background(), "guessed", "10.0.0.1"); ok || err != nil {
+		t.Errorf("expected a wrong token to fail without an error, got %v, %v", ok, err)
+	}
+
+	p.Secret = "wrong"
+	if _, err := p.Verify(context.Background(), "solved", "10.0.0.1"); err == nil {
+		t.Error("expected a wrong secret to be an error")
+	}
+}
+
+func TestProvider_Widget(t *testing.T) {
+	p, err := New("hcaptcha", "site-key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if widget := string(p.Widget()); !strings.Contains(widget, `<div class="h-captcha" data-sitekey="site-key">`) {
+		t.Errorf("unexpected widget %s", widget)
+	}
+
+	if _, err = New("friendlycaptcha", "", ""); err == nil {
+		t.Error("expected an unknown provider to be an error")
+	}
+}
+
+func TestReCAPTCHA_Score(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.FormValue("response") {
+		case "human":
+			_, _ = w.Write([]byte(`{"success": true, "score": 0.9, "action": "submit"}`))
+		case "bot":
+			_, _ = w.Write([]byte(`{"success": true, "score": 0.1, "action": "submit"}`))
+		default:
+			_, _ = w.Write([]byte(`{"success": true, "score": 0.9, "action": "login"}`))
+		}
+	}))
+	defer srv.Close()
+
+	p := ReCAPTCHA("site", "secret")
+	p.VerifyURL = srv.URL
+
+	for token, want := range map[string]bool{"human": true, "bot": false, "other-action": false} {
+		if ok, err := p.Verify(context.Background(), token, ""); ok != want || err != nil {
+			t.Errorf("%s: expected %v, got %v, %v", token, want, ok, err)
+		}
+	}
+}
+
+func TestProvider_Middleware(t *testing.T) {
+	p := Turnstile("site", "secret")
+	p.VerifyURL = "http://127.0.0.1:0"
+	p.BypassToken = "test-token"
+
+	var logged []interface{}
+	handler := p.Middleware(func(v ...interface{}) { logged = v })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	post := func(token string) int {
+		req := httptest.NewRequest("POST", "/contact", strings.NewReader("cf-turnstile-response="+token))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := post("test-token"); code != http.StatusOK {
+		t.Errorf("expected the bypass token to pass without asking the service, got %d", code)
+	}
+
+	if code := post(""); code != http.StatusForbidden || logged != nil {
+		t.Errorf("expected a form without a token to be refused quietly, got %d and %v", code, logged)
+	}
+
+	if code := post("guessed"); code != http.StatusForbidden || logged == nil {
+		t.Errorf("expected a service that cannot be reached to refuse and log, got %d and %v", code, logged)
+	}
+}
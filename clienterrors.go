@@ -0,0 +1,194 @@
+package gemquick
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/ratelimit"
+)
+
+// ClientError is an error that happened in a browser, either a javascript error reported by the
+// page or a Content-Security-Policy violation reported by the browser itself. It is dispatched
+// on app.Events, so listeners for client.error can forward it to an error tracker
+type ClientError struct {
+	// Kind is javascript or csp
+	Kind      string `json:"kind"`
+	Message   string `json:"message"`
+	URL       string `json:"url,omitempty"`
+	Source    string `json:"source,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	Column    int    `json:"column,omitempty"`
+	Stack     string `json:"stack,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	IP        string `json:"ip,omitempty"`
+}
+
+// Name is the name listeners are registered for
+func (e ClientError) Name() string {
+	return "client.error"
+}
+
+// maxClientErrorBody is the size of the largest report ClientErrors accepts
+const maxClientErrorBody = 64 << 10
+
+// jsErrorReport is what the client-errors.js of gq make client-errors sends
+type jsErrorReport struct {
+	Message string `json:"message"`
+	URL     string `json:"url"`
+	Source  string `json:"source"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Stack   string `json:"stack"`
+}
+
+// cspReport is the report-uri format of a Content-Security-Policy violation
+type cspReport struct {
+	Report struct {
+		DocumentURI        string `json:"document-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		BlockedURI         string `json:"blocked-uri"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+		ColumnNumber       int    `json:"column-number"`
+	} `json:"csp-report"`
+}
+
+// reportingAPIReport is one report of the report-to format of the Reporting API
+type reportingAPIReport struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+	Body struct {
+		DocumentURL        string `json:"documentURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		BlockedURL         string `json:"blockedURL"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+		ColumnNumber       int    `json:"columnNumber"`
+	} `json:"body"`
+}
+
+// ClientErrors collects the errors of the app's pages, answering 204 No Content for every report it
+// accepts. It takes javascript errors as json, and CSP violations as application/csp-report from a
+// report-uri directive or as application/reports+json from report-to. Reports that do not match
+// their schema are refused with 400 Bad Request, and every IP may send limit reports a minute.
+// Accepted reports are logged and dispatched as ClientError events
+func (g *Gemquick) ClientErrors(limit int) http.Handler {
+	limiter := ratelimit.New(limit, time.Minute)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reports, status, err := readClientErrors(http.MaxBytesReader(w, r.Body, maxClientErrorBody), r.Header.Get("Content-Type"))
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		for _, report := range reports {
+			report.UserAgent = r.UserAgent()
+			report.IP = ratelimit.ByIP(r)
+
+			g.ErrorLog.Printf("client %s error on %s: %q (%s:%d:%d)", report.Kind, report.URL, report.Message, report.Source, report.Line, report.Column)
+			if g.Events != nil {
+				if err := g.Events.DispatchContext(r.Context(), report); err != nil {
+					g.ErrorLog.Println(err)
+				}
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return limiter.Middleware(nil)(handler)
+}
+
+func readClientErrors(body io.Reader, contentType string) ([]ClientError, int, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+
+	switch mediaType {
+	case "application/json", "text/plain":
+		// text/plain is what navigator.sendBeacon sends a string as
+		var report jsErrorReport
+		if err := dec.Decode(&report); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid error report: %w", err)
+		}
+		if strings.TrimSpace(report.Message) == "" {
+			return nil, http.StatusBadRequest, errors.New("invalid error report: message is required")
+		}
+
+		return []ClientError{{
+			Kind:    "javascript",
+			Message: truncate(report.Message, 1000),
+			URL:     truncate(report.URL, 2000),
+			Source:  truncate(report.Source, 2000),
+			Line:    report.Line,
+			Column:  report.Column,
+			Stack:   truncate(report.Stack, 10000),
+		}}, 0, nil
+
+	case "application/csp-report":
+		// browsers add fields of their own to these reports, so unknown ones are fine
+		var report cspReport
+		if err := json.NewDecoder(body).Decode(&report); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid csp report: %w", err)
+		}
+		csp := report.Report
+		directive := csp.EffectiveDirective
+		if directive == "" {
+			directive = csp.ViolatedDirective
+		}
+		if directive == "" {
+			return nil, http.StatusBadRequest, errors.New("invalid csp report: the directive is missing")
+		}
+
+		return []ClientError{{
+			Kind:    "csp",
+			Message: fmt.Sprintf("%s blocked %s", directive, csp.BlockedURI),
+			URL:     csp.DocumentURI,
+			Source:  csp.SourceFile,
+			Line:    csp.LineNumber,
+			Column:  csp.ColumnNumber,
+		}}, 0, nil
+
+	case "application/reports+json":
+		var reports []reportingAPIReport
+		if err := json.NewDecoder(body).Decode(&reports); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid reports: %w", err)
+		}
+
+		var errs []ClientError
+		for _, report := range reports {
+			if report.Type != "csp-violation" {
+				continue
+			}
+			errs = append(errs, ClientError{
+				Kind:    "csp",
+				Message: fmt.Sprintf("%s blocked %s", report.Body.EffectiveDirective, report.Body.BlockedURL),
+				URL:     report.Body.DocumentURL,
+				Source:  report.Body.SourceFile,
+				Line:    report.Body.LineNumber,
+				Column:  report.Body.ColumnNumber,
+			})
+		}
+
+		return errs, 0, nil
+	}
+
+	return nil, http.StatusUnsupportedMediaType, fmt.Errorf("reports must be json, not %s", contentType)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return s[:n]
+}
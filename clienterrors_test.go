@@ -0,0 +1,70 @@
+package gemquick
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jimmitjoo/gemquick/events"
+)
+
+func TestClientErrors(t *testing.T) {
+	var logged bytes.Buffer
+	g := &Gemquick{ErrorLog: log.New(&logged, "", 0), Events: events.New(1)}
+
+	var received []ClientError
+	g.Events.Listen("client.error", events.ListenerFunc(func(e events.Event) error {
+		received = append(received, e.(ClientError))
+		return nil
+	}))
+
+	handler := g.ClientErrors(3)
+	post := func(contentType, body string) int {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/client-errors", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := post("text/plain;charset=UTF-8", `{"message": "x is undefined", "url": "https://shop.test/cart", "source": "app.js", "line": 3, "column": 7}`); code != http.StatusNoContent {
+		t.Errorf("expected a javascript error to be accepted, got %d", code)
+	}
+
+	if code := post("application/csp-report", `{"csp-report": {"document-uri": "https://shop.test/", "violated-directive": "script-src-elem", "blocked-uri": "https://evil.test/x.js", "status-code": 200}}`); code != http.StatusNoContent {
+		t.Errorf("expected a csp report to be accepted, got %d", code)
+	}
+
+	if code := post("application/json", `{"message": "boom", "password": "secret"}`); code != http.StatusBadRequest {
+		t.Errorf("expected a report with unknown fields to be refused, got %d", code)
+	}
+
+	if code := post("application/json", `{"message": "too many"}`); code != http.StatusTooManyRequests {
+		t.Errorf("expected the fourth report to be rate limited, got %d", code)
+	}
+
+	if len(received) != 2 || received[0].Kind != "javascript" || received[0].Line != 3 || received[1].Message != "script-src-elem blocked https://evil.test/x.js" {
+		t.Errorf("expected both reports as events, got %+v", received)
+	}
+
+	if !strings.Contains(logged.String(), `client javascript error on https://shop.test/cart: "x is undefined" (app.js:3:7)`) {
+		t.Errorf("expected the error to be logged, got %q", logged.String())
+	}
+}
+
+func TestReadClientErrors_Reports(t *testing.T) {
+	body := `[{"type": "deprecation", "body": {}}, {"type": "csp-violation", "url": "https://shop.test/", "body": {"documentURL": "https://shop.test/", "effectiveDirective": "img-src", "blockedURL": "https://cdn.test/a.png"}}]`
+
+	reports, _, err := readClientErrors(strings.NewReader(body), "application/reports+json")
+	if err != nil || len(reports) != 1 || reports[0].Message != "img-src blocked https://cdn.test/a.png" {
+		t.Errorf("expected the csp violation of the reports, got %+v, %v", reports, err)
+	}
+
+	if _, status, _ := readClientErrors(io.NopCloser(strings.NewReader("")), "application/xml"); status != http.StatusUnsupportedMediaType {
+		t.Errorf("expected other content types to be refused, got %d", status)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type benchResult struct {
+	latency time.Duration
+	status  int
+	err     error
+}
+
+// doBench attacks the routes of a running app for a fixed duration and reports latency percentiles.
+// With -rate the requests are paced at a constant rate, like vegeta does, otherwise every worker
+// sends its next request as soon as the previous one returned
+func (r *Runner) doBench(args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	baseURL := flags.String("url", r.getenv("APP_URL"), "base url of the running app")
+	routes := flags.String("routes", "/", "comma separated list of routes to request")
+	concurrency := flags.Int("c", 10, "number of concurrent workers")
+	duration := flags.Duration("d", 10*time.Second, "how long to run the test")
+	rate := flags.Int("rate", 0, "requests per second across all workers, 0 for as fast as possible")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout of a single request")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *baseURL == "" {
+		return errors.New("bench needs a url, either with -url or APP_URL in .env")
+	}
+
+	if *concurrency < 1 {
+		return errors.New("bench needs at least one worker")
+	}
+
+	var targets []string
+	for _, route := range strings.Split(*routes, ",") {
+		route = strings.TrimSpace(route)
+		if route == "" {
+			continue
+		}
+		targets = append(targets, strings.TrimRight(*baseURL, "/")+"/"+strings.TrimLeft(route, "/"))
+	}
+
+	r.green("Benchmarking %s for %s with %d workers", strings.Join(targets, ", "), *duration, *concurrency)
+
+	client := &http.Client{
+		Timeout: *timeout,
+		Transport: &http.Transport{
+			MaxIdleConns:        *concurrency,
+			MaxIdleConnsPerHost: *concurrency,
+		},
+	}
+
+	// ticks paces the workers when a rate is given, otherwise it is never read from
+	var ticks <-chan time.Time
+	if *rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(*rate))
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	deadline := time.Now().Add(*duration)
+	results := make(chan benchResult, *concurrency*10)
+
+	var wg sync.WaitGroup
+	for w := 0; w < *concurrency; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := worker; time.Now().Before(deadline); i++ {
+				if ticks != nil {
+					<-ticks
+				}
+				results <- benchRequest(client, targets[i%len(targets)])
+			}
+		}(w)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	start := time.Now()
+	var latencies []time.Duration
+	statuses := make(map[int]int)
+	errs := make(map[string]int)
+
+	for res := range results {
+		if res.err != nil {
+			errs[res.err.Error()]++
+			continue
+		}
+		latencies = append(latencies, res.latency)
+		statuses[res.status]++
+	}
+
+	r.printBenchReport(latencies, statuses, errs, time.Since(start))
+
+	return nil
+}
+
+func benchRequest(client *http.Client, target string) benchResult {
+	start := time.Now()
+
+	resp, err := client.Get(target)
+	if err != nil {
+		return benchResult{err: err}
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return benchResult{latency: time.Since(start), status: resp.StatusCode}
+}
+
+func (r *Runner) printBenchReport(latencies []time.Duration, statuses map[int]int, errs map[string]int, elapsed time.Duration) {
+	total := len(latencies)
+	for _, n := range errs {
+		total += n
+	}
+
+	r.yellow("Requests\t%d (%.1f/s)", total, float64(total)/elapsed.Seconds())
+
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+		var sum time.Duration
+		for _, l := range latencies {
+			sum += l
+		}
+
+		r.yellow("Latencies\tmean %s, p50 %s, p90 %s, p95 %s, p99 %s, max %s",
+			sum/time.Duration(len(latencies)),
+			percentile(latencies, 50),
+			percentile(latencies, 90),
+			percentile(latencies, 95),
+			percentile(latencies, 99),
+			latencies[len(latencies)-1])
+	}
+
+	codes := make([]int, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	var parts []string
+	for _, code := range codes {
+		parts = append(parts, fmt.Sprintf("%d: %d", code, statuses[code]))
+	}
+	r.yellow("Status codes\t%s", strings.Join(parts, ", "))
+
+	for msg, n := range errs {
+		r.red("Error\t%d x %s", n, msg)
+	}
+}
+
+// percentile expects sorted latencies
+func percentile(latencies []time.Duration, p int) time.Duration {
+	index := (len(latencies)*p+99)/100 - 1
+	if index < 0 {
+		index = 0
+	}
+
+	return latencies[index]
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// hasAppCommands reports whether the project has its own console entrypoint
+func (r *Runner) hasAppCommands() bool {
+	return r.RootPath != "" && fileExists(r.RootPath+"/cmd/console/main.go")
+}
+
+// doApp runs one of the project's commands, gq app:<command> or gq app to list them
+func (r *Runner) doApp(args []string) error {
+	if !r.hasAppCommands() {
+		return errors.New("the project has no commands yet, create one with gq make command <name>")
+	}
+
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	return r.runAppCommand(args)
+}
+
+// runAppCommand hands a command gq does not know to the project's console
+func (r *Runner) runAppCommand(args []string) error {
+	cmd := exec.Command("go", append([]string{"run", "./cmd/console"}, args...)...)
+	cmd.Dir = r.RootPath
+	cmd.Stdin = r.Stdin
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
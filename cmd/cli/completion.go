@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// doCompletion prints the completion script for shell, made from the command registry
+func (r *Runner) doCompletion(shell string) error {
+	var script string
+
+	switch shell {
+	case "bash":
+		script = bashCompletion()
+	case "zsh":
+		// zsh runs the bash completion through bashcompinit
+		script = "autoload -U +X compinit && compinit\nautoload -U +X bashcompinit && bashcompinit\n\n" + bashCompletion()
+	case "fish":
+		script = fishCompletion()
+	default:
+		return errors.New("completion needs a shell: bash, zsh or fish")
+	}
+
+	_, err := fmt.Fprint(r.Stdout, script)
+
+	return err
+}
+
+// completionPath is a command with the words that lead to it, e.g. "make key"
+type completionPath struct {
+	words string
+	cmd   *command
+}
+
+// completionPaths lists every command in the registry below prefix, deepest first, so the most
+// specific case of a completion script matches first
+func completionPaths(prefix string, cmds []*command) []completionPath {
+	var paths []completionPath
+	for _, c := range cmds {
+		words := strings.TrimSpace(prefix + " " + c.name)
+		paths = append(paths, completionPaths(words, c.subcommands)...)
+		paths = append(paths, completionPath{words, c})
+	}
+
+	sort.SliceStable(paths, func(i, j int) bool {
+		return strings.Count(paths[i].words, " ") > strings.Count(paths[j].words, " ")
+	})
+
+	return paths
+}
+
+func dashed(flags []string) []string {
+	dashed := make([]string, len(flags))
+	for i, flag := range flags {
+		dashed[i] = "--" + flag
+	}
+
+	return dashed
+}
+
+// bashCompletion completes the subcommands of the words typed so far, and the flags of the command
+// they name, whatever arguments follow it
+func bashCompletion() string {
+	var b strings.Builder
+
+	b.WriteString("# gq completion for bash, load it with: source <(gq completion bash)\n")
+	b.WriteString("_gq() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    local typed=\"${COMP_WORDS[*]:1:COMP_CWORD-1}\"\n")
+	b.WriteString("    local words=\"\"\n\n")
+	b.WriteString("    case \"$typed\" in\n")
+
+	for _, p := range completionPaths("", commands) {
+		if len(p.cmd.subcommands) > 0 {
+			words := append(commandNames(p.cmd.subcommands), dashed(p.cmd.flags)...)
+			fmt.Fprintf(&b, "        %q)\n            words=%q ;;\n", p.words, strings.Join(words, " "))
+		}
+
+		if len(p.cmd.flags) > 0 {
+			fmt.Fprintf(&b, "        %q|%q*)\n            words=%q ;;\n", p.words, p.words+" ", strings.Join(dashed(p.cmd.flags), " "))
+		}
+	}
+
+	top := append(commandNames(commands), dashed(globalFlags)...)
+	fmt.Fprintf(&b, "        \"\")\n            words=%q ;;\n", strings.Join(top, " "))
+	b.WriteString("    esac\n\n")
+	b.WriteString("    COMPREPLY=($(compgen -W \"$words\" -- \"$cur\"))\n")
+	b.WriteString("}\n\n")
+	b.WriteString("complete -F _gq gq\n")
+
+	return b.String()
+}
+
+// fishCompletion completes each command after the one it belongs to, with its summary
+func fishCompletion() string {
+	var b strings.Builder
+
+	b.WriteString("# gq completion for fish, load it with: gq completion fish | source\n")
+	b.WriteString("complete -c gq -f\n")
+
+	for _, flag := range globalFlags {
+		fmt.Fprintf(&b, "complete -c gq -l %s\n", flag)
+	}
+
+	for _, c := range commands {
+		fmt.Fprintf(&b, "complete -c gq -n __fish_use_subcommand -a %s -d %s\n", c.name, fishQuote(c.summary))
+	}
+
+	for _, p := range completionPaths("", commands) {
+		if len(p.cmd.subcommands) > 0 {
+			siblings := strings.Join(commandNames(p.cmd.subcommands), " ")
+			for _, sub := range p.cmd.subcommands {
+				fmt.Fprintf(&b, "complete -c gq -n %s -a %s -d %s\n",
+					fishQuote(fishSeen(p.words)+"; and not __fish_seen_subcommand_from "+siblings), sub.name, fishQuote(sub.summary))
+			}
+		}
+
+		for _, flag := range p.cmd.flags {
+			fmt.Fprintf(&b, "complete -c gq -n %s -l %s\n", fishQuote(fishSeen(p.words)), flag)
+		}
+	}
+
+	return b.String()
+}
+
+// fishSeen is the fish condition that every word of a command has been typed
+func fishSeen(words string) string {
+	var conditions []string
+	for _, word := range strings.Fields(words) {
+		conditions = append(conditions, "__fish_seen_subcommand_from "+word)
+	}
+
+	return strings.Join(conditions, "; and ")
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+}
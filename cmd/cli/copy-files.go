@@ -1,42 +1,23 @@
 package main
 
 import (
-	"embed"
-	"errors"
 	"os"
 )
 
-//go:embed templates
-var templateFS embed.FS
-
-func copyFileFromTemplate(templatePath, targetPath string) error {
-	// check to ensure targetPath does not already exist
-	if fileExists(targetPath) {
-		return errors.New(targetPath + " does already exist.")
-	}
-
-	// read template file
-	data, err := templateFS.ReadFile(templatePath)
-	if err != nil {
-		exitGracefully(err)
-	}
-
-	// write to targetPath
-	err = copyDataToFile(data, targetPath)
-	if err != nil {
-		exitGracefully(err)
-	}
-
-	return nil
+// readTemplate returns the project's override of a template if there is one,
+// and the template in r.FS otherwise
+func (r *Runner) readTemplate(templatePath string) ([]byte, error) {
+	return r.scaffoldOptions().Template(templatePath)
 }
 
-func copyDataToFile(data []byte, targetPath string) error {
-
+func (r *Runner) copyDataToFile(data []byte, targetPath string) error {
 	err := os.WriteFile(targetPath, data, 0644)
 	if err != nil {
-		exitGracefully(err)
+		return err
 	}
 
+	r.recordFile(targetPath)
+
 	return nil
 }
 
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/CloudyKit/jet/v6"
+	"github.com/gomodule/redigo/redis"
+	"github.com/jimmitjoo/gemquick/render"
+	"github.com/joho/godotenv"
+)
+
+// doctorCheck is one line of the gq doctor report. A check returns the fix to print when it fails
+type doctorCheck struct {
+	name  string
+	check func() (fix string, err error)
+}
+
+var migrationVersion = regexp.MustCompile(`^(\d+)_`)
+
+// doDoctor checks that the project is set up to run, and prints what to fix
+func (r *Runner) doDoctor() error {
+	checks := []doctorCheck{
+		{".env file", r.checkDotEnvFile},
+		{"required settings", r.checkRequiredSettings},
+		{"writable directories", r.checkWritableDirs},
+		{"views", r.checkViews},
+	}
+
+	if r.getenv("DATABASE_TYPE") != "" {
+		checks = append(checks, doctorCheck{"database connection", r.checkDatabase}, doctorCheck{"migrations", r.checkMigrations})
+	}
+
+	if r.getenv("CACHE") == "redis" || r.getenv("SESSION_TYPE") == "redis" {
+		checks = append(checks, doctorCheck{"redis", r.checkRedis})
+	}
+
+	if r.getenv("CACHE") == "badger" || r.getenv("SESSION_TYPE") == "badger" {
+		checks = append(checks, doctorCheck{"badger", r.checkBadger})
+	}
+
+	failed := 0
+	for _, c := range checks {
+		fix, err := c.check()
+		if err != nil {
+			failed++
+			r.red("✘ %s: %s", c.name, err)
+			if fix != "" {
+				r.yellow("  fix: %s", fix)
+			}
+			continue
+		}
+
+		r.green("✔ %s", c.name)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+
+	return nil
+}
+
+func (r *Runner) checkDotEnvFile() (string, error) {
+	_, err := godotenv.Read(filepath.Join(r.RootPath, ".env"))
+	if err != nil {
+		return "create a .env file in the project root, gq new creates one from the defaults", err
+	}
+
+	return "", nil
+}
+
+func (r *Runner) checkRequiredSettings() (string, error) {
+	var missing []string
+	for _, key := range []string{"APP_NAME", "PORT", "RENDERER", "SESSION_TYPE", "COOKIE_NAME", "KEY"} {
+		if r.getenv(key) == "" {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return "set them in .env", fmt.Errorf("missing %v", missing)
+	}
+
+	if len(r.getenv("KEY")) != 32 {
+		return "generate one with gq make key", errors.New("KEY must be 32 characters long")
+	}
+
+	if _, err := strconv.Atoi(r.getenv("PORT")); err != nil {
+		return "set PORT to a port number", fmt.Errorf("PORT %q is not a number", r.getenv("PORT"))
+	}
+
+	return "", nil
+}
+
+func (r *Runner) checkWritableDirs() (string, error) {
+	for _, dir := range []string{"tmp", "logs"} {
+		path := filepath.Join(r.RootPath, dir)
+
+		f, err := os.CreateTemp(path, ".doctor")
+		if err != nil {
+			return fmt.Sprintf("create %s and make sure the app can write to it", path), err
+		}
+
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}
+
+	return "", nil
+}
+
+func (r *Runner) checkViews() (string, error) {
+	views := filepath.Join(r.RootPath, "views")
+	if _, err := os.Stat(views); err != nil {
+		return "create a views directory in the project root", err
+	}
+
+	renderer := render.Render{
+		RootPath: r.RootPath,
+		JetViews: jet.NewSet(jet.NewOSFileSystemLoader(views), jet.InDevelopmentMode()),
+	}
+
+	if _, err := renderer.Precompile(); err != nil {
+		return "fix the templates listed above", err
+	}
+
+	return "", nil
+}
+
+func (r *Runner) checkDatabase() (string, error) {
+	db, err := r.gem.OpenDB(r.gem.DB.DataType, r.driverDSN())
+	if err != nil {
+		return "check the DATABASE_ settings in .env and that the database is running", err
+	}
+
+	return "", db.Close()
+}
+
+func (r *Runner) checkMigrations() (string, error) {
+	files, err := filepath.Glob(filepath.Join(r.RootPath, "migrations", "*.up.sql"))
+	if err != nil {
+		return "", err
+	}
+
+	var latest uint64
+	for _, file := range files {
+		if m := migrationVersion.FindStringSubmatch(filepath.Base(file)); m != nil {
+			if v, _ := strconv.ParseUint(m[1], 10, 64); v > latest {
+				latest = v
+			}
+		}
+	}
+
+	current, dirty, err := r.gem.MigrationVersion(r.getDSN())
+	if err != nil {
+		return "check the DATABASE_ settings in .env", err
+	}
+
+	if dirty {
+		return "fix the database by hand, then run gq migrate reset or force the version",
+			fmt.Errorf("migration %d failed halfway", current)
+	}
+
+	if uint64(current) < latest {
+		return "run gq migrate", fmt.Errorf("database is at %d, the latest migration is %d", current, latest)
+	}
+
+	return "", nil
+}
+
+func (r *Runner) checkRedis() (string, error) {
+	fix := "check REDIS_HOST, REDIS_PORT and REDIS_PASSWORD and that redis is running"
+
+	conn, err := redis.Dial("tcp", r.getenv("REDIS_HOST")+":"+r.getenv("REDIS_PORT"), redis.DialConnectTimeout(5*time.Second))
+	if err != nil {
+		return fix, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if password := r.getenv("REDIS_PASSWORD"); password != "" {
+		if _, err := conn.Do("AUTH", password); err != nil {
+			return fix, err
+		}
+	}
+
+	_, err = conn.Do("PING")
+
+	return fix, err
+}
+
+func (r *Runner) checkBadger() (string, error) {
+	path := filepath.Join(r.RootPath, "tmp", "badger")
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return "make sure the app can write to " + path, err
+	}
+
+	f, err := os.CreateTemp(path, ".doctor")
+	if err != nil {
+		return "make sure the app can write to " + path, err
+	}
+
+	_ = f.Close()
+
+	return "", os.Remove(f.Name())
+}
@@ -2,115 +2,126 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
+	"text/tabwriter"
 
-	"github.com/fatih/color"
-	"github.com/joho/godotenv"
+	"github.com/jimmitjoo/gemquick"
+	"github.com/jimmitjoo/gemquick/scaffold"
 )
 
-func setup(arg1, arg2 string) {
-	if arg1 != "new" && arg1 != "version" && arg1 != "help" {
-		err := godotenv.Load()
-		if err != nil {
-			exitGracefully(err)
-		}
-
-		path, err := os.Getwd()
-		if err != nil {
-			exitGracefully(err)
-		}
-
-		gem.RootPath = path
-		gem.Version = "0.0.1"
-		gem.DB.DataType = os.Getenv("DATABASE_TYPE")
+// getDSN returns the golang-migrate url of the project's database, or the one of --database
+func (r *Runner) getDSN() string {
+	if r.Database != "" {
+		return r.getenv(databaseDSNKey(r.Database))
 	}
-}
 
-func getDSN() string {
-	dbType := gem.DB.DataType
+	dbType := r.gem.DB.DataType
 	if dbType == "pgx" {
 		dbType = "postgres"
 	}
 
+	if dbType == "sqlite" || dbType == "sqlite3" {
+		return "sqlite3://" + r.sqlitePath()
+	}
+
+	// the dsn of sql server is a sqlserver:// url already
+	if dbType == "sqlserver" || dbType == "mssql" {
+		return gemquick.BuildDSNFrom(r.getenv)
+	}
+
 	if dbType == "postgres" || dbType == "postgresql" {
 		var dsn string
-		if os.Getenv("DATABASE_PASS") != "" {
+		if r.getenv("DATABASE_PASS") != "" {
 			dsn = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s&timezone=UTC&connect_timeout=5",
-				os.Getenv("DATABASE_USER"),
-				os.Getenv("DATABASE_PASS"),
-				os.Getenv("DATABASE_HOST"),
-				os.Getenv("DATABASE_PORT"),
-				os.Getenv("DATABASE_NAME"),
-				os.Getenv("DATABASE_SSL_MODE"))
+				r.getenv("DATABASE_USER"),
+				r.getenv("DATABASE_PASS"),
+				r.getenv("DATABASE_HOST"),
+				r.getenv("DATABASE_PORT"),
+				r.getenv("DATABASE_NAME"),
+				r.getenv("DATABASE_SSL_MODE"))
 		} else {
 			dsn = fmt.Sprintf("postgres://%s@%s:%s/%s?sslmode=%s&timezone=UTC&connect_timeout=5",
-				os.Getenv("DATABASE_USER"),
-				os.Getenv("DATABASE_HOST"),
-				os.Getenv("DATABASE_PORT"),
-				os.Getenv("DATABASE_NAME"),
-				os.Getenv("DATABASE_SSL_MODE"))
+				r.getenv("DATABASE_USER"),
+				r.getenv("DATABASE_HOST"),
+				r.getenv("DATABASE_PORT"),
+				r.getenv("DATABASE_NAME"),
+				r.getenv("DATABASE_SSL_MODE"))
 		}
 
 		return dsn
 	}
 
-	return "mysql://" + gem.BuildDSN()
+	return "mysql://" + gemquick.BuildDSNFrom(r.getenv)
 }
 
-func showHelp() {
-	color.Yellow(`Available commands:
-
-	help 					- show this help
-	version 				- show Gemquick version
-	migrate 				- runs all migrations up
-	migrate down 			- runs the last migration down
-	migrate reset 			- drops all tables and migrates them back up
-	make auth				- creates things for autentications
-	make handler <name>		- creates a new stub handler in the handlers directory
-	make migration <name>	- creates two new migrations, up and down
-	make model <name>		- creates a new model in the data directory
-	make session			- creates a table in the database to store sessions
-	make mail <name>		- creates a new email in the email directory
-
-	`)
+// driverDSN returns the dsn the commands that query the database open it with, which is empty
+// for a DATABASE_TYPE gemquick cannot connect to
+func (r *Runner) driverDSN() string {
+	if r.Database != "" {
+		// of the golang-migrate urls, only the postgres and sqlserver ones are dsns of their driver as well
+		dsn := r.getDSN()
+		for _, scheme := range []string{"mysql://", "sqlite3://"} {
+			dsn = strings.TrimPrefix(dsn, scheme)
+		}
+		return dsn
+	}
+
+	return gemquick.BuildDSNFrom(func(key string) string {
+		if key == "DATABASE_NAME" && (r.gem.DB.DataType == "sqlite" || r.gem.DB.DataType == "sqlite3") {
+			return r.sqlitePath()
+		}
+		return r.getenv(key)
+	})
 }
 
-func updateSourceFiles(path string, fi os.FileInfo, err error) error {
-	if err != nil {
-		return err
+// sqlitePath is the sqlite database file in DATABASE_NAME, which is relative to the project root
+func (r *Runner) sqlitePath() string {
+	name := r.getenv("DATABASE_NAME")
+	if name == "" || name == ":memory:" || filepath.IsAbs(name) {
+		return name
 	}
 
-	if fi.IsDir() {
-		return nil
-	}
+	return r.path(name)
+}
 
-	if filepath.Ext(path) == ".go" {
-		color.Yellow("Updating %s", path)
-	}
+// appModuleName reads the module path of the project from its go.mod,
+// falling back to myapp, which is what the templates use
+func (r *Runner) appModuleName() string {
+	return scaffold.ModuleName(r.RootPath)
+}
 
-	read, err := os.ReadFile(path)
-	if err != nil {
-		exitGracefully(err)
+// showHelp lists the commands in the registry
+func (r *Runner) showHelp() {
+	var help strings.Builder
+	w := tabwriter.NewWriter(&help, 0, 4, 2, ' ', 0)
+
+	var list func(prefix string, cmds []*command)
+	list = func(prefix string, cmds []*command) {
+		for _, c := range cmds {
+			usage := strings.TrimSpace(prefix + c.name + " " + c.args)
+			if c.run != nil && c.summary != "" {
+				fmt.Fprintf(w, "\t%s\t- %s\n", usage, c.summary)
+			}
+			list(prefix+c.name+" ", c.subcommands)
+		}
 	}
+	list("", commands)
+	_ = w.Flush()
 
-	newContents := string(read)
-	newContents = strings.Replace(newContents, "myapp", appUrl, -1)
+	r.yellow(`Available commands:
 
-	err = os.WriteFile(path, []byte(newContents), 0)
-	if err != nil {
-		exitGracefully(err)
-	}
+%s
+	Add --database <name> to migrate and make migration to use the database in DATABASE_<NAME>_DSN,
+	with its migrations in migrations/<name>.
 
-	return nil
-}
+	Add --dry-run to a make command to see the files it would create, and the changes it would
+	make to existing ones, without writing anything.
 
-func updateSource() {
-	err := filepath.Walk(".", updateSourceFiles)
-	if err != nil {
-		exitGracefully(err)
-	}
+	Add --json to any command to get its result as JSON instead of colored text.
+
+	Templates used by the make commands can be customized by placing a copy with the
+	same path under .gemquick/, e.g. .gemquick/templates/handlers/handler.go.txt
 
-	color.Green("Source updated successfully!")
+	`, help.String())
 }
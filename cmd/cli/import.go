@@ -0,0 +1,643 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jimmitjoo/gemquick"
+	"github.com/jimmitjoo/gemquick/database"
+)
+
+// doImport loads the rows of a CSV or NDJSON file into table, checking them against the validate
+// tags of the table's model in the data directory. Rows that fail, and chunks the database
+// refuses, go to an error report next to the file instead of stopping the import
+func (r *Runner) doImport(table, file string, args []string) error {
+	flags := flag.NewFlagSet("db:import", flag.ContinueOnError)
+	upsert := flags.String("upsert", "", "update the rows that conflict on these comma separated columns instead of failing")
+	chunk := flags.Int("chunk", database.DefaultChunkSize, "how many rows go in one statement")
+	report := flags.String("errors", "", "where the rows that were not imported are written, <file>.errors.csv by default")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if table == "" || file == "" {
+		return errors.New("db:import requires a table and a file")
+	}
+
+	if r.gem.DB.DataType == "" {
+		return errors.New("you have to define a database type to import rows")
+	}
+
+	if !filepath.IsAbs(file) {
+		file = r.path(file)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	rules, err := modelRules(r.path("data"), table)
+	if err != nil {
+		return err
+	}
+
+	db, err := r.gem.OpenDB(r.gem.DB.DataType, r.driverDSN())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if *report == "" {
+		*report = strings.TrimSuffix(file, filepath.Ext(file)) + ".errors.csv"
+	}
+
+	im := &importer{
+		db:        db,
+		dataType:  r.gem.DB.DataType,
+		table:     table,
+		chunkSize: *chunk,
+		rules:     rules,
+	}
+	if *upsert != "" {
+		im.upsert = strings.Split(*upsert, ",")
+	}
+
+	counter := &countingReader{r: f}
+	if !r.JSON {
+		im.progress = func(rows int) {
+			r.printProgress(counter.n, info.Size(), rows)
+		}
+	}
+
+	var src rowSource
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".csv":
+		src, err = newCSVSource(counter)
+	case ".ndjson", ".jsonl":
+		src = newNDJSONSource(counter)
+	default:
+		err = fmt.Errorf("%s is neither .csv nor .ndjson", filepath.Base(file))
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := im.run(context.Background(), src)
+	if im.progress != nil {
+		_, _ = fmt.Fprintln(r.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+
+	r.recordDetail("imported", res.imported)
+	r.recordDetail("failed", len(res.failed))
+	r.green("Imported %d rows into %s", res.imported, table)
+
+	if len(res.failed) == 0 {
+		return nil
+	}
+
+	err = writeImportErrors(*report, src.columns(), res.failed)
+	if err != nil {
+		return err
+	}
+	r.recordDetail("errors", *report)
+	r.yellow("%d rows were not imported, see %s", len(res.failed), *report)
+
+	return nil
+}
+
+// printProgress draws a progress bar of how much of the file was read
+func (r *Runner) printProgress(read, size int64, rows int) {
+	const width = 30
+
+	percent := 100
+	if size > 0 {
+		percent = int(read * 100 / size)
+	}
+	done := width * percent / 100
+
+	_, _ = fmt.Fprintf(r.Stdout, "\r%3d%% [%s%s] %d rows", percent, strings.Repeat("#", done), strings.Repeat(".", width-done), rows)
+}
+
+// countingReader counts the bytes read, for the progress bar
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// importRow is a row of the file, with the line it started on
+type importRow struct {
+	line   int
+	values map[string]interface{}
+	err    string
+}
+
+// rowSource streams the rows of a file. Every row has the same columns, the ones of the first row
+type rowSource interface {
+	columns() []string
+	// next returns the next row, with err set for a row that could not be read, and io.EOF at the end
+	next() (importRow, error)
+}
+
+type csvSource struct {
+	r      *csv.Reader
+	header []string
+}
+
+func newCSVSource(r io.Reader) (*csvSource, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading the header: %w", err)
+	}
+
+	for i, column := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+	}
+
+	// ReuseRecord reuses the header's slice as well
+	return &csvSource{r: cr, header: append([]string(nil), header...)}, nil
+}
+
+func (s *csvSource) columns() []string { return s.header }
+
+func (s *csvSource) next() (importRow, error) {
+	record, err := s.r.Read()
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return importRow{line: parseErr.StartLine, err: parseErr.Err.Error()}, nil
+	}
+	if err != nil {
+		return importRow{}, err
+	}
+
+	line, _ := s.r.FieldPos(0)
+	row := importRow{line: line, values: make(map[string]interface{}, len(s.header))}
+	for i, column := range s.header {
+		// empty cells are NULL, so that the database fills in its defaults where it can
+		if record[i] == "" {
+			row.values[column] = nil
+			continue
+		}
+		row.values[column] = record[i]
+	}
+
+	return row, nil
+}
+
+type ndjsonSource struct {
+	r      *bufio.Reader
+	line   int
+	header []string
+}
+
+func newNDJSONSource(r io.Reader) *ndjsonSource {
+	return &ndjsonSource{r: bufio.NewReader(r)}
+}
+
+func (s *ndjsonSource) columns() []string { return s.header }
+
+func (s *ndjsonSource) next() (importRow, error) {
+	for {
+		text, err := s.r.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || text == "") {
+			return importRow{}, err
+		}
+		s.line++
+
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		row := importRow{line: s.line}
+
+		dec := json.NewDecoder(strings.NewReader(text))
+		dec.UseNumber()
+
+		var values map[string]interface{}
+		if err := dec.Decode(&values); err != nil {
+			row.err = err.Error()
+			return row, nil
+		}
+
+		if s.header == nil {
+			for column := range values {
+				s.header = append(s.header, column)
+			}
+			sort.Strings(s.header)
+		}
+
+		row.values = make(map[string]interface{}, len(s.header))
+		for _, column := range s.header {
+			row.values[column] = jsonValue(values[column])
+		}
+
+		for column := range values {
+			if _, ok := row.values[column]; !ok {
+				row.err = fmt.Sprintf("%s is not a column of the first row", column)
+			}
+		}
+
+		return row, nil
+	}
+}
+
+// jsonValue turns a decoded JSON value into one for the database: numbers as int64 or float64, and
+// objects and arrays as their JSON, for json columns
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+
+	return v
+}
+
+// columnRules are the validate rules of a column, and the kind of its field, which min and max
+// depend on
+type columnRules struct {
+	rules []string
+	kind  reflect.Kind
+}
+
+// modelRules returns the validate rules of the model in dir whose Table method returns table, by
+// column. A table without a model has no rules
+func modelRules(dir, table string) (map[string]columnRules, error) {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	structs := map[string]*ast.StructType{}
+	model := ""
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				switch decl := decl.(type) {
+				case *ast.GenDecl:
+					for _, spec := range decl.Specs {
+						if ts, ok := spec.(*ast.TypeSpec); ok {
+							if st, ok := ts.Type.(*ast.StructType); ok {
+								structs[ts.Name.Name] = st
+							}
+						}
+					}
+				case *ast.FuncDecl:
+					if name := tableMethodOf(decl, table); name != "" {
+						model = name
+					}
+				}
+			}
+		}
+	}
+
+	st, ok := structs[model]
+	if !ok {
+		return nil, nil
+	}
+
+	rules := map[string]columnRules{}
+	for _, field := range st.Fields.List {
+		if field.Tag == nil || len(field.Names) == 0 {
+			continue
+		}
+
+		tag, _ := strconv.Unquote(field.Tag.Value)
+		validate := reflect.StructTag(tag).Get("validate")
+		column, _, _ := strings.Cut(reflect.StructTag(tag).Get("db"), ",")
+		if validate == "" || column == "" || column == "-" {
+			continue
+		}
+
+		rules[column] = columnRules{rules: strings.Split(validate, ","), kind: kindOf(field.Type)}
+	}
+
+	return rules, nil
+}
+
+// tableMethodOf returns the receiver type of fn when it is a Table method returning table
+func tableMethodOf(fn *ast.FuncDecl, table string) string {
+	if fn.Name.Name != "Table" || fn.Recv == nil || len(fn.Recv.List) != 1 || fn.Body == nil || len(fn.Body.List) != 1 {
+		return ""
+	}
+
+	ret, ok := fn.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return ""
+	}
+
+	lit, ok := ret.Results[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return ""
+	}
+
+	if value, _ := strconv.Unquote(lit.Value); value != table {
+		return ""
+	}
+
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name
+	}
+
+	return ""
+}
+
+func kindOf(expr ast.Expr) reflect.Kind {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return reflect.Invalid
+	}
+
+	switch ident.Name {
+	case "string":
+		return reflect.String
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return reflect.Int
+	case "float32", "float64":
+		return reflect.Float64
+	}
+
+	return reflect.Invalid
+}
+
+// validate checks values against rules the way ValidateStruct checks a model, and returns the
+// errors by column. The enum rule needs the model's type and is left to the database
+func validate(rules map[string]columnRules, values map[string]interface{}) map[string]string {
+	v := &gemquick.Validation{Data: url.Values{}, Errors: map[string]string{}}
+
+	for column, cr := range rules {
+		value := ""
+		if values[column] != nil {
+			value = fmt.Sprint(values[column])
+		}
+
+		for _, rule := range cr.rules {
+			name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+			if name != "required" && value == "" {
+				continue
+			}
+
+			switch name {
+			case "required":
+				v.Check(strings.TrimSpace(value) != "", column, "This field cannot be blank")
+			case "email":
+				v.IsEmail(column, value)
+			case "int":
+				v.IsInt(column, value)
+			case "float":
+				v.IsFloat(column, value)
+			case "date":
+				v.IsDateISO(column, value)
+			case "nospaces":
+				v.NoSpaces(column, value)
+			case "min", "max":
+				checkLimit(v, cr.kind, column, value, name, param)
+			}
+		}
+	}
+
+	return v.Errors
+}
+
+// checkLimit checks the min or max rule, on the length of strings and the value of numbers
+func checkLimit(v *gemquick.Validation, kind reflect.Kind, column, value, rule, param string) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	var size float64
+	message := ""
+	switch kind {
+	case reflect.String:
+		size = float64(utf8.RuneCountInString(value))
+		message = " characters"
+	case reflect.Int, reflect.Float64:
+		size, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			v.AddError(column, "This field must be a number")
+			return
+		}
+	default:
+		return
+	}
+
+	if rule == "min" && size < limit {
+		v.AddError(column, fmt.Sprintf("This field must be at least %s%s", param, message))
+	} else if rule == "max" && size > limit {
+		v.AddError(column, fmt.Sprintf("This field must be at most %s%s", param, message))
+	}
+}
+
+// importer loads rows in chunks, inserted or upserted on the upsert columns
+type importer struct {
+	db        database.Execer
+	dataType  string
+	table     string
+	upsert    []string
+	chunkSize int
+	rules     map[string]columnRules
+	// progress is told after every chunk how many rows were read
+	progress func(rows int)
+}
+
+type importResult struct {
+	imported int
+	failed   []importRow
+}
+
+func (im *importer) run(ctx context.Context, src rowSource) (importResult, error) {
+	var res importResult
+
+	chunkSize := im.chunkSize
+	if chunkSize <= 0 {
+		chunkSize = database.DefaultChunkSize
+	}
+
+	read := 0
+	chunk := make([]importRow, 0, chunkSize)
+	for {
+		row, err := src.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return res, err
+		}
+		read++
+
+		if row.err == "" {
+			row.err = joinErrors(validate(im.rules, row.values))
+		}
+		if row.err != "" {
+			res.failed = append(res.failed, row)
+			continue
+		}
+
+		chunk = append(chunk, row)
+		if len(chunk) == chunkSize {
+			im.flush(ctx, chunk, &res)
+			chunk = chunk[:0]
+			if im.progress != nil {
+				im.progress(read)
+			}
+		}
+	}
+
+	im.flush(ctx, chunk, &res)
+	if im.progress != nil {
+		im.progress(read)
+	}
+
+	return res, nil
+}
+
+// flush writes a chunk to the database. A chunk the database refuses is reported row by row
+func (im *importer) flush(ctx context.Context, chunk []importRow, res *importResult) {
+	if len(chunk) == 0 {
+		return
+	}
+
+	rows := make([]map[string]interface{}, len(chunk))
+	for i, row := range chunk {
+		rows[i] = row.values
+	}
+
+	var err error
+	if len(im.upsert) > 0 {
+		_, err = database.UpsertMany(ctx, im.db, im.dataType, im.table, rows, im.upsert, im.updateColumns(rows[0]), len(rows))
+	} else {
+		_, err = database.InsertMany(ctx, im.db, im.dataType, im.table, rows, len(rows))
+	}
+
+	if err != nil {
+		for _, row := range chunk {
+			row.err = err.Error()
+			res.failed = append(res.failed, row)
+		}
+		return
+	}
+
+	res.imported += len(chunk)
+}
+
+// updateColumns are the columns an upsert updates, all but the ones it conflicts on
+func (im *importer) updateColumns(row map[string]interface{}) []string {
+	conflict := map[string]bool{}
+	for _, column := range im.upsert {
+		conflict[column] = true
+	}
+
+	var columns []string
+	for column := range row {
+		if !conflict[column] {
+			columns = append(columns, column)
+		}
+	}
+	sort.Strings(columns)
+
+	return columns
+}
+
+func joinErrors(errs map[string]string) string {
+	if len(errs) == 0 {
+		return ""
+	}
+
+	messages := make([]string, 0, len(errs))
+	for column, message := range errs {
+		messages = append(messages, column+": "+message)
+	}
+	sort.Strings(messages)
+
+	return strings.Join(messages, "; ")
+}
+
+// writeImportErrors writes the rows that were not imported to a CSV file, with their line and
+// what was wrong with them in front of their values
+func writeImportErrors(name string, columns []string, rows []importRow) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	_ = w.Write(append([]string{"line", "error"}, columns...))
+
+	for _, row := range rows {
+		record := []string{strconv.Itoa(row.line), row.err}
+		for _, column := range columns {
+			value := ""
+			if row.values[column] != nil {
+				value = fmt.Sprint(row.values[column])
+			}
+			record = append(record, value)
+		}
+		_ = w.Write(record)
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const testModel = `package data
+
+type Customer struct {
+	ID    int    ` + "`db:\"id,omitempty\"`" + `
+	Email string ` + "`db:\"email\" validate:\"required,email\"`" + `
+	Name  string ` + "`db:\"name\" validate:\"max=5\"`" + `
+}
+
+func (c *Customer) Table() string {
+	return "customers"
+}
+`
+
+func TestModelRules(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "customer.go"), []byte(testModel), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := modelRules(dir, "customers")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rules) != 2 || strings.Join(rules["email"].rules, ",") != "required,email" {
+		t.Errorf("expected the rules of email and name, got %+v", rules)
+	}
+
+	if rules, _ := modelRules(dir, "orders"); len(rules) != 0 {
+		t.Errorf("expected no rules for a table without a model, got %+v", rules)
+	}
+
+	if rules, err := modelRules(filepath.Join(dir, "missing"), "customers"); err != nil || rules != nil {
+		t.Errorf("expected a project without a data directory to have no rules, got %+v, %v", rules, err)
+	}
+}
+
+func TestImporter_Upsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "customer.go"), []byte(testModel), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := modelRules(dir, "customers")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := newCSVSource(strings.NewReader("email,name\nada@example.com,Ada\nnot-an-email,Bob\ngrace@example.com,Grace\nlin@example.com,\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec(`^INSERT INTO customers \(email, name\) VALUES \(\$1, \$2\), \(\$3, \$4\) ON CONFLICT \(email\) DO UPDATE SET name = EXCLUDED.name$`).
+		WithArgs("ada@example.com", "Ada", "grace@example.com", "Grace").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`^INSERT INTO customers`).
+		WithArgs("lin@example.com", nil).
+		WillReturnError(errors.New("duplicate key"))
+
+	var progress []int
+	im := &importer{db: db, dataType: "pgx", table: "customers", upsert: []string{"email"}, chunkSize: 2, rules: rules,
+		progress: func(rows int) { progress = append(progress, rows) }}
+
+	res, err := im.run(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.imported != 2 || len(res.failed) != 2 {
+		t.Fatalf("expected 2 rows imported and 2 failed, got %d and %+v", res.imported, res.failed)
+	}
+
+	if res.failed[0].line != 3 || !strings.Contains(res.failed[0].err, "email: Invalid email address") {
+		t.Errorf("expected line 3 to fail validation, got %+v", res.failed[0])
+	}
+
+	if res.failed[1].line != 5 || !strings.Contains(res.failed[1].err, "duplicate key") {
+		t.Errorf("expected line 5 to be refused by the database, got %+v", res.failed[1])
+	}
+
+	if len(progress) != 2 || progress[1] != 4 {
+		t.Errorf("expected progress after every chunk, got %v", progress)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	report := filepath.Join(dir, "customers.errors.csv")
+	if err := writeImportErrors(report, src.columns(), res.failed); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 3 || strings.Join(records[0], ",") != "line,error,email,name" || records[1][2] != "not-an-email" {
+		t.Errorf("expected the failed rows in the report, got %v", records)
+	}
+}
+
+func TestNDJSONSource(t *testing.T) {
+	src := newNDJSONSource(strings.NewReader("{\"id\": 1, \"tags\": [\"a\"]}\n\n{\"id\": 2.5, \"extra\": true}\n{broken\n"))
+
+	row, err := src.next()
+	if err != nil || row.values["id"] != int64(1) || row.values["tags"] != `["a"]` {
+		t.Fatalf("expected the first row with its number and json, got %+v, %v", row, err)
+	}
+
+	row, _ = src.next()
+	if row.line != 3 || row.values["id"] != 2.5 || !strings.Contains(row.err, "extra") {
+		t.Errorf("expected a column missing from the first row to fail line 3, got %+v", row)
+	}
+
+	row, _ = src.next()
+	if row.line != 4 || row.err == "" {
+		t.Errorf("expected line 4 to fail to parse, got %+v", row)
+	}
+}
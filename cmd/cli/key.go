@@ -0,0 +1,236 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jimmitjoo/gemquick"
+	"github.com/jimmitjoo/gemquick/database"
+)
+
+var validIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// doKeyRotate generates a new KEY, re-encrypts the given table.column values (and the ones listed in
+// ENCRYPTED_COLUMNS) with it in a single transaction, and then swaps the key in .env.
+// Encrypted columns must live in tables with an id primary key
+func (r *Runner) doKeyRotate(columns []string) error {
+	if r.DryRun {
+		return errors.New("make key rotate cannot be dry run, it rewrites the encrypted columns")
+	}
+
+	oldKey := r.getenv("KEY")
+	if len(oldKey) != 32 {
+		return errors.New("KEY in .env must be 32 characters long to be rotated")
+	}
+
+	if env := r.getenv("ENCRYPTED_COLUMNS"); env != "" {
+		columns = append(columns, strings.Split(env, ",")...)
+	}
+
+	// check that the columns can be reached before anything is written
+	var db *sql.DB
+	if len(columns) > 0 {
+		if r.gem.DB.DataType == "" {
+			return errors.New("you have to define a database type to re-encrypt columns")
+		}
+
+		dsn := r.driverDSN()
+		if dsn == "" {
+			return fmt.Errorf("re-encrypting columns is not supported for DATABASE_TYPE %s", r.gem.DB.DataType)
+		}
+
+		var err error
+		db, err = r.gem.OpenDB(r.gem.DB.DataType, dsn)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = db.Close()
+		}()
+	}
+
+	newKey := r.gem.RandomString(32)
+
+	// write the new .env next to the old one first, so nothing is committed if that fails
+	envFile := filepath.Join(r.RootPath, ".env")
+	// encrypted sessions stay readable with the old keys until they expire
+	var previous string
+	if encrypt, _ := strconv.ParseBool(r.getenv("SESSION_ENCRYPT")); encrypt {
+		previous = appendKey(r.getenv("KEY_PREVIOUS"), oldKey)
+	}
+
+	tmpFile, err := writeEnvWithKey(envFile, newKey, previous)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmpFile)
+	}()
+
+	if len(columns) > 0 {
+		count, err := r.reencryptColumns(db, columns, oldKey, newKey)
+		if err != nil {
+			return err
+		}
+
+		r.green("Re-encrypted %d values", count)
+	}
+
+	err = os.Rename(tmpFile, envFile)
+	if err != nil {
+		return fmt.Errorf("values were re-encrypted with %s but .env could not be updated: %w", newKey, err)
+	}
+
+	r.green("KEY in .env rotated, restart the app to use it")
+	if previous != "" {
+		r.yellow("The old key is added to KEY_PREVIOUS so that encrypted sessions stay valid, remove the keys from it once their sessions have expired")
+	} else {
+		r.yellow("Sessions are not encrypted with KEY and stay valid")
+	}
+
+	return nil
+}
+
+// appendKey adds key to the comma separated list of keys, unless it is in it already
+func appendKey(list, key string) string {
+	var keys []string
+	for _, k := range strings.Split(list, ",") {
+		if k = strings.TrimSpace(k); k != "" && k != key {
+			keys = append(keys, k)
+		}
+	}
+
+	return strings.Join(append(keys, key), ",")
+}
+
+// writeEnvWithKey writes a copy of the .env file with KEY replaced, and KEY_PREVIOUS too when
+// previous is set, and returns its path
+func writeEnvWithKey(envFile, key, previous string) (string, error) {
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(string(content), "\n")
+	lines = setEnv(lines, "KEY", key)
+	if previous != "" {
+		lines = setEnv(lines, "KEY_PREVIOUS", previous)
+	}
+
+	info, err := os.Stat(envFile)
+	if err != nil {
+		return "", err
+	}
+
+	tmpFile := envFile + ".rotate"
+	err = os.WriteFile(tmpFile, []byte(strings.Join(lines, "\n")), info.Mode())
+	if err != nil {
+		return "", err
+	}
+
+	return tmpFile, nil
+}
+
+// setEnv replaces the setting name in the lines of a .env file, or adds it when it is not there
+func setEnv(lines []string, name, value string) []string {
+	replaced := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), name+"=") {
+			lines[i] = name + "=" + value
+			replaced = true
+		}
+	}
+
+	if !replaced {
+		// before the empty last line of a file that ends in a newline
+		if n := len(lines); n > 0 && lines[n-1] == "" {
+			return append(lines[:n-1], name+"="+value, "")
+		}
+		lines = append(lines, name+"="+value)
+	}
+
+	return lines
+}
+
+// reencryptColumns decrypts every value of the table.column columns with oldKey and encrypts it
+// with newKey in one transaction, which is rolled back when any value cannot be decrypted
+func (r *Runner) reencryptColumns(db *sql.DB, columns []string, oldKey, newKey string) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	oldEnc := gemquick.Encryption{Key: []byte(oldKey)}
+	newEnc := gemquick.Encryption{Key: []byte(newKey)}
+
+	count := 0
+	for _, column := range columns {
+		table, col, ok := strings.Cut(strings.TrimSpace(column), ".")
+		if !ok || !validIdentifier.MatchString(table) || !validIdentifier.MatchString(col) {
+			return 0, fmt.Errorf("%q is not a table.column", column)
+		}
+
+		n, err := r.reencryptColumn(tx, table, col, oldEnc, newEnc)
+		if err != nil {
+			return 0, fmt.Errorf("%s.%s: %w", table, col, err)
+		}
+		count += n
+	}
+
+	return count, tx.Commit()
+}
+
+func (r *Runner) reencryptColumn(tx *sql.Tx, table, column string, oldEnc, newEnc gemquick.Encryption) (int, error) {
+	rows, err := tx.Query(fmt.Sprintf("SELECT id, %s FROM %s WHERE %s IS NOT NULL AND %s <> ''", column, table, column, column))
+	if err != nil {
+		return 0, err
+	}
+
+	type value struct {
+		id        int64
+		encrypted string
+	}
+
+	var values []value
+	for rows.Next() {
+		var v value
+		if err := rows.Scan(&v.id, &v.encrypted); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		values = append(values, v)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	update := database.Rebind(r.gem.DB.DataType, fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", table, column))
+
+	for _, v := range values {
+		plain, err := oldEnc.Decrypt(v.encrypted)
+		if err != nil {
+			return 0, err
+		}
+
+		encrypted, err := newEnc.Encrypt(plain)
+		if err != nil {
+			return 0, err
+		}
+
+		if _, err := tx.Exec(update, encrypted, v.id); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(values), nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"database/sql/driver"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jimmitjoo/gemquick"
+)
+
+const (
+	oldTestKey = "0123456789abcdef0123456789abcdef"
+	newTestKey = "fedcba9876543210fedcba9876543210"
+)
+
+func TestRunner_ReencryptColumns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	encrypted, err := gemquick.Encryption{Key: []byte(oldTestKey)}.Encrypt("4111 1111 1111 1111")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRunner(t.TempDir())
+	r.gem.DB.DataType = "postgres"
+
+	var reencrypted string
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, card FROM customers WHERE card IS NOT NULL AND card <> ''`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "card"}).AddRow(7, encrypted))
+	mock.ExpectExec(`UPDATE customers SET card = \$1 WHERE id = \$2`).
+		WithArgs(capture{&reencrypted}, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	count, err := r.reencryptColumns(db, []string{"customers.card"}, oldTestKey, newTestKey)
+	if err != nil || count != 1 {
+		t.Fatalf("expected 1 value to be re-encrypted, got %d, %v", count, err)
+	}
+
+	plain, err := gemquick.Encryption{Key: []byte(newTestKey)}.Decrypt(reencrypted)
+	if err != nil || plain != "4111 1111 1111 1111" {
+		t.Errorf("expected the value to decrypt with the new key, got %q, %v", plain, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunner_ReencryptColumns_RollsBack(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewRunner(t.TempDir())
+	r.gem.DB.DataType = "postgres"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, card FROM customers`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "card"}).AddRow(7, "not encrypted"))
+	mock.ExpectRollback()
+
+	if _, err := r.reencryptColumns(db, []string{"customers.card"}, oldTestKey, newTestKey); err == nil {
+		t.Error("expected a value that does not decrypt to fail the rotation")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunner_KeyRotate_UnsupportedDatabase(t *testing.T) {
+	root := newProject(t)
+	env := "KEY=" + oldTestKey + "\nDATABASE_TYPE=oracle\nENCRYPTED_COLUMNS=customers.card\n"
+	if err := os.WriteFile(filepath.Join(root, ".env"), []byte(env), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRunner(root)
+	if err := r.setup("make"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.doKeyRotate(nil); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("expected an unsupported database to be rejected, got %v", err)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(root, ".env"))
+	if string(content) != env {
+		t.Errorf("expected .env to be left alone, got %s", content)
+	}
+}
+
+func TestWriteEnvWithKey(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envFile, []byte("APP_NAME=shop\nKEY="+oldTestKey+"\nPORT=4000\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpFile, err := writeEnvWithKey(envFile, newTestKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ := os.ReadFile(tmpFile)
+	if string(content) != "APP_NAME=shop\nKEY="+newTestKey+"\nPORT=4000\n" {
+		t.Errorf("expected only KEY to be replaced, got %q", content)
+	}
+
+	if info, _ := os.Stat(tmpFile); info.Mode().Perm() != 0600 {
+		t.Errorf("expected the file mode of .env to be kept, got %s", info.Mode())
+	}
+
+	// with encrypted sessions the old key is kept to read them
+	tmpFile, err = writeEnvWithKey(envFile, newTestKey, oldTestKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ = os.ReadFile(tmpFile)
+	if string(content) != "APP_NAME=shop\nKEY="+newTestKey+"\nPORT=4000\nKEY_PREVIOUS="+oldTestKey+"\n" {
+		t.Errorf("expected KEY_PREVIOUS to be added, got %q", content)
+	}
+}
+
+func TestAppendKey(t *testing.T) {
+	tests := []struct {
+		list, expected string
+	}{
+		{"", oldTestKey},
+		{"first", "first," + oldTestKey},
+		{"first, second,", "first,second," + oldTestKey},
+		{oldTestKey + ",first", "first," + oldTestKey},
+	}
+
+	for _, tt := range tests {
+		if list := appendKey(tt.list, oldTestKey); list != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.list, tt.expected, list)
+		}
+	}
+}
+
+// capture is a sqlmock argument that stores the value it is matched against
+type capture struct {
+	value *string
+}
+
+func (c capture) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	*c.value = s
+	return ok
+}
@@ -1,227 +1,193 @@
 package main
 
 import (
-	"bytes"
-	"errors"
-	"fmt"
-	"github.com/gertd/go-pluralize"
-	"os"
-	"strings"
-	"time"
-
-	"github.com/fatih/color"
-	"github.com/iancoleman/strcase"
-)
-
-func doMake(arg2, arg3 string) error {
-
-	switch arg2 {
-	case "key":
-		handleKey()
-
-	case "auth":
-		handleAuth()
-
-	case "mail":
-		handleMail(arg3)
-
-	case "handler":
-		handleHandler(arg3)
-
-	case "migration":
-		handleMigration(arg3)
+	"flag"
 
-	case "model":
-		handleModel(arg3)
-
-	case "session":
-		handleSession()
+	"github.com/jimmitjoo/gemquick/scaffold"
+)
 
-	default:
-		exitGracefully(errors.New("Unknown subcommand" + arg3))
+// scaffoldOptions points the generators of the scaffold package at the runner's project
+func (r *Runner) scaffoldOptions() scaffold.Options {
+	return scaffold.Options{
+		Root:          r.RootPath,
+		FS:            r.FS,
+		DatabaseType:  r.gem.DB.DataType,
+		MigrationsDir: r.migrationsDir(),
+		DryRun:        r.DryRun,
 	}
-
-	return nil
 }
 
-func handleKey() {
-	rnd := gem.RandomString(32)
-	color.Green("Your new encryption key is: %s", rnd)
-}
-
-func handleAuth() {
-	err := doAuth()
-	if err != nil {
-		exitGracefully(err)
+// report prints what a generator created and changed, and what is left to do by hand. In a dry
+// run it prints what the generator would have done, with the changes to the files it updates
+func (r *Runner) report(res *scaffold.Result, err error) error {
+	if res == nil {
+		return err
 	}
-}
 
-func handleMail(name string) {
-	if name == "" {
-		exitGracefully(errors.New("you must give the mail a name"))
+	created, updated := "Created %s", "Updated %s"
+	if r.DryRun {
+		r.recordDetail("dry_run", true)
+		created, updated = "Would create %s", "Would update %s"
 	}
 
-	err := doMail(name)
-	if err != nil {
-		exitGracefully(err)
+	for _, file := range res.Files {
+		r.recordFile(file)
+		r.green(created, file)
 	}
-}
 
-func handleHandler(name string) {
-	if name == "" {
-		exitGracefully(errors.New("you must give the handler a name"))
-	}
+	shown := map[string]bool{}
+	for _, file := range res.Updated {
+		previous, existed := res.Previous[file]
+		if r.DryRun && (!existed || shown[file]) {
+			// created by the generator as well, its content is new anyway
+			continue
+		}
+		shown[file] = true
 
-	fileName := gem.RootPath + "/handlers/" + strings.ToLower(name) + ".go"
-	if fileExists(fileName) {
-		exitGracefully(errors.New(fileName + " already exists."))
+		r.green(updated, file)
+		if r.DryRun {
+			r.printDiff(file, file+" (after make)", string(previous), string(res.Contents[file]))
+		}
 	}
 
-	data, err := templateFS.ReadFile("templates/handlers/handler.go.txt")
-	if err != nil {
-		exitGracefully(err)
+	for _, note := range res.Notes {
+		r.yellow(note)
 	}
 
-	handler := string(data)
-	handler = strings.ReplaceAll(handler, "$HANDLERNAME$", strcase.ToCamel(name))
+	return err
+}
 
-	err = os.WriteFile(fileName, []byte(handler), 0644)
-	if err != nil {
-		exitGracefully(err)
-	}
+func (r *Runner) handleKey() {
+	rnd := r.gem.RandomString(32)
+	r.green("Your new encryption key is: %s", rnd)
 }
 
-func handleMigration(name string) {
-	if name == "" {
-		exitGracefully(errors.New("migration name is required"))
+// doAuth creates everything authentication needs and runs its migration
+func (r *Runner) doAuth() error {
+	err := r.report(scaffold.Auth(r.scaffoldOptions()))
+	if err != nil || r.DryRun {
+		return err
 	}
 
-	// check if there is a database connection
-	if gem.DB.DataType == "" {
-		panic("you have to define a database type to create migrations")
+	err = r.doMigrate("up", "")
+	if err != nil {
+		return err
 	}
 
-	dbType := gem.DB.DataType
-	fileName := fmt.Sprintf("%d_%s.%s", time.Now().UnixMicro(), name, dbType)
-
-	migrationUpFile := gem.RootPath + "/migrations/" + fileName + ".up.sql"
-	migrationDownFile := gem.RootPath + "/migrations/" + fileName + ".down.sql"
+	r.yellow("  - users, tokens and remember_tokens migrations created and ran")
+	r.yellow("  - user and token models created")
+	r.yellow("  - auth middleware created")
 
-	err := copyFileFromTemplate("templates/migrations/migration."+dbType+".up.sql", migrationUpFile)
-	if err != nil {
-		exitGracefully(err)
-	}
+	return nil
+}
 
-	err = copyFileFromTemplate("templates/migrations/migration."+dbType+".down.sql", migrationDownFile)
-	if err != nil {
-		exitGracefully(err)
+// doSession creates the sessions table for storing sessions in the database
+func (r *Runner) doSession() error {
+	err := r.report(scaffold.Session(r.scaffoldOptions()))
+	if err != nil || r.DryRun {
+		return err
 	}
 
-	reformatMigration(migrationUpFile, name)
-	reformatMigration(migrationDownFile, name)
+	return r.doMigrate("up", "")
 }
 
-func reformatMigration(migrationFile string, tableName string) {
-	content, err := os.ReadFile(migrationFile)
+// doMail creates the templates of a new email: an html and a plain text one, or with --markdown a
+// single markdown template that the email package renders to both
+func (r *Runner) doMail(name string, args []string) error {
+	flags := flag.NewFlagSet("make mail", flag.ContinueOnError)
+	markdown := flags.Bool("markdown", false, "write the email in markdown instead of html and plain text")
+
+	err := flags.Parse(args)
 	if err != nil {
-		exitGracefully(err)
+		return err
 	}
 
-	if bytes.Contains(content, []byte("TABLENAME")) {
-		content = bytes.ReplaceAll(content, []byte("TABLENAME"), []byte(tableName))
-		if err = os.WriteFile(migrationFile, content, 0644); err != nil {
-			exitGracefully(err)
-		}
-	}
+	return r.report(scaffold.Mail(scaffold.MailOptions{Options: r.scaffoldOptions(), Name: name, Markdown: *markdown}))
 }
 
-func handleModel(name string) {
-	if name == "" {
-		exitGracefully(errors.New("model name is required"))
-	}
+// doMiddleware creates a middleware in the middleware directory and, with --register, adds it
+// to the top of the routes function so that it runs for every route
+func (r *Runner) doMiddleware(name string, args []string) error {
+	flags := flag.NewFlagSet("make middleware", flag.ContinueOnError)
+	register := flags.Bool("register", false, "use the middleware for every route in routes.go")
 
-	data, err := templateFS.ReadFile("templates/data/model.go.txt")
+	err := flags.Parse(args)
 	if err != nil {
-		exitGracefully(err)
+		return err
 	}
 
-	model := string(data)
-	plural := pluralize.NewClient()
-	var modelName = name
-	var tableName = name
+	return r.report(scaffold.Middleware(scaffold.MiddlewareOptions{Options: r.scaffoldOptions(), Name: name, Register: *register}))
+}
 
-	if plural.IsPlural(name) {
-		modelName = plural.Singular(name)
-		tableName = strings.ToLower(name)
-	} else {
-		tableName = strings.ToLower(plural.Plural(name))
-	}
+// doView creates the migration for a view, a materialized view with --materialized
+func (r *Runner) doView(name string, args []string) error {
+	flags := flag.NewFlagSet("make view", flag.ContinueOnError)
+	materialized := flags.Bool("materialized", false, "create a materialized view, postgres only")
 
-	fileName := gem.RootPath + "/data/" + strings.ToLower(modelName) + ".go"
-	if fileExists(fileName) {
-		exitGracefully(errors.New(fileName + " already exists."))
+	err := flags.Parse(args)
+	if err != nil {
+		return err
 	}
 
-	modelCamelName := strcase.ToCamel(name)
-	modelCamelNamePlural := pluralize.NewClient().Plural(modelCamelName)
-	model = strings.ReplaceAll(model, "$MODELNAME$", modelCamelName)
-	model = strings.ReplaceAll(model, "$TABLENAME$", tableName)
+	return r.report(scaffold.View(scaffold.ViewOptions{Options: r.scaffoldOptions(), Name: name, Materialized: *materialized}))
+}
+
+// doPartitionedTable creates the migration for a table partitioned by --column, per --by day or month
+func (r *Runner) doPartitionedTable(table string, args []string) error {
+	flags := flag.NewFlagSet("make partitioned-table", flag.ContinueOnError)
+	column := flags.String("column", "created_at", "the time column the table is partitioned by")
+	by := flags.String("by", "day", "how much time a partition covers, day or month")
 
-	err = copyDataToFile([]byte(model), fileName)
+	err := flags.Parse(args)
 	if err != nil {
-		exitGracefully(err)
+		return err
 	}
 
-	color.Green(modelCamelName+" created: %s", fileName)
+	return r.report(scaffold.PartitionedTable(scaffold.PartitionedTableOptions{Options: r.scaffoldOptions(), Table: table, Column: *column, Interval: *by}))
+}
 
-	// create a migration for the model
-	if gem.DB.DataType != "" {
+// doListener creates a listener, registered for the event given with --event
+func (r *Runner) doListener(name string, args []string) error {
+	flags := flag.NewFlagSet("make listener", flag.ContinueOnError)
+	event := flags.String("event", "", "register the listener for this event in listeners/providers.go")
+	queued := flags.Bool("queued", false, "handle the event in the background, through the event queue")
 
-		dbType := gem.DB.DataType
-		migrationFileName := fmt.Sprintf("%d_%s.%s", time.Now().UnixMicro(), "create_"+tableName+"_table", dbType)
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
 
-		migrationUpFile := gem.RootPath + "/migrations/" + migrationFileName + ".up.sql"
-		migrationDownFile := gem.RootPath + "/migrations/" + migrationFileName + ".down.sql"
+	return r.report(scaffold.Listener(scaffold.ListenerOptions{Options: r.scaffoldOptions(), Name: name, Event: *event, Queued: *queued}))
+}
 
-		err = copyFileFromTemplate("templates/migrations/migration."+dbType+".up.sql", migrationUpFile)
-		if err != nil {
-			exitGracefully(err)
-		}
+// doEnum creates an enum with the values in args, which can be mixed with its flags
+func (r *Runner) doEnum(name string, args []string) error {
+	flags := flag.NewFlagSet("make enum", flag.ContinueOnError)
+	iota := flags.Bool("iota", false, "store the enum as a number instead of as its value")
+	check := flags.String("check", "", "add a CHECK constraint for the values to this table.column")
+	lookup := flags.Bool("lookup", false, "create a lookup table holding the values")
 
-		err = copyFileFromTemplate("templates/migrations/migration."+dbType+".down.sql", migrationDownFile)
+	var values []string
+	for {
+		err := flags.Parse(args)
 		if err != nil {
-			exitGracefully(err)
+			return err
 		}
 
-		reformatMigration(migrationUpFile, tableName)
-		reformatMigration(migrationDownFile, tableName)
-
-		color.Green("Migrations for model %s created: %s", modelCamelName, migrationFileName)
-
-		// add model to models.go
-		modelsContent, err := os.ReadFile(gem.RootPath + "/data/models.go")
-		if err != nil {
-			exitGracefully(err)
+		if flags.NArg() == 0 {
+			break
 		}
 
-		// replace stubfile with the new model
-		if bytes.Contains(modelsContent, []byte(modelCamelName)) {
-			exitGracefully(errors.New(modelCamelName + " already exists in models.go"))
-		} else {
-			modelsContent = bytes.Replace(modelsContent, []byte("type Models struct {"), []byte("type Models struct {\n\t"+modelCamelNamePlural+" "+modelCamelName+"\n"), 1)
-			modelsContent = bytes.Replace(modelsContent, []byte("return Models{"), []byte("return Models{\n\t\t"+modelCamelNamePlural+": "+modelCamelName+"{},\n"), 1)
-			if err = os.WriteFile(gem.RootPath+"/data/models.go", modelsContent, 0644); err != nil {
-				exitGracefully(err)
-			}
-
-			color.Green(modelCamelName + " added to models.go")
-		}
+		values = append(values, flags.Arg(0))
+		args = flags.Args()[1:]
 	}
-}
 
-func handleSession() {
-	err := doSession()
-	if err != nil {
-		exitGracefully(err)
-	}
+	return r.report(scaffold.Enum(scaffold.EnumOptions{
+		Options: r.scaffoldOptions(),
+		Name:    name,
+		Values:  values,
+		Iota:    *iota,
+		Check:   *check,
+		Lookup:  *lookup,
+	}))
 }
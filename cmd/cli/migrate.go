@@ -1,40 +1,146 @@
 package main
 
-func doMigrate(arg2, arg3 string) error {
-	dsn := getDSN()
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// runMigrate migrates in direction with the arguments of gq migrate, adding the versions before and
+// after to the JSON result
+func (r *Runner) runMigrate(direction string, args []string) error {
+	if r.JSON && r.gem.DB.DataType != "" {
+		if from, _, err := r.gem.MigrationVersion(r.getDSN()); err == nil {
+			r.recordDetail("from_version", from)
+		}
+	}
+
+	err := r.doMigrate(direction, argAt(args, 0), argsFrom(args, 1)...)
+	if err != nil {
+		return err
+	}
+
+	if r.JSON && r.gem.DB.DataType != "" {
+		if to, _, err := r.gem.MigrationVersion(r.getDSN()); err == nil {
+			r.recordDetail("to_version", to)
+		}
+	}
+
+	r.yellow("Migrations completed")
+
+	return nil
+}
+
+// doMigrate runs the migrations in direction arg2. arg3 is "all" for down, a number of steps,
+// or "--to <version>" (also "--to=<version>") to migrate exactly to that version
+func (r *Runner) doMigrate(arg2, arg3 string, rest ...string) error {
+	dsn := r.getDSN()
+
+	steps, to, err := parseMigrateTarget(arg3, rest)
+	if err != nil {
+		return err
+	}
 
 	switch arg2 {
 	case "up":
-		err := gem.MigrateUp(dsn)
+		if to > 0 {
+			return r.migrateTo(to, dsn, true)
+		}
+
+		if steps > 0 {
+			return r.gem.Steps(steps, dsn)
+		}
+
+		err := r.gem.MigrateUp(dsn)
 		if err != nil {
 			return err
 		}
 	case "down":
+		if to > 0 {
+			return r.migrateTo(to, dsn, false)
+		}
+
 		if arg3 == "all" {
-			err := gem.MigrateDownAll(dsn)
+			err := r.gem.MigrateDownAll(dsn)
 			if err != nil {
 				return err
 			}
 			return nil
 		} else {
-			err := gem.Steps(-1, dsn)
+			if steps == 0 {
+				steps = 1
+			}
+
+			err := r.gem.Steps(-steps, dsn)
 			if err != nil {
 				return err
 			}
 			return nil
 		}
 	case "reset":
-		err := gem.MigrateDownAll(dsn)
+		err := r.gem.MigrateDownAll(dsn)
 		if err != nil {
 			return err
 		}
-		err = gem.MigrateUp(dsn)
+		err = r.gem.MigrateUp(dsn)
 		if err != nil {
 			return err
 		}
 
 	default:
-		showHelp()
+		r.showHelp()
 	}
 	return nil
 }
+
+// parseMigrateTarget reads a step count or a --to version from the arguments after the direction
+func parseMigrateTarget(arg3 string, rest []string) (steps int, to uint, err error) {
+	switch {
+	case arg3 == "" || arg3 == "all":
+		return 0, 0, nil
+	case arg3 == "--to" || arg3 == "-to":
+		if len(rest) == 0 {
+			return 0, 0, errors.New("--to needs the version to migrate to")
+		}
+		arg3 = "--to=" + rest[0]
+	}
+
+	value, ok := strings.CutPrefix(arg3, "--to=")
+	if !ok {
+		value, ok = strings.CutPrefix(arg3, "-to=")
+	}
+
+	if ok {
+		version, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || version == 0 {
+			return 0, 0, fmt.Errorf("%q is not a migration version", value)
+		}
+		return 0, uint(version), nil
+	}
+
+	steps, err = strconv.Atoi(arg3)
+	if err != nil || steps < 1 {
+		return 0, 0, fmt.Errorf("%q is not a number of steps", arg3)
+	}
+
+	return steps, 0, nil
+}
+
+// migrateTo migrates to version, refusing to go the other way than the command says
+func (r *Runner) migrateTo(version uint, dsn string, up bool) error {
+	current, _, err := r.gem.MigrationVersion(dsn)
+	if err != nil {
+		return err
+	}
+
+	if up && version < current {
+		return fmt.Errorf("the database is at %d, use migrate down --to %d to go back", current, version)
+	}
+
+	if !up && version > current {
+		return fmt.Errorf("the database is at %d, use migrate up --to %d to go forward", current, version)
+	}
+
+	return r.gem.MigrateTo(version, dsn)
+}
@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestParseMigrateTarget(t *testing.T) {
+	var tests = []struct {
+		name  string
+		arg3  string
+		rest  []string
+		steps int
+		to    uint
+		fails bool
+	}{
+		{"everything", "", nil, 0, 0, false},
+		{"all", "all", nil, 0, 0, false},
+		{"steps", "2", nil, 2, 0, false},
+		{"to with a space", "--to", []string{"20240101120000"}, 0, 20240101120000, false},
+		{"single dash to with a space", "-to", []string{"20240101120000"}, 0, 20240101120000, false},
+		{"to with an equals sign", "--to=20240101120000", nil, 0, 20240101120000, false},
+		{"single dash to with an equals sign", "-to=20240101120000", nil, 0, 20240101120000, false},
+		{"to without a version", "--to", nil, 0, 0, true},
+		{"to a version that is not a number", "--to=latest", nil, 0, 0, true},
+		{"to version zero", "--to=0", nil, 0, 0, true},
+		{"zero steps", "0", nil, 0, 0, true},
+		{"negative steps", "-1", nil, 0, 0, true},
+		{"not a number", "some", nil, 0, 0, true},
+	}
+
+	for _, e := range tests {
+		steps, to, err := parseMigrateTarget(e.arg3, e.rest)
+
+		if e.fails {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %d steps to %d", e.name, steps, to)
+			}
+			continue
+		}
+
+		if err != nil || steps != e.steps || to != e.to {
+			t.Errorf("%s: expected %d steps to %d, got %d steps to %d, %v", e.name, e.steps, e.to, steps, to, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/jimmitjoo/gemquick/openapi"
+)
+
+// doMock serves the example responses of the OpenAPI document, so the frontend can be built against
+// the api before its handlers are written
+func (r *Runner) doMock(args []string) error {
+	flags := flag.NewFlagSet("mock", flag.ContinueOnError)
+	spec := flags.String("spec", r.envOr("OPENAPI_SPEC", "openapi.json"), "the OpenAPI document (json) to serve the examples of")
+	port := flags.Int("port", 4010, "the port to serve the mock api on")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	path := *spec
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.RootPath, path)
+	}
+
+	doc, err := openapi.Load(path)
+	if err != nil {
+		return err
+	}
+
+	mock := doc.MockHandler()
+	for _, e := range doc.Endpoints() {
+		r.cyan("\t%-7s %s", e.Method, e.Path)
+	}
+	r.green("Mocking %s on http://localhost:%d, ask for other responses with a Prefer: code=404 header", *spec, *port)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		mock.ServeHTTP(rec, req)
+		r.white("%s %s %d", req.Method, req.URL.Path, rec.status)
+	})
+
+	return http.ListenAndServe(fmt.Sprintf(":%d", *port), handler)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"path/filepath"
+	"strings"
+
+	"github.com/jimmitjoo/gemquick/openapi"
+	"gopkg.in/yaml.v2"
+)
+
+// doOpenAPI writes an OpenAPI document for the routes in routes.go, as JSON or, when the file
+// ends in .yaml or .yml, as YAML
+func (r *Runner) doOpenAPI(args []string) error {
+	flags := flag.NewFlagSet("openapi", flag.ContinueOnError)
+	output := flags.String("o", r.envOr("OPENAPI_SPEC", "openapi.json"), "the file to write the document to")
+	prefix := flags.String("prefix", "/api", "only document the routes below this path, use / for every route")
+	title := flags.String("title", r.envOr("APP_NAME", r.appModuleName()), "the title of the api")
+	version := flags.String("version", "1.0.0", "the version of the api")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	g := &openapi.Generator{
+		Root:    r.RootPath,
+		Title:   *title,
+		Version: *version,
+		Prefix:  strings.TrimSuffix(*prefix, "/"),
+	}
+
+	spec, err := g.Generate()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	ext := strings.ToLower(filepath.Ext(*output))
+	if ext == ".yaml" || ext == ".yml" {
+		var doc interface{}
+		if err = json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+
+		data, err = yaml.Marshal(doc)
+		if err != nil {
+			return err
+		}
+	}
+
+	path := *output
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.RootPath, path)
+	}
+
+	err = r.copyDataToFile(data, path)
+	if err != nil {
+		return err
+	}
+
+	r.green("Documented %d paths in %s", len(spec.Paths), *output)
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// cliResult is what gq prints as JSON with --json
+type cliResult struct {
+	Command  string                 `json:"command"`
+	Success  bool                   `json:"success"`
+	Error    string                 `json:"error,omitempty"`
+	Files    []string               `json:"files,omitempty"`
+	Messages []string               `json:"messages,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+}
+
+// parseGlobalFlags sets the flags that apply to every command and returns the other arguments
+func (r *Runner) parseGlobalFlags(args []string) []string {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "--json" || arg == "-json":
+			r.JSON = true
+		case arg == "--dry-run" || arg == "-dry-run":
+			r.DryRun = true
+		case (arg == "--database" || arg == "-database") && i+1 < len(args):
+			r.Database = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--database="):
+			r.Database = strings.TrimPrefix(arg, "--database=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	if r.JSON {
+		r.result.Command = strings.Join(rest, " ")
+	}
+
+	return rest
+}
+
+// recordFile adds a created file to the JSON result
+func (r *Runner) recordFile(path string) {
+	if r.JSON {
+		r.result.Files = append(r.result.Files, path)
+	}
+}
+
+// recordDetail adds a command specific value to the JSON result
+func (r *Runner) recordDetail(key string, value interface{}) {
+	if r.JSON {
+		r.result.Details[key] = value
+	}
+}
+
+// printResult writes the JSON result and exits, with status 1 if err is set
+func (r *Runner) printResult(err error) {
+	r.result.Success = err == nil
+	if err != nil {
+		r.result.Error = err.Error()
+	}
+
+	enc := json.NewEncoder(r.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(r.result)
+
+	if err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
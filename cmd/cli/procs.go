@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jimmitjoo/gemquick/debugbar"
+)
+
+// process is the app or a command gq serve runs next to it, like a queue worker or an asset
+// watcher
+type process struct {
+	name string
+	args []string
+	// restart is set for processes that run the project's Go code, which are restarted with the
+	// app when a watched file changes
+	restart bool
+
+	cmd      *exec.Cmd
+	done     chan struct{}
+	stopping atomic.Bool
+}
+
+// start runs the process with its output going to the mux. When it exits without being stopped,
+// that is told in its output
+func (p *process) start(dir string, env []string, mux *logMux) error {
+	out := mux.writer(p.name)
+
+	p.cmd = exec.Command(p.args[0], p.args[1:]...)
+	p.cmd.Dir = dir
+	p.cmd.Env = env
+	p.cmd.Stdout = out
+	p.cmd.Stderr = out
+	p.done = make(chan struct{})
+	p.stopping.Store(false)
+
+	if err := p.cmd.Start(); err != nil {
+		p.cmd = nil
+		return err
+	}
+
+	go func(cmd *exec.Cmd, done chan struct{}) {
+		err := cmd.Wait()
+		_ = out.Close()
+		if !p.stopping.Load() {
+			if err != nil {
+				mux.add(p.name, "exited: "+err.Error())
+			} else {
+				mux.add(p.name, "exited")
+			}
+		}
+		close(done)
+	}(p.cmd, p.done)
+
+	return nil
+}
+
+// stop asks the process to shut down and kills it if it has not exited within five seconds
+func (p *process) stop() {
+	if p.cmd == nil || p.cmd.Process == nil {
+		return
+	}
+
+	p.stopping.Store(true)
+	_ = p.cmd.Process.Signal(syscall.SIGTERM)
+
+	select {
+	case <-p.done:
+	case <-time.After(5 * time.Second):
+		_ = p.cmd.Process.Kill()
+		<-p.done
+	}
+
+	p.cmd = nil
+}
+
+// readProcfile returns the processes of a Procfile, with a "name: command" line for every process.
+// A missing file has none
+func readProcfile(path string, restart []string) ([]*process, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var procs []*process
+	seen := map[string]bool{"app": true}
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, command, ok := strings.Cut(line, ":")
+		name, command = strings.TrimSpace(name), strings.TrimSpace(command)
+		if !ok || name == "" || command == "" {
+			return nil, fmt.Errorf("%s:%d: expected name: command", path, n)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s:%d: %s is taken, the app is run as app", path, n, name)
+		}
+		seen[name] = true
+
+		// exec hands the shell's process to the command, so that it gets the signal to stop
+		p := &process{name: name, args: []string{"sh", "-c", "exec " + command}}
+		for _, r := range restart {
+			p.restart = p.restart || r == name
+		}
+		procs = append(procs, p)
+	}
+
+	return procs, scanner.Err()
+}
+
+// prefixColors are given to the sources in turn
+var prefixColors = []color.Attribute{color.FgCyan, color.FgMagenta, color.FgBlue, color.FgGreen, color.FgYellow}
+
+// logMux interleaves the output of the processes gq serve runs line by line, each prefixed with
+// its source, and keeps the latest lines for the debug toolbar
+type logMux struct {
+	out   io.Writer
+	only  map[string]bool
+	width int
+	size  int
+
+	mu     sync.Mutex
+	colors map[string]*color.Color
+	lines  []debugbar.Line
+}
+
+// newLogMux returns a mux that prints the lines of the sources in only, or of every source when
+// only is empty, to out, and keeps the latest size lines of all of them
+func newLogMux(out io.Writer, sources, only []string, size int) *logMux {
+	m := &logMux{out: out, size: size, colors: map[string]*color.Color{}}
+
+	for i, source := range sources {
+		m.colors[source] = color.New(prefixColors[i%len(prefixColors)])
+		if len(source) > m.width {
+			m.width = len(source)
+		}
+	}
+
+	if len(only) > 0 {
+		m.only = map[string]bool{}
+		for _, source := range only {
+			m.only[source] = true
+		}
+	}
+
+	return m
+}
+
+// writer returns the writer for the output of source, which is split into lines
+func (m *logMux) writer(source string) *lineWriter {
+	return &lineWriter{mux: m, source: source}
+}
+
+func (m *logMux) add(source, text string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lines = append(m.lines, debugbar.Line{Time: time.Now(), Source: source, Text: text})
+	if len(m.lines) > m.size {
+		m.lines = m.lines[len(m.lines)-m.size:]
+	}
+
+	if m.only != nil && !m.only[source] {
+		return
+	}
+
+	prefix := fmt.Sprintf("%-*s |", m.width, source)
+	if c, ok := m.colors[source]; ok {
+		prefix = c.Sprint(prefix)
+	}
+	fmt.Fprintf(m.out, "%s %s\n", prefix, text)
+}
+
+// tail returns the latest n lines of the sources, or of every source when there are none
+func (m *logMux) tail(n int, sources []string) []debugbar.Line {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lines := []debugbar.Line{}
+	for i := len(m.lines) - 1; i >= 0 && len(lines) < n; i-- {
+		if len(sources) == 0 || contains(sources, m.lines[i].Source) {
+			lines = append(lines, m.lines[i])
+		}
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+
+	return lines
+}
+
+// ServeHTTP returns the latest lines as JSON, the latest n of the given sources, e.g.
+// /logs?n=50&source=worker. It is what the debug toolbar of the app reads
+func (m *logMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(r.URL.Query().Get("n"))
+	if err != nil || n <= 0 || n > m.size {
+		n = m.size
+	}
+
+	var sources []string
+	for _, source := range r.URL.Query()["source"] {
+		sources = append(sources, splitList(source)...)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(m.tail(n, sources))
+}
+
+// lineWriter hands the complete lines written to it to the mux, and holds on to the rest until
+// the line is complete or the process exits
+type lineWriter struct {
+	mux    *logMux
+	source string
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.mux.add(w.source, strings.TrimRight(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Close hands over the last line, when the process did not end it
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.mux.add(w.source, string(w.buf))
+		w.buf = nil
+	}
+
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
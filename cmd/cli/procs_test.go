@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/debugbar"
+)
+
+func TestReadProcfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Procfile.dev")
+	_ = os.WriteFile(path, []byte("# next to the app\nworker: go run ./cmd/worker\n\nassets: npm run watch -- --color\n"), 0644)
+
+	procs, err := readProcfile(path, []string{"worker"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(procs) != 2 || procs[0].name != "worker" || !procs[0].restart || procs[1].restart {
+		t.Fatalf("expected worker, restarted, and assets, got %+v", procs)
+	}
+	if procs[1].args[2] != "exec npm run watch -- --color" {
+		t.Errorf("expected the command to replace the shell, got %q", procs[1].args)
+	}
+
+	if procs, err := readProcfile(filepath.Join(t.TempDir(), "missing"), nil); err != nil || procs != nil {
+		t.Errorf("expected no processes without a Procfile, got %v %v", procs, err)
+	}
+
+	_ = os.WriteFile(path, []byte("app: ./server\n"), 0644)
+	if _, err := readProcfile(path, nil); err == nil {
+		t.Error("expected app to be taken")
+	}
+}
+
+func TestLogMux(t *testing.T) {
+	var out bytes.Buffer
+	mux := newLogMux(&out, []string{"app", "worker"}, []string{"worker"}, 3)
+
+	app, worker := mux.writer("app"), mux.writer("worker")
+	_, _ = app.Write([]byte("listening on :4000\n"))
+	_, _ = worker.Write([]byte("job 1 do"))
+	_, _ = worker.Write([]byte("ne\r\njob 2 started\npartial"))
+	_ = worker.Close()
+
+	if out.String() != "worker | job 1 done\nworker | job 2 started\nworker | partial\n" {
+		t.Errorf("expected the prefixed lines of the worker only, got %q", out.String())
+	}
+
+	if lines := mux.tail(10, nil); len(lines) != 3 || lines[0].Text != "job 1 done" || lines[2].Text != "partial" {
+		t.Errorf("expected the latest 3 lines in order, got %+v", lines)
+	}
+
+	_, _ = app.Write([]byte("GET /\n"))
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/logs?n=5&source=app", nil))
+
+	var lines []debugbar.Line
+	if err := json.NewDecoder(rr.Body).Decode(&lines); err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || lines[0].Source != "app" || lines[0].Text != "GET /" {
+		t.Errorf("expected the line of the app, got %+v", lines)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	var out bytes.Buffer
+	mux := newLogMux(&out, []string{"once", "forever"}, nil, 10)
+
+	once := &process{name: "once", args: []string{"sh", "-c", "echo hello; exit 3"}}
+	if err := once.start(t.TempDir(), nil, mux); err != nil {
+		t.Fatal(err)
+	}
+	<-once.done
+
+	forever := &process{name: "forever", args: []string{"sh", "-c", "exec sleep 60"}}
+	if err := forever.start(t.TempDir(), nil, mux); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	forever.stop()
+	if time.Since(start) > 4*time.Second {
+		t.Error("expected the process to stop on SIGTERM")
+	}
+
+	if !strings.Contains(out.String(), "once    | hello\nonce    | exited: exit status 3") || strings.Contains(out.String(), "forever") {
+		t.Errorf("expected a process that exits to be told, and one that is stopped not to be, got %q", out.String())
+	}
+}
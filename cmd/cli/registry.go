@@ -0,0 +1,218 @@
+package main
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/jimmitjoo/gemquick/scaffold"
+)
+
+// command is a gq command. The registry of commands drives running them, the help and the shell
+// completion, so a command added here shows up in all three
+type command struct {
+	name string
+	// args are shown after the name in the help, e.g. <name>
+	args    string
+	summary string
+	// flags are completed after the command, without their dashes
+	flags []string
+	// subcommands are chosen by the next argument, the command runs itself when none matches
+	subcommands []*command
+	run         func(r *Runner, args []string) error
+}
+
+// globalFlags apply to every command, see parseGlobalFlags
+var globalFlags = []string{"json", "database"}
+
+// commands is the registry of the commands gq knows. Anything else is handed to the project's
+// own console, see runAppCommand. It is filled in init, as the help refers back to it
+var commands []*command
+
+func init() {
+	// generator is a make subcommand that runs a generator of the scaffold package
+	generator := func(name, args, summary string, run func(r *Runner, opts scaffold.Options, args []string) error) *command {
+		return &command{name: name, args: args, summary: summary, run: func(r *Runner, args []string) error {
+			return run(r, r.scaffoldOptions(), args)
+		}}
+	}
+
+	commands = []*command{
+		{name: "help", summary: "show this help", run: func(r *Runner, args []string) error {
+			r.showHelp()
+			return nil
+		}},
+		{name: "version", summary: "show Gemquick version", run: func(r *Runner, args []string) error {
+			r.green("Gemquick version: %s", r.gem.Version)
+			return nil
+		}},
+		{name: "new", args: "<name> [--template full|api|htmx|minimal]", summary: "creates a new project, api has no views or sessions", flags: []string{"template"},
+			run: func(r *Runner, args []string) error {
+				if argAt(args, 0) == "" {
+					return errors.New("new requires a project name")
+				}
+				return r.doNew(args[0], argsFrom(args, 1))
+			}},
+		{name: "migrate", summary: "runs all migrations up",
+			run: func(r *Runner, args []string) error { return r.runMigrate("up", args) },
+			subcommands: []*command{
+				{name: "up", args: "[n] [--to <version>]", summary: "runs the next n migrations up, or up to the given version", flags: []string{"to"},
+					run: func(r *Runner, args []string) error { return r.runMigrate("up", args) }},
+				{name: "down", args: "[n|all] [--to <version>]", summary: "runs the last, the last n or all migrations down, or down to the given version", flags: []string{"to"},
+					run: func(r *Runner, args []string) error { return r.runMigrate("down", args) }},
+				{name: "reset", summary: "drops all tables and migrates them back up",
+					run: func(r *Runner, args []string) error { return r.runMigrate("reset", args) }},
+			}},
+		{name: "db:refresh-views", args: "[view...]", summary: "refreshes the given materialized views, or all of them, concurrently where they have a unique index",
+			run: func(r *Runner, args []string) error { return r.doRefreshViews(args) }},
+		{name: "db:import", args: "<table> <file> [--upsert <columns>]", summary: "loads a CSV or NDJSON file into a table in chunks, checked against its model, --upsert updates rows conflicting on the columns", flags: []string{"upsert", "chunk", "errors"},
+			run: func(r *Runner, args []string) error {
+				return r.doImport(argAt(args, 0), argAt(args, 1), argsFrom(args, 2))
+			}},
+		{name: "db:restore", args: "<archive...>", summary: "inserts the rows archived while pruning back into their tables, from ARCHIVE_DIR",
+			run: func(r *Runner, args []string) error { return r.doRestore(args) }},
+		{name: "upgrade", args: "[-apply]", summary: "shows how the Makefile, docker and init files differ from the skeleton, -apply updates them", flags: []string{"apply", "skeleton"},
+			run: func(r *Runner, args []string) error { return r.doUpgrade(args) }},
+		{name: "doctor", summary: "checks the project setup and tells what to fix",
+			run: func(r *Runner, args []string) error { return r.doDoctor() }},
+		{name: "serve", args: "[flags]", summary: "builds and runs the app and the processes of Procfile.dev, restarting the app when files change", flags: []string{"ignore", "ext", "debounce", "interval", "procfile", "restart", "only", "logs"},
+			run: func(r *Runner, args []string) error { return r.doServe(args) }},
+		{name: "openapi", args: "[-o openapi.json] [-prefix /api]", summary: "writes an OpenAPI document for the routes, .yaml for YAML", flags: []string{"o", "prefix", "title", "version"},
+			run: func(r *Runner, args []string) error { return r.doOpenAPI(args) }},
+		{name: "mock", args: "[-spec openapi.json] [-port 4010]", summary: "serves the example responses of the OpenAPI document", flags: []string{"spec", "port"},
+			run: func(r *Runner, args []string) error { return r.doMock(args) }},
+		{name: "tinker", summary: "opens a console that runs Go code against the booted app",
+			run: func(r *Runner, args []string) error { return r.doTinker() }},
+		{name: "bench", args: "[flags]", summary: "load tests the running app, see gq bench -h for the flags", flags: []string{"url", "routes", "c", "d", "rate", "timeout"},
+			run: func(r *Runner, args []string) error { return r.doBench(args) }},
+		{name: "app", summary: "lists the project's own commands, run one with gq app:<name> [args]",
+			run: func(r *Runner, args []string) error { return r.doApp(args) }},
+		{name: "completion", args: "bash|zsh|fish", summary: "prints the shell completion script, e.g. source <(gq completion bash)",
+			subcommands: []*command{{name: "bash"}, {name: "zsh"}, {name: "fish"}},
+			run:         func(r *Runner, args []string) error { return r.doCompletion(argAt(args, 0)) }},
+		{name: "make", summary: "generates code, see below", flags: []string{"dry-run"}, subcommands: []*command{
+			{name: "key", summary: "generates a new encryption key",
+				run: func(r *Runner, args []string) error {
+					r.handleKey()
+					return nil
+				},
+				subcommands: []*command{{name: "rotate", args: "[table.column...]", summary: "replaces KEY in .env and re-encrypts the given columns with it",
+					run: func(r *Runner, args []string) error { return r.doKeyRotate(args) }}}},
+			{name: "auth", summary: "creates things for autentications",
+				run: func(r *Runner, args []string) error { return r.doAuth() }},
+			generator("handler", "<name>", "creates a new stub handler in the handlers directory", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.Handler(scaffold.HandlerOptions{Options: opts, Name: argAt(args, 0)}))
+			}),
+			generator("migration", "<name>", "creates two new migrations, up and down", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.Migration(scaffold.MigrationOptions{Options: opts, Name: argAt(args, 0)}))
+			}),
+			{name: "view", args: "<name> [--materialized]", summary: "creates the migration for a view, or a materialized view on postgres", flags: []string{"materialized"},
+				run: func(r *Runner, args []string) error { return r.doView(argAt(args, 0), argsFrom(args, 1)) }},
+			{name: "partitioned-table", args: "<name> [--column created_at] [--by day|month]", summary: "creates the migration for a postgres table partitioned by time, for logs, metrics or audits", flags: []string{"column", "by"},
+				run: func(r *Runner, args []string) error { return r.doPartitionedTable(argAt(args, 0), argsFrom(args, 1)) }},
+			generator("model", "<name>", "creates a new model in the data directory", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.Model(scaffold.ModelOptions{Options: opts, Name: argAt(args, 0)}))
+			}),
+			{name: "session", summary: "creates a table in the database to store sessions",
+				run: func(r *Runner, args []string) error { return r.doSession() }},
+			generator("settings", "", "adds the settings table and handlers to manage it under /admin/settings, new projects have them with the routes commented out", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.Settings(opts))
+			}),
+			generator("activity", "", "creates the activities table that app.Activity records user actions to", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.Activity(opts))
+			}),
+			generator("tags", "", "creates the taggables table that app.Tags tags models of any type in", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.Tags(opts))
+			}),
+			generator("security-reports", "", "creates the security_reports table and the email for the vulnerability reports sent to /.well-known/security", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.SecurityReports(opts))
+			}),
+			generator("favorites", "", "creates the favorites table that app.Favorites keeps what users favorite in", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.Favorites(opts))
+			}),
+			{name: "mail", args: "<name> [--markdown]", summary: "creates a new email in the email directory, in markdown with --markdown", flags: []string{"markdown"},
+				run: func(r *Runner, args []string) error { return r.doMail(argAt(args, 0), argsFrom(args, 1)) }},
+			generator("request", "<name>", "creates a new validated form request in the requests directory", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.Request(scaffold.RequestOptions{Options: opts, Name: argAt(args, 0)}))
+			}),
+			generator("policy", "<model>", "creates a new authorization policy in the policies directory", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.Policy(scaffold.PolicyOptions{Options: opts, Model: argAt(args, 0)}))
+			}),
+			generator("event", "<name>", "creates a new event in the events directory", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.Event(scaffold.EventOptions{Options: opts, Name: argAt(args, 0)}))
+			}),
+			{name: "listener", args: "<name> [--event <event>] [--queued]", summary: "creates a new event listener in the listeners directory, registered for the event with --event, in the background with --queued", flags: []string{"event", "queued"},
+				run: func(r *Runner, args []string) error { return r.doListener(argAt(args, 0), argsFrom(args, 1)) }},
+			generator("command", "<name>", "creates an application command with access to the booted app, run it with gq app:<name>", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.Command(scaffold.CommandOptions{Options: opts, Name: argAt(args, 0)}))
+			}),
+			generator("notification", "<name>", "creates a new notification sent by mail, sms or stored in the database", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.Notification(scaffold.NotificationOptions{Options: opts, Name: argAt(args, 0)}))
+			}),
+			{name: "middleware", args: "<name> [--register]", summary: "creates a middleware, --register uses it for every route", flags: []string{"register"},
+				run: func(r *Runner, args []string) error { return r.doMiddleware(argAt(args, 0), argsFrom(args, 1)) }},
+			generator("websocket", "<name>", "creates a websocket handler, its route and a javascript client", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.Websocket(scaffold.WebsocketOptions{Options: opts, Name: argAt(args, 0)}))
+			}),
+			generator("client-errors", "", "creates public/js/client-errors.js, which reports the javascript errors of a page to the app's /client-errors endpoint", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.ClientErrors(opts))
+			}),
+			generator("observer", "<model>", "creates an observer told about every created, updated and deleted model, and wires it to the model", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.Observer(scaffold.ObserverOptions{Options: opts, Model: argAt(args, 0)}))
+			}),
+			generator("grpc", "<name>", "creates a gRPC service with its proto file, server and registration, and a make proto target", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.GRPC(scaffold.GRPCOptions{Options: opts, Name: argAt(args, 0)}))
+			}),
+			{name: "enum", args: "<name> <values...> [flags]", summary: "creates a typed enum in the data directory, --iota stores it as a number, --check table.column and --lookup add a CHECK constraint or lookup table", flags: []string{"iota", "check", "lookup"},
+				run: func(r *Runner, args []string) error { return r.doEnum(argAt(args, 0), argsFrom(args, 1)) }},
+			generator("repository", "<model>", "creates a repository interface for a model, with a database and an in-memory implementation", func(r *Runner, opts scaffold.Options, args []string) error {
+				return r.report(scaffold.Repository(scaffold.RepositoryOptions{Options: opts, Model: argAt(args, 0)}))
+			}),
+			{name: "test", summary: "creates tests, see make test model", subcommands: []*command{
+				generator("model", "<name>", "creates tests for a model that run in a rolled back transaction on the test database", func(r *Runner, opts scaffold.Options, args []string) error {
+					return r.report(scaffold.ModelTest(scaffold.ModelTestOptions{Options: opts, Model: argAt(args, 0)}))
+				}),
+			}},
+		}},
+	}
+}
+
+// findCommand returns the command called name, or nil
+func findCommand(cmds []*command, name string) *command {
+	for _, c := range cmds {
+		if c.name == name {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// dispatch runs the command in args: a subcommand when the next argument names one, and the
+// command itself otherwise, with the remaining arguments
+func (r *Runner) dispatch(c *command, args []string) error {
+	// subcommands that neither run nor have subcommands of their own, like the shells of
+	// completion, are only there to be completed
+	if sub := findCommand(c.subcommands, argAt(args, 0)); sub != nil && (sub.run != nil || len(sub.subcommands) > 0) {
+		return r.dispatch(sub, args[1:])
+	}
+
+	if c.run == nil {
+		if argAt(args, 0) == "" {
+			return errors.New(c.name + " requires a subcommand")
+		}
+		return errors.New("Unknown subcommand " + args[0])
+	}
+
+	return c.run(r, args)
+}
+
+// commandNames returns the names of cmds, sorted
+func commandNames(cmds []*command) []string {
+	names := make([]string, 0, len(cmds))
+	for _, c := range cmds {
+		names = append(names, c.name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+
+	"github.com/jimmitjoo/gemquick/prune"
+)
+
+// doRestore inserts the rows of archives written while pruning back into their tables. Names
+// that are not a path to an archive are looked up in ARCHIVE_DIR
+func (r *Runner) doRestore(archives []string) error {
+	if len(archives) == 0 {
+		return errors.New("db:restore requires the archives to restore")
+	}
+
+	if r.gem.DB.DataType == "" {
+		return errors.New("you have to define a database type to restore archives")
+	}
+
+	db, err := r.gem.OpenDB(r.gem.DB.DataType, r.driverDSN())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	dir := r.envOr("ARCHIVE_DIR", "storage/archive")
+	if !filepath.IsAbs(dir) {
+		dir = r.path(dir)
+	}
+
+	archiver := &prune.Archiver{Dir: dir}
+	for _, name := range archives {
+		if !filepath.IsAbs(name) {
+			name = r.path(name)
+		}
+
+		table, n, err := archiver.Restore(context.Background(), db, r.gem.DB.DataType, name)
+		if err != nil {
+			return err
+		}
+		r.green("Restored %d rows to %s from %s", n, table, filepath.Base(name))
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/jimmitjoo/gemquick"
+	"github.com/jimmitjoo/gemquick/scaffold"
+	"github.com/joho/godotenv"
+)
+
+// Runner runs gq commands for the project in RootPath. Commands only reach the project through
+// the runner, never through the working directory or package level state, so runners for
+// different projects can work side by side, in parallel tests or in tools that call the
+// generators without going through the command line
+type Runner struct {
+	// RootPath is the project the commands work on, and where gq new creates a project
+	RootPath string
+	// FS holds the templates the generators copy, the ones built into gq unless set. A project
+	// can still override them in its .gemquick directory
+	FS fs.FS
+	// Stdout is where commands print their progress, and Stdin what tinker and app commands read
+	Stdout io.Writer
+	Stdin  io.Reader
+	// Database is set by --database <name>, to run migrate and make migration against the
+	// database in DATABASE_<NAME>_DSN instead of the default one
+	Database string
+	// DryRun is set by --dry-run. The make commands then print what they would create and
+	// change, with the changes to existing files, instead of writing them
+	DryRun bool
+	// JSON is set by --json. Instead of colored text, the messages and created files are then
+	// collected into a cliResult, which main prints as JSON for CI tooling and editors
+	JSON bool
+
+	gem    gemquick.Gemquick
+	env    map[string]string
+	result cliResult
+}
+
+// NewRunner returns a runner for the project in rootPath, using the built in templates and the
+// standard input and output
+func NewRunner(rootPath string) *Runner {
+	return &Runner{
+		RootPath: rootPath,
+		FS:       scaffold.Templates,
+		Stdout:   os.Stdout,
+		Stdin:    os.Stdin,
+		result:   cliResult{Details: map[string]interface{}{}},
+	}
+}
+
+// Run runs the command in args the way gq does, e.g. []string{"make", "handler", "orders"}
+func (r *Runner) Run(args []string) error {
+	args = r.parseGlobalFlags(args)
+	if len(args) == 0 {
+		r.showHelp()
+		return errors.New("no command provided")
+	}
+
+	err := r.setup(args[0])
+	if err != nil {
+		return err
+	}
+
+	if name, ok := strings.CutPrefix(args[0], "app:"); ok {
+		return r.doApp(append([]string{name}, args[1:]...))
+	}
+
+	if r.DryRun && args[0] != "make" {
+		return errors.New("--dry-run only works with the make commands")
+	}
+
+	c := findCommand(commands, args[0])
+	if c == nil {
+		if !r.hasAppCommands() {
+			r.showHelp()
+			return nil
+		}
+
+		return r.runAppCommand(args)
+	}
+
+	return r.dispatch(c, args[1:])
+}
+
+// setup reads the project's .env and points the framework at the project, for every command
+// that works on an existing project
+func (r *Runner) setup(arg1 string) error {
+	r.gem.Version = "0.0.1"
+
+	if arg1 == "new" || arg1 == "version" || arg1 == "help" || arg1 == "completion" {
+		return nil
+	}
+
+	env, err := godotenv.Read(filepath.Join(r.RootPath, ".env"))
+	// doctor reports a missing .env itself, and mock only needs the OpenAPI document
+	if err != nil && arg1 != "doctor" && arg1 != "mock" {
+		return err
+	}
+
+	r.env = env
+	r.gem.RootPath = r.RootPath
+	r.gem.MigrationsPath = ""
+	r.gem.DB.DataType = r.getenv("DATABASE_TYPE")
+
+	if r.Database != "" {
+		return r.useDatabase(r.Database)
+	}
+
+	return nil
+}
+
+// getenv returns a setting from the project's .env, or from the environment when it is not there
+func (r *Runner) getenv(key string) string {
+	if value, ok := r.env[key]; ok {
+		return value
+	}
+
+	return os.Getenv(key)
+}
+
+// envOr returns a setting like getenv, and fallback when it is not set anywhere
+func (r *Runner) envOr(key, fallback string) string {
+	if value, ok := r.env[key]; ok {
+		return value
+	}
+
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+
+	return fallback
+}
+
+// path joins elements to the project root
+func (r *Runner) path(elem ...string) string {
+	return filepath.Join(append([]string{r.RootPath}, elem...)...)
+}
+
+func (r *Runner) green(format string, a ...interface{})  { r.say(color.FgGreen, format, a...) }
+func (r *Runner) yellow(format string, a ...interface{}) { r.say(color.FgYellow, format, a...) }
+func (r *Runner) red(format string, a ...interface{})    { r.say(color.FgRed, format, a...) }
+func (r *Runner) cyan(format string, a ...interface{})   { r.say(color.FgCyan, format, a...) }
+func (r *Runner) white(format string, a ...interface{})  { r.say(color.FgWhite, format, a...) }
+
+// say prints a line to Stdout in color, or adds it to the messages of the JSON result
+func (r *Runner) say(attribute color.Attribute, format string, a ...interface{}) {
+	message := format
+	if len(a) > 0 {
+		message = fmt.Sprintf(format, a...)
+	}
+
+	if r.JSON {
+		for _, line := range strings.Split(strings.TrimRight(message, "\n"), "\n") {
+			if strings.TrimSpace(line) != "" {
+				r.result.Messages = append(r.result.Messages, strings.TrimSpace(line))
+			}
+		}
+		return
+	}
+
+	c := color.New(attribute)
+	if r.Stdout != os.Stdout {
+		c.DisableColor()
+	}
+
+	if !strings.HasSuffix(message, "\n") {
+		message += "\n"
+	}
+	_, _ = c.Fprint(r.Stdout, message)
+}
+
+func argAt(args []string, i int) string {
+	if len(args) > i {
+		return args[i]
+	}
+
+	return ""
+}
+
+func argsFrom(args []string, i int) []string {
+	if len(args) > i {
+		return args[i:]
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jimmitjoo/gemquick"
+)
+
+// newProject creates a project with an empty .env and the directories the generators write to
+func newProject(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for _, dir := range []string{"handlers", "data", "email"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(root, ".env"), []byte("APP_NAME=test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return root
+}
+
+func TestRunner_Run(t *testing.T) {
+	var tests = []struct {
+		name string
+		args []string
+		file string
+	}{
+		{"handler", []string{"make", "handler", "orders"}, "handlers/orders.go"},
+		{"enum", []string{"make", "enum", "status", "draft", "published"}, "data/status_enum.go"},
+		{"markdown mail", []string{"make", "mail", "welcome", "--markdown"}, "email/welcome.md.tmpl"},
+	}
+
+	for _, e := range tests {
+		e := e
+		t.Run(e.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			r := NewRunner(newProject(t))
+			r.Stdout = &out
+
+			if err := r.Run(e.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if !fileExists(filepath.Join(r.RootPath, e.file)) {
+				t.Errorf("expected %s to be created in %s, got output %s", e.file, r.RootPath, out.String())
+			}
+		})
+	}
+}
+
+func TestRunner_DryRun(t *testing.T) {
+	var out bytes.Buffer
+	r := NewRunner(newProject(t))
+	r.Stdout = &out
+
+	err := r.Run([]string{"make", "handler", "orders", "--dry-run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fileExists(filepath.Join(r.RootPath, "handlers", "orders.go")) {
+		t.Error("expected nothing to be written in a dry run")
+	}
+
+	if !strings.Contains(out.String(), "Would create "+filepath.Join(r.RootPath, "handlers", "orders.go")) {
+		t.Error("expected the file to be listed, got", out.String())
+	}
+
+	err = NewRunner(r.RootPath).Run([]string{"migrate", "--dry-run"})
+	if err == nil {
+		t.Error("expected --dry-run to be refused outside make")
+	}
+}
+
+func TestRunner_FS(t *testing.T) {
+	t.Parallel()
+
+	r := NewRunner(newProject(t))
+	r.Stdout = &bytes.Buffer{}
+	r.FS = fstest.MapFS{
+		"templates/handlers/handler.go.txt": {Data: []byte("package handlers\n\n// $HANDLERNAME$ is custom\n")},
+	}
+
+	if err := r.Run([]string{"make", "handler", "orders"}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(r.RootPath, "handlers", "orders.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(content), "Orders is custom") {
+		t.Errorf("expected the handler from the runner's FS, got %s", content)
+	}
+}
+
+func TestRunner_JSON(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	r := NewRunner(newProject(t))
+	r.Stdout = &out
+
+	if err := r.Run([]string{"--json", "make", "enum", "status", "draft"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.result.Command != "make enum status draft" || len(r.result.Files) != 1 || len(r.result.Messages) != 1 {
+		t.Errorf("unexpected result %+v", r.result)
+	}
+
+	if out.Len() > 0 {
+		t.Errorf("expected the messages in the result instead of the output, got %s", out.String())
+	}
+
+	if err := r.Run([]string{"make", "enum", "status", "draft"}); err == nil {
+		t.Error("expected an error when the enum already exists")
+	}
+}
+
+func TestRunner_Dispatch(t *testing.T) {
+	var tests = []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{"missing subcommand", []string{"make"}, "make requires a subcommand"},
+		{"unknown subcommand", []string{"make", "widget"}, "Unknown subcommand widget"},
+		{"nested subcommand", []string{"make", "test", "model", "order"}, ""},
+		{"unknown shell", []string{"completion", "tcsh"}, "completion needs a shell: bash, zsh or fish"},
+		{"app command without console", []string{"app:prune"}, "the project has no commands yet, create one with gq make command <name>"},
+	}
+
+	for _, e := range tests {
+		r := NewRunner(newProject(t))
+		r.Stdout = &bytes.Buffer{}
+
+		err := r.Run(e.args)
+		if (err == nil && e.err != "") || (err != nil && err.Error() != e.err) {
+			t.Errorf("%s: expected error %q, got %v", e.name, e.err, err)
+		}
+	}
+}
+
+func TestRunner_Completion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var out bytes.Buffer
+		r := NewRunner(t.TempDir())
+		r.Stdout = &out
+
+		if err := r.Run([]string{"completion", shell}); err != nil {
+			t.Fatal(err)
+		}
+
+		// every generator is completed after make
+		for _, generator := range commandNames(findCommand(commands, "make").subcommands) {
+			if !strings.Contains(out.String(), generator) {
+				t.Errorf("%s: expected make %s in the completion, got %s", shell, generator, out.String())
+			}
+		}
+	}
+}
+
+func TestRunner_DSNFromEnvFile(t *testing.T) {
+	root := newProject(t)
+	env := "DATABASE_TYPE=postgres\nDATABASE_HOST=db.internal\nDATABASE_PORT=5432\nDATABASE_USER=shop\nDATABASE_NAME=shop\n"
+	if err := os.WriteFile(filepath.Join(root, ".env"), []byte(env), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DATABASE_HOST", "")
+
+	r := NewRunner(root)
+	if err := r.setup("doctor"); err != nil {
+		t.Fatal(err)
+	}
+
+	if dsn := gemquick.BuildDSNFrom(r.getenv); !strings.Contains(dsn, "host=db.internal port=5432") {
+		t.Errorf("expected the dsn to come from .env, got %q", dsn)
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/debugbar"
+)
+
+// watcher polls the project for changed files. Polling keeps gq free of platform specific
+// notification code, and a project is small enough to stat every few hundred milliseconds
+type watcher struct {
+	root       string
+	extensions []string
+	ignore     []string
+	modTimes   map[string]time.Time
+}
+
+// doServe builds and runs the app, and rebuilds and restarts it whenever a watched file changes.
+// The processes of the Procfile run next to it, and the output of all of them is interleaved with
+// the name of the process in front of every line
+func (r *Runner) doServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	ignore := flags.String("ignore", r.envOr("SERVE_IGNORE", ".git,tmp,logs,vendor,node_modules,public"), "comma separated list of paths or glob patterns that are not watched")
+	extensions := flags.String("ext", r.envOr("SERVE_EXTENSIONS", ".go,.jet,.tmpl,.env,go.mod"), "comma separated list of file endings that trigger a restart")
+	debounce := flags.Duration("debounce", r.serveDebounceFromEnv(), "how long to wait for more changes before restarting")
+	interval := flags.Duration("interval", 500*time.Millisecond, "how often to look for changes")
+	procfile := flags.String("procfile", r.envOr("SERVE_PROCFILE", "Procfile.dev"), "the file with a name: command line for every process to run next to the app")
+	restart := flags.String("restart", r.envOr("SERVE_RESTART", "worker"), "comma separated list of the processes that are restarted with the app")
+	only := flags.String("only", "", "comma separated list of the processes to show the output of, app for the app")
+	logs := flags.String("logs", r.envOr("SERVE_LOGS_ADDR", "127.0.0.1:4001"), "the address of the log tail the debug toolbar reads, none when empty")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	w := &watcher{
+		root:       r.RootPath,
+		extensions: splitList(*extensions),
+		ignore:     splitList(*ignore),
+	}
+
+	if _, err := w.scan(); err != nil {
+		return err
+	}
+
+	procs, err := readProcfile(r.path(*procfile), splitList(*restart))
+	if err != nil {
+		return err
+	}
+
+	binary := filepath.Join(r.RootPath, "tmp", "gq-serve")
+	app := &process{name: "app", args: []string{binary}, restart: true}
+
+	sources := []string{app.name}
+	for _, p := range procs {
+		sources = append(sources, p.name)
+	}
+	mux := newLogMux(r.Stdout, sources, splitList(*only), 1000)
+
+	env := os.Environ()
+	if *logs != "" {
+		tail, err := serveLogTail(*logs, mux)
+		if err != nil {
+			r.yellow("The debug toolbar gets no log tail: %v", err)
+		} else {
+			env = append(env, debugbar.TailEnv+"="+tail)
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	for _, p := range procs {
+		r.startProcess(p, env, mux)
+	}
+	if r.build(binary) {
+		r.startProcess(app, env, mux)
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	var pending []string
+	var lastChange time.Time
+
+	for {
+		select {
+		case <-signals:
+			app.stop()
+			for _, p := range procs {
+				p.stop()
+			}
+			return nil
+
+		case <-ticker.C:
+			changed, err := w.scan()
+			if err != nil {
+				r.red("Error watching files: %v", err)
+				continue
+			}
+
+			if len(changed) > 0 {
+				pending = append(pending, changed...)
+				lastChange = time.Now()
+				continue
+			}
+
+			if len(pending) == 0 || time.Since(lastChange) < *debounce {
+				continue
+			}
+
+			if len(pending) == 1 {
+				r.yellow("%s changed, restarting...", pending[0])
+			} else {
+				r.yellow("%d files changed, restarting...", len(pending))
+			}
+			pending = nil
+
+			restarted := append([]*process{app}, procs...)
+			for _, p := range restarted {
+				if p.restart {
+					p.stop()
+				}
+			}
+
+			// a failed build leaves everything stopped, so the next change can try again
+			if !r.build(binary) {
+				continue
+			}
+			for _, p := range restarted {
+				if p.restart {
+					r.startProcess(p, env, mux)
+				}
+			}
+		}
+	}
+}
+
+// build builds the app into binary, and tells whether it succeeded
+func (r *Runner) build(binary string) bool {
+	start := time.Now()
+
+	build := exec.Command("go", "build", "-o", binary, ".")
+	build.Dir = r.RootPath
+	build.Stdout = r.Stdout
+	build.Stderr = os.Stderr
+
+	if err := build.Run(); err != nil {
+		r.red("Build failed: %v", err)
+		return false
+	}
+
+	r.green("Built in %s", time.Since(start).Round(time.Millisecond))
+
+	return true
+}
+
+func (r *Runner) startProcess(p *process, env []string, mux *logMux) {
+	if err := p.start(r.RootPath, env, mux); err != nil {
+		r.red("Could not start %s: %v", p.name, err)
+	}
+}
+
+// serveLogTail serves the latest output of the processes on addr, and returns its url
+func serveLogTail(addr string, mux *logMux) (string, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+
+	routes := http.NewServeMux()
+	routes.Handle("/logs", mux)
+	go func() {
+		_ = http.Serve(ln, routes)
+	}()
+
+	return "http://" + ln.Addr().String() + "/logs", nil
+}
+
+// scan returns the watched files that were added, changed or removed since the last scan.
+// The first scan only records what is there
+func (w *watcher) scan() ([]string, error) {
+	first := w.modTimes == nil
+	seen := make(map[string]time.Time)
+	var changed []string
+
+	err := filepath.WalkDir(w.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		rel, _ := filepath.Rel(w.root, path)
+		if rel != "." && w.ignored(rel, d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() || !w.watched(d.Name()) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		seen[rel] = info.ModTime()
+		if previous, exists := w.modTimes[rel]; !first && (!exists || !previous.Equal(info.ModTime())) {
+			changed = append(changed, rel)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for rel := range w.modTimes {
+		if _, exists := seen[rel]; !exists {
+			changed = append(changed, rel)
+		}
+	}
+
+	w.modTimes = seen
+
+	return changed, nil
+}
+
+func (w *watcher) ignored(rel, name string) bool {
+	for _, pattern := range w.ignore {
+		if rel == pattern || strings.HasPrefix(rel, pattern+string(filepath.Separator)) {
+			return true
+		}
+		if match, _ := filepath.Match(pattern, name); match {
+			return true
+		}
+		if match, _ := filepath.Match(pattern, rel); match {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *watcher) watched(name string) bool {
+	for _, ext := range w.extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, filepath.Clean(item))
+		}
+	}
+
+	return items
+}
+
+// serveDebounceFromEnv lets SERVE_DEBOUNCE override the default debounce, in milliseconds
+func (r *Runner) serveDebounceFromEnv() time.Duration {
+	ms, err := strconv.Atoi(r.getenv("SERVE_DEBOUNCE"))
+	if err != nil {
+		return 300 * time.Millisecond
+	}
+
+	return time.Duration(ms) * time.Millisecond
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// tinkerPackages can be used in tinker without importing them first
+var tinkerPackages = map[string]string{
+	"bytes":    "bytes",
+	"context":  "context",
+	"errors":   "errors",
+	"filepath": "path/filepath",
+	"http":     "net/http",
+	"math":     "math",
+	"sort":     "sort",
+	"sql":      "database/sql",
+	"strconv":  "strconv",
+	"strings":  "strings",
+	"time":     "time",
+}
+
+// tinkerSession holds what is kept between inputs: imports and declarations of funcs and types.
+// Values are not kept, since every input runs in a fresh process with the app booted again
+type tinkerSession struct {
+	r        *Runner
+	dir      string
+	module   string
+	models   bool
+	packages map[string]string
+	decls    []string
+}
+
+// doTinker starts an interactive console in which Go code runs against the booted app, with app,
+// db, cache and models ready to use
+func (r *Runner) doTinker() error {
+	dir := filepath.Join(r.RootPath, "tmp", "tinker")
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	s := &tinkerSession{
+		r:        r,
+		dir:      dir,
+		module:   r.appModuleName(),
+		models:   r.hasModelsConstructor(),
+		packages: map[string]string{},
+	}
+	for name, path := range tinkerPackages {
+		s.packages[name] = path
+	}
+
+	ready := "app, db and cache"
+	if s.models {
+		ready = "app, db, cache and models"
+	}
+	r.green("%s are ready. Every input runs on its own, declare funcs to reuse code. Type :help for help", ready)
+
+	reader := bufio.NewReader(r.Stdin)
+	for {
+		input, err := r.readTinkerInput(reader)
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(r.Stdout)
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		input = strings.TrimSpace(input)
+		switch {
+		case input == "":
+			continue
+		case input == ":quit" || input == ":q" || input == "exit":
+			return nil
+		case input == ":help":
+			r.printTinkerHelp()
+		case input == ":reset":
+			s.decls = nil
+			r.yellow("declarations removed")
+		case input == ":decls":
+			fmt.Fprintln(r.Stdout, strings.Join(s.decls, "\n\n"))
+		case strings.HasPrefix(input, ":import "):
+			path := strings.Trim(strings.TrimSpace(strings.TrimPrefix(input, ":import ")), `"`)
+			s.packages[filepath.Base(path)] = path
+		default:
+			s.eval(input)
+		}
+	}
+}
+
+// readTinkerInput reads one line, or more while brackets are left open
+func (r *Runner) readTinkerInput(reader *bufio.Reader) (string, error) {
+	var input strings.Builder
+	prompt := ">>> "
+
+	for {
+		fmt.Fprint(r.Stdout, prompt)
+		line, err := reader.ReadString('\n')
+		input.WriteString(line)
+
+		if err != nil {
+			if input.Len() > 0 && errors.Is(err, io.EOF) {
+				return input.String(), nil
+			}
+			return "", err
+		}
+
+		if openBrackets(input.String()) <= 0 {
+			return input.String(), nil
+		}
+		prompt = "... "
+	}
+}
+
+// openBrackets counts the brackets that are opened but not closed, skipping string literals
+func openBrackets(src string) int {
+	open := 0
+	var quote rune
+
+	for i, r := range src {
+		if quote != 0 {
+			if r == quote && (quote == '`' || i == 0 || src[i-1] != '\\') {
+				quote = 0
+			}
+			continue
+		}
+
+		switch r {
+		case '"', '\'', '`':
+			quote = r
+		case '{', '(', '[':
+			open++
+		case '}', ')', ']':
+			open--
+		}
+	}
+
+	return open
+}
+
+func (s *tinkerSession) eval(input string) {
+	// funcs, types, vars and consts are kept for the inputs that follow
+	if file, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+input, 0); err == nil && len(file.Decls) > 0 {
+		if out, err := s.run(append(s.decls, input), ""); err != nil {
+			s.r.printTinkerError(out)
+			return
+		}
+		s.decls = append(s.decls, input)
+		s.r.green("declared")
+		return
+	}
+
+	// a lone expression is shown, unless it has no value or prints itself
+	if expr, err := parser.ParseExpr(input); err == nil && !printCall(expr) {
+		out, err := s.run(s.decls, "show("+input+")")
+		if err == nil || !bytes.Contains(out, []byte("used as value")) && !bytes.Contains(out, []byte("(no value)")) {
+			s.print(out, err)
+			return
+		}
+	}
+
+	out, err := s.run(s.decls, input+showDefined(input))
+	s.print(out, err)
+}
+
+// printCall reports whether expr calls fmt.Print and the like, whose results nobody wants to see
+func printCall(expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+
+	pkg, ok := sel.X.(*ast.Ident)
+
+	return ok && pkg.Name == "fmt" && strings.HasPrefix(sel.Sel.Name, "Print")
+}
+
+func (s *tinkerSession) print(out []byte, err error) {
+	if err != nil {
+		s.r.printTinkerError(out)
+		return
+	}
+
+	_, _ = s.r.Stdout.Write(out)
+}
+
+// showDefined shows the variables statements define with :=, which also keeps the compiler from
+// complaining that they are not used
+func showDefined(input string) string {
+	file, err := parser.ParseFile(token.NewFileSet(), "", "package p\nfunc f() {\n"+input+"\n}", 0)
+	if err != nil {
+		return ""
+	}
+
+	var names []string
+	for _, stmt := range file.Decls[0].(*ast.FuncDecl).Body.List {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || assign.Tok != token.DEFINE {
+			continue
+		}
+		for _, lhs := range assign.Lhs {
+			if ident, ok := lhs.(*ast.Ident); ok && ident.Name != "_" {
+				names = append(names, ident.Name)
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return ""
+	}
+
+	return "\nshow(" + strings.Join(names, ", ") + ")"
+}
+
+// run writes the program for decls and body to tmp/tinker and runs it from the project root
+func (s *tinkerSession) run(decls []string, body string) ([]byte, error) {
+	data, err := s.r.readTemplate("templates/tinker/main.go.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	source := strings.Join(decls, "\n\n") + "\n" + body
+
+	var imports []string
+	if s.models {
+		imports = append(imports, "\t\""+s.module+"/data\"\n")
+	}
+	for name, path := range s.packages {
+		if regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\.`).MatchString(source) {
+			imports = append(imports, "\t\""+path+"\"\n")
+		}
+	}
+	sort.Strings(imports)
+
+	models := ""
+	if s.models {
+		models = "\tmodels := data.New(db)\n\t_ = models\n"
+	}
+
+	program := string(data)
+	program = strings.ReplaceAll(program, "$IMPORTS$", strings.Join(imports, ""))
+	program = strings.ReplaceAll(program, "$DECLS$", strings.Join(decls, "\n\n"))
+	program = strings.ReplaceAll(program, "$MODELS$", models)
+	program = strings.ReplaceAll(program, "$BODY$", body)
+
+	err = os.WriteFile(filepath.Join(s.dir, "main.go"), []byte(program), 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("go", "run", "./tmp/tinker")
+	cmd.Dir = s.r.RootPath
+	cmd.Stdin = s.r.Stdin
+
+	return cmd.CombinedOutput()
+}
+
+// hasModelsConstructor reports whether data.New exists to create the models with
+func (r *Runner) hasModelsConstructor() bool {
+	content, err := os.ReadFile(filepath.Join(r.RootPath, "data", "models.go"))
+	if err != nil {
+		return false
+	}
+
+	return bytes.Contains(content, []byte("func New("))
+}
+
+func (r *Runner) printTinkerError(out []byte) {
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "exit status") {
+			continue
+		}
+		// compiler messages point into the generated program, which means little to the user
+		if _, message, ok := strings.Cut(line, "main.go:"); ok {
+			if _, rest, ok := strings.Cut(message, ": "); ok {
+				line = rest
+			}
+		}
+		r.red(line)
+	}
+}
+
+func (r *Runner) printTinkerHelp() {
+	r.yellow(`Type Go code and press enter. Expressions are shown as JSON, errors by their message,
+and variables declared with := are shown after the statement runs. Lines with open brackets
+continue on the next line.
+
+	app			the booted *gemquick.Gemquick
+	db			the database pool, app.DB.Pool
+	cache		the cache, app.Cache
+	models		data.New(db), when the project has a data.New
+
+	:import <path>	make a package available, the standard ones like strings and time already are
+	:decls			list the funcs and types declared in this session
+	:reset			forget the declarations
+	:quit			leave tinker
+`)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/jimmitjoo/gemquick/scaffold"
+	"github.com/joho/godotenv"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// upgradeFiles are the files gq new takes from the skeleton that the project is not expected to edit
+var upgradeFiles = []string{"Makefile", "Dockerfile", "docker-compose.yml", "init-gemquick.go"}
+
+// doUpgrade compares the framework managed files of the project with the current skeleton, and prints
+// the differences or, with -apply, overwrites them. Settings missing from .env are added in both cases
+func (r *Runner) doUpgrade(args []string) error {
+	flags := flag.NewFlagSet("upgrade", flag.ContinueOnError)
+	apply := flags.Bool("apply", false, "overwrite the files instead of printing the differences")
+	skeleton := flags.String("skeleton", scaffold.SkeletonURL, "git url or local directory of the skeleton to compare with")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	dir, err := fetchSkeleton(*skeleton)
+	if err != nil {
+		return err
+	}
+	if dir != *skeleton {
+		defer os.RemoveAll(dir)
+	}
+
+	module := r.appModuleName()
+	changed := 0
+
+	for _, name := range upgradeFiles {
+		source := name
+		if name == "Makefile" {
+			source = "Makefile.mac"
+			if runtime.GOOS == "windows" {
+				source = "Makefile.windows"
+			}
+		}
+
+		want, err := os.ReadFile(filepath.Join(dir, source))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		// gq new renames the skeleton's module the same way
+		wanted := strings.ReplaceAll(string(want), "myapp", module)
+
+		target := filepath.Join(r.RootPath, name)
+		have, err := os.ReadFile(target)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		if string(have) == wanted {
+			r.green("%s is up to date", name)
+			continue
+		}
+		changed++
+
+		if *apply {
+			err = os.WriteFile(target, []byte(wanted), 0644)
+			if err != nil {
+				return err
+			}
+			r.green("%s upgraded", name)
+			continue
+		}
+
+		r.printDiff(name, name+" (skeleton)", string(have), wanted)
+	}
+
+	err = r.addMissingEnv(*apply)
+	if err != nil {
+		return err
+	}
+
+	if changed > 0 && !*apply {
+		r.yellow("Run gq upgrade -apply to overwrite the %d files above", changed)
+	}
+
+	return nil
+}
+
+// fetchSkeleton returns skeleton if it is a local directory, and otherwise clones it into a temporary one
+func fetchSkeleton(skeleton string) (string, error) {
+	if info, err := os.Stat(skeleton); err == nil && info.IsDir() {
+		return skeleton, nil
+	}
+
+	dir, err := os.MkdirTemp("", "gq-skeleton")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = git.PlainClone(dir, false, &git.CloneOptions{URL: skeleton, Depth: 1})
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// printDiff prints the lines that differ between have, labelled from, and want, labelled to
+func (r *Runner) printDiff(from, to, have, want string) {
+	r.yellow("--- %s", from)
+	r.yellow("+++ %s", to)
+
+	for _, d := range diff.Do(have, want) {
+		lines := strings.SplitAfter(d.Text, "\n")
+		for _, line := range lines {
+			if line == "" {
+				continue
+			}
+			line = strings.TrimSuffix(line, "\n")
+
+			switch d.Type {
+			case diffmatchpatch.DiffDelete:
+				r.red("-%s", line)
+			case diffmatchpatch.DiffInsert:
+				r.green("+%s", line)
+			}
+		}
+	}
+}
+
+// addMissingEnv appends the settings of the embedded .env template that the project's .env
+// does not have, with their default values, or only lists them unless apply is set
+func (r *Runner) addMissingEnv(apply bool) error {
+	envFile := filepath.Join(r.RootPath, ".env")
+	existing, err := godotenv.Read(envFile)
+	if err != nil {
+		return err
+	}
+
+	data, err := r.readTemplate("templates/env.txt")
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, _, ok := strings.Cut(line, "=")
+		if !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if _, found := existing[key]; !found && !strings.Contains(line, "${") {
+			missing = append(missing, line)
+		}
+	}
+
+	if len(missing) == 0 {
+		r.green(".env has every setting")
+		return nil
+	}
+
+	if !apply {
+		r.yellow(".env is missing these settings:\n\t%s", strings.Join(missing, "\n\t"))
+		return nil
+	}
+
+	f, err := os.OpenFile(envFile, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "\n# added by gq upgrade\n%s\n", strings.Join(missing, "\n"))
+	if err != nil {
+		return err
+	}
+
+	r.green("Added %d settings to .env", len(missing))
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jimmitjoo/gemquick/database"
+)
+
+// doRefreshViews refreshes the named materialized views of the project's database, or all of them
+func (r *Runner) doRefreshViews(views []string) error {
+	if r.gem.DB.DataType == "" {
+		return errors.New("you have to define a database type to refresh views")
+	}
+
+	if !database.Postgres(r.gem.DB.DataType) {
+		return database.ErrNoMaterializedViews
+	}
+
+	db, err := r.gem.OpenDB(r.gem.DB.DataType, r.driverDSN())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	refreshed, err := database.RefreshMaterializedViews(context.Background(), db, r.gem.DB.DataType, views...)
+	for _, view := range refreshed {
+		r.green("Refreshed %s", view)
+	}
+
+	if err == nil && len(refreshed) == 0 {
+		r.yellow("There are no materialized views to refresh")
+	}
+
+	return err
+}
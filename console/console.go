@@ -0,0 +1,113 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Command is an interface that defines the methods an application command must implement
+type Command interface {
+	Name() string
+	Description() string
+	Handle(args []string) error
+}
+
+// ErrUnknownCommand is returned by Run when no command is registered under the requested name
+var ErrUnknownCommand = errors.New("unknown command")
+
+// Console holds the commands an application registers, and runs them by name
+type Console struct {
+	Out io.Writer
+
+	mu       sync.RWMutex
+	commands map[string]Command
+}
+
+// New returns a console without any commands, writing to stdout
+func New() *Console {
+	return &Console{
+		Out:      os.Stdout,
+		commands: make(map[string]Command),
+	}
+}
+
+// Register adds commands to the console, replacing any command with the same name
+func (c *Console) Register(commands ...Command) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, command := range commands {
+		c.commands[command.Name()] = command
+	}
+}
+
+// RegisterSigned adds commands that declare their arguments and options in a signature, see
+// Signed, and fails on the first signature it cannot parse
+func (c *Console) RegisterSigned(commands ...Signed) error {
+	for _, command := range commands {
+		signature, err := ParseSignature(command.Signature())
+		if err != nil {
+			return err
+		}
+
+		c.Register(&signed{command: command, signature: signature})
+	}
+
+	return nil
+}
+
+// Commands returns the registered commands sorted by name
+func (c *Console) Commands() []Command {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	commands := make([]Command, 0, len(c.commands))
+	for _, command := range c.commands {
+		commands = append(commands, command)
+	}
+
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name() < commands[j].Name()
+	})
+
+	return commands
+}
+
+// Run looks up the command named by the first argument and hands it the rest.
+// Without arguments, or with list or help, it prints the available commands, and
+// a Signed command followed by --help prints its usage
+func (c *Console) Run(args []string) error {
+	if len(args) == 0 || args[0] == "list" || args[0] == "help" {
+		c.List()
+		return nil
+	}
+
+	c.mu.RLock()
+	command, exists := c.commands[args[0]]
+	c.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, args[0])
+	}
+
+	if s, ok := command.(*signed); ok && len(args) == 2 && (args[1] == "--help" || args[1] == "-h") {
+		fmt.Fprint(c.Out, s.signature.Usage())
+		return nil
+	}
+
+	return command.Handle(args[1:])
+}
+
+// List prints the name and description of every registered command
+func (c *Console) List() {
+	fmt.Fprintln(c.Out, "Available commands:")
+	fmt.Fprintln(c.Out)
+
+	for _, command := range c.Commands() {
+		fmt.Fprintf(c.Out, "\t%-24s- %s\n", command.Name(), command.Description())
+	}
+}
@@ -0,0 +1,70 @@
+package console
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type greetCommand struct {
+	greeted []string
+}
+
+func (g *greetCommand) Name() string {
+	return "greet"
+}
+
+func (g *greetCommand) Description() string {
+	return "says hello"
+}
+
+func (g *greetCommand) Handle(args []string) error {
+	if len(args) == 0 {
+		return errors.New("who should I greet?")
+	}
+
+	g.greeted = append(g.greeted, args...)
+	return nil
+}
+
+func TestConsole_Run(t *testing.T) {
+	c := New()
+	greet := &greetCommand{}
+	c.Register(greet)
+
+	err := c.Run([]string{"greet", "alice", "bob"})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if strings.Join(greet.greeted, ",") != "alice,bob" {
+		t.Error("expected the arguments to be passed to the command, got", greet.greeted)
+	}
+
+	err = c.Run([]string{"greet"})
+	if err == nil {
+		t.Error("expected the error of the command to be returned")
+	}
+
+	err = c.Run([]string{"unknown"})
+	if !errors.Is(err, ErrUnknownCommand) {
+		t.Error("expected ErrUnknownCommand, got", err)
+	}
+}
+
+func TestConsole_List(t *testing.T) {
+	c := New()
+	out := &bytes.Buffer{}
+	c.Out = out
+	c.Register(&greetCommand{})
+
+	err := c.Run(nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if !strings.Contains(out.String(), "greet") || !strings.Contains(out.String(), "says hello") {
+		t.Error("expected the command to be listed, got", out.String())
+	}
+}
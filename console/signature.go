@@ -0,0 +1,274 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Signed is a command that declares its arguments and options in a signature, which the console
+// parses and checks before Handle runs, e.g.
+//
+//	users:prune {days=30 : Keep users active this many days} {--dry-run : Only list the users}
+//
+// The first word is the name of the command. In braces follow arguments, {name} is required,
+// {name?} optional, {name=default} optional with a default, {name*} takes one or more of the
+// remaining arguments and {name?*} any number of them. Options follow, {--force} is a flag,
+// {--limit=} takes a value and {--limit=10} has a default. Text after a colon describes the
+// argument or option in the usage
+type Signed interface {
+	Signature() string
+	Description() string
+	Handle(in *Input) error
+}
+
+// Input holds the arguments and options a Signed command was called with
+type Input struct {
+	arguments map[string][]string
+	options   map[string]string
+}
+
+// Argument returns the value of the argument name, its default when it was not given
+func (in *Input) Argument(name string) string {
+	if values := in.arguments[name]; len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// Arguments returns the values of an argument that takes every remaining argument
+func (in *Input) Arguments(name string) []string {
+	return in.arguments[name]
+}
+
+// Option returns the value of the option name, its default when it was not given
+func (in *Input) Option(name string) string {
+	return in.options[name]
+}
+
+// Flag reports whether the flag name was given
+func (in *Input) Flag(name string) bool {
+	set, _ := strconv.ParseBool(in.options[name])
+	return set
+}
+
+// Int returns the value of the argument or option name as a number, and 0 when it is empty
+func (in *Input) Int(name string) (int, error) {
+	value, ok := in.options[name]
+	if !ok {
+		value = in.Argument(name)
+	}
+
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number, got %s", name, value)
+	}
+
+	return n, nil
+}
+
+// parameter is an argument or option of a signature
+type parameter struct {
+	name        string
+	description string
+	option      bool
+	// takesValue is set for options with = and for every argument
+	takesValue bool
+	required   bool
+	list       bool
+	defaultVal string
+}
+
+// Signature is a parsed signature, see Signed
+type Signature struct {
+	Name       string
+	parameters []parameter
+}
+
+var signatureParameter = regexp.MustCompile(`\{([^}]*)\}`)
+
+// ParseSignature parses the signature of a Signed command
+func ParseSignature(signature string) (*Signature, error) {
+	name, _, _ := strings.Cut(strings.TrimSpace(signature), " ")
+	if name == "" || strings.HasPrefix(name, "{") {
+		return nil, fmt.Errorf("the signature %q does not start with the command name", signature)
+	}
+
+	s := &Signature{Name: name}
+	for _, match := range signatureParameter.FindAllStringSubmatch(signature, -1) {
+		spec, description, _ := strings.Cut(match[1], ":")
+		spec = strings.TrimSpace(spec)
+		p := parameter{description: strings.TrimSpace(description), takesValue: true, required: true}
+
+		if strings.HasPrefix(spec, "--") {
+			p.option = true
+			p.required = false
+			spec = strings.TrimPrefix(spec, "--")
+			spec, p.defaultVal, p.takesValue = strings.Cut(spec, "=")
+		} else {
+			if before, def, ok := strings.Cut(spec, "="); ok {
+				spec, p.defaultVal, p.required = before, def, false
+			}
+			if strings.HasSuffix(spec, "*") {
+				spec, p.list = strings.TrimSuffix(spec, "*"), true
+			}
+			if strings.HasSuffix(spec, "?") {
+				spec, p.required = strings.TrimSuffix(spec, "?"), false
+			}
+		}
+
+		p.name = spec
+		if p.name == "" {
+			return nil, fmt.Errorf("the signature %q has a parameter without a name", signature)
+		}
+
+		s.parameters = append(s.parameters, p)
+	}
+
+	return s, nil
+}
+
+// Parse checks args against the signature and returns them as Input
+func (s *Signature) Parse(args []string) (*Input, error) {
+	in := &Input{arguments: map[string][]string{}, options: map[string]string{}}
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		p, ok := s.option(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown option --%s", name)
+		}
+
+		switch {
+		case !p.takesValue && hasValue:
+			return nil, fmt.Errorf("the option --%s does not take a value", name)
+		case !p.takesValue:
+			value = "true"
+		case !hasValue:
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("the option --%s needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+
+		in.options[name] = value
+	}
+
+	for _, p := range s.parameters {
+		if p.option {
+			if _, given := in.options[p.name]; !given && p.takesValue {
+				in.options[p.name] = p.defaultVal
+			}
+			continue
+		}
+
+		switch {
+		case len(positional) > 0 && p.list:
+			in.arguments[p.name], positional = positional, nil
+		case len(positional) > 0:
+			in.arguments[p.name], positional = positional[:1], positional[1:]
+		case p.required:
+			return nil, fmt.Errorf("the argument %s is missing", p.name)
+		case p.defaultVal != "":
+			in.arguments[p.name] = []string{p.defaultVal}
+		}
+	}
+
+	if len(positional) > 0 {
+		return nil, errors.New("too many arguments: " + strings.Join(positional, " "))
+	}
+
+	return in, nil
+}
+
+func (s *Signature) option(name string) (parameter, bool) {
+	for _, p := range s.parameters {
+		if p.option && p.name == name {
+			return p, true
+		}
+	}
+
+	return parameter{}, false
+}
+
+// Usage describes how to call the command and what its arguments and options are
+func (s *Signature) Usage() string {
+	var b strings.Builder
+	b.WriteString("Usage: " + s.Name)
+
+	for _, p := range s.parameters {
+		name := p.name
+		if p.option {
+			name = "--" + name
+			if p.takesValue {
+				name += "=<value>"
+			}
+		}
+		if p.list {
+			name += "..."
+		}
+		if !p.required {
+			name = "[" + name + "]"
+		}
+		b.WriteString(" " + name)
+	}
+	b.WriteString("\n")
+
+	for _, p := range s.parameters {
+		name := p.name
+		if p.option {
+			name = "--" + name
+		}
+
+		description := p.description
+		if p.defaultVal != "" {
+			description = strings.TrimSpace(description + " (default " + p.defaultVal + ")")
+		}
+		if description == "" {
+			fmt.Fprintf(&b, "\t%s\n", name)
+			continue
+		}
+		fmt.Fprintf(&b, "\t%-24s- %s\n", name, description)
+	}
+
+	return b.String()
+}
+
+// signed adapts a Signed command to Command
+type signed struct {
+	command   Signed
+	signature *Signature
+}
+
+func (s *signed) Name() string        { return s.signature.Name }
+func (s *signed) Description() string { return s.command.Description() }
+
+// Handle checks args against the signature and hands them to the command
+func (s *signed) Handle(args []string) error {
+	in, err := s.signature.Parse(args)
+	if err != nil {
+		return fmt.Errorf("%w\n%s", err, s.signature.Usage())
+	}
+
+	return s.command.Handle(in)
+}
@@ -0,0 +1,124 @@
+package console
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type pruneCommand struct {
+	in *Input
+}
+
+func (p *pruneCommand) Signature() string {
+	return "users:prune {days=30 : Keep users active this many days} {emails?* : Only these users} {--dry-run : Only list them} {--limit= : At most this many}"
+}
+
+func (p *pruneCommand) Description() string {
+	return "removes inactive users"
+}
+
+func (p *pruneCommand) Handle(in *Input) error {
+	p.in = in
+	return nil
+}
+
+func TestSignature_Parse(t *testing.T) {
+	s, err := ParseSignature((&pruneCommand{}).Signature())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "users:prune" {
+		t.Error("expected the name users:prune, got", s.Name)
+	}
+
+	in, err := s.Parse([]string{"7", "a@example.com", "--dry-run", "--limit", "10", "b@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if days, _ := in.Int("days"); days != 7 {
+		t.Error("expected days 7, got", days)
+	}
+	if got := strings.Join(in.Arguments("emails"), ","); got != "a@example.com,b@example.com" {
+		t.Error("expected both emails, got", got)
+	}
+	if !in.Flag("dry-run") || in.Option("limit") != "10" {
+		t.Error("expected --dry-run and --limit 10, got", in.options)
+	}
+
+	in, err = s.Parse(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if in.Argument("days") != "30" || in.Flag("dry-run") || len(in.Arguments("emails")) != 0 {
+		t.Error("expected the defaults, got", in.arguments, in.options)
+	}
+
+	tests := map[string][]string{
+		"unknown option":    {"--force"},
+		"flag with a value": {"--dry-run=yes"},
+		"missing value":     {"--limit"},
+	}
+	for name, args := range tests {
+		if _, err := s.Parse(args); err == nil {
+			t.Errorf("%s: expected %v to be refused", name, args)
+		}
+	}
+}
+
+func TestSignature_Required(t *testing.T) {
+	s, err := ParseSignature("mail:send {to : Who gets it} {subject?}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Parse(nil); err == nil {
+		t.Error("expected a missing required argument to be refused")
+	}
+
+	if _, err := s.Parse([]string{"a", "b", "c"}); err == nil {
+		t.Error("expected too many arguments to be refused")
+	}
+
+	if _, err := ParseSignature("{to}"); err == nil {
+		t.Error("expected a signature without a name to be refused")
+	}
+}
+
+func TestConsole_RegisterSigned(t *testing.T) {
+	c := New()
+	out := &bytes.Buffer{}
+	c.Out = out
+
+	prune := &pruneCommand{}
+	err := c.RegisterSigned(prune)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Run([]string{"users:prune", "14", "--dry-run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if prune.in.Argument("days") != "14" || !prune.in.Flag("dry-run") {
+		t.Error("expected the parsed input to be handed to the command")
+	}
+
+	err = c.Run([]string{"users:prune", "--force"})
+	if err == nil || !strings.Contains(err.Error(), "Usage: users:prune [days]") {
+		t.Error("expected an error with the usage, got", err)
+	}
+
+	err = c.Run([]string{"users:prune", "--help"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "--dry-run") || !strings.Contains(out.String(), "(default 30)") {
+		t.Error("expected the usage to be printed, got", out.String())
+	}
+}
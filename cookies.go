@@ -0,0 +1,191 @@
+package gemquick
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CookiePolicy holds the attributes every cookie of the app gets: the session cookie, the CSRF
+// cookie and the cookies set with Set, like the remember me cookie of the auth scaffold. It
+// starts from the preset of APP_ENV, and COOKIE_SECURE, COOKIE_SAMESITE, COOKIE_HOST_PREFIX,
+// COOKIE_PARTITIONED and COOKIE_DOMAIN override it when they are set
+type CookiePolicy struct {
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+	// HostPrefix names the cookies __Host-, so that browsers only take them over https, from
+	// the host itself and for the whole site
+	HostPrefix bool
+	// Partitioned keeps the cookies of an app that is embedded on other sites apart per site
+	Partitioned bool
+	Domain      string
+}
+
+// cookiePreset returns the attributes of the cookies for an environment. Apps that are served
+// over https get cookies that only travel over https and cannot be read by scripts. Any
+// environment that is not known is taken for development, served over plain http. No preset
+// renames the cookies with __Host-, since that would log out every user of an app that upgrades
+// and break the forms they have open, so COOKIE_HOST_PREFIX turns it on
+func cookiePreset(env string) CookiePolicy {
+	switch strings.ToLower(env) {
+	case "production", "prod", "staging":
+		return CookiePolicy{Secure: true, HttpOnly: true, SameSite: http.SameSiteLaxMode}
+	default:
+		return CookiePolicy{HttpOnly: true, SameSite: http.SameSiteLaxMode}
+	}
+}
+
+// createCookiePolicy returns the preset of APP_ENV with the overrides of the env. Combinations
+// browsers refuse, like a __Host- cookie with a domain, are errors rather than cookies that are
+// silently dropped
+func (g *Gemquick) createCookiePolicy() (CookiePolicy, error) {
+	p := cookiePreset(os.Getenv("APP_ENV"))
+
+	for name, value := range map[string]*bool{
+		"COOKIE_SECURE":      &p.Secure,
+		"COOKIE_HOST_PREFIX": &p.HostPrefix,
+		"COOKIE_PARTITIONED": &p.Partitioned,
+	} {
+		if s := os.Getenv(name); s != "" {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return p, fmt.Errorf("%s: %s is not true or false", name, s)
+			}
+			*value = b
+		}
+	}
+
+	switch strings.ToLower(os.Getenv("COOKIE_SAMESITE")) {
+	case "":
+	case "lax":
+		p.SameSite = http.SameSiteLaxMode
+	case "strict":
+		p.SameSite = http.SameSiteStrictMode
+	case "none":
+		p.SameSite = http.SameSiteNoneMode
+	default:
+		return p, fmt.Errorf("COOKIE_SAMESITE: %s is not lax, strict or none", os.Getenv("COOKIE_SAMESITE"))
+	}
+
+	// a domain of localhost is what apps were generated with, and is no domain to browsers
+	if domain := os.Getenv("COOKIE_DOMAIN"); domain != "" && domain != "localhost" {
+		p.Domain = domain
+	}
+
+	return p, p.validate()
+}
+
+func (p CookiePolicy) validate() error {
+	switch {
+	case p.HostPrefix && !p.Secure:
+		return errors.New("COOKIE_HOST_PREFIX needs COOKIE_SECURE=true")
+	case p.HostPrefix && p.Domain != "":
+		return errors.New("COOKIE_HOST_PREFIX cannot be used with COOKIE_DOMAIN")
+	case p.Partitioned && !p.Secure:
+		return errors.New("COOKIE_PARTITIONED needs COOKIE_SECURE=true")
+	case p.SameSite == http.SameSiteNoneMode && !p.Secure:
+		return errors.New("COOKIE_SAMESITE=none needs COOKIE_SECURE=true")
+	}
+
+	return nil
+}
+
+// Name returns the name a cookie is set and read with, prefixed with __Host- when the policy
+// asks for it
+func (p CookiePolicy) Name(name string) string {
+	if p.HostPrefix && name != "" && !strings.HasPrefix(name, "__Host-") {
+		return "__Host-" + name
+	}
+
+	return name
+}
+
+// Apply gives the cookie the attributes of the policy. A cookie that was told to be Secure or
+// HttpOnly stays so
+func (p CookiePolicy) Apply(c *http.Cookie) {
+	c.Name = p.Name(c.Name)
+	c.Secure = c.Secure || p.Secure
+	c.HttpOnly = c.HttpOnly || p.HttpOnly
+	if c.SameSite == http.SameSiteDefaultMode || p.SameSite == http.SameSiteNoneMode {
+		c.SameSite = p.SameSite
+	}
+
+	if p.HostPrefix {
+		c.Domain = ""
+		c.Path = "/"
+	} else if c.Domain == "" {
+		c.Domain = p.Domain
+	}
+}
+
+// Set applies the policy to the cookie and adds it to the response
+func (p CookiePolicy) Set(w http.ResponseWriter, c *http.Cookie) {
+	p.Apply(c)
+
+	v := c.String()
+	if v == "" {
+		return
+	}
+	if p.Partitioned {
+		v += "; Partitioned"
+	}
+
+	w.Header().Add("Set-Cookie", v)
+}
+
+// partitionCookies adds the Partitioned attribute to the cookies the next handlers set, which
+// net/http and the session manager have no field for
+func (p CookiePolicy) partitionCookies(next http.Handler) http.Handler {
+	if !p.Partitioned {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&partitionedWriter{ResponseWriter: w}, r)
+	})
+}
+
+type partitionedWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *partitionedWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		cookies := w.Header()["Set-Cookie"]
+		for i, c := range cookies {
+			if !strings.Contains(strings.ToLower(c), "; partitioned") {
+				cookies[i] = c + "; Partitioned"
+			}
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *partitionedWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *partitionedWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the writer of the server
+func (w *partitionedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
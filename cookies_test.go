@@ -0,0 +1,85 @@
+package gemquick
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreateCookiePolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected CookiePolicy
+		err      bool
+	}{
+		{"development", map[string]string{}, CookiePolicy{HttpOnly: true, SameSite: http.SameSiteLaxMode}, false},
+		{"production keeps the names", map[string]string{"APP_ENV": "production"}, CookiePolicy{Secure: true, HttpOnly: true, SameSite: http.SameSiteLaxMode}, false},
+		{"prefix", map[string]string{"APP_ENV": "production", "COOKIE_HOST_PREFIX": "true"}, CookiePolicy{Secure: true, HttpOnly: true, SameSite: http.SameSiteLaxMode, HostPrefix: true}, false},
+		{"domain", map[string]string{"APP_ENV": "staging", "COOKIE_DOMAIN": "example.com"}, CookiePolicy{Secure: true, HttpOnly: true, SameSite: http.SameSiteLaxMode, Domain: "example.com"}, false},
+		{"localhost is no domain", map[string]string{"APP_ENV": "production", "COOKIE_HOST_PREFIX": "true", "COOKIE_DOMAIN": "localhost"}, CookiePolicy{Secure: true, HttpOnly: true, SameSite: http.SameSiteLaxMode, HostPrefix: true}, false},
+		{"embedded", map[string]string{"APP_ENV": "production", "COOKIE_SAMESITE": "none", "COOKIE_PARTITIONED": "true"}, CookiePolicy{Secure: true, HttpOnly: true, SameSite: http.SameSiteNoneMode, Partitioned: true}, false},
+		{"prefix over http", map[string]string{"COOKIE_HOST_PREFIX": "true"}, CookiePolicy{}, true},
+		{"prefix with domain", map[string]string{"APP_ENV": "production", "COOKIE_HOST_PREFIX": "true", "COOKIE_DOMAIN": "example.com"}, CookiePolicy{}, true},
+		{"none over http", map[string]string{"COOKIE_SAMESITE": "none"}, CookiePolicy{}, true},
+		{"unknown samesite", map[string]string{"COOKIE_SAMESITE": "sometimes"}, CookiePolicy{}, true},
+		{"not a bool", map[string]string{"COOKIE_SECURE": "yes please"}, CookiePolicy{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"APP_ENV", "COOKIE_SECURE", "COOKIE_SAMESITE", "COOKIE_HOST_PREFIX", "COOKIE_PARTITIONED", "COOKIE_DOMAIN"} {
+				t.Setenv(name, tt.env[name])
+			}
+
+			p, err := (&Gemquick{}).createCookiePolicy()
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, p)
+			}
+		})
+	}
+}
+
+func TestCookiePolicy_Set(t *testing.T) {
+	p := CookiePolicy{Secure: true, HttpOnly: true, SameSite: http.SameSiteLaxMode, HostPrefix: true, Partitioned: true}
+
+	w := httptest.NewRecorder()
+	p.Set(w, &http.Cookie{Name: "_app_remember", Value: "1|abc", Domain: "example.com", Path: "/account", SameSite: http.SameSiteStrictMode})
+
+	expected := "__Host-_app_remember=1|abc; Path=/; HttpOnly; Secure; SameSite=Strict; Partitioned"
+	if got := w.Header().Get("Set-Cookie"); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	if p.Name("__Host-_app_remember") != "__Host-_app_remember" {
+		t.Error("expected a prefixed name to be left alone")
+	}
+}
+
+func TestCookiePolicy_PartitionCookies(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "token", Secure: true})
+		_, _ = w.Write([]byte("ok"))
+	}
+
+	w := httptest.NewRecorder()
+	CookiePolicy{Partitioned: true}.partitionCookies(http.HandlerFunc(handler)).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if got := w.Header().Get("Set-Cookie"); !strings.HasSuffix(got, "; Partitioned") {
+		t.Errorf("expected the session cookie to be partitioned, got %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	CookiePolicy{}.partitionCookies(http.HandlerFunc(handler)).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if got := w.Header().Get("Set-Cookie"); strings.Contains(got, "Partitioned") {
+		t.Errorf("expected no partitioned cookie, got %q", got)
+	}
+}
@@ -0,0 +1,66 @@
+package gemquick
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/cors"
+)
+
+// CORS is the middleware that applies the CORS policy of a group of routes, so that every group
+// can allow other origins, like a public api that allows every origin and an admin api that only
+// allows its own frontend. A policy that allows credentials for every origin is logged and
+// handled without credentials
+func (g *Gemquick) CORS(group string) func(http.Handler) http.Handler {
+	p := CORSPolicy(group)
+	if err := p.Validate(); err != nil {
+		g.ErrorLog.Printf("CORS policy of %q: %v, handling it without credentials", group, err)
+		p.AllowCredentials = false
+	}
+
+	return p.Handler
+}
+
+// CORSPolicy reads the policy of group from the CORS_<GROUP>_* settings, like
+// CORS_ADMIN_ALLOWED_ORIGINS. The settings a group leaves empty are taken from the CORS_* settings
+// every group shares, and a group without allowed origins allows no cross-origin requests
+func CORSPolicy(group string) cors.Policy {
+	setting := func(name string) string {
+		if group != "" {
+			if value := os.Getenv("CORS_" + strings.ToUpper(group) + "_" + name); value != "" {
+				return value
+			}
+		}
+
+		return os.Getenv("CORS_" + name)
+	}
+
+	p := cors.Policy{
+		AllowedOrigins: splitList(setting("ALLOWED_ORIGINS")),
+		AllowedMethods: splitList(setting("ALLOWED_METHODS")),
+		AllowedHeaders: splitList(setting("ALLOWED_HEADERS")),
+		ExposedHeaders: splitList(setting("EXPOSED_HEADERS")),
+	}
+
+	p.AllowCredentials, _ = strconv.ParseBool(setting("ALLOW_CREDENTIALS"))
+	if seconds, err := strconv.Atoi(setting("MAX_AGE")); err == nil {
+		p.MaxAge = time.Duration(seconds) * time.Second
+	}
+
+	return p
+}
+
+// splitList splits a comma separated setting, dropping empty entries
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,128 @@
+// Package cors answers cross-origin requests according to a policy, so that one app can serve
+// groups of routes that different origins may call, like a public api anyone may call and an
+// admin api only its own frontend may
+package cors
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy is what a group of routes allows cross-origin requests to do
+type Policy struct {
+	// AllowedOrigins are the origins that may call the routes, like https://admin.shop.test, or *
+	// for every origin. When it is empty no cross-origin request is allowed
+	AllowedOrigins []string
+	// AllowedMethods are the methods preflight requests are allowed, DefaultMethods when it is empty
+	AllowedMethods []string
+	// AllowedHeaders are the request headers preflight requests are allowed, DefaultHeaders when
+	// it is empty
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers the calling script may read
+	ExposedHeaders []string
+	// AllowCredentials lets the browser send cookies and authorization headers along. It cannot
+	// be combined with the * origin, see Validate
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache the answer to a preflight request
+	MaxAge time.Duration
+}
+
+// DefaultMethods are the methods allowed when a policy does not list any
+var DefaultMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// DefaultHeaders are the request headers allowed when a policy does not list any
+var DefaultHeaders = []string{"Accept", "Authorization", "Content-Type", "X-Requested-With"}
+
+// ErrWildcardCredentials is the error of a policy that would let every website make requests with
+// the cookies of the user and read the responses
+var ErrWildcardCredentials = errors.New("cors: credentials cannot be allowed for every origin")
+
+// Validate returns ErrWildcardCredentials for a policy that allows credentials together with the
+// * origin. Such a policy is handled without credentials
+func (p Policy) Validate() error {
+	if p.AllowCredentials && p.AllowsOrigin("*") {
+		return ErrWildcardCredentials
+	}
+
+	return nil
+}
+
+// AllowsOrigin reports whether origin may call the routes
+func (p Policy) AllowsOrigin(origin string) bool {
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Handler answers preflight requests and adds the CORS headers to the responses of next. Requests
+// from origins that are not allowed get no CORS headers, so that the browser refuses to hand the
+// response to the calling script, and their preflight requests are answered with 403 Forbidden
+func (p Policy) Handler(next http.Handler) http.Handler {
+	methods := p.AllowedMethods
+	if len(methods) == 0 {
+		methods = DefaultMethods
+	}
+
+	headers := p.AllowedHeaders
+	if len(headers) == 0 {
+		headers = DefaultHeaders
+	}
+
+	wildcard := p.AllowsOrigin("*")
+	credentials := p.AllowCredentials && !wildcard
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// the answer depends on the origin, so caches must keep one per origin
+		w.Header().Add("Vary", "Origin")
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !p.AllowsOrigin(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// the origin is only ever named when it is one of the allowed origins
+		if wildcard {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if credentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if len(p.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(p.ExposedHeaders, ", "))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+		if p.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,86 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPolicy_Handler(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	admin := Policy{
+		AllowedOrigins:   []string{"https://admin.shop.test"},
+		ExposedHeaders:   []string{"X-Total-Count"},
+		AllowCredentials: true,
+		MaxAge:           5 * time.Minute,
+	}.Handler(ok)
+	public := Policy{AllowedOrigins: []string{"*"}}.Handler(ok)
+	careless := Policy{AllowedOrigins: []string{"*"}, AllowCredentials: true}.Handler(ok)
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		method  string
+		origin  string
+		status  int
+		headers map[string]string
+	}{
+		{"same origin", admin, "GET", "", http.StatusOK, map[string]string{"Access-Control-Allow-Origin": ""}},
+		{"allowed origin", admin, "GET", "https://admin.shop.test", http.StatusOK, map[string]string{
+			"Access-Control-Allow-Origin":      "https://admin.shop.test",
+			"Access-Control-Allow-Credentials": "true",
+			"Access-Control-Expose-Headers":    "X-Total-Count",
+			"Vary":                             "Origin",
+		}},
+		{"other origin", admin, "GET", "https://evil.test", http.StatusOK, map[string]string{"Access-Control-Allow-Origin": ""}},
+		{"preflight", admin, "OPTIONS", "https://admin.shop.test", http.StatusNoContent, map[string]string{
+			"Access-Control-Allow-Origin":  "https://admin.shop.test",
+			"Access-Control-Allow-Methods": "GET, POST, PUT, PATCH, DELETE",
+			"Access-Control-Allow-Headers": "Accept, Authorization, Content-Type, X-Requested-With",
+			"Access-Control-Max-Age":       "300",
+		}},
+		{"preflight from other origin", admin, "OPTIONS", "https://evil.test", http.StatusForbidden, map[string]string{"Access-Control-Allow-Origin": ""}},
+		{"wildcard", public, "GET", "https://anyone.test", http.StatusOK, map[string]string{"Access-Control-Allow-Origin": "*"}},
+		{"wildcard with credentials", careless, "GET", "https://evil.test", http.StatusOK, map[string]string{
+			"Access-Control-Allow-Origin":      "*",
+			"Access-Control-Allow-Credentials": "",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/admin/users", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.method == "OPTIONS" {
+				req.Header.Set("Access-Control-Request-Method", "DELETE")
+			}
+
+			rr := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rr.Code)
+			}
+			for header, want := range tt.headers {
+				if got := rr.Header().Get(header); got != want {
+					t.Errorf("expected %s %q, got %q", header, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	if err := (Policy{AllowedOrigins: []string{"https://shop.test", "*"}, AllowCredentials: true}).Validate(); err != ErrWildcardCredentials {
+		t.Errorf("expected credentials for every origin to be refused, got %v", err)
+	}
+	if err := (Policy{AllowedOrigins: []string{"https://shop.test"}, AllowCredentials: true}).Validate(); err != nil {
+		t.Errorf("expected credentials for a listed origin to be fine, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package gemquick
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCORSPolicy(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_METHODS", "GET, POST")
+	t.Setenv("CORS_MAX_AGE", "600")
+	t.Setenv("CORS_PUBLIC_ALLOWED_ORIGINS", "*")
+	t.Setenv("CORS_ADMIN_ALLOWED_ORIGINS", "https://admin.shop.test")
+	t.Setenv("CORS_ADMIN_ALLOWED_METHODS", "GET,DELETE")
+	t.Setenv("CORS_ADMIN_ALLOW_CREDENTIALS", "true")
+
+	public := CORSPolicy("public")
+	if !reflect.DeepEqual(public.AllowedOrigins, []string{"*"}) || !reflect.DeepEqual(public.AllowedMethods, []string{"GET", "POST"}) || public.AllowCredentials || public.MaxAge != 10*time.Minute {
+		t.Errorf("expected the public group to use the shared settings, got %+v", public)
+	}
+
+	admin := CORSPolicy("admin")
+	if !reflect.DeepEqual(admin.AllowedOrigins, []string{"https://admin.shop.test"}) || !reflect.DeepEqual(admin.AllowedMethods, []string{"GET", "DELETE"}) || !admin.AllowCredentials {
+		t.Errorf("expected the admin group to override the shared settings, got %+v", admin)
+	}
+
+	if other := CORSPolicy("billing"); len(other.AllowedOrigins) != 0 {
+		t.Errorf("expected a group without origins to allow none, got %v", other.AllowedOrigins)
+	}
+}
+
+func TestCORS_WildcardCredentials(t *testing.T) {
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	t.Setenv("CORS_PUBLIC_ALLOWED_ORIGINS", "*")
+
+	var logged bytes.Buffer
+	g := &Gemquick{ErrorLog: log.New(&logged, "", 0)}
+	handler := g.CORS("public")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/api/me", nil)
+	req.Header.Set("Origin", "https://evil.test")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Header().Get("Access-Control-Allow-Origin") != "*" || rr.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("expected the wildcard without credentials, got %v", rr.Header())
+	}
+	if !strings.Contains(logged.String(), "public") {
+		t.Errorf("expected the policy to be logged, got %q", logged.String())
+	}
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Value scans the one column of the first row of query into dest, like a count or a name, and
+// returns sql.ErrNoRows when there is no row:
+//
+//	var count int
+//	err := database.Value(ctx, db, &count, "SELECT COUNT(*) FROM orders WHERE status = ?", "paid")
+func Value(ctx context.Context, db Querier, dest interface{}, query string, args ...interface{}) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err := rows.Scan(dest); err != nil {
+		return err
+	}
+
+	return rows.Close()
+}
+
+// Pluck scans the one column of every row of query into dest, a pointer to a slice of its type:
+//
+//	var emails []string
+//	err := database.Pluck(ctx, db, &emails, "SELECT email FROM users WHERE active = ?", true)
+func Pluck(ctx context.Context, db Querier, dest interface{}, query string, args ...interface{}) error {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Pointer || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("cannot pluck into a %T, use a pointer to a slice", dest)
+	}
+	slice = slice.Elem()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	slice.SetLen(0)
+	for rows.Next() {
+		value := reflect.New(slice.Type().Elem())
+		if err := rows.Scan(value.Interface()); err != nil {
+			return err
+		}
+		slice.Set(reflect.Append(slice, value.Elem()))
+	}
+
+	return rows.Err()
+}
+
+// Sum returns the sum of a numeric column of the rows of query, written with ? placeholders, and 0
+// when there are none:
+//
+//	total, err := database.Sum(ctx, db, dataType, "amount", "SELECT * FROM orders WHERE user_id = ?", userID)
+func Sum(ctx context.Context, db Querier, dataType, column, query string, args ...interface{}) (float64, error) {
+	return aggregate(ctx, db, dataType, "SUM", column, query, args)
+}
+
+// Avg returns the average of a numeric column of the rows of query, as Sum does. Sql server
+// averages integers as integers, so the column is cast to a float there
+func Avg(ctx context.Context, db Querier, dataType, column, query string, args ...interface{}) (float64, error) {
+	return aggregate(ctx, db, dataType, "AVG", column, query, args)
+}
+
+// Min returns the least value of a numeric column of the rows of query, as Sum does. The least of
+// other columns, like dates, is read with Value and SELECT MIN
+func Min(ctx context.Context, db Querier, dataType, column, query string, args ...interface{}) (float64, error) {
+	return aggregate(ctx, db, dataType, "MIN", column, query, args)
+}
+
+// Max returns the greatest value of a numeric column of the rows of query, as Min does
+func Max(ctx context.Context, db Querier, dataType, column, query string, args ...interface{}) (float64, error) {
+	return aggregate(ctx, db, dataType, "MAX", column, query, args)
+}
+
+func aggregate(ctx context.Context, db Querier, dataType, fn, column, query string, args []interface{}) (float64, error) {
+	if !validIdentifier.MatchString(column) || strings.Contains(column, ".") {
+		return 0, fmt.Errorf("%q is not a column of the query", column)
+	}
+
+	value := column
+	if fn == "AVG" && Dialect(dataType) == "sqlserver" {
+		value = "CAST(" + column + " AS FLOAT)"
+	}
+
+	var result sql.NullFloat64
+	err := Value(ctx, db, &result, Rebind(dataType, "SELECT "+fn+"("+value+") FROM ("+query+") AS source"), args...)
+
+	return result.Float64, err
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestValue(t *testing.T) {
+	db, mock := newMock(t)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT COUNT").WithArgs("paid").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("SELECT name").WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	var count int
+	if err := Value(ctx, db, &count, "SELECT COUNT(*) FROM orders WHERE status = ?", "paid"); err != nil || count != 3 {
+		t.Errorf("expected 3, got %d, %v", count, err)
+	}
+
+	var name string
+	if err := Value(ctx, db, &name, "SELECT name FROM users WHERE id = 0"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected no rows, got %v", err)
+	}
+}
+
+func TestPluck(t *testing.T) {
+	db, mock := newMock(t)
+
+	mock.ExpectQuery("SELECT email").WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("ada@example.com").AddRow("grace@example.com"))
+
+	var emails []string
+	if err := Pluck(context.Background(), db, &emails, "SELECT email FROM users"); err != nil || len(emails) != 2 || emails[1] != "grace@example.com" {
+		t.Errorf("expected both emails, got %v, %v", emails, err)
+	}
+
+	if err := Pluck(context.Background(), db, emails, "SELECT email FROM users"); err == nil {
+		t.Error("expected a slice that is not a pointer to be refused")
+	}
+}
+
+func TestAggregates(t *testing.T) {
+	db, mock := newMock(t)
+	ctx := context.Background()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT SUM(amount) FROM (SELECT * FROM orders WHERE user_id = $1) AS source")).
+		WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(12.5))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT AVG(CAST(amount AS FLOAT)) FROM (SELECT * FROM orders) AS source")).
+		WillReturnRows(sqlmock.NewRows([]string{"avg"}).AddRow(2.5))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT MAX(amount) FROM (SELECT * FROM orders) AS source")).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+
+	if sum, err := Sum(ctx, db, "pgx", "amount", "SELECT * FROM orders WHERE user_id = ?", 7); err != nil || sum != 12.5 {
+		t.Errorf("expected 12.5, got %g, %v", sum, err)
+	}
+	if avg, err := Avg(ctx, db, "sqlserver", "amount", "SELECT * FROM orders"); err != nil || avg != 2.5 {
+		t.Errorf("expected 2.5, got %g, %v", avg, err)
+	}
+	if max, err := Max(ctx, db, "mysql", "amount", "SELECT * FROM orders"); err != nil || max != 0 {
+		t.Errorf("expected 0 without rows, got %g, %v", max, err)
+	}
+	if _, err := Min(ctx, db, "mysql", "orders.amount", "SELECT * FROM orders"); err == nil {
+		t.Error("expected a qualified column to be refused")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
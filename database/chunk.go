@@ -0,0 +1,46 @@
+package database
+
+import "context"
+
+// Chunk reads the rows of query into slices of up to size rows of T, a struct or a pointer to one,
+// and calls fn with each in turn, so a large result is processed with the memory of one chunk. It
+// pages by keyset on column as Paginate does, so rows that are changed by fn are not skipped or read
+// twice the way they would be with an offset, and it stops at the first error fn returns:
+//
+//	err := database.Chunk(ctx, db, dataType, "id", 500, func(users []data.User) error {
+//		return mailer.Remind(users)
+//	}, "SELECT * FROM users WHERE active = ?", true)
+func Chunk[T any](ctx context.Context, db Querier, dataType, column string, size int, fn func([]T) error, query string, args ...interface{}) error {
+	var after interface{}
+	for {
+		var rows []T
+		page, err := Paginate(ctx, db, dataType, &rows, Cursor{Column: column, After: after, Limit: size}, query, args...)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		if err := fn(rows); err != nil {
+			return err
+		}
+
+		if page.Next == nil {
+			return nil
+		}
+		after = page.Next
+	}
+}
+
+// Each is Chunk for one row at a time, read in chunks of size rows
+func Each[T any](ctx context.Context, db Querier, dataType, column string, size int, fn func(T) error, query string, args ...interface{}) error {
+	return Chunk(ctx, db, dataType, column, size, func(rows []T) error {
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, query, args...)
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestChunk(t *testing.T) {
+	db, mock := newMock(t)
+	ctx := context.Background()
+	columns := []string{"id", "first_name"}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM (SELECT * FROM users WHERE active = ?) AS page ORDER BY id LIMIT 3")).
+		WithArgs(true).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "Ada").AddRow(2, "Grace").AddRow(3, "Hedy"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM (SELECT * FROM users WHERE active = ?) AS page WHERE id > ? ORDER BY id LIMIT 3")).
+		WithArgs(true, 2).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(3, "Hedy"))
+
+	var chunks [][]user
+	err := Chunk(ctx, db, "mysql", "id", 2, func(users []user) error {
+		chunks = append(chunks, users)
+		return nil
+	}, "SELECT * FROM users WHERE active = ?", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || len(chunks[0]) != 2 || chunks[1][0].ID != 3 {
+		t.Errorf("expected users 1 and 2, then 3, got %+v", chunks)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM (SELECT * FROM users) AS page ORDER BY id LIMIT 3")).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "Ada").AddRow(2, "Grace").AddRow(3, "Hedy"))
+
+	stop := errors.New("stop")
+	var seen []int
+	err = Each(ctx, db, "mysql", "id", 2, func(u *user) error {
+		seen = append(seen, u.ID)
+		if u.ID == 2 {
+			return stop
+		}
+		return nil
+	}, "SELECT * FROM users")
+	if !errors.Is(err, stop) || len(seen) != 2 {
+		t.Errorf("expected Each to stop at user 2, got %v and %v", seen, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,83 @@
+// Package database holds the SQL helpers shared by the framework and the apps built on it. The
+// framework writes its queries with ? placeholders, and Rebind turns them into what the database
+// of DATABASE_TYPE expects.
+//
+// There is no query builder: queries are SQL, and every helper takes the context of the request
+// so that its deadline reaches the database. What a query builder would add is a function here
+// when SQL cannot say it once for every DATABASE_TYPE, like placeholders, scanning into structs,
+// JSON paths and upserts. What SQL already says the same way everywhere, like subqueries and
+// parenthesized conditions, is written in the query, and what needs a model layer, like
+// relations, is left to the models
+package database
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect returns the SQL dialect of a DATABASE_TYPE: postgres for postgres, postgresql and pgx,
+// mysql for mysql and mariadb, sqlite for sqlite and sqlite3, sqlserver for sqlserver and mssql,
+// and the type itself otherwise
+func Dialect(dataType string) string {
+	switch strings.ToLower(dataType) {
+	case "postgres", "postgresql", "pgx":
+		return "postgres"
+	case "mysql", "mariadb":
+		return "mysql"
+	case "sqlite", "sqlite3":
+		return "sqlite"
+	case "sqlserver", "mssql":
+		return "sqlserver"
+	}
+
+	return strings.ToLower(dataType)
+}
+
+// Postgres reports whether a DATABASE_TYPE is postgres
+func Postgres(dataType string) bool {
+	return Dialect(dataType) == "postgres"
+}
+
+// Rebind rewrites the ? placeholders of query for a DATABASE_TYPE, so postgres gets $1, $2 and so
+// on, and sql server @p1, @p2 and so on. Question marks in quoted strings and identifiers are left
+// alone, and so is the query for the databases that take ? as they are
+func Rebind(dataType, query string) string {
+	var prefix string
+	switch Dialect(dataType) {
+	case "postgres":
+		prefix = "$"
+	case "sqlserver":
+		prefix = "@p"
+	}
+	if prefix == "" || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n := 0
+	var quote rune
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '[' && prefix == "@p":
+			// sql server quotes identifiers in brackets, where postgres has its arrays
+			quote = ']'
+		case r == '?':
+			n++
+			b.WriteString(prefix)
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
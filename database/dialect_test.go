@@ -0,0 +1,35 @@
+package database
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		dataType string
+		query    string
+		expected string
+	}{
+		{"pgx", "SELECT * FROM users WHERE id = ? AND email = ?", "SELECT * FROM users WHERE id = $1 AND email = $2"},
+		{"postgresql", "UPDATE posts SET title = ? WHERE body = 'why?' AND id = ?", "UPDATE posts SET title = $1 WHERE body = 'why?' AND id = $2"},
+		{"postgres", `SELECT "what?" FROM t WHERE a = ?`, `SELECT "what?" FROM t WHERE a = $1`},
+		{"mysql", "SELECT * FROM users WHERE id = ?", "SELECT * FROM users WHERE id = ?"},
+		{"mariadb", "INSERT INTO t (a) VALUES (?)", "INSERT INTO t (a) VALUES (?)"},
+		{"pgx", "SELECT 1", "SELECT 1"},
+		{"sqlserver", "SELECT [why?] FROM t WHERE a = ? AND b = 'c?' AND d = ?", "SELECT [why?] FROM t WHERE a = @p1 AND b = 'c?' AND d = @p2"},
+		{"pgx", "SELECT ARRAY[?, ?]", "SELECT ARRAY[$1, $2]"},
+		{"mssql", "DELETE FROM t WHERE id = ?", "DELETE FROM t WHERE id = @p1"},
+	}
+
+	for _, e := range tests {
+		if got := Rebind(e.dataType, e.query); got != e.expected {
+			t.Errorf("Rebind(%s, %q): expected %q, got %q", e.dataType, e.query, e.expected, got)
+		}
+	}
+}
+
+func TestDialect(t *testing.T) {
+	for dataType, expected := range map[string]string{"pgx": "postgres", "postgresql": "postgres", "mariadb": "mysql", "mysql": "mysql", "sqlite3": "sqlite", "mssql": "sqlserver", "": ""} {
+		if got := Dialect(dataType); got != expected {
+			t.Errorf("Dialect(%q): expected %q, got %q", dataType, expected, got)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"fmt"
+)
+
+// WhereWithinRadius returns the condition and arguments that select the rows whose point column
+// lies within km kilometers of lat, lng, for "near me" features:
+//
+//	near, args, err := database.WhereWithinRadius(dataType, "location", 59.33, 18.07, 5)
+//	err = database.Get(ctx, db, &shops, database.Rebind(dataType, "SELECT * FROM shops WHERE "+near), args...)
+//
+// The column is a PostGIS geography or geometry point in SRID 4326 on postgres, a POINT with the
+// longitude as X on mysql, and a geography point on sql server. Sqlite has no spatial functions
+func WhereWithinRadius(dataType, column string, lat, lng, km float64) (string, []interface{}, error) {
+	if err := checkPoint(column, lat, lng); err != nil {
+		return "", nil, err
+	}
+	if km < 0 {
+		return "", nil, fmt.Errorf("a radius of %g km is negative", km)
+	}
+
+	switch Dialect(dataType) {
+	case "postgres":
+		// ST_DWithin uses the spatial index of the column, which a comparison of ST_Distance does not
+		return fmt.Sprintf("ST_DWithin(%s::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography, ?)", column),
+			[]interface{}{lng, lat, km * 1000}, nil
+	case "mysql":
+		return fmt.Sprintf("ST_Distance_Sphere(%s, POINT(?, ?)) <= ?", column), []interface{}{lng, lat, km * 1000}, nil
+	case "sqlserver":
+		return fmt.Sprintf("%s.STDistance(geography::Point(?, ?, 4326)) <= ?", column), []interface{}{lat, lng, km * 1000}, nil
+	}
+
+	return "", nil, fmt.Errorf("%s databases have no spatial functions", dataType)
+}
+
+// Distance returns the SQL and arguments of the distance in kilometers from the point column to
+// lat, lng, to select it or to order by it, nearest first:
+//
+//	distance, args, err := database.Distance(dataType, "location", 59.33, 18.07)
+//	query := "SELECT *, " + distance + " AS distance FROM shops ORDER BY distance LIMIT 10"
+//
+// The column is what it is for WhereWithinRadius
+func Distance(dataType, column string, lat, lng float64) (string, []interface{}, error) {
+	if err := checkPoint(column, lat, lng); err != nil {
+		return "", nil, err
+	}
+
+	switch Dialect(dataType) {
+	case "postgres":
+		return fmt.Sprintf("ST_Distance(%s::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography) / 1000", column),
+			[]interface{}{lng, lat}, nil
+	case "mysql":
+		return fmt.Sprintf("ST_Distance_Sphere(%s, POINT(?, ?)) / 1000", column), []interface{}{lng, lat}, nil
+	case "sqlserver":
+		return fmt.Sprintf("%s.STDistance(geography::Point(?, ?, 4326)) / 1000", column), []interface{}{lat, lng}, nil
+	}
+
+	return "", nil, fmt.Errorf("%s databases have no spatial functions", dataType)
+}
+
+func checkPoint(column string, lat, lng float64) error {
+	if !validIdentifier.MatchString(column) {
+		return fmt.Errorf("%q is not a column name", column)
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return fmt.Errorf("%g, %g is not a latitude and longitude", lat, lng)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWhereWithinRadius(t *testing.T) {
+	tests := []struct {
+		dataType string
+		where    string
+		args     []interface{}
+	}{
+		{"pgx", "ST_DWithin(location::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography, ?)", []interface{}{18.07, 59.33, 5000.0}},
+		{"mysql", "ST_Distance_Sphere(location, POINT(?, ?)) <= ?", []interface{}{18.07, 59.33, 5000.0}},
+		{"sqlserver", "location.STDistance(geography::Point(?, ?, 4326)) <= ?", []interface{}{59.33, 18.07, 5000.0}},
+	}
+
+	for _, tt := range tests {
+		where, args, err := WhereWithinRadius(tt.dataType, "location", 59.33, 18.07, 5)
+		if err != nil || where != tt.where || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%s: unexpected condition %q with %v, %v", tt.dataType, where, args, err)
+		}
+	}
+
+	for _, dataType := range []string{"sqlite", "oracle"} {
+		if _, _, err := WhereWithinRadius(dataType, "location", 59.33, 18.07, 5); err == nil {
+			t.Errorf("expected %s to be refused", dataType)
+		}
+	}
+	if _, _, err := WhereWithinRadius("pgx", "location", 91, 18.07, 5); err == nil {
+		t.Error("expected a latitude past the pole to be refused")
+	}
+	if _, _, err := WhereWithinRadius("pgx", "location; DROP TABLE shops", 59.33, 18.07, 5); err == nil {
+		t.Error("expected an invalid column to be refused")
+	}
+}
+
+func TestDistance(t *testing.T) {
+	distance, args, err := Distance("postgres", "location", 59.33, 18.07)
+	if err != nil || distance != "ST_Distance(location::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography) / 1000" || !reflect.DeepEqual(args, []interface{}{18.07, 59.33}) {
+		t.Errorf("unexpected distance %q with %v, %v", distance, args, err)
+	}
+
+	distance, _, _ = Distance("mariadb", "location", 59.33, 18.07)
+	if distance != "ST_Distance_Sphere(location, POINT(?, ?)) / 1000" {
+		t.Errorf("unexpected mysql distance %q", distance)
+	}
+}
@@ -0,0 +1,288 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// QueryEvent is one statement sent to the database, as seen by the hooks. Duration and Err are
+// set once it has run
+type QueryEvent struct {
+	Query    string
+	Args     []interface{}
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// QueryHook is told about every statement a pool opened with Instrument runs, to log slow
+// queries, record metrics or start tracing spans. BeforeQuery may return a context carrying its
+// own values, like a span, which AfterQuery is called with
+type QueryHook interface {
+	BeforeQuery(ctx context.Context, e *QueryEvent) context.Context
+	AfterQuery(ctx context.Context, e *QueryEvent)
+}
+
+// Hooks are the query hooks of a pool. Hooks can be added while the pool is in use, so an app
+// adds its own to app.DB.Hooks after the framework opened the pool
+type Hooks struct {
+	mu    sync.RWMutex
+	hooks []QueryHook
+}
+
+// Add adds hooks, which are called in the order they were added
+func (h *Hooks) Add(hooks ...QueryHook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.hooks = append(h.hooks, hooks...)
+}
+
+func (h *Hooks) list() []QueryHook {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.hooks
+}
+
+// run calls the hooks around fn, which runs the statement
+func (h *Hooks) run(ctx context.Context, query string, args []driver.NamedValue, fn func(ctx context.Context) error) error {
+	hooks := h.list()
+	if len(hooks) == 0 {
+		return fn(ctx)
+	}
+
+	e := &QueryEvent{Query: query, Start: time.Now()}
+	for _, arg := range args {
+		e.Args = append(e.Args, arg.Value)
+	}
+
+	for _, hook := range hooks {
+		ctx = hook.BeforeQuery(ctx, e)
+	}
+
+	err := fn(ctx)
+	// a driver that cannot run a statement directly has it prepared first, which runs the hooks then
+	if errors.Is(err, driver.ErrSkip) {
+		return err
+	}
+
+	e.Duration = time.Since(e.Start)
+	e.Err = err
+	for _, hook := range hooks {
+		hook.AfterQuery(ctx, e)
+	}
+
+	return err
+}
+
+// SlowQueryLogger is a hook that logs the statements that take Threshold or longer
+type SlowQueryLogger struct {
+	Threshold time.Duration
+	Log       *log.Logger
+}
+
+// BeforeQuery does nothing, the duration is known afterwards
+func (l SlowQueryLogger) BeforeQuery(ctx context.Context, e *QueryEvent) context.Context {
+	return ctx
+}
+
+// AfterQuery logs the statement when it was slow
+func (l SlowQueryLogger) AfterQuery(ctx context.Context, e *QueryEvent) {
+	if e.Duration >= l.Threshold && l.Log != nil {
+		l.Log.Printf("slow query (%s): %s %v", e.Duration.Round(time.Millisecond), e.Query, e.Args)
+	}
+}
+
+// Instrument returns a connector whose connections call hooks around every statement they run
+//
+//	hooks := &database.Hooks{}
+//	db := sql.OpenDB(database.Instrument(connector, hooks))
+func Instrument(c driver.Connector, hooks *Hooks) driver.Connector {
+	return &instrumentedConnector{Connector: c, hooks: hooks}
+}
+
+type instrumentedConnector struct {
+	driver.Connector
+	hooks *Hooks
+}
+
+func (c *instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedConn{Conn: conn, hooks: c.hooks}, nil
+}
+
+// instrumentedConn passes everything on to the driver's connection, and the interfaces the
+// connection does not implement to the fallbacks of database/sql
+type instrumentedConn struct {
+	driver.Conn
+	hooks *Hooks
+}
+
+func (c *instrumentedConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedStmt{Stmt: stmt, conn: c, query: query, hooks: c.hooks}, nil
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedStmt{Stmt: stmt, conn: c, query: query, hooks: c.hooks}, nil
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errors.New("the driver does not support transaction options")
+	}
+
+	// the fallback database/sql itself uses for drivers without BeginTx
+	return c.Conn.Begin()
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	var rows driver.Rows
+	err := c.hooks.run(ctx, query, args, func(ctx context.Context) error {
+		var err error
+		rows, err = q.QueryContext(ctx, query, args)
+		return err
+	})
+
+	return rows, err
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	var res driver.Result
+	err := c.hooks.run(ctx, query, args, func(ctx context.Context) error {
+		var err error
+		res, err = e.ExecContext(ctx, query, args)
+		return err
+	})
+
+	return res, err
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+
+	return true
+}
+
+func (c *instrumentedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
+
+type instrumentedStmt struct {
+	driver.Stmt
+	conn  *instrumentedConn
+	query string
+	hooks *Hooks
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	var res driver.Result
+	err := s.hooks.run(ctx, s.query, args, func(ctx context.Context) error {
+		var err error
+		if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+			res, err = e.ExecContext(ctx, args)
+		} else {
+			res, err = s.Stmt.Exec(values(args))
+		}
+		return err
+	})
+
+	return res, err
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	var rows driver.Rows
+	err := s.hooks.run(ctx, s.query, args, func(ctx context.Context) error {
+		var err error
+		if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+			rows, err = q.QueryContext(ctx, args)
+		} else {
+			rows, err = s.Stmt.Query(values(args))
+		}
+		return err
+	})
+
+	return rows, err
+}
+
+func (s *instrumentedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+
+	// database/sql only asks the connection when the statement cannot check values itself
+	return s.conn.CheckNamedValue(nv)
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	vs := make([]driver.Value, len(args))
+	for i, arg := range args {
+		vs[i] = arg.Value
+	}
+
+	return vs
+}
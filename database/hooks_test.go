@@ -0,0 +1,117 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type ctxKey struct{}
+
+type recordingHook struct {
+	events []QueryEvent
+	traced int
+}
+
+func (h *recordingHook) BeforeQuery(ctx context.Context, e *QueryEvent) context.Context {
+	return context.WithValue(ctx, ctxKey{}, "span")
+}
+
+func (h *recordingHook) AfterQuery(ctx context.Context, e *QueryEvent) {
+	if ctx.Value(ctxKey{}) == "span" {
+		h.traced++
+	}
+	h.events = append(h.events, *e)
+}
+
+type testConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c testConnector) Connect(ctx context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c testConnector) Driver() driver.Driver                            { return c.driver }
+
+func TestInstrument(t *testing.T) {
+	mockDB, mock, err := sqlmock.NewWithDSN("hooks_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mockDB.Close()
+
+	hooks := &Hooks{}
+	db := sql.OpenDB(Instrument(testConnector{driver: mockDB.Driver(), dsn: "hooks_test"}, hooks))
+	defer db.Close()
+
+	// statements run before a hook is added are not reported to it
+	mock.ExpectExec(`DELETE FROM sessions`).WillReturnResult(sqlmock.NewResult(0, 0))
+	if _, err := db.Exec("DELETE FROM sessions"); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &recordingHook{}
+	hooks.Add(rec)
+
+	mock.ExpectExec(`UPDATE users SET active = \? WHERE id = \?`).WithArgs(true, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	if _, err := db.Exec("UPDATE users SET active = ? WHERE id = ?", true, 7); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(`SELECT name FROM users`).WillReturnError(errors.New("no such table"))
+	if _, err := db.Query("SELECT name FROM users"); err == nil {
+		t.Fatal("expected the query to fail")
+	}
+
+	mock.ExpectPrepare(`INSERT INTO users`).ExpectExec().WithArgs("ada").WillReturnResult(sqlmock.NewResult(1, 1))
+	stmt, err := db.Prepare("INSERT INTO users (name) VALUES (?)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stmt.Exec("ada"); err != nil {
+		t.Fatal(err)
+	}
+	_ = stmt.Close()
+
+	if len(rec.events) != 3 || rec.traced != 3 {
+		t.Fatalf("expected 3 statements with the context of BeforeQuery, got %+v", rec.events)
+	}
+
+	if e := rec.events[0]; !strings.HasPrefix(e.Query, "UPDATE users") || len(e.Args) != 2 || e.Args[1] != int64(7) || e.Err != nil {
+		t.Errorf("expected the update with its arguments, got %+v", e)
+	}
+
+	if e := rec.events[1]; e.Err == nil || e.Err.Error() != "no such table" {
+		t.Errorf("expected the error of the query, got %+v", e)
+	}
+
+	if e := rec.events[2]; !strings.HasPrefix(e.Query, "INSERT INTO users") || e.Args[0] != "ada" {
+		t.Errorf("expected the prepared insert, got %+v", e)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSlowQueryLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := SlowQueryLogger{Threshold: 100 * time.Millisecond, Log: log.New(&buf, "", 0)}
+
+	l.AfterQuery(context.Background(), &QueryEvent{Query: "SELECT 1", Duration: 10 * time.Millisecond})
+	if buf.Len() != 0 {
+		t.Errorf("expected a fast query not to be logged, got %q", buf.String())
+	}
+
+	l.AfterQuery(context.Background(), &QueryEvent{Query: "SELECT * FROM orders", Args: []interface{}{3}, Duration: 1500 * time.Millisecond})
+	if !strings.Contains(buf.String(), "slow query (1.5s): SELECT * FROM orders [3]") {
+		t.Errorf("expected the slow query to be logged, got %q", buf.String())
+	}
+}
@@ -0,0 +1,257 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Execer runs statements, like *sql.DB, *sql.Tx and *sql.Conn
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// DefaultChunkSize is how many rows InsertMany puts in one statement when it is given no size
+const DefaultChunkSize = 500
+
+// maxParameters is the most placeholders postgres takes in one statement, mysql takes more
+const maxParameters = 65535
+
+// sql server takes fewer parameters in a statement, and at most 1000 rows in a VALUES list
+const (
+	maxSQLServerParameters = 2100
+	maxSQLServerRows       = 1000
+)
+
+// Expr is SQL that InsertMany, InsertStructs, Upsert and UpsertMany put into a row as it is
+// instead of binding it as a value, for what the database computes, like CURRENT_TIMESTAMP or
+// gen_random_uuid(). It is not escaped, so it must never come from user input, and takes no
+// arguments
+type Expr string
+
+// validIdentifier matches the table and column names the helpers put into their SQL as they are
+var validIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// InsertMany inserts rows into table with multi-row INSERT statements of up to chunkSize rows
+// each, DefaultChunkSize when it is 0, and returns how many rows were inserted. Every row must have
+// the same columns. The statements run one after the other on db, so give it a *sql.Tx to insert
+// all rows or none
+//
+//	n, err := database.InsertMany(ctx, db, "pgx", "users", []map[string]interface{}{
+//		{"email": "ada@example.com", "active": true},
+//		{"email": "grace@example.com", "active": false},
+//	}, 0)
+func InsertMany(ctx context.Context, db Execer, dataType, table string, rows []map[string]interface{}, chunkSize int) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	columns, values, err := mapValues(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	return insertValues(ctx, db, dataType, table, columns, values, chunkSize, "", "")
+}
+
+// InsertStructs is InsertMany for a slice of structs or of pointers to structs. The columns are the
+// fields' db tags or snake cased names, as for ScanAll, and fields tagged omitempty, like
+// `db:"id,omitempty"`, are left out when they are zero in every row so the database fills them in
+func InsertStructs(ctx context.Context, db Execer, dataType, table string, rows interface{}, chunkSize int) (int64, error) {
+	slice := reflect.ValueOf(rows)
+	if slice.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("cannot insert a %T, use a slice of structs", rows)
+	}
+
+	if slice.Len() == 0 {
+		return 0, nil
+	}
+
+	elem := slice.Type().Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return 0, fmt.Errorf("cannot insert a %T, use a slice of structs", rows)
+	}
+
+	structs := make([]reflect.Value, slice.Len())
+	for i := range structs {
+		structs[i] = reflect.Indirect(slice.Index(i))
+		if !structs[i].IsValid() {
+			return 0, fmt.Errorf("row %d is nil", i)
+		}
+	}
+
+	var columns []string
+	var fields [][]int
+	for _, f := range insertFields(elem) {
+		if f.omitEmpty && allZero(structs, f.index) {
+			continue
+		}
+		columns = append(columns, f.column)
+		fields = append(fields, f.index)
+	}
+
+	values := make([][]interface{}, len(structs))
+	for i, s := range structs {
+		values[i] = make([]interface{}, len(fields))
+		for j, index := range fields {
+			values[i][j] = fieldByIndex(s, index).Interface()
+		}
+	}
+
+	return insertValues(ctx, db, dataType, table, columns, values, chunkSize, "", "")
+}
+
+// mapValues returns the sorted columns of the first row and the values of every row in their order
+func mapValues(rows []map[string]interface{}) ([]string, [][]interface{}, error) {
+	columns := make([]string, 0, len(rows[0]))
+	for column := range rows[0] {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	values := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return nil, nil, fmt.Errorf("row %d has %d columns, the first row has %d", i, len(row), len(columns))
+		}
+
+		values[i] = make([]interface{}, len(columns))
+		for j, column := range columns {
+			value, ok := row[column]
+			if !ok {
+				return nil, nil, fmt.Errorf("row %d has no value for %s", i, column)
+			}
+			values[i][j] = value
+		}
+	}
+
+	return columns, values, nil
+}
+
+// insertValues inserts the rows of values, which are in the order of columns, in chunks. Prefix
+// starts every statement before its rows, INSERT INTO table (columns) VALUES when it is empty, and
+// suffix ends it, e.g. with an ON CONFLICT clause
+func insertValues(ctx context.Context, db Execer, dataType, table string, columns []string, values [][]interface{}, chunkSize int, prefix, suffix string) (int64, error) {
+	if !validIdentifier.MatchString(table) {
+		return 0, fmt.Errorf("%q is not a table name", table)
+	}
+
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("no columns to insert into %s", table)
+	}
+
+	for _, column := range columns {
+		if !validIdentifier.MatchString(column) {
+			return 0, fmt.Errorf("%q is not a column name", column)
+		}
+	}
+
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	limit := maxParameters
+	if Dialect(dataType) == "sqlserver" {
+		limit = maxSQLServerParameters - 1
+		if chunkSize > maxSQLServerRows {
+			chunkSize = maxSQLServerRows
+		}
+	}
+	if chunkSize*len(columns) > limit {
+		chunkSize = limit / len(columns)
+	}
+
+	if prefix == "" {
+		prefix = fmt.Sprintf("INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", "))
+	}
+
+	var inserted int64
+	for start := 0; start < len(values); start += chunkSize {
+		end := start + chunkSize
+		if end > len(values) {
+			end = len(values)
+		}
+
+		var query strings.Builder
+		query.WriteString(prefix)
+		args := make([]interface{}, 0, (end-start)*len(columns))
+		for i, row := range values[start:end] {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteByte('(')
+			for j, v := range row {
+				if j > 0 {
+					query.WriteString(", ")
+				}
+				if expr, ok := v.(Expr); ok {
+					query.WriteString(string(expr))
+					continue
+				}
+				query.WriteByte('?')
+				args = append(args, v)
+			}
+			query.WriteByte(')')
+		}
+		query.WriteString(suffix)
+
+		res, err := db.ExecContext(ctx, Rebind(dataType, query.String()), args...)
+		if err != nil {
+			return inserted, fmt.Errorf("inserting rows %d to %d into %s: %w", start+1, end, table, err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			n = int64(end - start)
+		}
+		inserted += n
+	}
+
+	return inserted, nil
+}
+
+type insertField struct {
+	column    string
+	index     []int
+	omitEmpty bool
+}
+
+// insertFields lists the columns of a struct type in the order of its fields
+func insertFields(t reflect.Type) []insertField {
+	columns := fieldsOf(t)
+
+	var fields []insertField
+	for column, index := range columns {
+		f := t.FieldByIndex(index)
+		_, options, _ := strings.Cut(f.Tag.Get("db"), ",")
+		fields = append(fields, insertField{column: column, index: index, omitEmpty: strings.Contains(options, "omitempty")})
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].index, fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	return fields
+}
+
+func allZero(structs []reflect.Value, index []int) bool {
+	for _, s := range structs {
+		if !fieldByIndex(s, index).IsZero() {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestInsertMany(t *testing.T) {
+	db, mock := newMock(t)
+
+	mock.ExpectExec(`INSERT INTO users \(active, email\) VALUES \(\$1, \$2\), \(\$3, \$4\)$`).
+		WithArgs(true, "ada@example.com", false, "grace@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO users \(active, email\) VALUES \(\$1, \$2\)$`).
+		WithArgs(true, "linus@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := InsertMany(context.Background(), db, "pgx", "users", []map[string]interface{}{
+		{"email": "ada@example.com", "active": true},
+		{"email": "grace@example.com", "active": false},
+		{"email": "linus@example.com", "active": true},
+	}, 2)
+	if err != nil || n != 3 {
+		t.Errorf("expected 3 inserted rows, got %d, %v", n, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInsertMany_Expr(t *testing.T) {
+	db, mock := newMock(t)
+
+	mock.ExpectExec(`INSERT INTO users \(created_at, email\) VALUES \(CURRENT_TIMESTAMP, \$1\), \(CURRENT_TIMESTAMP, \$2\)$`).
+		WithArgs("ada@example.com", "grace@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	now := Expr("CURRENT_TIMESTAMP")
+	n, err := InsertMany(context.Background(), db, "pgx", "users", []map[string]interface{}{
+		{"email": "ada@example.com", "created_at": now},
+		{"email": "grace@example.com", "created_at": now},
+	}, 0)
+	if err != nil || n != 2 {
+		t.Errorf("expected 2 inserted rows, got %d, %v", n, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInsertMany_Invalid(t *testing.T) {
+	db, _ := newMock(t)
+
+	_, err := InsertMany(context.Background(), db, "mysql", "users", []map[string]interface{}{
+		{"email": "ada@example.com"},
+		{"name": "Grace"},
+	}, 0)
+	if err == nil {
+		t.Error("expected an error for rows with different columns")
+	}
+
+	_, err = InsertMany(context.Background(), db, "mysql", "users; DROP TABLE users", []map[string]interface{}{{"email": "x"}}, 0)
+	if err == nil {
+		t.Error("expected an error for an invalid table name")
+	}
+}
+
+func TestInsertStructs(t *testing.T) {
+	db, mock := newMock(t)
+	now := time.Now()
+
+	mock.ExpectExec(`INSERT INTO users \(first_name, email_address, created_at\) VALUES \(\?, \?, \?\), \(\?, \?, \?\)$`).
+		WithArgs("Ada", "ada@example.com", now, "Grace", "grace@example.com", now).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	users := []user{
+		{FirstName: "Ada", Email: "ada@example.com", timestamps: timestamps{CreatedAt: now}},
+		{FirstName: "Grace", Email: "grace@example.com", timestamps: timestamps{CreatedAt: now}},
+	}
+
+	if n, err := InsertStructs(context.Background(), db, "mysql", "users", users, 0); err != nil || n != 2 {
+		t.Errorf("expected 2 inserted rows, got %d, %v", n, err)
+	}
+}
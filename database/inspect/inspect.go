@@ -0,0 +1,474 @@
+// Package inspect reads the schema of a postgres, mysql or sqlite database: its tables with their
+// columns, indexes and foreign keys, in the same structure for every database. Reverse
+// scaffolding and admin tooling build on it
+package inspect
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jimmitjoo/gemquick/database"
+)
+
+// Table is a table with its columns in the order they were created in
+type Table struct {
+	Name        string       `json:"name"`
+	Columns     []Column     `json:"columns"`
+	PrimaryKey  []string     `json:"primary_key"`
+	Indexes     []Index      `json:"indexes"`
+	ForeignKeys []ForeignKey `json:"foreign_keys"`
+}
+
+// Column returns the column with the name, and false when the table has none
+func (t Table) Column(name string) (Column, bool) {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+
+	return Column{}, false
+}
+
+// Column is a column of a table. Type is the type as the database spells it, like character
+// varying(255) on postgres and varchar(255) on mysql
+type Column struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+	// Default is the expression of the default value, nil when the column has none
+	Default       *string `json:"default,omitempty"`
+	AutoIncrement bool    `json:"auto_increment"`
+}
+
+// Index is an index of a table, including the one of the primary key
+type Index struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	Primary bool     `json:"primary"`
+}
+
+// ForeignKey is a foreign key of a table. OnDelete and OnUpdate are NO ACTION, RESTRICT, CASCADE,
+// SET NULL or SET DEFAULT. Foreign keys have no names in sqlite
+type ForeignKey struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	RefTable   string   `json:"ref_table"`
+	RefColumns []string `json:"ref_columns"`
+	OnDelete   string   `json:"on_delete"`
+	OnUpdate   string   `json:"on_update"`
+}
+
+// Tables returns the names of the tables of the database, sorted, without the ones of the
+// database itself. dataType is the DATABASE_TYPE
+func Tables(ctx context.Context, db database.Querier, dataType string) ([]string, error) {
+	var query string
+	switch database.Dialect(dataType) {
+	case "postgres":
+		query = "SELECT tablename FROM pg_tables WHERE schemaname = current_schema() ORDER BY tablename"
+	case "mysql":
+		query = "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name"
+	case "sqlite":
+		query = "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name"
+	default:
+		return nil, fmt.Errorf("inspect: %s databases are not supported", dataType)
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, rows.Err()
+}
+
+// Schema returns every table of the database, see Tables
+func Schema(ctx context.Context, db database.Querier, dataType string) ([]Table, error) {
+	names, err := Tables(ctx, db, dataType)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]Table, 0, len(names))
+	for _, name := range names {
+		t, err := Inspect(ctx, db, dataType, name)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+
+	return tables, nil
+}
+
+// Inspect returns the table with the name, with its columns, indexes and foreign keys
+func Inspect(ctx context.Context, db database.Querier, dataType, name string) (Table, error) {
+	var d dialect
+	switch database.Dialect(dataType) {
+	case "postgres":
+		d = postgres{}
+	case "mysql":
+		d = mysql{}
+	case "sqlite":
+		d = sqlite{}
+	default:
+		return Table{}, fmt.Errorf("inspect: %s databases are not supported", dataType)
+	}
+
+	t := Table{Name: name}
+
+	var err error
+	if t.Columns, err = d.columns(ctx, db, name); err != nil {
+		return t, fmt.Errorf("inspect: the columns of %s: %w", name, err)
+	}
+	if len(t.Columns) == 0 {
+		return t, fmt.Errorf("inspect: there is no table %s", name)
+	}
+
+	if t.Indexes, err = d.indexes(ctx, db, name); err != nil {
+		return t, fmt.Errorf("inspect: the indexes of %s: %w", name, err)
+	}
+
+	if t.ForeignKeys, err = d.foreignKeys(ctx, db, name); err != nil {
+		return t, fmt.Errorf("inspect: the foreign keys of %s: %w", name, err)
+	}
+
+	for _, index := range t.Indexes {
+		if index.Primary {
+			t.PrimaryKey = index.Columns
+		}
+	}
+	if t.PrimaryKey == nil {
+		t.PrimaryKey, err = d.primaryKey(ctx, db, name)
+		if err != nil {
+			return t, fmt.Errorf("inspect: the primary key of %s: %w", name, err)
+		}
+	}
+
+	return t, nil
+}
+
+type dialect interface {
+	columns(ctx context.Context, db database.Querier, table string) ([]Column, error)
+	indexes(ctx context.Context, db database.Querier, table string) ([]Index, error)
+	foreignKeys(ctx context.Context, db database.Querier, table string) ([]ForeignKey, error)
+	// primaryKey is asked for the primary key of tables without a primary index
+	primaryKey(ctx context.Context, db database.Querier, table string) ([]string, error)
+}
+
+type postgres struct{}
+
+func (postgres) columns(ctx context.Context, db database.Querier, table string) ([]Column, error) {
+	rows, err := db.QueryContext(ctx, `SELECT a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+	pg_get_expr(d.adbin, d.adrelid), a.attidentity <> ''
+FROM pg_attribute a LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE a.attrelid = to_regclass(quote_ident($1)) AND a.attnum > 0 AND NOT a.attisdropped ORDER BY a.attnum`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []Column
+	for rows.Next() {
+		var c Column
+		var def sql.NullString
+		if err = rows.Scan(&c.Name, &c.Type, &c.Nullable, &def, &c.AutoIncrement); err != nil {
+			return nil, err
+		}
+		if def.Valid {
+			c.Default = &def.String
+			c.AutoIncrement = c.AutoIncrement || strings.HasPrefix(def.String, "nextval(")
+		}
+		columns = append(columns, c)
+	}
+
+	return columns, rows.Err()
+}
+
+func (postgres) indexes(ctx context.Context, db database.Querier, table string) ([]Index, error) {
+	rows, err := db.QueryContext(ctx, `SELECT i.relname, x.indisunique, x.indisprimary,
+	array_to_string(ARRAY(SELECT a.attname FROM unnest(x.indkey) WITH ORDINALITY k(attnum, n)
+		JOIN pg_attribute a ON a.attrelid = x.indrelid AND a.attnum = k.attnum ORDER BY k.n), ',')
+FROM pg_index x JOIN pg_class i ON i.oid = x.indexrelid
+WHERE x.indrelid = to_regclass(quote_ident($1)) ORDER BY i.relname`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []Index
+	for rows.Next() {
+		var index Index
+		var columns string
+		if err = rows.Scan(&index.Name, &index.Unique, &index.Primary, &columns); err != nil {
+			return nil, err
+		}
+		index.Columns = splitColumns(columns)
+		indexes = append(indexes, index)
+	}
+
+	return indexes, rows.Err()
+}
+
+// postgresActions are the codes of the actions of pg_constraint
+var postgresActions = map[string]string{"a": "NO ACTION", "r": "RESTRICT", "c": "CASCADE", "n": "SET NULL", "d": "SET DEFAULT"}
+
+func (postgres) foreignKeys(ctx context.Context, db database.Querier, table string) ([]ForeignKey, error) {
+	rows, err := db.QueryContext(ctx, `SELECT c.conname,
+	array_to_string(ARRAY(SELECT a.attname FROM unnest(c.conkey) WITH ORDINALITY k(attnum, n)
+		JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum ORDER BY k.n), ','),
+	f.relname,
+	array_to_string(ARRAY(SELECT a.attname FROM unnest(c.confkey) WITH ORDINALITY k(attnum, n)
+		JOIN pg_attribute a ON a.attrelid = c.confrelid AND a.attnum = k.attnum ORDER BY k.n), ','),
+	c.confdeltype, c.confupdtype
+FROM pg_constraint c JOIN pg_class f ON f.oid = c.confrelid
+WHERE c.contype = 'f' AND c.conrelid = to_regclass(quote_ident($1)) ORDER BY c.conname`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []ForeignKey
+	for rows.Next() {
+		var fk ForeignKey
+		var columns, refColumns, onDelete, onUpdate string
+		if err = rows.Scan(&fk.Name, &columns, &fk.RefTable, &refColumns, &onDelete, &onUpdate); err != nil {
+			return nil, err
+		}
+		fk.Columns, fk.RefColumns = splitColumns(columns), splitColumns(refColumns)
+		fk.OnDelete, fk.OnUpdate = postgresActions[onDelete], postgresActions[onUpdate]
+		keys = append(keys, fk)
+	}
+
+	return keys, rows.Err()
+}
+
+func (postgres) primaryKey(ctx context.Context, db database.Querier, table string) ([]string, error) {
+	return nil, nil
+}
+
+type mysql struct{}
+
+func (mysql) columns(ctx context.Context, db database.Querier, table string) ([]Column, error) {
+	rows, err := db.QueryContext(ctx, `SELECT column_name, column_type, is_nullable = 'YES', column_default, extra LIKE '%auto_increment%'
+FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []Column
+	for rows.Next() {
+		var c Column
+		var def sql.NullString
+		if err = rows.Scan(&c.Name, &c.Type, &c.Nullable, &def, &c.AutoIncrement); err != nil {
+			return nil, err
+		}
+		if def.Valid {
+			c.Default = &def.String
+		}
+		columns = append(columns, c)
+	}
+
+	return columns, rows.Err()
+}
+
+func (mysql) indexes(ctx context.Context, db database.Querier, table string) ([]Index, error) {
+	rows, err := db.QueryContext(ctx, `SELECT index_name, non_unique = 0, column_name
+FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? ORDER BY index_name, seq_in_index`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []Index
+	for rows.Next() {
+		var name, column string
+		var unique bool
+		if err = rows.Scan(&name, &unique, &column); err != nil {
+			return nil, err
+		}
+
+		// the columns of an index come one row each
+		if n := len(indexes); n > 0 && indexes[n-1].Name == name {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, column)
+			continue
+		}
+		indexes = append(indexes, Index{Name: name, Columns: []string{column}, Unique: unique, Primary: name == "PRIMARY"})
+	}
+
+	return indexes, rows.Err()
+}
+
+func (mysql) foreignKeys(ctx context.Context, db database.Querier, table string) ([]ForeignKey, error) {
+	rows, err := db.QueryContext(ctx, `SELECT k.constraint_name, k.column_name, k.referenced_table_name, k.referenced_column_name, r.delete_rule, r.update_rule
+FROM information_schema.key_column_usage k
+JOIN information_schema.referential_constraints r ON r.constraint_schema = k.constraint_schema AND r.constraint_name = k.constraint_name
+WHERE k.table_schema = DATABASE() AND k.table_name = ? AND k.referenced_table_name IS NOT NULL
+ORDER BY k.constraint_name, k.ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []ForeignKey
+	for rows.Next() {
+		var fk ForeignKey
+		var column, refColumn string
+		if err = rows.Scan(&fk.Name, &column, &fk.RefTable, &refColumn, &fk.OnDelete, &fk.OnUpdate); err != nil {
+			return nil, err
+		}
+
+		if n := len(keys); n > 0 && keys[n-1].Name == fk.Name {
+			keys[n-1].Columns = append(keys[n-1].Columns, column)
+			keys[n-1].RefColumns = append(keys[n-1].RefColumns, refColumn)
+			continue
+		}
+		fk.Columns, fk.RefColumns = []string{column}, []string{refColumn}
+		keys = append(keys, fk)
+	}
+
+	return keys, rows.Err()
+}
+
+func (mysql) primaryKey(ctx context.Context, db database.Querier, table string) ([]string, error) {
+	return nil, nil
+}
+
+type sqlite struct{}
+
+func (sqlite) columns(ctx context.Context, db database.Querier, table string) ([]Column, error) {
+	rows, err := db.QueryContext(ctx, `SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []Column
+	var primary []int
+	for rows.Next() {
+		var c Column
+		var notNull bool
+		var def sql.NullString
+		var pk int
+		if err = rows.Scan(&c.Name, &c.Type, &notNull, &def, &pk); err != nil {
+			return nil, err
+		}
+		c.Nullable = !notNull && pk == 0
+		if def.Valid {
+			c.Default = &def.String
+		}
+		if pk > 0 {
+			primary = append(primary, len(columns))
+		}
+		columns = append(columns, c)
+	}
+
+	// a single INTEGER PRIMARY KEY is the rowid, which counts up by itself
+	if len(primary) == 1 && strings.EqualFold(columns[primary[0]].Type, "integer") {
+		columns[primary[0]].AutoIncrement = true
+	}
+
+	return columns, rows.Err()
+}
+
+func (sqlite) indexes(ctx context.Context, db database.Querier, table string) ([]Index, error) {
+	rows, err := db.QueryContext(ctx, `SELECT l.name, l."unique", l.origin = 'pk', i.name
+FROM pragma_index_list(?) l JOIN pragma_index_info(l.name) i ORDER BY l.name, i.seqno`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []Index
+	for rows.Next() {
+		var name, column string
+		var unique, primary bool
+		if err = rows.Scan(&name, &unique, &primary, &column); err != nil {
+			return nil, err
+		}
+
+		if n := len(indexes); n > 0 && indexes[n-1].Name == name {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, column)
+			continue
+		}
+		indexes = append(indexes, Index{Name: name, Columns: []string{column}, Unique: unique, Primary: primary})
+	}
+
+	return indexes, rows.Err()
+}
+
+func (sqlite) foreignKeys(ctx context.Context, db database.Querier, table string) ([]ForeignKey, error) {
+	rows, err := db.QueryContext(ctx, `SELECT id, "table", "from", "to", on_delete, on_update FROM pragma_foreign_key_list(?) ORDER BY id, seq`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []ForeignKey
+	previous := -1
+	for rows.Next() {
+		var id int
+		var fk ForeignKey
+		var column string
+		var refColumn sql.NullString
+		if err = rows.Scan(&id, &fk.RefTable, &column, &refColumn, &fk.OnDelete, &fk.OnUpdate); err != nil {
+			return nil, err
+		}
+
+		if id != previous {
+			keys = append(keys, fk)
+			previous = id
+		}
+
+		// a key without referenced columns refers to the primary key of RefTable
+		n := len(keys) - 1
+		keys[n].Columns = append(keys[n].Columns, column)
+		if refColumn.Valid {
+			keys[n].RefColumns = append(keys[n].RefColumns, refColumn.String)
+		}
+	}
+
+	return keys, rows.Err()
+}
+
+// primaryKey returns the columns of the primary key of a rowid table, which has no index for it
+func (sqlite) primaryKey(ctx context.Context, db database.Querier, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err = rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, rows.Err()
+}
+
+func splitColumns(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,148 @@
+package inspect
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestInspect_SQLite(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	def := "'draft'"
+	mock.ExpectQuery(`FROM pragma_table_info\(\?\) ORDER BY cid`).WithArgs("posts").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "type", "notnull", "dflt_value", "pk"}).
+			AddRow("id", "INTEGER", false, nil, 1).
+			AddRow("user_id", "INTEGER", true, nil, 0).
+			AddRow("status", "VARCHAR(20)", true, def, 0).
+			AddRow("body", "TEXT", false, nil, 0))
+	mock.ExpectQuery(`FROM pragma_index_list\(\?\) l JOIN pragma_index_info`).WithArgs("posts").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "unique", "pk", "column"}).
+			AddRow("posts_user_status_idx", false, false, "user_id").
+			AddRow("posts_user_status_idx", false, false, "status"))
+	mock.ExpectQuery(`FROM pragma_foreign_key_list\(\?\)`).WithArgs("posts").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "table", "from", "to", "on_delete", "on_update"}).
+			AddRow(0, "users", "user_id", "id", "CASCADE", "NO ACTION"))
+	mock.ExpectQuery(`SELECT name FROM pragma_table_info\(\?\) WHERE pk > 0`).WithArgs("posts").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("id"))
+
+	table, err := Inspect(context.Background(), db, "sqlite3", "posts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Table{
+		Name: "posts",
+		Columns: []Column{
+			{Name: "id", Type: "INTEGER", AutoIncrement: true},
+			{Name: "user_id", Type: "INTEGER"},
+			{Name: "status", Type: "VARCHAR(20)", Default: &def},
+			{Name: "body", Type: "TEXT", Nullable: true},
+		},
+		PrimaryKey:  []string{"id"},
+		Indexes:     []Index{{Name: "posts_user_status_idx", Columns: []string{"user_id", "status"}}},
+		ForeignKeys: []ForeignKey{{Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}, OnDelete: "CASCADE", OnUpdate: "NO ACTION"}},
+	}
+	if !reflect.DeepEqual(table, expected) {
+		t.Errorf("expected\n%+v, got\n%+v", expected, table)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInspect_MySQL(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`FROM information_schema.columns`).WithArgs("order_items").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name", "column_type", "nullable", "column_default", "auto_increment"}).
+			AddRow("order_id", "bigint unsigned", 0, nil, 0).
+			AddRow("line", "int", 0, nil, 0).
+			AddRow("product_id", "bigint unsigned", 1, nil, 0))
+	mock.ExpectQuery(`FROM information_schema.statistics`).WithArgs("order_items").
+		WillReturnRows(sqlmock.NewRows([]string{"index_name", "unique", "column_name"}).
+			AddRow("PRIMARY", 1, "order_id").
+			AddRow("PRIMARY", 1, "line").
+			AddRow("order_items_product_idx", 0, "product_id"))
+	mock.ExpectQuery(`FROM information_schema.key_column_usage k`).WithArgs("order_items").
+		WillReturnRows(sqlmock.NewRows([]string{"constraint_name", "column_name", "referenced_table_name", "referenced_column_name", "delete_rule", "update_rule"}).
+			AddRow("order_items_order_fk", "order_id", "orders", "id", "CASCADE", "RESTRICT").
+			AddRow("order_items_product_fk", "product_id", "products", "id", "SET NULL", "RESTRICT"))
+
+	table, err := Inspect(context.Background(), db, "mariadb", "order_items")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(table.PrimaryKey, []string{"order_id", "line"}) {
+		t.Errorf("expected the composite primary key, got %v", table.PrimaryKey)
+	}
+	if len(table.Indexes) != 2 || !table.Indexes[0].Primary || table.Indexes[1].Unique {
+		t.Errorf("expected the primary and product indexes, got %+v", table.Indexes)
+	}
+	if len(table.ForeignKeys) != 2 || table.ForeignKeys[1].OnDelete != "SET NULL" {
+		t.Errorf("expected two foreign keys, got %+v", table.ForeignKeys)
+	}
+	if c, ok := table.Column("product_id"); !ok || !c.Nullable {
+		t.Errorf("expected product_id to be nullable, got %+v", c)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInspect_Postgres(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT tablename FROM pg_tables`).
+		WillReturnRows(sqlmock.NewRows([]string{"tablename"}).AddRow("users"))
+	mock.ExpectQuery(`FROM pg_attribute a`).WithArgs("users").
+		WillReturnRows(sqlmock.NewRows([]string{"attname", "type", "nullable", "default", "identity"}).
+			AddRow("id", "integer", false, "nextval('users_id_seq'::regclass)", false).
+			AddRow("email", "character varying(255)", false, nil, false))
+	mock.ExpectQuery(`FROM pg_index x`).WithArgs("users").
+		WillReturnRows(sqlmock.NewRows([]string{"relname", "indisunique", "indisprimary", "columns"}).
+			AddRow("users_email_key", true, false, "email").
+			AddRow("users_pkey", true, true, "id"))
+	mock.ExpectQuery(`FROM pg_constraint c`).WithArgs("users").
+		WillReturnRows(sqlmock.NewRows([]string{"conname", "columns", "relname", "ref_columns", "confdeltype", "confupdtype"}))
+
+	tables, err := Schema(context.Background(), db, "pgx")
+	if err != nil || len(tables) != 1 {
+		t.Fatalf("expected the users table, got %+v, %v", tables, err)
+	}
+
+	users := tables[0]
+	if !users.Columns[0].AutoIncrement || users.Columns[1].AutoIncrement {
+		t.Errorf("expected only the serial id to count up, got %+v", users.Columns)
+	}
+	if !reflect.DeepEqual(users.PrimaryKey, []string{"id"}) || users.ForeignKeys != nil {
+		t.Errorf("expected the id primary key and no foreign keys, got %+v", users)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInspect_Unsupported(t *testing.T) {
+	if _, err := Inspect(context.Background(), nil, "mssql", "users"); err == nil {
+		t.Error("expected an error for a database that is not supported")
+	}
+}
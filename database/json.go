@@ -0,0 +1,251 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// jsonKey matches a key of a JSON path, which is put into the SQL as it is
+var jsonKey = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// JSONPath returns the SQL that reads the text at path in a JSON column, written as
+// column->key->key, e.g. data->settings->theme, where a number is an index into an array, like
+// data->items->0->name. It is data->'settings'->>'theme' for postgres and
+// JSON_UNQUOTE(JSON_EXTRACT(data, '$.settings.theme')) for mysql, or JSON_VALUE with the same path
+// for sql server, to compare with a placeholder:
+//
+//	path, err := database.JSONPath(dataType, "data->settings->theme")
+//	rows, err := db.Query(database.Rebind(dataType, "SELECT * FROM users WHERE "+path+" = ?"), "dark")
+func JSONPath(dataType, path string) (string, error) {
+	column, keys, err := splitJSONPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	if Postgres(dataType) {
+		if len(keys) == 0 {
+			return column + "::text", nil
+		}
+
+		expr := column
+		for i, key := range keys {
+			arrow := "->"
+			if i == len(keys)-1 {
+				arrow = "->>"
+			}
+			// a number is an array index, which postgres only takes unquoted
+			if arrayIndex(key) {
+				expr += arrow + key
+				continue
+			}
+			expr += arrow + "'" + key + "'"
+		}
+
+		return expr, nil
+	}
+
+	// sqlite's json_extract returns strings without their quotes already
+	if Dialect(dataType) == "sqlite" {
+		return fmt.Sprintf("json_extract(%s, '%s')", column, mysqlJSONPath(keys)), nil
+	}
+
+	if Dialect(dataType) == "sqlserver" {
+		if len(keys) == 0 {
+			return column, nil
+		}
+		return fmt.Sprintf("JSON_VALUE(%s, '%s')", column, mysqlJSONPath(keys)), nil
+	}
+
+	return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, '%s'))", column, mysqlJSONPath(keys)), nil
+}
+
+// WhereJSONContains returns the condition and argument that select the rows whose JSON column
+// contains value, e.g. database.WhereJSONContains(dataType, "tags", []string{"go"}) for a tags
+// array holding go. Value is encoded as JSON. Sql server has no containment, so there value is a
+// scalar, or an array of them, that the array in the column holds
+func WhereJSONContains(dataType, column string, value interface{}) (string, []interface{}, error) {
+	if !validIdentifier.MatchString(column) {
+		return "", nil, fmt.Errorf("%q is not a column name", column)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if Postgres(dataType) {
+		return column + "::jsonb @> ?::jsonb", []interface{}{string(encoded)}, nil
+	}
+
+	if Dialect(dataType) == "sqlite" {
+		return "", nil, errors.New("sqlite has no JSON containment, use JSONPath to compare a key")
+	}
+
+	if Dialect(dataType) == "sqlserver" {
+		return sqlServerJSONContains(column, encoded)
+	}
+
+	return "JSON_CONTAINS(" + column + ", ?)", []interface{}{string(encoded)}, nil
+}
+
+// JSONSet returns the SQL assignment and argument that set path, written as for JSONPath, to value
+// in an UPDATE, e.g. data = jsonb_set(data::jsonb, '{settings,theme}', ?::jsonb) for postgres and
+// data = JSON_SET(data, '$.settings.theme', CAST(? AS JSON)) for mysql. Value is encoded as JSON.
+// Sql server's JSON_MODIFY only sets strings, objects and arrays from a placeholder
+func JSONSet(dataType, path string, value interface{}) (string, []interface{}, error) {
+	column, keys, err := splitJSONPath(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(keys) == 0 {
+		return "", nil, fmt.Errorf("%q has no key to set, use column->key", path)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if Postgres(dataType) {
+		return fmt.Sprintf("%s = jsonb_set(%s::jsonb, '{%s}', ?::jsonb)", column, column, strings.Join(keys, ",")),
+			[]interface{}{string(encoded)}, nil
+	}
+
+	if Dialect(dataType) == "sqlite" {
+		return fmt.Sprintf("%s = json_set(%s, '%s', json(?))", column, column, mysqlJSONPath(keys)),
+			[]interface{}{string(encoded)}, nil
+	}
+
+	if Dialect(dataType) == "sqlserver" {
+		return sqlServerJSONSet(column, mysqlJSONPath(keys), encoded)
+	}
+
+	return fmt.Sprintf("%s = JSON_SET(%s, '%s', CAST(? AS JSON))", column, column, mysqlJSONPath(keys)),
+		[]interface{}{string(encoded)}, nil
+}
+
+// the type column of OPENJSON for strings, numbers and booleans
+var openJSONTypes = map[string]int{"string": 1, "number": 2, "bool": 3}
+
+// sqlServerJSONContains returns the condition that the array in column holds every scalar of
+// encoded, with OPENJSON, which gives every element as text along with its type
+func sqlServerJSONContains(column string, encoded []byte) (string, []interface{}, error) {
+	// numbers are compared as they were written
+	d := json.NewDecoder(bytes.NewReader(encoded))
+	d.UseNumber()
+
+	var value interface{}
+	if err := d.Decode(&value); err != nil {
+		return "", nil, err
+	}
+
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+	if len(values) == 0 {
+		return "", nil, errors.New("sqlserver: an empty array is contained by every array, there is nothing to look for")
+	}
+
+	var conditions []string
+	var args []interface{}
+	for _, v := range values {
+		var text, kind string
+		switch v := v.(type) {
+		case string:
+			text, kind = v, "string"
+		case json.Number:
+			text, kind = v.String(), "number"
+		case bool:
+			text, kind = strconv.FormatBool(v), "bool"
+		default:
+			return "", nil, fmt.Errorf("sqlserver: only strings, numbers and booleans can be looked for in a JSON array, not %s", encodedKind(v))
+		}
+
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM OPENJSON("+column+") WHERE [value] = ? AND [type] = ?)")
+		args = append(args, text, openJSONTypes[kind])
+	}
+
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// sqlServerJSONSet returns the JSON_MODIFY assignment of path in column. Objects and arrays go
+// through JSON_QUERY so that they are not stored as a string, which does not work for numbers,
+// booleans and null
+func sqlServerJSONSet(column, path string, encoded []byte) (string, []interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(encoded, &value); err != nil {
+		return "", nil, err
+	}
+
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("%s = JSON_MODIFY(%s, '%s', ?)", column, column, path), []interface{}{v}, nil
+	case map[string]interface{}, []interface{}:
+		return fmt.Sprintf("%s = JSON_MODIFY(%s, '%s', JSON_QUERY(?))", column, column, path),
+			[]interface{}{string(encoded)}, nil
+	default:
+		return "", nil, fmt.Errorf("sqlserver: JSON_MODIFY cannot set %s from a placeholder, only strings, objects and arrays", encodedKind(v))
+	}
+}
+
+// encodedKind names the JSON kind of a decoded value for errors
+func encodedKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case float64, json.Number:
+		return "a number"
+	case []interface{}:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// splitJSONPath splits column->key->key into the column and its keys
+func splitJSONPath(path string) (string, []string, error) {
+	parts := strings.Split(path, "->")
+	column := strings.TrimSpace(parts[0])
+	if !validIdentifier.MatchString(column) {
+		return "", nil, fmt.Errorf("%q is not a column name", column)
+	}
+
+	keys := make([]string, 0, len(parts)-1)
+	for _, key := range parts[1:] {
+		key = strings.TrimSpace(key)
+		if !jsonKey.MatchString(key) {
+			return "", nil, fmt.Errorf("%q is not a valid key in %s", key, path)
+		}
+		keys = append(keys, key)
+	}
+
+	return column, keys, nil
+}
+
+// mysqlJSONPath writes keys as a mysql path, $.settings.theme, with numbers as array indexes
+func mysqlJSONPath(keys []string) string {
+	path := "$"
+	for _, key := range keys {
+		if arrayIndex(key) {
+			path += "[" + key + "]"
+			continue
+		}
+		path += "." + key
+	}
+
+	return path
+}
+
+// arrayIndex reports whether a key of a JSON path is a number, which indexes an array
+func arrayIndex(key string) bool {
+	return strings.Trim(key, "0123456789") == ""
+}
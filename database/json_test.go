@@ -0,0 +1,100 @@
+package database
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	tests := []struct {
+		dataType string
+		path     string
+		expected string
+	}{
+		{"pgx", "data->settings->theme", "data->'settings'->>'theme'"},
+		{"postgres", "data->theme", "data->>'theme'"},
+		{"pgx", "data->items->0->name", "data->'items'->0->>'name'"},
+		{"pgx", "tags->1", "tags->>1"},
+		{"mysql", "data->settings->theme", "JSON_UNQUOTE(JSON_EXTRACT(data, '$.settings.theme'))"},
+		{"mariadb", "data->items->0->name", "JSON_UNQUOTE(JSON_EXTRACT(data, '$.items[0].name'))"},
+		{"sqlite", "data->settings->theme", "json_extract(data, '$.settings.theme')"},
+		{"sqlserver", "data->items->0->name", "JSON_VALUE(data, '$.items[0].name')"},
+		{"mssql", "data", "data"},
+	}
+
+	for _, e := range tests {
+		got, err := JSONPath(e.dataType, e.path)
+		if err != nil || got != e.expected {
+			t.Errorf("JSONPath(%s, %s): expected %s, got %s, %v", e.dataType, e.path, e.expected, got, err)
+		}
+	}
+
+	if _, err := JSONPath("pgx", "data->'; DROP TABLE users"); err == nil {
+		t.Error("expected an error for an invalid key")
+	}
+}
+
+func TestWhereJSONContains(t *testing.T) {
+	where, args, err := WhereJSONContains("pgx", "tags", []string{"go"})
+	if err != nil || where != "tags::jsonb @> ?::jsonb" || !reflect.DeepEqual(args, []interface{}{`["go"]`}) {
+		t.Errorf("unexpected condition %q with %v, %v", where, args, err)
+	}
+
+	where, _, _ = WhereJSONContains("mysql", "tags", "go")
+	if where != "JSON_CONTAINS(tags, ?)" {
+		t.Errorf("unexpected mysql condition %q", where)
+	}
+
+	if _, _, err := WhereJSONContains("sqlite3", "tags", "go"); err == nil {
+		t.Error("expected containment to be refused on sqlite")
+	}
+}
+
+func TestJSONSet(t *testing.T) {
+	set, args, err := JSONSet("pgx", "data->settings->theme", "dark")
+	if err != nil || set != "data = jsonb_set(data::jsonb, '{settings,theme}', ?::jsonb)" || args[0] != `"dark"` {
+		t.Errorf("unexpected assignment %q with %v, %v", set, args, err)
+	}
+
+	set, _, _ = JSONSet("mysql", "data->settings->theme", "dark")
+	if set != "data = JSON_SET(data, '$.settings.theme', CAST(? AS JSON))" {
+		t.Errorf("unexpected mysql assignment %q", set)
+	}
+
+	set, _, _ = JSONSet("sqlite", "data->settings->theme", "dark")
+	if set != "data = json_set(data, '$.settings.theme', json(?))" {
+		t.Errorf("unexpected sqlite assignment %q", set)
+	}
+}
+
+func TestJSON_SQLServer(t *testing.T) {
+	where, args, err := WhereJSONContains("sqlserver", "tags", []interface{}{"go", 7, true})
+	expected := "EXISTS (SELECT 1 FROM OPENJSON(tags) WHERE [value] = ? AND [type] = ?) AND " +
+		"EXISTS (SELECT 1 FROM OPENJSON(tags) WHERE [value] = ? AND [type] = ?) AND " +
+		"EXISTS (SELECT 1 FROM OPENJSON(tags) WHERE [value] = ? AND [type] = ?)"
+	if err != nil || where != expected || !reflect.DeepEqual(args, []interface{}{"go", 1, "7", 2, "true", 3}) {
+		t.Errorf("unexpected condition %q with %v, %v", where, args, err)
+	}
+	if got := Rebind("sqlserver", where); !strings.HasSuffix(got, "WHERE [value] = @p5 AND [type] = @p6)") {
+		t.Errorf("expected the placeholders to be numbered, got %q", got)
+	}
+
+	if _, _, err := WhereJSONContains("sqlserver", "data", map[string]string{"theme": "dark"}); err == nil {
+		t.Error("expected containment of an object to be refused")
+	}
+
+	set, args, err := JSONSet("sqlserver", "data->settings->theme", "dark")
+	if err != nil || set != "data = JSON_MODIFY(data, '$.settings.theme', ?)" || args[0] != "dark" {
+		t.Errorf("unexpected assignment %q with %v, %v", set, args, err)
+	}
+
+	set, args, err = JSONSet("sqlserver", "data->settings", map[string]string{"theme": "dark"})
+	if err != nil || set != "data = JSON_MODIFY(data, '$.settings', JSON_QUERY(?))" || args[0] != `{"theme":"dark"}` {
+		t.Errorf("unexpected assignment %q with %v, %v", set, args, err)
+	}
+
+	if _, _, err := JSONSet("sqlserver", "data->count", 3); err == nil {
+		t.Error("expected a number to be refused")
+	}
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+)
+
+// LockForUpdate returns the clause that ends a SELECT in a transaction to lock the rows it reads
+// until the transaction ends, so that no other transaction changes them in between, like stock
+// that is decremented:
+//
+//	lock, err := database.LockForUpdate(dataType)
+//	err = tx.QueryRowContext(ctx, database.Rebind(dataType, "SELECT stock FROM products WHERE id = ?"+lock), id).Scan(&stock)
+//
+// Sqlite lets one transaction write at a time, so it has no clause. Sql server locks with a hint
+// after the table name instead, WITH (UPDLOCK, ROWLOCK), which cannot be added at the end
+func LockForUpdate(dataType string) (string, error) {
+	switch Dialect(dataType) {
+	case "postgres", "mysql":
+		return " FOR UPDATE", nil
+	case "sqlite":
+		return "", nil
+	case "sqlserver":
+		return "", errors.New("sql server locks rows with WITH (UPDLOCK, ROWLOCK) after the table name")
+	}
+
+	return "", fmt.Errorf("%s databases are not supported", dataType)
+}
+
+// SharedLock returns the clause that ends a SELECT in a transaction to keep other transactions
+// from changing the rows it reads until the transaction ends, while they may still read them. It
+// is what LockForUpdate is otherwise, and WITH (HOLDLOCK, ROWLOCK) on sql server
+func SharedLock(dataType string) (string, error) {
+	switch Dialect(dataType) {
+	case "postgres":
+		return " FOR SHARE", nil
+	case "mysql":
+		// FOR SHARE is mysql 8 only, and mariadb does not know it
+		return " LOCK IN SHARE MODE", nil
+	case "sqlite":
+		return "", nil
+	case "sqlserver":
+		return "", errors.New("sql server locks rows with WITH (HOLDLOCK, ROWLOCK) after the table name")
+	}
+
+	return "", fmt.Errorf("%s databases are not supported", dataType)
+}
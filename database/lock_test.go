@@ -0,0 +1,24 @@
+package database
+
+import "testing"
+
+func TestLockClauses(t *testing.T) {
+	tests := []struct {
+		dataType       string
+		update, shared string
+		err            bool
+	}{
+		{"pgx", " FOR UPDATE", " FOR SHARE", false},
+		{"mariadb", " FOR UPDATE", " LOCK IN SHARE MODE", false},
+		{"sqlite3", "", "", false},
+		{"sqlserver", "", "", true},
+	}
+
+	for _, tt := range tests {
+		update, err := LockForUpdate(tt.dataType)
+		shared, sharedErr := SharedLock(tt.dataType)
+		if update != tt.update || shared != tt.shared || (err != nil) != tt.err || (sharedErr != nil) != tt.err {
+			t.Errorf("%s: expected %q and %q, got %q and %q, %v", tt.dataType, tt.update, tt.shared, update, shared, err)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratedb "github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// Migration is one migration of a Migrator, and whether the database has it
+type Migration struct {
+	Version uint   `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+// MigrationStatus is the version a database is migrated to and the migrations there are
+type MigrationStatus struct {
+	Version uint `json:"version"`
+	// Dirty is set when the migration to Version failed halfway, and has to be fixed by hand
+	Dirty      bool        `json:"dirty"`
+	Migrations []Migration `json:"migrations"`
+}
+
+// Migrator runs the migrations in FS, named like the ones gq make migration creates, e.g.
+// 1650000000_create_users.up.sql, from application code: on boot, or against the test database
+// in tests. The migrations are usually embedded in the binary
+//
+//	//go:embed migrations
+//	var migrations embed.FS
+//
+//	m := database.NewMigrator(app.DB.Pool, app.DB.DataType, migrations, "migrations")
+//	err := m.Up(ctx)
+//
+// It runs on a connection of DB, which it leaves open. Migrations with more than one statement
+// need multiStatements=true in the dsn on mysql
+type Migrator struct {
+	DB       *sql.DB
+	DataType string
+	FS       fs.FS
+	// Dir is the directory of FS the migrations are in, its root when it is empty
+	Dir string
+}
+
+// NewMigrator returns a migrator for the migrations in dir of migrations
+func NewMigrator(db *sql.DB, dataType string, migrations fs.FS, dir string) *Migrator {
+	return &Migrator{DB: db, DataType: dataType, FS: migrations, Dir: dir}
+}
+
+// Up runs every migration that has not run yet. A database that is up to date is not an error
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.run(ctx, func(mg *migrate.Migrate) error { return mg.Up() })
+}
+
+// Down runs the last n migrations down
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	if n <= 0 {
+		return errors.New("the number of migrations to run down has to be positive")
+	}
+
+	return m.run(ctx, func(mg *migrate.Migrate) error { return mg.Steps(-n) })
+}
+
+// Reset runs every migration down
+func (m *Migrator) Reset(ctx context.Context) error {
+	return m.run(ctx, func(mg *migrate.Migrate) error { return mg.Down() })
+}
+
+// To runs the migrations up or down until the database is at version
+func (m *Migrator) To(ctx context.Context, version uint) error {
+	return m.run(ctx, func(mg *migrate.Migrate) error { return mg.Migrate(version) })
+}
+
+// Status returns the version the database is at and which migrations it has
+func (m *Migrator) Status(ctx context.Context) (MigrationStatus, error) {
+	var status MigrationStatus
+
+	migrations, err := m.migrations()
+	if err != nil {
+		return status, err
+	}
+
+	err = m.run(ctx, func(mg *migrate.Migrate) error {
+		status.Version, status.Dirty, err = mg.Version()
+		if errors.Is(err, migrate.ErrNilVersion) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return status, err
+	}
+
+	for i := range migrations {
+		migrations[i].Applied = status.Version > 0 && migrations[i].Version <= status.Version
+	}
+	status.Migrations = migrations
+
+	return status, nil
+}
+
+// run runs f with a migrate instance on a connection of its own, which it closes after
+func (m *Migrator) run(ctx context.Context, f func(mg *migrate.Migrate) error) error {
+	src, err := m.source()
+	if err != nil {
+		return err
+	}
+
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		_ = src.Close()
+		return err
+	}
+
+	// drivers made with a connection close only the connection, and leave the pool to the app
+	var driver migratedb.Driver
+	switch Dialect(m.DataType) {
+	case "postgres":
+		driver, err = postgres.WithConnection(ctx, conn, &postgres.Config{})
+	case "mysql":
+		driver, err = mysql.WithConnection(ctx, conn, &mysql.Config{})
+	case "sqlserver":
+		driver, err = sqlServerWithConnection(ctx, conn)
+	default:
+		err = fmt.Errorf("migrations are not supported for DATABASE_TYPE %s", m.DataType)
+	}
+	if err != nil {
+		_ = conn.Close()
+		_ = src.Close()
+		return err
+	}
+
+	mg, err := migrate.NewWithInstance("iofs", src, Dialect(m.DataType), driver)
+	if err != nil {
+		_ = driver.Close()
+		_ = src.Close()
+		return err
+	}
+	defer mg.Close()
+
+	err = f(mg)
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+
+	return err
+}
+
+func (m *Migrator) source() (source.Driver, error) {
+	if m.FS == nil {
+		return nil, errors.New("the migrator has no migrations")
+	}
+
+	dir := m.Dir
+	if dir == "" {
+		dir = "."
+	}
+
+	return iofs.New(m.FS, dir)
+}
+
+// migrations lists the migrations in FS, by version
+func (m *Migrator) migrations() ([]Migration, error) {
+	src, err := m.source()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	var migrations []Migration
+
+	version, err := src.First()
+	for err == nil {
+		var name string
+		r, identifier, readErr := src.ReadUp(version)
+		if readErr == nil {
+			_ = r.Close()
+			name = identifier
+		}
+
+		migrations = append(migrations, Migration{Version: version, Name: name})
+		version, err = src.Next(version)
+	}
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return migrations, nil
+	}
+
+	return migrations, err
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var migrationsFS = fstest.MapFS{
+	"migrations/1650000000_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id serial primary key)")},
+	"migrations/1650000000_create_users.down.sql": {Data: []byte("DROP TABLE users")},
+	"migrations/1660000000_create_posts.up.sql":   {Data: []byte("CREATE TABLE posts (id serial primary key)")},
+	"migrations/1660000000_create_posts.down.sql": {Data: []byte("DROP TABLE posts")},
+	"migrations/readme.md":                        {Data: []byte("not a migration")},
+}
+
+func TestMigrator_Migrations(t *testing.T) {
+	m := NewMigrator(nil, "pgx", migrationsFS, "migrations")
+
+	migrations, err := m.migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) != 2 || migrations[0].Version != 1650000000 || migrations[0].Name != "create_users" || migrations[1].Name != "create_posts" {
+		t.Errorf("expected both migrations in order, got %+v", migrations)
+	}
+}
+
+func TestMigrator_Unsupported(t *testing.T) {
+	db, _ := newMock(t)
+
+	m := NewMigrator(db, "sqlite", migrationsFS, "migrations")
+	if err := m.Up(context.Background()); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("expected migrations on sqlite to be refused, got %v", err)
+	}
+
+	m = NewMigrator(db, "pgx", nil, "")
+	if _, err := m.Status(context.Background()); err == nil {
+		t.Error("expected a migrator without migrations to fail")
+	}
+}
+
+func TestMigrator_SQLServer(t *testing.T) {
+	db, mock := newMock(t)
+
+	mock.ExpectExec(`IF OBJECT_ID\(N'schema_migrations', N'U'\) IS NULL CREATE TABLE schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`EXEC @status = sp_getapplock`).WithArgs(sqlServerLock).WillReturnRows(sqlmock.NewRows([]string{""}).AddRow(0))
+	mock.ExpectQuery(`SELECT TOP 1 version, dirty FROM schema_migrations`).WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1650000000, false))
+
+	// only the posts migration is new
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO schema_migrations \(version, dirty\) VALUES \(@p1, @p2\)`).WithArgs(1660000000, true).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(`CREATE TABLE posts`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO schema_migrations`).WithArgs(1660000000, false).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectExec(`EXEC sp_releaseapplock`).WithArgs(sqlServerLock).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	m := NewMigrator(db, "sqlserver", migrationsFS, "migrations")
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Named rewrites the :name parameters of query into the placeholders of a DATABASE_TYPE and returns
+// the arguments in their order, for conditions that read better by name than by position:
+//
+//	query, args, err := database.Named(dataType, "SELECT * FROM orders WHERE status = :status AND created_at > :since",
+//		map[string]interface{}{"status": "paid", "since": since})
+//
+// A name can be used more than once, and a slice, other than []byte, becomes a list for IN, like
+// id IN (:ids). Names in quoted strings and identifiers are left alone, and so are postgres casts
+// like ::date
+func Named(dataType, query string, params map[string]interface{}) (string, []interface{}, error) {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	var args []interface{}
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[' && Dialect(dataType) == "sqlserver":
+			quote = ']'
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			// a cast, written as it is
+			b.WriteString("::")
+			i++
+			continue
+		case c == ':' && i+1 < len(query) && nameStart(query[i+1]):
+			end := i + 1
+			for end < len(query) && (nameStart(query[end]) || query[end] >= '0' && query[end] <= '9') {
+				end++
+			}
+
+			name := query[i+1 : end]
+			value, ok := params[name]
+			if !ok {
+				return "", nil, fmt.Errorf("no value for :%s", name)
+			}
+
+			v := reflect.ValueOf(value)
+			if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+				if v.Len() == 0 {
+					return "", nil, fmt.Errorf(":%s is an empty list", name)
+				}
+				b.WriteString("?" + strings.Repeat(", ?", v.Len()-1))
+				for j := 0; j < v.Len(); j++ {
+					args = append(args, v.Index(j).Interface())
+				}
+			} else {
+				b.WriteByte('?')
+				args = append(args, value)
+			}
+
+			i = end - 1
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+
+	return Rebind(dataType, b.String()), args, nil
+}
+
+func nameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
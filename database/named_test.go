@@ -0,0 +1,33 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	params := map[string]interface{}{"status": "paid", "ids": []int{1, 2}, "since": "2024-01-01"}
+
+	query, args, err := Named("pgx", "SELECT * FROM orders WHERE status = :status AND id IN (:ids) AND created_at::date > :since AND note <> ':status' OR status = :status", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "SELECT * FROM orders WHERE status = $1 AND id IN ($2, $3) AND created_at::date > $4 AND note <> ':status' OR status = $5" {
+		t.Errorf("unexpected query %q", query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"paid", 1, 2, "2024-01-01", "paid"}) {
+		t.Errorf("unexpected arguments %v", args)
+	}
+
+	query, args, _ = Named("sqlserver", "SELECT [a:b] FROM t WHERE data = :data", map[string]interface{}{"data": []byte("x")})
+	if query != "SELECT [a:b] FROM t WHERE data = @p1" || len(args) != 1 {
+		t.Errorf("expected bytes to be one value, got %q with %v", query, args)
+	}
+
+	if _, _, err := Named("mysql", "SELECT * FROM orders WHERE status = :state", params); err == nil {
+		t.Error("expected a missing name to be refused")
+	}
+	if _, _, err := Named("mysql", "SELECT * FROM orders WHERE id IN (:ids)", map[string]interface{}{"ids": []int{}}); err == nil {
+		t.Error("expected an empty list to be refused")
+	}
+}
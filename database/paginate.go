@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Cursor asks for a page of keyset pagination over Column, which the rows are ordered by and
+// which has to be unique and indexed, like id. Unlike an offset, it costs the same on the last
+// page of a large table as on the first
+type Cursor struct {
+	Column string
+	// After asks for the rows after the value, Before for the rows before it, and neither for
+	// the first page
+	After  interface{}
+	Before interface{}
+	Limit  int
+}
+
+// Page tells where the pages next to a page start, nil when there is none. Next is passed as the
+// After of the next page, and Prev as the Before of the previous one
+type Page struct {
+	Next interface{}
+	Prev interface{}
+}
+
+// Paginate scans the page of the query that c asks for into dest, a pointer to a slice of structs
+// with a field for the column, and returns where the pages next to it start. The query is written
+// with ? placeholders and without ORDER BY or LIMIT, which Paginate adds:
+//
+//	var orders []data.Order
+//	page, err := database.Paginate(ctx, db, dataType, &orders, database.Cursor{Column: "id", After: after, Limit: 50},
+//		"SELECT * FROM orders WHERE user_id = ?", userID)
+func Paginate(ctx context.Context, db Querier, dataType string, dest interface{}, c Cursor, query string, args ...interface{}) (Page, error) {
+	if !validIdentifier.MatchString(c.Column) || strings.Contains(c.Column, ".") {
+		return Page{}, fmt.Errorf("%q is not a column of the query", c.Column)
+	}
+	if c.Limit <= 0 {
+		return Page{}, errors.New("a page needs a limit")
+	}
+	if c.After != nil && c.Before != nil {
+		return Page{}, errors.New("a page starts after a row or ends before one, not both")
+	}
+
+	backward := c.Before != nil
+
+	var where, order string
+	args = append([]interface{}{}, args...)
+	switch {
+	case backward:
+		where, order = " WHERE "+c.Column+" < ?", " DESC"
+		args = append(args, c.Before)
+	case c.After != nil:
+		where = " WHERE " + c.Column + " > ?"
+		args = append(args, c.After)
+	}
+
+	// one row more than the page tells whether there is another page
+	limit := strconv.Itoa(c.Limit + 1)
+	var paged string
+	if Dialect(dataType) == "sqlserver" {
+		paged = "SELECT TOP (" + limit + ") * FROM (" + query + ") AS page" + where + " ORDER BY " + c.Column + order
+	} else {
+		paged = "SELECT * FROM (" + query + ") AS page" + where + " ORDER BY " + c.Column + order + " LIMIT " + limit
+	}
+
+	if err := Get(ctx, db, dest, Rebind(dataType, paged), args...); err != nil {
+		return Page{}, err
+	}
+
+	slice := reflect.ValueOf(dest).Elem()
+	elem := slice.Type().Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	index, ok := fieldsOf(elem)[strings.ToLower(c.Column)]
+	if !ok {
+		return Page{}, fmt.Errorf("%s has no field for %s", elem, c.Column)
+	}
+
+	more := slice.Len() > c.Limit
+	if more {
+		slice.SetLen(c.Limit)
+	}
+	if backward {
+		swap := reflect.Swapper(slice.Interface())
+		for i, j := 0, slice.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	if slice.Len() == 0 {
+		return Page{}, nil
+	}
+
+	value := func(i int) interface{} {
+		row := slice.Index(i)
+		if row.Kind() == reflect.Pointer {
+			row = row.Elem()
+		}
+		return fieldByIndex(row, index).Interface()
+	}
+
+	var page Page
+	if more || backward {
+		page.Next = value(slice.Len() - 1)
+	}
+	if (more && backward) || c.After != nil {
+		page.Prev = value(0)
+	}
+
+	return page, nil
+}
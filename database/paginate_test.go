@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPaginate(t *testing.T) {
+	db, mock := newMock(t)
+	ctx := context.Background()
+	columns := []string{"id", "first_name"}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM (SELECT * FROM users WHERE active = $1) AS page WHERE id > $2 ORDER BY id LIMIT 3")).
+		WithArgs(true, 10).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(11, "Ada").AddRow(12, "Grace").AddRow(13, "Hedy"))
+
+	var users []user
+	page, err := Paginate(ctx, db, "pgx", &users, Cursor{Column: "id", After: 10, Limit: 2}, "SELECT * FROM users WHERE active = ?", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[1].ID != 12 || page.Next != 12 || page.Prev != 11 {
+		t.Errorf("expected users 11 and 12 with more after them, got %+v and %+v", users, page)
+	}
+
+	// going back from 11 reads downwards, and hands the rows back in order
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM (SELECT * FROM users) AS page WHERE id < ? ORDER BY id DESC LIMIT 3")).
+		WithArgs(11).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(10, "Alan").AddRow(9, "Barbara"))
+
+	var earlier []*user
+	page, err = Paginate(ctx, db, "mysql", &earlier, Cursor{Column: "id", Before: 11, Limit: 2}, "SELECT * FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(earlier) != 2 || earlier[0].ID != 9 || page.Prev != nil || page.Next != 10 {
+		t.Errorf("expected users 9 and 10 on the first page, got %+v and %+v", earlier, page)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT TOP (3) * FROM (SELECT * FROM users) AS page ORDER BY id")).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "Ada"))
+
+	page, err = Paginate(ctx, db, "sqlserver", &users, Cursor{Column: "id", Limit: 2}, "SELECT * FROM users")
+	if err != nil || len(users) != 1 || page != (Page{}) {
+		t.Errorf("expected the only page, got %+v and %+v, %v", users, page, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := Paginate(ctx, db, "pgx", &users, Cursor{Column: "id", After: 1, Before: 5, Limit: 2}, "SELECT * FROM users"); err == nil {
+		t.Error("expected after and before together to be refused")
+	}
+	if _, err := Paginate(ctx, db, "pgx", &users, Cursor{Column: "users.id", Limit: 2}, "SELECT * FROM users"); err == nil {
+		t.Error("expected a qualified column to be refused")
+	}
+}
@@ -0,0 +1,220 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// PartitionInterval is how much time one partition of a table partitioned by range covers
+type PartitionInterval string
+
+// The intervals a Partitioner creates partitions for
+const (
+	Daily   PartitionInterval = "day"
+	Monthly PartitionInterval = "month"
+)
+
+// DefaultPartitionsAhead is how many partitions a Partitioner keeps ready after the current one
+const DefaultPartitionsAhead = 3
+
+// layout names the partitions after the day or month they start on, e.g. logs_p20261016
+func (i PartitionInterval) layout() string {
+	if i == Monthly {
+		return "200601"
+	}
+
+	return "20060102"
+}
+
+// start returns the start of the partition t falls in
+func (i PartitionInterval) start(t time.Time) time.Time {
+	t = t.UTC()
+	if i == Monthly {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// add moves the start of a partition n partitions on
+func (i PartitionInterval) add(start time.Time, n int) time.Time {
+	if i == Monthly {
+		return start.AddDate(0, n, 0)
+	}
+
+	return start.AddDate(0, 0, n)
+}
+
+// Partitioner maintains the partitions of a postgres table partitioned by range on a time
+// column, like logs, metrics or audit rows: it creates the partitions for the coming days or
+// months before rows arrive for them, and drops the ones older than Retention
+//
+//	p := &database.Partitioner{DB: app.DB.Pool, DataType: app.DB.DataType, Table: "logs", Interval: database.Daily, Retention: 30 * 24 * time.Hour}
+//	err := p.MaintainOn(app.Scheduler, "@daily")
+type Partitioner struct {
+	DB       QueryExecer
+	DataType string
+	Table    string
+	// Interval is how much time a partition covers, Daily when it is empty
+	Interval PartitionInterval
+	// Ahead is how many partitions are created after the current one, DefaultPartitionsAhead when it is 0
+	Ahead int
+	// Retention drops the partitions whose rows are all older than it, none when it is 0
+	Retention time.Duration
+	ErrorLog  *log.Logger
+
+	now func() time.Time
+}
+
+// Partition returns the name of the partition of table that holds the rows of t
+func (p *Partitioner) Partition(t time.Time) string {
+	interval := p.interval()
+	return p.relation() + "_p" + interval.start(t).Format(interval.layout())
+}
+
+// Maintain creates the missing partitions from the current one to Ahead partitions from now, and
+// drops the ones past Retention. It returns the names of the partitions it created and dropped
+func (p *Partitioner) Maintain(ctx context.Context) (created, dropped []string, err error) {
+	if !Postgres(p.DataType) {
+		return nil, nil, errors.New("partition maintenance is only supported on postgres")
+	}
+
+	if !validIdentifier.MatchString(p.Table) {
+		return nil, nil, fmt.Errorf("%q is not a table name", p.Table)
+	}
+
+	partitions, err := p.partitions(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existing := make(map[string]bool, len(partitions))
+	for _, name := range partitions {
+		existing[name] = true
+	}
+
+	now := time.Now()
+	if p.now != nil {
+		now = p.now()
+	}
+
+	interval := p.interval()
+	ahead := p.Ahead
+	if ahead == 0 {
+		ahead = DefaultPartitionsAhead
+	}
+
+	current := interval.start(now)
+	for n := 0; n <= ahead; n++ {
+		start := interval.add(current, n)
+		name := p.Partition(start)
+		if existing[name] {
+			continue
+		}
+
+		query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+			p.schema()+name, p.Table, start.Format("2006-01-02 15:04:05-07"), interval.add(start, 1).Format("2006-01-02 15:04:05-07"))
+		if _, err := p.DB.ExecContext(ctx, query); err != nil {
+			return created, dropped, fmt.Errorf("creating partition %s: %w", name, err)
+		}
+		created = append(created, name)
+	}
+
+	if p.Retention <= 0 {
+		return created, dropped, nil
+	}
+
+	cutoff := now.Add(-p.Retention)
+	prefix := p.relation() + "_p"
+	for _, name := range partitions {
+		// partitions named some other way, like a default partition, are not ours to drop
+		suffix, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+
+		start, err := time.Parse(interval.layout(), suffix)
+		if err != nil {
+			continue
+		}
+
+		if interval.add(start, 1).After(cutoff) {
+			continue
+		}
+
+		if _, err := p.DB.ExecContext(ctx, "DROP TABLE IF EXISTS "+p.schema()+name); err != nil {
+			return created, dropped, fmt.Errorf("dropping partition %s: %w", name, err)
+		}
+		dropped = append(dropped, name)
+	}
+
+	return created, dropped, nil
+}
+
+// MaintainOn schedules Maintain with the cron spec, e.g. "@daily" on app.Scheduler, logging its
+// errors. Run Maintain once at boot as well, so that the current partition exists before the
+// first scheduled run
+func (p *Partitioner) MaintainOn(scheduler *cron.Cron, spec string) error {
+	_, err := scheduler.AddFunc(spec, func() {
+		_, _, err := p.Maintain(context.Background())
+		if err != nil && p.ErrorLog != nil {
+			p.ErrorLog.Printf("maintaining the partitions of %s: %v", p.Table, err)
+		}
+	})
+
+	return err
+}
+
+// partitions returns the names of the partitions table has now, sorted
+func (p *Partitioner) partitions(ctx context.Context) ([]string, error) {
+	rows, err := p.DB.QueryContext(ctx, `SELECT c.relname FROM pg_inherits i
+	JOIN pg_class c ON c.oid = i.inhrelid
+WHERE i.inhparent = to_regclass($1) ORDER BY c.relname`, p.Table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var partitions []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		partitions = append(partitions, name)
+	}
+
+	return partitions, rows.Err()
+}
+
+func (p *Partitioner) interval() PartitionInterval {
+	if p.Interval == "" {
+		return Daily
+	}
+
+	return p.Interval
+}
+
+// relation is the table name without its schema, which its partitions are named after
+func (p *Partitioner) relation() string {
+	if _, name, found := strings.Cut(p.Table, "."); found {
+		return name
+	}
+
+	return p.Table
+}
+
+// schema is the schema the table was given with, and its partitions are created in, with a dot
+func (p *Partitioner) schema() string {
+	if schema, _, found := strings.Cut(p.Table, "."); found {
+		return schema + "."
+	}
+
+	return ""
+}
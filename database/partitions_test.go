@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPartitioner_Maintain(t *testing.T) {
+	db, mock := newMock(t)
+
+	p := &Partitioner{DB: db, DataType: "pgx", Table: "logs", Ahead: 2, Retention: 48 * time.Hour,
+		now: func() time.Time { return time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC) }}
+
+	mock.ExpectQuery(`SELECT c.relname FROM pg_inherits`).WithArgs("logs").
+		WillReturnRows(sqlmock.NewRows([]string{"relname"}).
+			AddRow("logs_default").
+			AddRow("logs_p20261013").
+			AddRow("logs_p20261014").
+			AddRow("logs_p20261015").
+			AddRow("logs_p20261016"))
+	mock.ExpectExec(`^CREATE TABLE IF NOT EXISTS logs_p20261017 PARTITION OF logs FOR VALUES FROM \('2026-10-17 00:00:00\+00'\) TO \('2026-10-18 00:00:00\+00'\)$`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^CREATE TABLE IF NOT EXISTS logs_p20261018 PARTITION OF logs`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^DROP TABLE IF EXISTS logs_p20261013$`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	created, dropped, err := p.Maintain(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(created) != 2 || len(dropped) != 1 || dropped[0] != "logs_p20261013" {
+		t.Errorf("expected 2 partitions to be created and logs_p20261013 dropped, got %v and %v", created, dropped)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPartitioner_Monthly(t *testing.T) {
+	db, mock := newMock(t)
+
+	p := &Partitioner{DB: db, DataType: "postgres", Table: "audit.events", Interval: Monthly, Ahead: 1,
+		now: func() time.Time { return time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC) }}
+
+	if name := p.Partition(p.now()); name != "events_p202612" {
+		t.Errorf("expected events_p202612, got %s", name)
+	}
+
+	mock.ExpectQuery(`SELECT c.relname FROM pg_inherits`).WithArgs("audit.events").
+		WillReturnRows(sqlmock.NewRows([]string{"relname"}))
+	mock.ExpectExec(`^CREATE TABLE IF NOT EXISTS audit.events_p202612 PARTITION OF audit.events FOR VALUES FROM \('2026-12-01 00:00:00\+00'\) TO \('2027-01-01 00:00:00\+00'\)$`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^CREATE TABLE IF NOT EXISTS audit.events_p202701 PARTITION OF audit.events FOR VALUES FROM \('2027-01-01 00:00:00\+00'\) TO \('2027-02-01 00:00:00\+00'\)$`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if _, _, err := p.Maintain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPartitioner_OnlyPostgres(t *testing.T) {
+	db, _ := newMock(t)
+
+	p := &Partitioner{DB: db, DataType: "mysql", Table: "logs"}
+	if _, _, err := p.Maintain(context.Background()); err == nil {
+		t.Error("expected an error for mysql")
+	}
+}
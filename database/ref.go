@@ -0,0 +1,71 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/iancoleman/strcase"
+)
+
+// Ref refers to a model of any type, e.g. Ref{Type: "post", ID: 7}. Polymorphic tables, like
+// activities and taggables, store it as a type and an id column
+type Ref struct {
+	Type string `json:"type"`
+	ID   int64  `json:"id"`
+}
+
+// IsZero reports whether r refers to nothing
+func (r Ref) IsZero() bool {
+	return r.Type == "" && r.ID == 0
+}
+
+func (r Ref) String() string {
+	return fmt.Sprintf("%s %d", r.Type, r.ID)
+}
+
+// Referencer is implemented by models that choose how they are referred to
+type Referencer interface {
+	Ref() Ref
+}
+
+// RefTo returns the reference to v: v itself when it is a Ref, what Ref returns when v
+// is a Referencer, and otherwise the snake cased name of v's struct type with its ID field, so
+// a *data.BlogPost with ID 7 is Ref{Type: "blog_post", ID: 7}
+func RefTo(v interface{}) (Ref, error) {
+	switch v := v.(type) {
+	case nil:
+		return Ref{}, errors.New("cannot refer to nil")
+	case Ref:
+		return v, nil
+	case *Ref:
+		return *v, nil
+	case Referencer:
+		return v.Ref(), nil
+	}
+
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return Ref{}, errors.New("cannot refer to a nil " + value.Type().String())
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return Ref{}, fmt.Errorf("cannot refer to a %s, use a struct with an ID or a Ref", value.Type())
+	}
+
+	id := value.FieldByName("ID")
+	ref := Ref{Type: strcase.ToSnake(value.Type().Name())}
+	switch {
+	case id.CanInt():
+		ref.ID = id.Int()
+	case id.CanUint():
+		ref.ID = int64(id.Uint())
+	default:
+		return Ref{}, fmt.Errorf("cannot refer to a %s, it has no integer ID field", value.Type())
+	}
+
+	return ref, nil
+}
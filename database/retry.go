@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"math/rand"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Retry runs statements and transactions again when they fail on an error that goes away by itself,
+// like a deadlock or a serialization failure, waiting longer before every next attempt. Only
+// errors that prove the work had no effect are retried, see Transient
+type Retry struct {
+	// Attempts is how often a function runs at most, counting the first run. Below 2 it never
+	// runs again
+	Attempts int
+	// Backoff is the wait before the first retry, which doubles before every next one up to
+	// MaxBackoff, with some jitter so that the transactions that deadlocked do not meet again
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// OnRetry is told about every retry before its wait, e.g. to log or count it
+	OnRetry func(attempt int, wait time.Duration, err error)
+
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Do runs fn until it succeeds, fails on an error that is not Transient or has run Attempts
+// times. Every run starts from scratch, so fn must be idempotent: a single statement, or the
+// statements of a transaction, see Tx, and nothing outside the database like sending mail
+func (r Retry) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	wait := r.Backoff
+	if wait <= 0 {
+		wait = 50 * time.Millisecond
+	}
+
+	sleep := r.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= r.Attempts || !Transient(err) {
+			return err
+		}
+
+		// up to half of the wait again
+		jittered := wait + time.Duration(rand.Int63n(int64(wait)/2+1))
+		if r.OnRetry != nil {
+			r.OnRetry(attempt, jittered, err)
+		}
+
+		if err := sleep(ctx, jittered); err != nil {
+			return err
+		}
+
+		wait *= 2
+		if r.MaxBackoff > 0 && wait > r.MaxBackoff {
+			wait = r.MaxBackoff
+		}
+	}
+}
+
+// Tx runs fn in a transaction on db and commits it, running the whole transaction again when it
+// fails on a transient error. The transaction is rolled back when fn returns an error, and fn
+// must be idempotent apart from what it does in tx
+func (r Retry) Tx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	return r.Do(ctx, func(ctx context.Context) error {
+		tx, err := db.BeginTx(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		if err = fn(tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		return tx.Commit()
+	})
+}
+
+// Transient reports whether err proves that the statement or transaction had no effect, so that
+// running it again is safe: a deadlock, serialization failure or lock wait timeout that the server
+// rolled back, a busy or locked sqlite database, or a connection that could not be used or made
+// before anything was sent. A connection that drops while a statement runs is not transient,
+// since the statement, or the commit, may have gone through
+func Transient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// pgx's errors tell their SQLSTATE
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		switch pgErr.SQLState() {
+		case "40001", "40P01":
+			return true
+		}
+		return false
+	}
+
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		// deadlock and lock wait timeout
+		switch myErr.Number {
+		case 1213, 1205:
+			return true
+		}
+		return false
+	}
+
+	if code, ok := sqliteCode(err); ok {
+		return code == sqliteBusy || code == sqliteLocked
+	}
+
+	// the driver was told not to send the statement, or there was nothing to send it to
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// the primary result codes of SQLITE_BUSY and SQLITE_LOCKED
+const (
+	sqliteBusy   = 5
+	sqliteLocked = 6
+)
+
+// sqliteCode returns the primary result code of a sqlite error, read from the Code field of
+// mattn/go-sqlite3's Error or the Code method of modernc.org/sqlite's, without importing either
+// driver and with it cgo
+func sqliteCode(err error) (int, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if c, ok := err.(interface{ Code() int }); ok {
+			// the extended codes keep the primary one in their low byte
+			return c.Code() & 0xff, true
+		}
+
+		v := reflect.ValueOf(err)
+		if v.Kind() == reflect.Pointer {
+			v = v.Elem()
+		}
+		if v.Kind() == reflect.Struct && v.Type().PkgPath() == "github.com/mattn/go-sqlite3" {
+			if f := v.FieldByName("Code"); f.IsValid() && f.CanInt() {
+				return int(f.Int()), true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
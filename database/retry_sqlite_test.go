@@ -0,0 +1,18 @@
+//go:build cgo
+
+package database
+
+import (
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestTransient_SQLite(t *testing.T) {
+	if !Transient(sqlite3.Error{Code: sqlite3.ErrBusy}) || !Transient(&sqlite3.Error{Code: sqlite3.ErrLocked}) {
+		t.Error("expected SQLITE_BUSY and SQLITE_LOCKED to be transient")
+	}
+	if Transient(sqlite3.Error{Code: sqlite3.ErrConstraint}) {
+		t.Error("expected a constraint violation not to be transient")
+	}
+}
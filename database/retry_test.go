@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+)
+
+func TestTransient(t *testing.T) {
+	tests := []struct {
+		err       error
+		transient bool
+	}{
+		{&pgconn.PgError{Code: "40001"}, true},
+		{fmt.Errorf("saving order: %w", &pgconn.PgError{Code: "40P01"}), true},
+		{&pgconn.PgError{Code: "23505"}, false},
+		{&mysql.MySQLError{Number: 1213}, true},
+		{&mysql.MySQLError{Number: 1062}, false},
+		{errors.New("database is locked"), false},
+		{sqliteError{code: 5}, true},
+		{fmt.Errorf("inserting: %w", sqliteError{code: 262}), true},
+		{sqliteError{code: 19}, false},
+		{driver.ErrBadConn, true},
+		{syscall.ECONNREFUSED, true},
+		{mysql.ErrInvalidConn, false},
+		{io.ErrUnexpectedEOF, false},
+		{syscall.ECONNRESET, false},
+		{&mysql.MySQLError{Number: 2013}, false},
+		{&pgconn.PgError{Code: "08006"}, false},
+		{context.Canceled, false},
+		{errors.New("syntax error"), false},
+	}
+
+	for _, tt := range tests {
+		if got := Transient(tt.err); got != tt.transient {
+			t.Errorf("%v: expected %v, got %v", tt.err, tt.transient, got)
+		}
+	}
+}
+
+// sqliteError has the Code method of modernc.org/sqlite's errors
+type sqliteError struct{ code int }
+
+func (e sqliteError) Error() string { return "database is locked" }
+func (e sqliteError) Code() int     { return e.code }
+
+func TestRetry_Do(t *testing.T) {
+	var waits []time.Duration
+	r := Retry{
+		Attempts:   4,
+		Backoff:    10 * time.Millisecond,
+		MaxBackoff: 30 * time.Millisecond,
+		sleep: func(ctx context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			return nil
+		},
+	}
+
+	retried := 0
+	r.OnRetry = func(attempt int, wait time.Duration, err error) { retried++ }
+
+	runs := 0
+	err := r.Do(context.Background(), func(ctx context.Context) error {
+		runs++
+		return &pgconn.PgError{Code: "40001"}
+	})
+
+	if runs != 4 || retried != 3 || err == nil {
+		t.Fatalf("expected 4 runs and 3 retries before giving up, got %d and %d, %v", runs, retried, err)
+	}
+
+	// 10, 20 and 30ms, each with up to half again of jitter
+	for i, base := range []time.Duration{10, 20, 30} {
+		base *= time.Millisecond
+		if waits[i] < base || waits[i] > base+base/2 {
+			t.Errorf("expected wait %d to be between %s and %s, got %s", i+1, base, base+base/2, waits[i])
+		}
+	}
+
+	runs = 0
+	err = r.Do(context.Background(), func(ctx context.Context) error {
+		runs++
+		return errors.New("syntax error")
+	})
+	if runs != 1 || err == nil {
+		t.Errorf("expected other errors not to be retried, got %d runs", runs)
+	}
+}
+
+func TestRetry_Tx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE accounts").WillReturnError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found"})
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE accounts").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	r := Retry{Attempts: 2, sleep: func(ctx context.Context, d time.Duration) error { return nil }}
+	err = r.Tx(context.Background(), db, nil, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE accounts SET balance = balance - 10 WHERE id = 1")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,199 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/iancoleman/strcase"
+)
+
+// Querier runs queries, like *sql.DB, *sql.Tx and *sql.Conn
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Get runs the query and scans every row into dest, a pointer to a slice of structs or of
+// pointers to structs, see ScanAll
+//
+//	var users []data.User
+//	err := database.Get(ctx, db, &users, "SELECT * FROM users WHERE active = $1", true)
+func Get(ctx context.Context, db Querier, dest interface{}, query string, args ...interface{}) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return ScanAll(rows, dest)
+}
+
+// First runs the query and scans its first row into dest, a pointer to a struct. It returns
+// sql.ErrNoRows when there is none
+func First(ctx context.Context, db Querier, dest interface{}, query string, args ...interface{}) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return ScanOne(rows, dest)
+}
+
+// ScanAll scans the rows into dest, a pointer to a slice of structs or of pointers to structs, and
+// closes them. Columns go to the field whose db tag names them, as in `db:"created_at,omitempty"`,
+// or else to the field whose snake cased name they are. Fields tagged `db:"-"` are skipped, the
+// fields of embedded structs are included, and columns without a field are dropped
+func ScanAll(rows *sql.Rows, dest interface{}) error {
+	defer rows.Close()
+
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Pointer || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("cannot scan rows into a %T, use a pointer to a slice", dest)
+	}
+	slice = slice.Elem()
+
+	elem := slice.Type().Elem()
+	pointers := elem.Kind() == reflect.Pointer
+	if pointers {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot scan rows into a %T, use a slice of structs", dest)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	fields := fieldsOf(elem)
+	slice.SetLen(0)
+
+	for rows.Next() {
+		row := reflect.New(elem)
+		err = rows.Scan(targets(row.Elem(), columns, fields)...)
+		if err != nil {
+			return err
+		}
+
+		if pointers {
+			slice.Set(reflect.Append(slice, row))
+		} else {
+			slice.Set(reflect.Append(slice, row.Elem()))
+		}
+	}
+
+	return rows.Err()
+}
+
+// ScanOne scans the first of the rows into dest, a pointer to a struct, the way ScanAll does, and
+// closes them. It returns sql.ErrNoRows when there are no rows
+func ScanOne(rows *sql.Rows, dest interface{}) error {
+	defer rows.Close()
+
+	row := reflect.ValueOf(dest)
+	if row.Kind() != reflect.Pointer || row.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot scan a row into a %T, use a pointer to a struct", dest)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+
+	err = rows.Scan(targets(row.Elem(), columns, fieldsOf(row.Elem().Type()))...)
+	if err != nil {
+		return err
+	}
+
+	return rows.Close()
+}
+
+// targets returns where each column of a row is scanned to in the struct v
+func targets(v reflect.Value, columns []string, fields map[string][]int) []interface{} {
+	targets := make([]interface{}, len(columns))
+	for i, column := range columns {
+		index, ok := fields[strings.ToLower(column)]
+		if !ok {
+			targets[i] = new(interface{})
+			continue
+		}
+
+		targets[i] = fieldByIndex(v, index).Addr().Interface()
+	}
+
+	return targets
+}
+
+// fieldByIndex is v.FieldByIndex, allocating the embedded struct pointers on the way
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+var fieldCache sync.Map
+
+// fieldsOf maps the column names of a struct type to the index of their field
+func fieldsOf(t reflect.Type) map[string][]int {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.(map[string][]int)
+	}
+
+	fields := map[string][]int{}
+	addFields(t, nil, fields)
+	fieldCache.Store(t, fields)
+
+	return fields
+}
+
+func addFields(t reflect.Type, parent []int, fields map[string][]int) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+
+		name, _, _ := strings.Cut(f.Tag.Get("db"), ",")
+		if name == "-" {
+			continue
+		}
+
+		embedded := f.Type
+		if embedded.Kind() == reflect.Pointer {
+			embedded = embedded.Elem()
+		}
+		if f.Anonymous && name == "" && embedded.Kind() == reflect.Struct {
+			addFields(embedded, index, fields)
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = strcase.ToSnake(f.Name)
+		}
+
+		// a field of the outer struct wins over one of an embedded struct with the same column
+		if _, ok := fields[strings.ToLower(name)]; !ok || len(index) < len(fields[strings.ToLower(name)]) {
+			fields[strings.ToLower(name)] = index
+		}
+	}
+}
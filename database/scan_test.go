@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type timestamps struct {
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type user struct {
+	ID        int    `db:"id,omitempty"`
+	FirstName string // first_name
+	Email     string `db:"email_address"`
+	Password  string `db:"-"`
+	timestamps
+}
+
+func newMock(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db, mock
+}
+
+func TestGet(t *testing.T) {
+	db, mock := newMock(t)
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT \* FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "email_address", "password", "created_at", "unknown"}).
+			AddRow(1, "Ada", "ada@example.com", "secret", now, "x").
+			AddRow(2, "Grace", "grace@example.com", "secret", now, "y"))
+
+	var users []*user
+	if err := Get(context.Background(), db, &users, "SELECT * FROM users"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users) != 2 || users[1].ID != 2 || users[1].FirstName != "Grace" || users[1].Email != "grace@example.com" {
+		t.Fatalf("unexpected users %+v", users)
+	}
+
+	if users[0].Password != "" || !users[0].CreatedAt.Equal(now) {
+		t.Errorf("expected the password to be skipped and the embedded created_at to be set, got %+v", users[0])
+	}
+}
+
+func TestFirst(t *testing.T) {
+	db, mock := newMock(t)
+
+	mock.ExpectQuery(`SELECT id, first_name FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"ID", "first_name"}).AddRow(7, "Ada"))
+	mock.ExpectQuery(`SELECT id, first_name FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name"}))
+
+	var u user
+	if err := First(context.Background(), db, &u, "SELECT id, first_name FROM users"); err != nil || u.ID != 7 || u.FirstName != "Ada" {
+		t.Errorf("unexpected user %+v, %v", u, err)
+	}
+
+	if err := First(context.Background(), db, &u, "SELECT id, first_name FROM users"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestScanAll_InvalidDestination(t *testing.T) {
+	db, mock := newMock(t)
+
+	mock.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	var ids []int
+	if err := Get(context.Background(), db, &ids, "SELECT id FROM users"); err == nil {
+		t.Error("expected an error for a slice of ints")
+	}
+}
@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// ErrDestructiveSeed is returned by SeedRunner.Run for seeders that are Destructive in
+// production, unless the runner is forced
+var ErrDestructiveSeed = errors.New("refusing to run destructive seeders in production")
+
+// Seeder fills the database with rows, like the lookup values an app needs or demo data. Seed
+// runs in the transaction of the whole run
+type Seeder interface {
+	Name() string
+	Seed(ctx context.Context, tx *sql.Tx) error
+}
+
+// DependentSeeder is a seeder that needs the rows of other seeders, which run before it
+type DependentSeeder interface {
+	Seeder
+	// DependsOn returns the names of the seeders to run first
+	DependsOn() []string
+}
+
+// DestructiveSeeder is a seeder that can delete or overwrite rows, like one that truncates its
+// table first. SeedRunner refuses to run it in production unless forced
+type DestructiveSeeder interface {
+	Seeder
+	Destructive() bool
+}
+
+// SeederRegistry holds seeders by name, in the order they were registered
+type SeederRegistry struct {
+	mu      sync.RWMutex
+	seeders map[string]Seeder
+	names   []string
+}
+
+// NewSeederRegistry returns an empty registry
+func NewSeederRegistry() *SeederRegistry {
+	return &SeederRegistry{seeders: map[string]Seeder{}}
+}
+
+// Seeders is the registry RegisterSeeder adds to, and the one a SeedRunner uses by default
+var Seeders = NewSeederRegistry()
+
+// RegisterSeeder adds seeders to Seeders, usually from an init function of the package they are in
+func RegisterSeeder(seeders ...Seeder) {
+	Seeders.Register(seeders...)
+}
+
+// Register adds seeders. A seeder with the name of one registered before replaces it
+func (reg *SeederRegistry) Register(seeders ...Seeder) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for _, s := range seeders {
+		if _, ok := reg.seeders[s.Name()]; !ok {
+			reg.names = append(reg.names, s.Name())
+		}
+		reg.seeders[s.Name()] = s
+	}
+}
+
+// Names returns the names of the registered seeders, in the order they were registered
+func (reg *SeederRegistry) Names() []string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	return append([]string(nil), reg.names...)
+}
+
+// Ordered returns the named seeders, or all of them when no names are given, with the seeders
+// they depend on, ordered so that every seeder comes after its dependencies. Seeders that do not
+// depend on each other stay in the order they were registered
+func (reg *SeederRegistry) Ordered(names ...string) ([]Seeder, error) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	if len(names) == 0 {
+		names = reg.names
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+
+	var ordered []Seeder
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("seeders depend on each other: %s", strings.Join(append(path, name), " -> "))
+		}
+
+		s, ok := reg.seeders[name]
+		if !ok {
+			if len(path) > 0 {
+				return fmt.Errorf("seeder %s depends on %s, which is not registered", path[len(path)-1], name)
+			}
+			return fmt.Errorf("there is no seeder %s", name)
+		}
+
+		state[name] = visiting
+		if d, ok := s.(DependentSeeder); ok {
+			for _, dep := range d.DependsOn() {
+				if err := visit(dep, append(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = done
+
+		ordered = append(ordered, s)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// SeedRunner runs seeders in one transaction, so that a failing seeder leaves the database as it
+// was
+//
+//	runner := &database.SeedRunner{DB: app.DB.Pool, Environment: os.Getenv("APP_ENV")}
+//	ran, err := runner.Run(ctx)
+type SeedRunner struct {
+	DB *sql.DB
+	// Registry holds the seeders, Seeders when it is nil
+	Registry *SeederRegistry
+	// Environment is the one the app runs in. In production, destructive seeders only run when
+	// Force is set
+	Environment string
+	Force       bool
+	InfoLog     *log.Logger
+}
+
+// Production reports whether the runner's Environment is production
+func (r *SeedRunner) Production() bool {
+	switch strings.ToLower(r.Environment) {
+	case "production", "prod":
+		return true
+	}
+
+	return false
+}
+
+// Run runs the named seeders, or all of them when no names are given, after the seeders they
+// depend on, and returns the names of the ones that ran
+func (r *SeedRunner) Run(ctx context.Context, names ...string) ([]string, error) {
+	reg := r.Registry
+	if reg == nil {
+		reg = Seeders
+	}
+
+	seeders, err := reg.Ordered(names...)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Production() && !r.Force {
+		for _, s := range seeders {
+			if d, ok := s.(DestructiveSeeder); ok && d.Destructive() {
+				return nil, fmt.Errorf("%w: %s, force it to run anyway", ErrDestructiveSeed, s.Name())
+			}
+		}
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ran := make([]string, 0, len(seeders))
+	for _, s := range seeders {
+		if err := s.Seed(ctx, tx); err != nil {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("seeding %s: %w", s.Name(), err)
+		}
+
+		if r.InfoLog != nil {
+			r.InfoLog.Printf("seeded %s", s.Name())
+		}
+		ran = append(ran, s.Name())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ran, nil
+}
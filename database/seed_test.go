@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testSeeder struct {
+	name        string
+	deps        []string
+	destructive bool
+	query       string
+}
+
+func (s testSeeder) Name() string        { return s.name }
+func (s testSeeder) DependsOn() []string { return s.deps }
+func (s testSeeder) Destructive() bool   { return s.destructive }
+
+func (s testSeeder) Seed(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, s.query)
+	return err
+}
+
+func TestSeederRegistry_Ordered(t *testing.T) {
+	reg := NewSeederRegistry()
+	reg.Register(
+		testSeeder{name: "orders", deps: []string{"customers", "products"}},
+		testSeeder{name: "products"},
+		testSeeder{name: "customers", deps: []string{"countries"}},
+		testSeeder{name: "countries"},
+	)
+
+	seeders, err := reg.Ordered()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, s := range seeders {
+		names = append(names, s.Name())
+	}
+	if strings.Join(names, ",") != "countries,customers,products,orders" {
+		t.Errorf("expected every seeder after its dependencies, got %v", names)
+	}
+
+	if seeders, _ := reg.Ordered("customers"); len(seeders) != 2 {
+		t.Errorf("expected customers to come with countries, got %d seeders", len(seeders))
+	}
+
+	reg.Register(testSeeder{name: "countries", deps: []string{"orders"}})
+	if _, err := reg.Ordered(); err == nil || !strings.Contains(err.Error(), "depend on each other") {
+		t.Errorf("expected a cycle to be refused, got %v", err)
+	}
+
+	if _, err := reg.Ordered("missing"); err == nil {
+		t.Error("expected an unknown seeder to be refused")
+	}
+}
+
+func TestSeedRunner_Run(t *testing.T) {
+	db, mock := newMock(t)
+
+	reg := NewSeederRegistry()
+	reg.Register(
+		testSeeder{name: "users", deps: []string{"roles"}, query: "INSERT INTO users"},
+		testSeeder{name: "roles", query: "INSERT INTO roles"},
+	)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO roles`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO users`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ran, err := (&SeedRunner{DB: db, Registry: reg}).Run(context.Background())
+	if err != nil || strings.Join(ran, ",") != "roles,users" {
+		t.Errorf("expected roles and users to be seeded, got %v, %v", ran, err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO roles`).WillReturnError(errors.New("duplicate key"))
+	mock.ExpectRollback()
+
+	if _, err := (&SeedRunner{DB: db, Registry: reg}).Run(context.Background(), "users"); err == nil || !strings.Contains(err.Error(), "seeding roles") {
+		t.Errorf("expected the failing seeder to roll the run back, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSeedRunner_Production(t *testing.T) {
+	db, mock := newMock(t)
+
+	reg := NewSeederRegistry()
+	reg.Register(testSeeder{name: "demo", destructive: true, query: "TRUNCATE demo"})
+
+	runner := &SeedRunner{DB: db, Registry: reg, Environment: "production"}
+	if _, err := runner.Run(context.Background()); !errors.Is(err, ErrDestructiveSeed) {
+		t.Errorf("expected a destructive seeder to be refused in production, got %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`TRUNCATE demo`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	runner.Force = true
+	if _, err := runner.Run(context.Background()); err != nil {
+		t.Errorf("expected a forced run to seed, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	nurl "net/url"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratedb "github.com/golang-migrate/migrate/v4/database"
+)
+
+// the golang-migrate driver for sql server pulls in the Azure AD SDK, so sqlserver:// migration
+// urls are run by this one, on the driver the app connects with
+func init() {
+	migratedb.Register("sqlserver", &sqlServerMigrations{})
+}
+
+// sqlServerLock is the application lock that keeps two instances from migrating at once
+const sqlServerLock = "gemquick_schema_migrations"
+
+// sqlServerMigrations runs migrations on sql server for golang-migrate, keeping the version in
+// schema_migrations like the drivers for the other databases do. A migration is sent as one
+// batch, so it cannot have GO separators, and a CREATE VIEW, PROCEDURE or TRIGGER has to be the
+// only statement of its migration
+type sqlServerMigrations struct {
+	conn *sql.Conn
+	// db is the pool opened for a migration url, which is closed with the driver
+	db     *sql.DB
+	locked bool
+}
+
+// sqlServerWithConnection returns the driver that migrates on conn, which it closes when it is
+// closed
+func sqlServerWithConnection(ctx context.Context, conn *sql.Conn) (migratedb.Driver, error) {
+	d := &sqlServerMigrations{conn: conn}
+	if err := d.ensureVersionTable(ctx); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Open opens the database of a sqlserver:// url, the dsn of the driver without the x- options of
+// golang-migrate
+func (d *sqlServerMigrations) Open(url string) (migratedb.Driver, error) {
+	u, err := nurl.Parse(url)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlserver", migrate.FilterCustomQuery(u).String())
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	driver, err := sqlServerWithConnection(context.Background(), conn)
+	if err != nil {
+		_ = conn.Close()
+		_ = db.Close()
+		return nil, err
+	}
+	driver.(*sqlServerMigrations).db = db
+
+	return driver, nil
+}
+
+func (d *sqlServerMigrations) Close() error {
+	err := d.conn.Close()
+	if d.db != nil {
+		if dbErr := d.db.Close(); err == nil {
+			err = dbErr
+		}
+	}
+
+	return err
+}
+
+// Lock takes the application lock of the migrations for the session, without waiting for it
+func (d *sqlServerMigrations) Lock() error {
+	if d.locked {
+		return migratedb.ErrLocked
+	}
+
+	var status int
+	err := d.conn.QueryRowContext(context.Background(),
+		"DECLARE @status INT; EXEC @status = sp_getapplock @Resource = @p1, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = 0; SELECT @status",
+		sqlServerLock).Scan(&status)
+	if err != nil {
+		return err
+	}
+	// sp_getapplock returns 0 or 1 when it got the lock, and a negative number when it did not
+	if status < 0 {
+		return migratedb.ErrLocked
+	}
+
+	d.locked = true
+
+	return nil
+}
+
+func (d *sqlServerMigrations) Unlock() error {
+	if !d.locked {
+		return nil
+	}
+
+	_, err := d.conn.ExecContext(context.Background(),
+		"EXEC sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'", sqlServerLock)
+	if err != nil {
+		return err
+	}
+
+	d.locked = false
+
+	return nil
+}
+
+func (d *sqlServerMigrations) Run(migration io.Reader) error {
+	statements, err := io.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+
+	if _, err := d.conn.ExecContext(context.Background(), string(statements)); err != nil {
+		return migratedb.Error{OrigErr: err, Err: "migration failed", Query: statements}
+	}
+
+	return nil
+}
+
+func (d *sqlServerMigrations) SetVersion(version int, dirty bool) error {
+	ctx := context.Background()
+
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// a failed first migration leaves NilVersion behind as dirty
+	if version >= 0 || (version == migratedb.NilVersion && dirty) {
+		_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES (@p1, @p2)", version, dirty)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (d *sqlServerMigrations) Version() (int, bool, error) {
+	var version int
+	var dirty bool
+
+	err := d.conn.QueryRowContext(context.Background(), "SELECT TOP 1 version, dirty FROM schema_migrations").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return migratedb.NilVersion, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return version, dirty, nil
+}
+
+// Drop drops every table of the database, with the foreign keys between them first
+func (d *sqlServerMigrations) Drop() error {
+	_, err := d.conn.ExecContext(context.Background(), `DECLARE @sql NVARCHAR(MAX) = N'';
+SELECT @sql += N'ALTER TABLE ' + QUOTENAME(OBJECT_SCHEMA_NAME(parent_object_id)) + N'.' + QUOTENAME(OBJECT_NAME(parent_object_id)) + N' DROP CONSTRAINT ' + QUOTENAME(name) + N';' FROM sys.foreign_keys;
+SELECT @sql += N'DROP TABLE ' + QUOTENAME(SCHEMA_NAME(schema_id)) + N'.' + QUOTENAME(name) + N';' FROM sys.tables;
+EXEC sp_executesql @sql;`)
+
+	return err
+}
+
+func (d *sqlServerMigrations) ensureVersionTable(ctx context.Context) error {
+	_, err := d.conn.ExecContext(ctx,
+		"IF OBJECT_ID(N'schema_migrations', N'U') IS NULL CREATE TABLE schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BIT NOT NULL)")
+	if err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Upsert inserts data into table, or updates the updateColumns of the row it conflicts with on
+// conflictColumns, which must be a primary key or unique index. It is ON CONFLICT ... DO UPDATE for
+// postgres and sqlite, ON DUPLICATE KEY UPDATE for mysql, which finds the conflicting key on its own,
+// and MERGE for sql server. Without updateColumns an existing row is left as it is
+//
+//	err := database.Upsert(ctx, db, dataType, "settings",
+//		map[string]interface{}{"name": "site.name", "value": "Shop", "updated_at": time.Now()},
+//		[]string{"name"}, []string{"value", "updated_at"})
+func Upsert(ctx context.Context, db Execer, dataType, table string, data map[string]interface{}, conflictColumns, updateColumns []string) error {
+	_, err := UpsertMany(ctx, db, dataType, table, []map[string]interface{}{data}, conflictColumns, updateColumns, 1)
+	return err
+}
+
+// UpsertMany is Upsert for many rows, in statements of chunkSize rows as for InsertMany. It returns
+// the rows affected as the database counts them, which for mysql is 2 for every updated row
+func UpsertMany(ctx context.Context, db Execer, dataType, table string, rows []map[string]interface{}, conflictColumns, updateColumns []string, chunkSize int) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	columns, values, err := mapValues(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	if Dialect(dataType) == "sqlserver" {
+		prefix, suffix, err := mergeClauses(table, columns, conflictColumns, updateColumns)
+		if err != nil {
+			return 0, err
+		}
+
+		return insertValues(ctx, db, dataType, table, columns, values, chunkSize, prefix, suffix)
+	}
+
+	suffix, err := upsertClause(dataType, columns, conflictColumns, updateColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	return insertValues(ctx, db, dataType, table, columns, values, chunkSize, "", suffix)
+}
+
+// mergeClauses returns what goes before and after the rows of a sql server MERGE, which inserts the
+// rows that do not match on conflictColumns and updates the ones that do. HOLDLOCK keeps two of them
+// from inserting the same row at once
+func mergeClauses(table string, columns, conflictColumns, updateColumns []string) (string, string, error) {
+	if len(conflictColumns) == 0 {
+		return "", "", fmt.Errorf("an upsert needs the columns it conflicts on")
+	}
+
+	for _, column := range append(append([]string{}, conflictColumns...), updateColumns...) {
+		if !validIdentifier.MatchString(column) {
+			return "", "", fmt.Errorf("%q is not a column name", column)
+		}
+	}
+
+	on := make([]string, len(conflictColumns))
+	for i, column := range conflictColumns {
+		on[i] = "t." + column + " = s." + column
+	}
+
+	inserted := make([]string, len(columns))
+	for i, column := range columns {
+		inserted[i] = "s." + column
+	}
+
+	prefix := fmt.Sprintf("MERGE INTO %s WITH (HOLDLOCK) AS t USING (VALUES ", table)
+	suffix := fmt.Sprintf(") AS s (%s) ON %s", strings.Join(columns, ", "), strings.Join(on, " AND "))
+
+	if len(updateColumns) > 0 {
+		set := make([]string, len(updateColumns))
+		for i, column := range updateColumns {
+			set[i] = "t." + column + " = s." + column
+		}
+		suffix += " WHEN MATCHED THEN UPDATE SET " + strings.Join(set, ", ")
+	}
+
+	suffix += fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s);", strings.Join(columns, ", "), strings.Join(inserted, ", "))
+
+	return prefix, suffix, nil
+}
+
+// upsertClause returns the clause that turns an INSERT into an upsert
+func upsertClause(dataType string, columns, conflictColumns, updateColumns []string) (string, error) {
+	if len(conflictColumns) == 0 {
+		return "", fmt.Errorf("an upsert needs the columns it conflicts on")
+	}
+
+	for _, column := range append(append([]string{}, conflictColumns...), updateColumns...) {
+		if !validIdentifier.MatchString(column) {
+			return "", fmt.Errorf("%q is not a column name", column)
+		}
+	}
+
+	if Postgres(dataType) || Dialect(dataType) == "sqlite" {
+		if len(updateColumns) == 0 {
+			return fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", strings.Join(conflictColumns, ", ")), nil
+		}
+
+		set := make([]string, len(updateColumns))
+		for i, column := range updateColumns {
+			set[i] = column + " = EXCLUDED." + column
+		}
+
+		return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(conflictColumns, ", "), strings.Join(set, ", ")), nil
+	}
+
+	if len(updateColumns) == 0 {
+		// setting a key column to itself leaves the row as it is, unlike INSERT IGNORE, which
+		// also ignores every other error
+		return fmt.Sprintf(" ON DUPLICATE KEY UPDATE %s = %s", conflictColumns[0], conflictColumns[0]), nil
+	}
+
+	set := make([]string, len(updateColumns))
+	for i, column := range updateColumns {
+		set[i] = column + " = VALUES(" + column + ")"
+	}
+
+	return " ON DUPLICATE KEY UPDATE " + strings.Join(set, ", "), nil
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestUpsert(t *testing.T) {
+	tests := []struct {
+		dataType string
+		update   []string
+		expected string
+	}{
+		{"pgx", []string{"value"}, `INSERT INTO settings \(name, value\) VALUES \(\$1, \$2\) ON CONFLICT \(name\) DO UPDATE SET value = EXCLUDED.value$`},
+		{"pgx", nil, `INSERT INTO settings \(name, value\) VALUES \(\$1, \$2\) ON CONFLICT \(name\) DO NOTHING$`},
+		{"mysql", []string{"value"}, `INSERT INTO settings \(name, value\) VALUES \(\?, \?\) ON DUPLICATE KEY UPDATE value = VALUES\(value\)$`},
+		{"mariadb", nil, `INSERT INTO settings \(name, value\) VALUES \(\?, \?\) ON DUPLICATE KEY UPDATE name = name$`},
+		{"sqlite", []string{"value"}, `INSERT INTO settings \(name, value\) VALUES \(\?, \?\) ON CONFLICT \(name\) DO UPDATE SET value = EXCLUDED.value$`},
+		{"sqlserver", []string{"value"}, `MERGE INTO settings WITH \(HOLDLOCK\) AS t USING \(VALUES \(@p1, @p2\)\) AS s \(name, value\) ON t.name = s.name WHEN MATCHED THEN UPDATE SET t.value = s.value WHEN NOT MATCHED THEN INSERT \(name, value\) VALUES \(s.name, s.value\);$`},
+		{"mssql", nil, `MERGE INTO settings WITH \(HOLDLOCK\) AS t USING \(VALUES \(@p1, @p2\)\) AS s \(name, value\) ON t.name = s.name WHEN NOT MATCHED THEN INSERT`},
+	}
+
+	for _, e := range tests {
+		db, mock := newMock(t)
+		mock.ExpectExec(e.expected).WithArgs("site.name", "Shop").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := Upsert(context.Background(), db, e.dataType, "settings", map[string]interface{}{"name": "site.name", "value": "Shop"}, []string{"name"}, e.update)
+		if err != nil {
+			t.Errorf("%s %v: %s", e.dataType, e.update, err)
+		}
+	}
+}
+
+func TestUpsert_Expr(t *testing.T) {
+	db, mock := newMock(t)
+	mock.ExpectExec(`MERGE INTO settings WITH \(HOLDLOCK\) AS t USING \(VALUES \(@p1, SYSDATETIME\(\)\)\) AS s \(name, updated_at\)`).
+		WithArgs("site.name").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := Upsert(context.Background(), db, "sqlserver", "settings", map[string]interface{}{"name": "site.name", "updated_at": Expr("SYSDATETIME()")}, []string{"name"}, []string{"updated_at"})
+	if err != nil {
+		t.Error(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUpsert_Invalid(t *testing.T) {
+	db, _ := newMock(t)
+
+	err := Upsert(context.Background(), db, "pgx", "settings", map[string]interface{}{"name": "x"}, nil, nil)
+	if err == nil {
+		t.Error("expected an error without conflict columns")
+	}
+
+	err = Upsert(context.Background(), db, "pgx", "settings", map[string]interface{}{"name": "x"}, []string{"name"}, []string{"value = 1; --"})
+	if err == nil {
+		t.Error("expected an error for an invalid update column")
+	}
+}
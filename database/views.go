@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoMaterializedViews is returned by the materialized view helpers for databases that do not
+// have them, like mysql
+var ErrNoMaterializedViews = errors.New("materialized views are only supported on postgres")
+
+// QueryExecer both queries and runs statements, like *sql.DB, *sql.Tx and *sql.Conn
+type QueryExecer interface {
+	Querier
+	Execer
+}
+
+// MaterializedView is a materialized view in the current schema
+type MaterializedView struct {
+	Name string
+	// Populated is false for a view created WITH NO DATA that was never refreshed
+	Populated bool
+	// Unique is whether the view has a unique index, which REFRESH ... CONCURRENTLY needs
+	Unique bool
+}
+
+// Concurrent reports whether the view can be refreshed without locking out its readers
+func (v MaterializedView) Concurrent() bool {
+	return v.Populated && v.Unique
+}
+
+// MaterializedViews returns the materialized views in the current schema, sorted by name
+func MaterializedViews(ctx context.Context, db Querier, dataType string) ([]MaterializedView, error) {
+	if !Postgres(dataType) {
+		return nil, ErrNoMaterializedViews
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT m.matviewname, m.ispopulated,
+	EXISTS (SELECT 1 FROM pg_index i JOIN pg_class c ON c.oid = i.indrelid
+		WHERE c.relname = m.matviewname AND c.relnamespace = to_regnamespace(m.schemaname) AND i.indisunique)
+FROM pg_matviews m WHERE m.schemaname = current_schema() ORDER BY m.matviewname`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var views []MaterializedView
+	for rows.Next() {
+		var v MaterializedView
+		if err := rows.Scan(&v.Name, &v.Populated, &v.Unique); err != nil {
+			return nil, err
+		}
+		views = append(views, v)
+	}
+
+	return views, rows.Err()
+}
+
+// RefreshMaterializedView runs REFRESH MATERIALIZED VIEW for view, CONCURRENTLY when concurrently
+// is set. A concurrent refresh lets queries read the old rows while it runs, but needs the view to
+// be populated and to have a unique index
+func RefreshMaterializedView(ctx context.Context, db Execer, dataType, view string, concurrently bool) error {
+	if !Postgres(dataType) {
+		return ErrNoMaterializedViews
+	}
+
+	if !validIdentifier.MatchString(view) {
+		return fmt.Errorf("%q is not a view name", view)
+	}
+
+	query := "REFRESH MATERIALIZED VIEW " + view
+	if concurrently {
+		query = "REFRESH MATERIALIZED VIEW CONCURRENTLY " + view
+	}
+
+	_, err := db.ExecContext(ctx, query)
+
+	return err
+}
+
+// RefreshMaterializedViews refreshes the named materialized views, or all of them when no names
+// are given, one after the other. Views that can be are refreshed concurrently. It returns the
+// views that were refreshed, which stops at the first that fails
+//
+//	_, err := database.RefreshMaterializedViews(ctx, app.DB.Pool, app.DB.DataType, "daily_sales")
+func RefreshMaterializedViews(ctx context.Context, db QueryExecer, dataType string, names ...string) ([]string, error) {
+	views, err := MaterializedViews(ctx, db, dataType)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]MaterializedView, len(views))
+	for _, v := range views {
+		byName[v.Name] = v
+	}
+
+	if len(names) == 0 {
+		for _, v := range views {
+			names = append(names, v.Name)
+		}
+	}
+
+	var refreshed []string
+	for _, name := range names {
+		v, ok := byName[name]
+		if !ok {
+			return refreshed, fmt.Errorf("there is no materialized view %s", name)
+		}
+
+		err := RefreshMaterializedView(ctx, db, dataType, name, v.Concurrent())
+		if err != nil {
+			return refreshed, fmt.Errorf("refreshing %s: %w", name, err)
+		}
+
+		refreshed = append(refreshed, name)
+	}
+
+	return refreshed, nil
+}
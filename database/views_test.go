@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRefreshMaterializedViews(t *testing.T) {
+	db, mock := newMock(t)
+
+	mock.ExpectQuery(`SELECT m.matviewname, m.ispopulated`).
+		WillReturnRows(sqlmock.NewRows([]string{"matviewname", "ispopulated", "exists"}).
+			AddRow("daily_sales", true, true).
+			AddRow("top_products", true, false).
+			AddRow("yearly_sales", false, true))
+	mock.ExpectExec(`^REFRESH MATERIALIZED VIEW CONCURRENTLY daily_sales$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^REFRESH MATERIALIZED VIEW top_products$`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^REFRESH MATERIALIZED VIEW yearly_sales$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	refreshed, err := RefreshMaterializedViews(context.Background(), db, "pgx")
+	if err != nil || len(refreshed) != 3 {
+		t.Fatalf("expected every view to be refreshed, got %v, %v", refreshed, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRefreshMaterializedViews_Named(t *testing.T) {
+	db, mock := newMock(t)
+
+	mock.ExpectQuery(`SELECT m.matviewname, m.ispopulated`).
+		WillReturnRows(sqlmock.NewRows([]string{"matviewname", "ispopulated", "exists"}).
+			AddRow("daily_sales", true, true))
+	mock.ExpectExec(`^REFRESH MATERIALIZED VIEW CONCURRENTLY daily_sales$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	refreshed, err := RefreshMaterializedViews(context.Background(), db, "postgres", "daily_sales", "missing")
+	if err == nil || len(refreshed) != 1 {
+		t.Errorf("expected daily_sales to be refreshed and missing to fail, got %v, %v", refreshed, err)
+	}
+}
+
+func TestRefreshMaterializedView(t *testing.T) {
+	db, _ := newMock(t)
+
+	if err := RefreshMaterializedView(context.Background(), db, "mysql", "daily_sales", false); !errors.Is(err, ErrNoMaterializedViews) {
+		t.Errorf("expected ErrNoMaterializedViews for mysql, got %v", err)
+	}
+
+	if err := RefreshMaterializedView(context.Background(), db, "pgx", "sales; DROP TABLE users", false); err == nil {
+		t.Error("expected an invalid view name to be rejected")
+	}
+}
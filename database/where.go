@@ -0,0 +1,37 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// WhereIn returns the condition and arguments that select the rows whose column is one of values,
+// a slice, with a placeholder bound to every value. A list cannot be written once in SQL for any
+// number of values, and an empty one is invalid, so it becomes 1 = 0, which selects nothing:
+//
+//	in, args, err := database.WhereIn("status", []string{"paid", "shipped"})
+//	err = database.Get(ctx, db, &orders, database.Rebind(dataType, "SELECT * FROM orders WHERE user_id = ? AND "+in),
+//		append([]interface{}{userID}, args...)...)
+//
+// Groups of conditions and BETWEEN are written in the query, with ? for their values
+func WhereIn(column string, values interface{}) (string, []interface{}, error) {
+	if !validIdentifier.MatchString(column) {
+		return "", nil, fmt.Errorf("%q is not a column name", column)
+	}
+
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 {
+		return "", nil, fmt.Errorf("cannot select from a %T, use a slice", values)
+	}
+	if v.Len() == 0 {
+		return "1 = 0", nil, nil
+	}
+
+	args := make([]interface{}, v.Len())
+	for i := range args {
+		args[i] = v.Index(i).Interface()
+	}
+
+	return column + " IN (?" + strings.Repeat(", ?", len(args)-1) + ")", args, nil
+}
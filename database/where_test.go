@@ -0,0 +1,24 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWhereIn(t *testing.T) {
+	in, args, err := WhereIn("orders.status", []string{"paid", "shipped"})
+	if err != nil || in != "orders.status IN (?, ?)" || !reflect.DeepEqual(args, []interface{}{"paid", "shipped"}) {
+		t.Errorf("unexpected condition %q with %v, %v", in, args, err)
+	}
+
+	if in, args, err := WhereIn("id", []int{}); err != nil || in != "1 = 0" || args != nil {
+		t.Errorf("expected an empty list to select nothing, got %q with %v, %v", in, args, err)
+	}
+
+	if _, _, err := WhereIn("id", 1); err == nil {
+		t.Error("expected a value that is not a slice to be refused")
+	}
+	if _, _, err := WhereIn("id) OR (1 = 1", []int{1}); err == nil {
+		t.Error("expected an invalid column to be refused")
+	}
+}
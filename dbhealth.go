@@ -0,0 +1,35 @@
+package gemquick
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// poolMetrics adds the stats of the connection pool of db to app.Metrics as gauges, read whenever
+// a snapshot is taken: the open, in use and idle connections, how often a statement waited for a
+// connection and how long it waited in all
+func (g *Gemquick) poolMetrics(db *sql.DB) {
+	if g.Metrics == nil || db == nil {
+		return
+	}
+
+	g.Metrics.GaugeFunc("db_open_connections", func() float64 { return float64(db.Stats().OpenConnections) })
+	g.Metrics.GaugeFunc("db_in_use_connections", func() float64 { return float64(db.Stats().InUse) })
+	g.Metrics.GaugeFunc("db_idle_connections", func() float64 { return float64(db.Stats().Idle) })
+	g.Metrics.GaugeFunc("db_wait_count", func() float64 { return float64(db.Stats().WaitCount) })
+	g.Metrics.GaugeFunc("db_wait_duration_seconds", func() float64 { return db.Stats().WaitDuration.Seconds() })
+}
+
+// databaseHealthy pings the database, when there is one, for Readiness. A database that does not
+// answer within two seconds is unhealthy
+func (g *Gemquick) databaseHealthy(ctx context.Context) error {
+	if g.DB.Pool == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	return g.DB.Pool.PingContext(ctx)
+}
@@ -0,0 +1,70 @@
+package gemquick
+
+import (
+	"database/sql"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jimmitjoo/gemquick/metrics"
+)
+
+func TestReadyz_UnhealthyDatabase(t *testing.T) {
+	db, err := sql.Open("gqfake", "health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// every ping opens a connection of its own
+	db.SetMaxIdleConns(0)
+
+	g := &Gemquick{
+		InfoLog:  log.New(io.Discard, "", 0),
+		ErrorLog: log.New(io.Discard, "", 0),
+		DB:       Database{Pool: db},
+	}
+
+	readyz := func() int {
+		w := httptest.NewRecorder()
+		g.Readiness(w, httptest.NewRequest("GET", "/readyz", nil))
+		return w.Code
+	}
+
+	if code := readyz(); code != http.StatusOK {
+		t.Errorf("expected 200 while the database answers, got %d", code)
+	}
+
+	fake.setDown("health", true)
+	defer fake.setDown("health", false)
+
+	if code := readyz(); code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while the database does not answer, got %d", code)
+	}
+}
+
+func TestPoolMetrics(t *testing.T) {
+	db, err := sql.Open("gqfake", "metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err = db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	g := &Gemquick{Metrics: metrics.New()}
+	g.poolMetrics(db)
+
+	s := g.Metrics.Snapshot()
+	for _, name := range []string{"db_open_connections", "db_in_use_connections", "db_idle_connections", "db_wait_count", "db_wait_duration_seconds"} {
+		if _, ok := s.Gauges[name]; !ok {
+			t.Errorf("expected the %s gauge", name)
+		}
+	}
+	if s.Gauges["db_open_connections"] != 1 || s.Gauges["db_idle_connections"] != 1 {
+		t.Errorf("expected the connection of the ping to be open and idle, got %v", s.Gauges)
+	}
+}
@@ -0,0 +1,230 @@
+package gemquick
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jimmitjoo/gemquick/cache"
+	"github.com/jimmitjoo/gemquick/database"
+	"github.com/jimmitjoo/gemquick/debugbar"
+)
+
+// debugToolbarEnabled is true in debug mode, unless DEBUG_TOOLBAR is false
+func (g *Gemquick) debugToolbarEnabled() bool {
+	if !g.Debug {
+		return false
+	}
+
+	enabled, err := strconv.ParseBool(os.Getenv("DEBUG_TOOLBAR"))
+	return err != nil || enabled
+}
+
+// setupDebugToolbar records the log lines and cache operations of the app for the toolbar, and
+// finds the log tail of gq serve when it runs the app
+func (g *Gemquick) setupDebugToolbar() {
+	g.debugTail = os.Getenv(debugbar.TailEnv)
+	g.debugLogs = debugbar.NewRecorder(500)
+	g.InfoLog.SetOutput(io.MultiWriter(g.InfoLog.Writer(), g.debugLogs))
+	g.ErrorLog.SetOutput(io.MultiWriter(g.ErrorLog.Writer(), g.debugLogs))
+
+	if g.Cache != nil {
+		g.debugCache = debugbar.NewRecorder(500)
+		g.Cache = &recordingCache{Cache: g.Cache, events: g.debugCache}
+	}
+}
+
+// DebugToolbar adds a collapsible toolbar to the bottom of html pages, showing the request, the
+// session, the queries run with the request context, the rendered templates and the cache
+// operations and log lines of the app while the request was served. Under gq serve it also shows
+// the latest output of the app and the processes next to it, like workers. It is used in debug
+// mode, after the session is loaded. Requests made by htmx get no toolbar, since they are parts of
+// a page
+func (g *Gemquick) DebugToolbar(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || r.Header.Get("HX-Request") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		c := debugbar.NewCollector()
+		r = r.WithContext(debugbar.NewContext(r.Context(), c))
+		tw := &toolbarWriter{ResponseWriter: w}
+		next.ServeHTTP(tw, r)
+
+		if !tw.buffering {
+			return
+		}
+
+		page := tw.buf.Bytes()
+		bar, err := debugbar.Render(g.toolbarData(r, c, tw.status))
+		if err != nil {
+			g.ErrorLog.Println("rendering the debug toolbar:", err)
+		} else {
+			page = debugbar.Inject(page, bar)
+		}
+
+		w.Header().Del("Content-Length")
+		w.WriteHeader(tw.status)
+		_, _ = w.Write(page)
+	})
+}
+
+func (g *Gemquick) toolbarData(r *http.Request, c *debugbar.Collector, status int) debugbar.Data {
+	end := time.Now()
+	d := debugbar.Data{
+		Request: debugbar.Request{
+			Method:   r.Method,
+			Path:     r.URL.RequestURI(),
+			Status:   status,
+			Duration: end.Sub(c.Start),
+			Headers:  r.Header,
+		},
+		Session:   map[string]interface{}{},
+		Queries:   c.Queries(),
+		Templates: c.Templates(),
+	}
+
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		d.Request.Route = rctx.RoutePattern()
+	}
+
+	if g.Session != nil && sessionsEnabled() {
+		for _, key := range g.Session.Keys(r.Context()) {
+			d.Session[key] = g.Session.Get(r.Context(), key)
+		}
+	}
+
+	if g.debugCache != nil {
+		d.Cache = g.debugCache.Between(c.Start, end)
+	}
+	if g.debugLogs != nil {
+		d.Logs = g.debugLogs.Between(c.Start, end)
+	}
+	if g.debugTail != "" {
+		// gq serve may have been stopped while the app runs on, which is no reason to log
+		d.Processes, _ = debugbar.Tail(g.debugTail, 100)
+	}
+
+	return d
+}
+
+// toolbarWriter holds back html responses until the handler is done, so that the toolbar can be
+// added to them, and passes every other response through
+type toolbarWriter struct {
+	http.ResponseWriter
+	buf       bytes.Buffer
+	status    int
+	decided   bool
+	buffering bool
+}
+
+func (w *toolbarWriter) decide(status int, first []byte) {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	w.status = status
+
+	contentType := w.Header().Get("Content-Type")
+	if contentType == "" && len(first) > 0 {
+		contentType = http.DetectContentType(first)
+	}
+	w.buffering = strings.HasPrefix(contentType, "text/html")
+
+	if !w.buffering {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *toolbarWriter) WriteHeader(status int) {
+	w.decide(status, nil)
+}
+
+func (w *toolbarWriter) Write(b []byte) (int, error) {
+	w.decide(http.StatusOK, b)
+	if w.buffering {
+		return w.buf.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *toolbarWriter) Flush() {
+	if w.buffering {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.decide(http.StatusOK, nil)
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the writer of the server
+func (w *toolbarWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// queryToolbar is the query hook that lists the queries run with a request context in its toolbar
+type queryToolbar struct{}
+
+func (queryToolbar) BeforeQuery(ctx context.Context, e *database.QueryEvent) context.Context {
+	return ctx
+}
+
+func (queryToolbar) AfterQuery(ctx context.Context, e *database.QueryEvent) {
+	q := debugbar.Query{SQL: e.Query, Args: e.Args, Duration: e.Duration}
+	if e.Err != nil {
+		q.Err = e.Err.Error()
+	}
+
+	debugbar.FromContext(ctx).AddQuery(q)
+}
+
+// recordingCache records the operations on the app's cache for the toolbar
+type recordingCache struct {
+	cache.Cache
+	events *debugbar.Recorder
+}
+
+func (c *recordingCache) Has(key string) (bool, error) {
+	ok, err := c.Cache.Has(key)
+	c.events.Record("has %s: %v", key, ok)
+	return ok, err
+}
+
+func (c *recordingCache) Get(key string) (interface{}, error) {
+	v, err := c.Cache.Get(key)
+	if err != nil {
+		c.events.Record("get %s: miss", key)
+	} else {
+		c.events.Record("get %s: hit", key)
+	}
+	return v, err
+}
+
+func (c *recordingCache) Set(key string, value interface{}, ttl ...int) error {
+	c.events.Record("set %s", key)
+	return c.Cache.Set(key, value, ttl...)
+}
+
+func (c *recordingCache) Forget(key string) error {
+	c.events.Record("forget %s", key)
+	return c.Cache.Forget(key)
+}
+
+func (c *recordingCache) EmptyByMatch(pattern string) error {
+	c.events.Record("empty %s", pattern)
+	return c.Cache.EmptyByMatch(pattern)
+}
+
+func (c *recordingCache) Flush() error {
+	c.events.Record("flush")
+	return c.Cache.Flush()
+}
@@ -0,0 +1,204 @@
+// Package debugbar collects what happened while serving a request, like the queries it ran and
+// the templates it rendered, for the toolbar that debug mode adds to the bottom of every page
+package debugbar
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type contextKey struct{}
+
+// Query is a statement run with the request context
+type Query struct {
+	SQL      string
+	Args     []interface{}
+	Duration time.Duration
+	Err      string
+}
+
+// Collector holds what one request did
+type Collector struct {
+	Start time.Time
+
+	mu        sync.Mutex
+	queries   []Query
+	templates []string
+}
+
+// NewCollector returns a collector for a request that starts now
+func NewCollector() *Collector {
+	return &Collector{Start: time.Now()}
+}
+
+// NewContext returns ctx carrying c
+func NewContext(ctx context.Context, c *Collector) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the collector in ctx, nil when the request gets no toolbar
+func FromContext(ctx context.Context) *Collector {
+	c, _ := ctx.Value(contextKey{}).(*Collector)
+	return c
+}
+
+// AddQuery records a query
+func (c *Collector) AddQuery(q Query) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.queries = append(c.queries, q)
+}
+
+// Template records that the request in ctx rendered the template, and does nothing when the
+// request gets no toolbar
+func Template(ctx context.Context, name string) {
+	c := FromContext(ctx)
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.templates = append(c.templates, name)
+}
+
+// Queries returns the recorded queries, in the order they ran
+func (c *Collector) Queries() []Query {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Query(nil), c.queries...)
+}
+
+// Templates returns the rendered templates, in the order they were rendered
+func (c *Collector) Templates() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string(nil), c.templates...)
+}
+
+// Event is a line the app logged or a cache operation
+type Event struct {
+	Time time.Time
+	Text string
+}
+
+// Recorder keeps the latest events of one kind for the whole app, for the code that has no
+// request context to record to, like loggers and caches. A request's toolbar shows the events
+// between its start and end, which in development is rarely shared with another request
+type Recorder struct {
+	mu     sync.Mutex
+	size   int
+	events []Event
+}
+
+// NewRecorder returns a recorder that keeps the latest size events
+func NewRecorder(size int) *Recorder {
+	return &Recorder{size: size}
+}
+
+// Record adds an event that happens now
+func (r *Recorder) Record(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, Event{Time: time.Now(), Text: fmt.Sprintf(format, args...)})
+	if len(r.events) > r.size {
+		r.events = r.events[len(r.events)-r.size:]
+	}
+}
+
+// Write records every line of p, so that the recorder can be the output of a logger
+func (r *Recorder) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		r.Record("%s", line)
+	}
+
+	return len(p), nil
+}
+
+// Between returns the events from from up to to
+func (r *Recorder) Between(from, to time.Time) []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []Event
+	for _, e := range r.events {
+		if !e.Time.Before(from) && !e.Time.After(to) {
+			events = append(events, e)
+		}
+	}
+
+	return events
+}
+
+// Request describes the request the toolbar is for
+type Request struct {
+	Method   string
+	Path     string
+	Route    string
+	Status   int
+	Duration time.Duration
+	Headers  http.Header
+}
+
+// HeaderNames returns the names of the request headers, sorted
+func (r Request) HeaderNames() []string {
+	names := make([]string, 0, len(r.Headers))
+	for name := range r.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Data is everything the toolbar shows
+type Data struct {
+	Request   Request
+	Session   map[string]interface{}
+	Queries   []Query
+	Templates []string
+	Cache     []Event
+	Logs      []Event
+	// Processes is the latest output of the processes gq serve runs, when it runs the app
+	Processes []Line
+}
+
+// QueryTime returns the time all queries took together
+func (d Data) QueryTime() time.Duration {
+	var total time.Duration
+	for _, q := range d.Queries {
+		total += q.Duration
+	}
+
+	return total
+}
+
+// Inject puts the toolbar at the end of the body of the page, or at the end of the page when it
+// has no closing body tag
+func Inject(page, toolbar []byte) []byte {
+	i := bytes.LastIndex(bytes.ToLower(page), []byte("</body>"))
+	if i < 0 {
+		return append(page, toolbar...)
+	}
+
+	out := make([]byte, 0, len(page)+len(toolbar))
+	out = append(out, page[:i]...)
+	out = append(out, toolbar...)
+
+	return append(out, page[i:]...)
+}
@@ -0,0 +1,97 @@
+package debugbar
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInject(t *testing.T) {
+	page := Inject([]byte("<html><body><p>hi</p></BODY></html>"), []byte("<div>bar</div>"))
+	if string(page) != "<html><body><p>hi</p><div>bar</div></BODY></html>" {
+		t.Errorf("expected the toolbar before the closing body tag, got %s", page)
+	}
+
+	if page := Inject([]byte("<p>hi</p>"), []byte("<div>bar</div>")); string(page) != "<p>hi</p><div>bar</div>" {
+		t.Errorf("expected the toolbar at the end of a page without body, got %s", page)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder(2)
+	_, _ = r.Write([]byte("first\nsecond\n"))
+	from := time.Now()
+	r.Record("get %s: hit", "users:1")
+
+	events := r.Between(time.Time{}, time.Now())
+	if len(events) != 2 || events[0].Text != "second" {
+		t.Errorf("expected the latest 2 events, got %+v", events)
+	}
+
+	if events := r.Between(from, time.Now()); len(events) != 1 || events[0].Text != "get users:1: hit" {
+		t.Errorf("expected the events of the window, got %+v", events)
+	}
+}
+
+func TestRender(t *testing.T) {
+	c := NewCollector()
+	ctx := NewContext(context.Background(), c)
+	c.AddQuery(Query{SQL: "SELECT * FROM users WHERE id = $1", Args: []interface{}{7}, Duration: 2 * time.Millisecond})
+	c.AddQuery(Query{SQL: "SELECT nope", Err: "syntax error"})
+	Template(ctx, "home.jet")
+	Template(context.Background(), "ignored.jet")
+
+	bar, err := Render(Data{
+		Request:   Request{Method: "GET", Path: "/", Status: 200},
+		Session:   map[string]interface{}{"userID": 7},
+		Queries:   c.Queries(),
+		Templates: c.Templates(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"2 queries in 2.0ms", "SELECT * FROM users WHERE id = $1 [7]", `class="gq-error"`, "home.jet", "userID"} {
+		if !strings.Contains(string(bar), expected) {
+			t.Errorf("expected %q in the toolbar", expected)
+		}
+	}
+
+	if strings.Contains(string(bar), "ignored.jet") {
+		t.Error("expected templates of requests without a toolbar not to be recorded")
+	}
+}
+
+func TestTail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("n") != "2" || r.URL.Query().Get("source") != "worker" {
+			t.Errorf("expected n and source in the query, got %s", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`[{"time":"2024-05-01T10:00:00Z","source":"worker","text":"job done"}]`))
+	}))
+	defer server.Close()
+
+	lines, err := Tail(server.URL+"/logs", 2, "worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || lines[0].Source != "worker" || lines[0].Text != "job done" {
+		t.Errorf("expected the line of the worker, got %+v", lines)
+	}
+
+	bar, err := Render(Data{Processes: lines})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(bar), "Processes (1)") || !strings.Contains(string(bar), "job done") {
+		t.Error("expected the process lines in the toolbar")
+	}
+
+	server.Close()
+	if _, err := Tail(server.URL+"/logs", 2); err == nil {
+		t.Error("expected an error when gq serve is gone")
+	}
+}
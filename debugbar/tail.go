@@ -0,0 +1,57 @@
+package debugbar
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// TailEnv holds the url of the combined log tail of gq serve, which it sets for the processes it
+// runs
+const TailEnv = "GQ_SERVE_LOGS"
+
+// Line is a line of output of one of the processes gq serve runs
+type Line struct {
+	Time   time.Time `json:"time"`
+	Source string    `json:"source"`
+	Text   string    `json:"text"`
+}
+
+// tailClient gives up quickly, so that a gq serve that went away does not hold up pages
+var tailClient = &http.Client{Timeout: 300 * time.Millisecond}
+
+// Tail returns the latest n lines of the combined log tail at tailURL, of the given sources or of
+// every process when there are none
+func Tail(tailURL string, n int, sources ...string) ([]Line, error) {
+	u, err := url.Parse(tailURL)
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	q.Set("n", strconv.Itoa(n))
+	for _, source := range sources {
+		q.Add("source", source)
+	}
+	u.RawQuery = q.Encode()
+
+	resp, err := tailClient.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("log tail: %s", resp.Status)
+	}
+
+	var lines []Line
+	if err := json.NewDecoder(resp.Body).Decode(&lines); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
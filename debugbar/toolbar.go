@@ -0,0 +1,65 @@
+package debugbar
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"time"
+)
+
+var toolbar = template.Must(template.New("toolbar").Funcs(template.FuncMap{
+	"ms": func(d time.Duration) string {
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	},
+	"clock": func(t time.Time) string {
+		return t.Format("15:04:05.000")
+	},
+}).Parse(`
+<div id="gq-debugbar">
+<style>
+#gq-debugbar{position:fixed;bottom:0;left:0;right:0;z-index:2147483647;max-height:60vh;overflow:auto;background:#1e1e2e;color:#cdd6f4;font:12px/1.5 ui-monospace,Menlo,Consolas,monospace;border-top:2px solid #f38ba8}
+#gq-debugbar>details>summary{cursor:pointer;padding:4px 10px;font-weight:bold}
+#gq-debugbar details details{padding:0 10px 6px}
+#gq-debugbar summary span{margin-right:14px}
+#gq-debugbar table{border-collapse:collapse;width:100%}
+#gq-debugbar td{border-top:1px solid #313244;padding:2px 6px;vertical-align:top;white-space:pre-wrap;word-break:break-all}
+#gq-debugbar .gq-error{color:#f38ba8}
+</style>
+<details>
+<summary><span>{{.Request.Method}} {{.Request.Path}}</span><span>{{.Request.Status}}</span><span>{{ms .Request.Duration}}</span><span>{{len .Queries}} queries in {{ms .QueryTime}}</span><span>{{len .Templates}} templates</span><span>{{len .Cache}} cache</span><span>{{len .Logs}} logs</span>{{if .Processes}}<span>{{len .Processes}} process lines</span>{{end}}</summary>
+<details open><summary>Request</summary><table>
+<tr><td>route</td><td>{{.Request.Route}}</td></tr>
+{{range .Request.HeaderNames}}<tr><td>{{.}}</td><td>{{index $.Request.Headers .}}</td></tr>{{end}}
+</table></details>
+<details><summary>Session ({{len .Session}})</summary><table>
+{{range $key, $value := .Session}}<tr><td>{{$key}}</td><td>{{printf "%#v" $value}}</td></tr>{{end}}
+</table></details>
+<details><summary>Queries ({{len .Queries}})</summary><table>
+{{range .Queries}}<tr><td>{{ms .Duration}}</td><td{{if .Err}} class="gq-error"{{end}}>{{.SQL}}{{if .Args}} {{printf "%v" .Args}}{{end}}{{if .Err}}
+{{.Err}}{{end}}</td></tr>{{end}}
+</table></details>
+<details><summary>Templates ({{len .Templates}})</summary><table>
+{{range .Templates}}<tr><td>{{.}}</td></tr>{{end}}
+</table></details>
+<details><summary>Cache ({{len .Cache}})</summary><table>
+{{range .Cache}}<tr><td>{{clock .Time}}</td><td>{{.Text}}</td></tr>{{end}}
+</table></details>
+<details><summary>Logs ({{len .Logs}})</summary><table>
+{{range .Logs}}<tr><td>{{clock .Time}}</td><td>{{.Text}}</td></tr>{{end}}
+</table></details>
+{{if .Processes}}<details><summary>Processes ({{len .Processes}})</summary><table>
+{{range .Processes}}<tr><td>{{clock .Time}}</td><td>{{.Source}}</td><td>{{.Text}}</td></tr>{{end}}
+</table></details>{{end}}
+</details>
+</div>
+`))
+
+// Render returns the html of the toolbar
+func Render(d Data) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toolbar.Execute(&buf, d); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,75 @@
+package gemquick
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/database"
+	"github.com/jimmitjoo/gemquick/debugbar"
+)
+
+func TestDebugToolbar(t *testing.T) {
+	g := &Gemquick{Debug: true, InfoLog: log.New(io.Discard, "", 0), ErrorLog: log.New(io.Discard, "", 0)}
+	g.setupDebugToolbar()
+
+	page := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queryToolbar{}.AfterQuery(r.Context(), &database.QueryEvent{Query: "SELECT * FROM orders"})
+		debugbar.Template(r.Context(), "orders.jet")
+		g.InfoLog.Println("listing orders")
+
+		w.Header().Set("Content-Length", "40")
+		_, _ = w.Write([]byte("<html><body><h1>Orders</h1></body></html>"))
+	})
+
+	rr := httptest.NewRecorder()
+	g.DebugToolbar(page).ServeHTTP(rr, httptest.NewRequest("GET", "/orders", nil))
+
+	body := rr.Body.String()
+	for _, expected := range []string{`<div id="gq-debugbar">`, "SELECT * FROM orders", "orders.jet", "listing orders"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %q in the page", expected)
+		}
+	}
+	if !strings.HasSuffix(body, "</body></html>") || rr.Header().Get("Content-Length") != "" {
+		t.Errorf("expected the toolbar inside the body and no stale length, got %q", body)
+	}
+
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	})
+
+	rr = httptest.NewRecorder()
+	g.DebugToolbar(api).ServeHTTP(rr, httptest.NewRequest("POST", "/api/orders", nil))
+	if rr.Code != http.StatusCreated || rr.Body.String() != `{"id":1}` {
+		t.Errorf("expected json to pass through, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/orders", nil)
+	req.Header.Set("HX-Request", "true")
+	g.DebugToolbar(page).ServeHTTP(rr, req)
+	if strings.Contains(rr.Body.String(), "gq-debugbar") {
+		t.Error("expected htmx requests to get no toolbar")
+	}
+}
+
+func TestRecordingCache(t *testing.T) {
+	events := debugbar.NewRecorder(10)
+	c := &recordingCache{Cache: &testCache{}, events: events}
+	_ = c.EmptyByMatch("users:*")
+
+	if recorded := events.Between(time.Time{}, time.Now()); len(recorded) != 1 || recorded[0].Text != "empty users:*" {
+		t.Errorf("expected the cache operation to be recorded, got %+v", recorded)
+	}
+
+	// the query hook ignores requests without a toolbar
+	queryToolbar{}.AfterQuery(context.Background(), &database.QueryEvent{Query: "SELECT 1"})
+}
@@ -1,31 +1,263 @@
 package gemquick
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	_ "github.com/jackc/pgconn"
 	_ "github.com/jackc/pgx/v4"
 	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/jimmitjoo/gemquick/database"
+	"github.com/jimmitjoo/gemquick/pool"
 )
 
-func (g *Gemquick) OpenDB(dbType, dsn string) (*sql.DB, error) {
+// OpenDB opens a connection pool and verifies it with a ping. When failover dsns are given,
+// every new connection in the pool tries the dsns in order, starting with the last one that worked
+func (g *Gemquick) OpenDB(dbType, dsn string, failover ...string) (*sql.DB, error) {
+	db, err := openDB(dbType, dsn, g.queryHooks(), failover...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Ping()
+
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	g.setDBReady(true)
+
+	return db, nil
+}
+
+// OpenDBLazy opens a connection pool without waiting for the database to answer, and keeps
+// pinging it in the background until it does. Until then Ready reports false, so the app can
+// boot and serve its readiness probe while the database is still starting
+func (g *Gemquick) OpenDBLazy(dbType string, backoff time.Duration, dsn string, failover ...string) (*sql.DB, error) {
+	db, err := openDB(dbType, dsn, g.queryHooks(), failover...)
+	if err != nil {
+		return nil, err
+	}
+
+	g.setDBReady(false)
+
+	pool.SafeGo(func() {
+		wait := backoff
+		for {
+			err := db.Ping()
+			if err == nil {
+				g.setDBReady(true)
+				if g.InfoLog != nil {
+					g.InfoLog.Println("connected to database")
+				}
+				return
+			}
+
+			if g.ErrorLog != nil {
+				g.ErrorLog.Printf("database not reachable yet, retrying in %s: %v", wait, err)
+			}
+
+			time.Sleep(wait)
+			if wait < maxLazyBackoff {
+				wait *= 2
+			}
+		}
+	})
+
+	return db, nil
+}
+
+// maxLazyBackoff caps the wait between background connection attempts made by OpenDBLazy
+const maxLazyBackoff = 30 * time.Second
+
+// queryHooks returns the hooks of the pools the app opens, which app.DB.Hooks holds as well
+func (g *Gemquick) queryHooks() *database.Hooks {
+	if g.dbHooks == nil {
+		g.dbHooks = &database.Hooks{}
+	}
+
+	return g.dbHooks
+}
+
+// driverPackages register the drivers that are not linked into every app
+var driverPackages = map[string]string{
+	"sqlite3":   "github.com/jimmitjoo/gemquick/drivers/sqlite",
+	"sqlserver": "github.com/jimmitjoo/gemquick/drivers/sqlserver",
+}
+
+func openDB(dbType, dsn string, hooks *database.Hooks, failover ...string) (*sql.DB, error) {
 	if dbType == "postgres" || dbType == "postgresql" {
 		dbType = "pgx"
 	} else if dbType == "mysql" || dbType == "mariadb" {
 		dbType = "mysql"
+	} else if dbType == "sqlite" {
+		dbType = "sqlite3"
+	} else if dbType == "mssql" {
+		dbType = "sqlserver"
 	}
 
-	db, err := sql.Open(dbType, dsn)
-
+	// sql.Open does not connect, it only resolves the registered driver for us
+	probe, err := sql.Open(dbType, dsn)
 	if err != nil {
+		if pkg, ok := driverPackages[dbType]; ok {
+			return nil, fmt.Errorf("%w, the app imports _ %q to use it", err, pkg)
+		}
 		return nil, err
 	}
+	d := probe.Driver()
+	_ = probe.Close()
 
-	err = db.Ping()
+	var connector driver.Connector
+	if len(failover) > 0 {
+		connector = &failoverConnector{driver: d, dsns: append([]string{dsn}, failover...)}
+	} else if dc, ok := d.(driver.DriverContext); ok {
+		connector, err = dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		connector = dsnConnector{driver: d, dsn: dsn}
+	}
 
+	return sql.OpenDB(database.Instrument(connector, hooks)), nil
+}
+
+// dsnConnector opens connections to dsn for drivers without connectors of their own
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Ready reports whether the app can serve traffic, which is false while a lazily opened
+// database has not answered yet or the warmup hooks have not finished
+func (g *Gemquick) Ready() bool {
+	return atomic.LoadInt32(&g.dbPending) == 0 && g.warmedUp()
+}
+
+func (g *Gemquick) setDBReady(ready bool) {
+	if ready {
+		atomic.StoreInt32(&g.dbPending, 0)
+	} else {
+		atomic.StoreInt32(&g.dbPending, 1)
+	}
+}
+
+// OpenDBWithRetry calls OpenDB until it succeeds or retries is exhausted, doubling the wait
+// between attempts starting at backoff
+func (g *Gemquick) OpenDBWithRetry(dbType string, retries int, backoff time.Duration, dsn string, failover ...string) (*sql.DB, error) {
+	var db *sql.DB
+	var err error
+
+	for attempt := 0; attempt <= retries; attempt++ {
+		db, err = g.OpenDB(dbType, dsn, failover...)
+		if err == nil {
+			return db, nil
+		}
+
+		if attempt < retries {
+			if g.ErrorLog != nil {
+				g.ErrorLog.Printf("could not connect to database (attempt %d of %d), retrying in %s: %v", attempt+1, retries+1, backoff, err)
+			}
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+
+	return nil, fmt.Errorf("could not connect to database after %d attempts: %w", retries+1, err)
+}
+
+// failoverConnector hands out connections from the first dsn that accepts one. Since database/sql
+// discards broken connections, a pool built on it reconnects to a standby when the primary goes away
+type failoverConnector struct {
+	driver driver.Driver
+	dsns   []string
+
+	mu      sync.Mutex
+	current int
+}
+
+func (c *failoverConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	start := c.current
+	c.mu.Unlock()
+
+	var errs []error
+
+	for i := 0; i < len(c.dsns); i++ {
+		index := (start + i) % len(c.dsns)
+
+		conn, err := c.connect(ctx, c.dsns[index])
+		if err == nil {
+			c.mu.Lock()
+			c.current = index
+			c.mu.Unlock()
+
+			return conn, nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+func (c *failoverConnector) connect(ctx context.Context, dsn string) (driver.Conn, error) {
+	if dc, ok := c.driver.(driver.DriverContext); ok {
+		connector, err := dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+
+		return connector.Connect(ctx)
+	}
+
+	return c.driver.Open(dsn)
+}
+
+func (c *failoverConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// createRetry returns how the app retries after transient database errors: up to
+// DATABASE_RETRIES runs, 3 when it is empty and 1 to never retry, waiting DATABASE_RETRY_BACKOFF
+// before the first retry, 50ms when it is empty. Retries are logged and counted in db_retries
+func (g *Gemquick) createRetry() database.Retry {
+	attempts, err := strconv.Atoi(os.Getenv("DATABASE_RETRIES"))
 	if err != nil {
-		return nil, err
+		attempts = 3
 	}
 
-	return db, nil
+	backoff, err := time.ParseDuration(os.Getenv("DATABASE_RETRY_BACKOFF"))
+	if err != nil {
+		backoff = 50 * time.Millisecond
+	}
+
+	return database.Retry{
+		Attempts:   attempts,
+		Backoff:    backoff,
+		MaxBackoff: 2 * time.Second,
+		OnRetry: func(attempt int, wait time.Duration, err error) {
+			g.ErrorLog.Printf("retrying after a transient database error in %s (attempt %d): %v", wait, attempt, err)
+			if g.Metrics != nil {
+				g.Metrics.Counter("db_retries").Inc()
+			}
+		},
+	}
 }
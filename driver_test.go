@@ -0,0 +1,195 @@
+package gemquick
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errHostDown = errors.New("connection refused")
+
+// fakeDriver accepts connections to every dsn except the ones marked down, and records the dsns
+// it was asked to connect to
+type fakeDriver struct {
+	mu     sync.Mutex
+	down   map[string]bool
+	opened []string
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.opened = append(d.opened, dsn)
+	if d.down[dsn] {
+		return nil, errHostDown
+	}
+
+	return fakeConn{}, nil
+}
+
+func (d *fakeDriver) setDown(dsn string, down bool) {
+	d.mu.Lock()
+	d.down[dsn] = down
+	d.mu.Unlock()
+}
+
+func (d *fakeDriver) reset() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	opened := d.opened
+	d.opened = nil
+	return opened
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+var fake = &fakeDriver{down: map[string]bool{}}
+
+func init() {
+	sql.Register("gqfake", fake)
+}
+
+func TestOpenDB_Failover(t *testing.T) {
+	fake.setDown("primary", true)
+	defer fake.setDown("primary", false)
+	fake.reset()
+
+	g := &Gemquick{}
+	db, err := g.OpenDB("gqfake", "primary", "standby")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxIdleConns(0)
+
+	if opened := fake.reset(); strings.Join(opened, ",") != "primary,standby" {
+		t.Errorf("expected the standby to be tried after the primary, got %v", opened)
+	}
+
+	// the connector starts with the dsn that worked last, and rotates back once it goes away
+	fake.setDown("primary", false)
+	fake.setDown("standby", true)
+	defer fake.setDown("standby", false)
+
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	if opened := fake.reset(); strings.Join(opened, ",") != "standby,primary" {
+		t.Errorf("expected the standby to be tried first and the primary next, got %v", opened)
+	}
+
+	fake.setDown("primary", true)
+	if err := db.Ping(); !errors.Is(err, errHostDown) {
+		t.Errorf("expected the errors of every dsn when all are down, got %v", err)
+	}
+}
+
+func TestOpenDBWithRetry_Exhausted(t *testing.T) {
+	fake.setDown("primary", true)
+	defer fake.setDown("primary", false)
+	fake.reset()
+
+	g := &Gemquick{ErrorLog: log.New(io.Discard, "", 0)}
+	_, err := g.OpenDBWithRetry("gqfake", 2, time.Millisecond, "primary")
+
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") || !errors.Is(err, errHostDown) {
+		t.Errorf("expected the last connection error to be wrapped after 3 attempts, got %v", err)
+	}
+
+	if opened := fake.reset(); len(opened) != 3 {
+		t.Errorf("expected 3 connection attempts, got %d", len(opened))
+	}
+}
+
+func TestOpenDB_DriverPackage(t *testing.T) {
+	g := &Gemquick{ErrorLog: log.New(io.Discard, "", 0)}
+
+	_, err := g.OpenDB("sqlite", "data/app.db")
+	if err == nil || !strings.Contains(err.Error(), `"github.com/jimmitjoo/gemquick/drivers/sqlite"`) {
+		t.Errorf("expected the error to name the package of the driver, got %v", err)
+	}
+}
+
+func TestBuildDSNFrom(t *testing.T) {
+	env := map[string]string{
+		"DATABASE_HOST":     "db",
+		"DATABASE_PORT":     "3306",
+		"DATABASE_USER":     "shop",
+		"DATABASE_PASS":     "secret",
+		"DATABASE_NAME":     "shop",
+		"DATABASE_SSL_MODE": "disable",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	env["DATABASE_TYPE"] = "mariadb"
+	if dsn := BuildDSNFrom(getenv); !strings.HasPrefix(dsn, "shop:secret@tcp(db:3306)/shop?") || !strings.Contains(dsn, "tls=false") {
+		t.Errorf("expected a mysql dsn, got %q", dsn)
+	}
+
+	env["DATABASE_TYPE"] = "postgres"
+	if dsn := BuildDSNFrom(getenv); !strings.HasPrefix(dsn, "host=db port=3306 user=shop dbname=shop") {
+		t.Errorf("expected a postgres dsn, got %q", dsn)
+	}
+
+	env["DATABASE_TYPE"] = "sqlserver"
+	if dsn := BuildDSNFrom(getenv); !strings.HasPrefix(dsn, "sqlserver://shop:secret@db:3306?") || !strings.Contains(dsn, "database=shop") || !strings.Contains(dsn, "encrypt=disable") {
+		t.Errorf("expected a sql server dsn, got %q", dsn)
+	}
+
+	env["DATABASE_TYPE"] = "sqlite"
+	env["DATABASE_NAME"] = "data/shop.db"
+	if dsn := BuildDSNFrom(getenv); !strings.HasPrefix(dsn, "file:data/shop.db?") || !strings.Contains(dsn, "_foreign_keys=on") {
+		t.Errorf("expected a sqlite dsn, got %q", dsn)
+	}
+
+	env["DATABASE_NAME"] = ":memory:"
+	if dsn := BuildDSNFrom(getenv); !strings.Contains(dsn, "cache=shared") {
+		t.Errorf("expected an in-memory database shared by the pool, got %q", dsn)
+	}
+
+	env["DATABASE_TYPE"] = "oracle"
+	if dsn := BuildDSNFrom(getenv); dsn != "" {
+		t.Errorf("expected no dsn for an unsupported database, got %q", dsn)
+	}
+}
+
+func TestFailoverDSNs(t *testing.T) {
+	t.Setenv("DATABASE_TYPE", "mysql")
+	t.Setenv("DATABASE_PORT", "3306")
+	t.Setenv("DATABASE_FAILOVER_HOSTS", "standby, backup:3307, [fd00::2]:3308, fd00::3, [fd00::4]")
+
+	g := &Gemquick{ErrorLog: log.New(io.Discard, "", 0)}
+	dsns := g.FailoverDSNs()
+	expected := []string{"@tcp(standby:3306)/", "@tcp(backup:3307)/", "@tcp([fd00::2]:3308)/", "@tcp([fd00::3]:3306)/", "@tcp([fd00::4]:3306)/"}
+	if len(dsns) != len(expected) {
+		t.Fatalf("expected mysql dsns for every host, got %v", dsns)
+	}
+	for i, host := range expected {
+		if !strings.Contains(dsns[i], host) {
+			t.Errorf("expected %s in %s", host, dsns[i])
+		}
+	}
+
+	t.Setenv("DATABASE_TYPE", "postgres")
+	if dsns := g.FailoverDSNs(); !strings.Contains(dsns[3], "host=fd00::3 port=3306 ") {
+		t.Errorf("expected the ipv6 address as the postgres host, got %s", dsns[3])
+	}
+
+	t.Setenv("DATABASE_TYPE", "oracle")
+	if dsns := g.FailoverDSNs(); dsns != nil {
+		t.Errorf("expected the hosts to be ignored for an unsupported database, got %v", dsns)
+	}
+}
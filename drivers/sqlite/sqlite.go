@@ -0,0 +1,12 @@
+// Package sqlite registers the sqlite driver and its migrations for apps on DATABASE_TYPE=sqlite,
+// which import it for its side effects, next to gemquick:
+//
+//	import _ "github.com/jimmitjoo/gemquick/drivers/sqlite"
+//
+// The driver uses cgo, so it is left out of the apps on the other databases
+package sqlite
+
+import (
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/mattn/go-sqlite3"
+)
@@ -0,0 +1,12 @@
+// Package sqlserver registers the Microsoft sql server driver for apps on DATABASE_TYPE=sqlserver,
+// which import it for its side effects, next to gemquick:
+//
+//	import _ "github.com/jimmitjoo/gemquick/drivers/sqlserver"
+//
+// The driver and its dependencies are large, so they are left out of the apps on the other
+// databases. Migrations run on it with the migration driver of the database package
+package sqlserver
+
+import (
+	_ "github.com/microsoft/go-mssqldb"
+)
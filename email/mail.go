@@ -2,6 +2,7 @@ package email
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	apimail "github.com/ainsleyclark/go-mail"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/vanng822/go-premailer/premailer"
 	mail "github.com/xhit/go-simple-mail/v2"
 )
@@ -38,25 +40,66 @@ type Message struct {
 	Template    string
 	Attachments []string
 	Data        interface{}
+	// RequestID is the id of the request that sent the message. It is set by QueueContext,
+	// added to SMTP messages as the X-Request-ID header and copied to the Result
+	RequestID string
 }
 
 type Result struct {
-	Success bool
-	Error   error
+	Success   bool
+	Error     error
+	RequestID string
 }
 
+// QueueContext pushes msg onto Jobs, tagged with the request id found in ctx so that a
+// failure reported on Results can be traced back to the request that sent it
+func (m *Mail) QueueContext(ctx context.Context, msg Message) {
+	if msg.RequestID == "" {
+		msg.RequestID = middleware.GetReqID(ctx)
+	}
+
+	m.Jobs <- msg
+}
+
+// ListenForMail sends the messages pushed onto Jobs for as long as the process runs
 func (m *Mail) ListenForMail() {
+	m.ListenForMailContext(context.Background())
+}
+
+// ListenForMailContext sends the messages pushed onto Jobs until ctx is cancelled,
+// and then sends the messages that are still queued before it returns
+func (m *Mail) ListenForMailContext(ctx context.Context) {
 	for {
-		msg := <-m.Jobs
-		err := m.Send(msg)
-		if err != nil {
-			m.Results <- Result{Success: false, Error: err}
-		} else {
-			m.Results <- Result{Success: true}
+		select {
+		case msg := <-m.Jobs:
+			m.handleJob(msg)
+		case <-ctx.Done():
+			for {
+				select {
+				case msg := <-m.Jobs:
+					m.handleJob(msg)
+				default:
+					return
+				}
+			}
 		}
 	}
 }
 
+// handleJob sends msg and reports the result on Results. Results are dropped when
+// nobody reads them and the channel is full, so the listener never blocks on them
+func (m *Mail) handleJob(msg Message) {
+	result := Result{Success: true, RequestID: msg.RequestID}
+	if err := m.Send(msg); err != nil {
+		result = Result{Success: false, Error: err, RequestID: msg.RequestID}
+	}
+
+	select {
+	case m.Results <- result:
+	default:
+	}
+}
+
 func (m *Mail) Send(msg Message) error {
 	var err error
 	if m.API != "" && m.APIKey != "" && m.APIUrl != "" && m.API != "smtp" {
@@ -184,6 +227,10 @@ func (m *Mail) SendSMTPMessage(msg Message) error {
 	email.SetBody(mail.TextHTML, formattedMessage)
 	email.AddAlternative(mail.TextPlain, plainTextMessage)
 
+	if msg.RequestID != "" {
+		email.AddHeader("X-Request-ID", msg.RequestID)
+	}
+
 	if len(msg.Attachments) > 0 {
 		for _, attachment := range msg.Attachments {
 			email.AddAttachment(attachment)
@@ -212,6 +259,9 @@ func (m *Mail) getEncryption(encryption string) mail.Encryption {
 }
 
 func (m *Mail) buildHTMLMessage(msg Message) (string, error) {
+	if m.hasMarkdown(msg) {
+		return m.buildMarkdownHTMLMessage(msg)
+	}
 
 	templateToRender := fmt.Sprintf("%s/%s.html.tmpl", m.Templates, msg.Template)
 
@@ -235,6 +285,10 @@ func (m *Mail) buildHTMLMessage(msg Message) (string, error) {
 }
 
 func (m *Mail) buildPlainTextMessage(msg Message) (string, error) {
+	if m.hasMarkdown(msg) {
+		return m.buildMarkdownPlainTextMessage(msg)
+	}
+
 	templateToRender := fmt.Sprintf("%s/%s.plain.tmpl", m.Templates, msg.Template)
 
 	t, err := template.New("email-html").ParseFiles(templateToRender)
@@ -0,0 +1,233 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// MarkdownLayout wraps the HTML of markdown mails. A <name>.md.tmpl mail is rendered into it, unless
+// the templates directory has a layout.html.tmpl, which gets the HTML as .Body and the message
+// data as .Data
+var MarkdownLayout = `<!doctype html>
+<html>
+<head>
+    <meta name="viewport" content="width=device-width" />
+    <meta http-equiv="Content-Type" content="text/html; charset=UTF-8" />
+    <style>
+        body { background-color: #f4f5f7; color: #3d4852; font-family: -apple-system, 'Segoe UI', Roboto, Helvetica, Arial, sans-serif; margin: 0; padding: 24px 0; }
+        .wrapper { background-color: #ffffff; border-radius: 4px; margin: 0 auto; max-width: 570px; padding: 32px; }
+        h1 { font-size: 20px; margin-top: 0; }
+        h2 { font-size: 16px; }
+        h3, h4, h5, h6 { font-size: 14px; }
+        p, li { font-size: 16px; line-height: 1.5; }
+        a { color: #3869d4; }
+        a.button { background-color: #2d3748; border-radius: 4px; color: #ffffff; display: inline-block; padding: 10px 18px; text-decoration: none; }
+        blockquote { border-left: 4px solid #e8e5ef; color: #718096; margin: 0; padding-left: 16px; }
+        code { background-color: #f4f5f7; font-family: Menlo, Consolas, monospace; font-size: 14px; }
+        pre { background-color: #f4f5f7; padding: 12px; white-space: pre-wrap; }
+        hr { border: 0; border-top: 1px solid #e8e5ef; margin: 24px 0; }
+    </style>
+</head>
+<body>
+<div class="wrapper">
+{{.Body}}
+</div>
+</body>
+</html>`
+
+var (
+	mdHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	mdRule    = regexp.MustCompile(`^(\*\s*){3,}$|^(-\s*){3,}$|^(_\s*){3,}$`)
+	mdBullet  = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	mdNumber  = regexp.MustCompile(`^\d+[.)]\s+(.*)$`)
+	mdCode    = regexp.MustCompile("`([^`]+)`")
+	mdLink    = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)(\{\.button\})?`)
+	mdBold    = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	mdItalic  = regexp.MustCompile(`\*([^*\s][^*]*)\*|\b_([^_\s][^_]*)_\b`)
+)
+
+// hasMarkdown reports whether the mail msg sends is written in markdown
+func (m *Mail) hasMarkdown(msg Message) bool {
+	_, err := os.Stat(fmt.Sprintf("%s/%s.md.tmpl", m.Templates, msg.Template))
+	return err == nil
+}
+
+// buildMarkdown executes the markdown template of msg with its data
+func (m *Mail) buildMarkdown(msg Message) (string, error) {
+	templateToRender := fmt.Sprintf("%s/%s.md.tmpl", m.Templates, msg.Template)
+
+	t, err := template.New("email-markdown").ParseFiles(templateToRender)
+	if err != nil {
+		return "", err
+	}
+
+	var markdown bytes.Buffer
+	if err = t.ExecuteTemplate(&markdown, "body", msg.Data); err != nil {
+		return "", err
+	}
+
+	return markdown.String(), nil
+}
+
+// buildMarkdownHTMLMessage renders the markdown of msg as HTML in the layout, with the styles of
+// the layout inlined
+func (m *Mail) buildMarkdownHTMLMessage(msg Message) (string, error) {
+	markdown, err := m.buildMarkdown(msg)
+	if err != nil {
+		return "", err
+	}
+
+	layout := MarkdownLayout
+	if custom, err := os.ReadFile(fmt.Sprintf("%s/layout.html.tmpl", m.Templates)); err == nil {
+		layout = string(custom)
+	}
+
+	t, err := htmltemplate.New("email-layout").Parse(layout)
+	if err != nil {
+		return "", err
+	}
+
+	var htmlMessage bytes.Buffer
+	err = t.Execute(&htmlMessage, map[string]interface{}{
+		"Body": htmltemplate.HTML(MarkdownToHTML(markdown)),
+		"Data": msg.Data,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return m.inlineCSS(htmlMessage.String())
+}
+
+// buildMarkdownPlainTextMessage returns the markdown of msg with links spelled out and the
+// emphasis removed, which reads well as plain text
+func (m *Mail) buildMarkdownPlainTextMessage(msg Message) (string, error) {
+	markdown, err := m.buildMarkdown(msg)
+	if err != nil {
+		return "", err
+	}
+
+	plain := mdLink.ReplaceAllString(markdown, "$1 ($2)")
+	plain = mdBold.ReplaceAllString(plain, "$1$2")
+	plain = mdCode.ReplaceAllString(plain, "$1")
+
+	return strings.TrimSpace(plain) + "\n", nil
+}
+
+// MarkdownToHTML converts the markdown mails are written in to HTML. It knows headings,
+// paragraphs, bold and italic text, inline and fenced code, links, block quotes, lists and
+// horizontal rules, and [Text](url){.button} renders a link as a button. HTML in the markdown
+// is escaped, so values from the message data cannot add markup
+func MarkdownToHTML(markdown string) string {
+	var out strings.Builder
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + strings.Join(paragraph, "\n") + "</p>\n")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		switch {
+		case line == "":
+			flush()
+
+		case strings.HasPrefix(line, "```"):
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, html.EscapeString(lines[i]))
+			}
+			out.WriteString("<pre><code>" + strings.Join(code, "\n") + "</code></pre>\n")
+
+		case mdHeading.MatchString(line):
+			flush()
+			match := mdHeading.FindStringSubmatch(line)
+			level := len(match[1])
+			out.WriteString(fmt.Sprintf("<h%d>%s</h%d>\n", level, inlineMarkdown(match[2]), level))
+
+		case mdRule.MatchString(line):
+			flush()
+			out.WriteString("<hr>\n")
+
+		case strings.HasPrefix(line, ">"):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quoted = append(quoted, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+			}
+			i--
+			out.WriteString("<blockquote>\n" + MarkdownToHTML(strings.Join(quoted, "\n")) + "</blockquote>\n")
+
+		case mdBullet.MatchString(line), mdNumber.MatchString(line):
+			flush()
+			item, tag := mdBullet, "ul"
+			if !mdBullet.MatchString(line) {
+				item, tag = mdNumber, "ol"
+			}
+
+			out.WriteString("<" + tag + ">\n")
+			for ; i < len(lines) && item.MatchString(strings.TrimSpace(lines[i])); i++ {
+				text := item.FindStringSubmatch(strings.TrimSpace(lines[i]))[1]
+				out.WriteString("<li>" + inlineMarkdown(text) + "</li>\n")
+			}
+			i--
+			out.WriteString("</" + tag + ">\n")
+
+		default:
+			text := inlineMarkdown(line)
+			// two trailing spaces break the line
+			if strings.HasSuffix(lines[i], "  ") {
+				text += "<br>"
+			}
+			paragraph = append(paragraph, text)
+		}
+	}
+	flush()
+
+	return out.String()
+}
+
+// inlineMarkdown escapes text and converts its code, links and emphasis
+func inlineMarkdown(text string) string {
+	// code spans are set aside first, so nothing inside them is formatted
+	var spans []string
+	text = mdCode.ReplaceAllStringFunc(text, func(s string) string {
+		spans = append(spans, "<code>"+html.EscapeString(mdCode.FindStringSubmatch(s)[1])+"</code>")
+		return fmt.Sprintf("\x00%d\x00", len(spans)-1)
+	})
+
+	text = html.EscapeString(text)
+
+	text = mdLink.ReplaceAllStringFunc(text, func(s string) string {
+		match := mdLink.FindStringSubmatch(s)
+		href := match[2]
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(href)), "javascript:") {
+			href = "#"
+		}
+		if match[3] != "" {
+			return `<a class="button" href="` + href + `">` + match[1] + `</a>`
+		}
+		return `<a href="` + href + `">` + match[1] + `</a>`
+	})
+
+	text = mdBold.ReplaceAllString(text, "<strong>$1$2</strong>")
+	text = mdItalic.ReplaceAllString(text, "<em>$1$2</em>")
+
+	for i, span := range spans {
+		text = strings.Replace(text, fmt.Sprintf("\x00%d\x00", i), span, 1)
+	}
+
+	return text
+}
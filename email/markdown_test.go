@@ -0,0 +1,59 @@
+package email
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownToHTML(t *testing.T) {
+	var tests = []struct {
+		name     string
+		markdown string
+		expected string
+	}{
+		{"heading", "# Welcome, **Ada**", "<h1>Welcome, <strong>Ada</strong></h1>\n"},
+		{"paragraph", "Hello\nthere", "<p>Hello\nthere</p>\n"},
+		{"emphasis", "*soon* and `x < y`", "<p><em>soon</em> and <code>x &lt; y</code></p>\n"},
+		{"link", "[Docs](https://example.com?a=1&b=2)", `<p><a href="https://example.com?a=1&amp;b=2">Docs</a></p>` + "\n"},
+		{"button", "[Confirm](https://example.com){.button}", `<p><a class="button" href="https://example.com">Confirm</a></p>` + "\n"},
+		{"script link", "[Click](javascript:alert(1))", `<p><a href="#">Click</a>)</p>` + "\n"},
+		{"list", "- one\n- two", "<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n"},
+		{"ordered list", "1. one\n2. two", "<ol>\n<li>one</li>\n<li>two</li>\n</ol>\n"},
+		{"rule", "---", "<hr>\n"},
+		{"quote", "> quoted", "<blockquote>\n<p>quoted</p>\n</blockquote>\n"},
+		{"code block", "```\n<b>\n```", "<pre><code>&lt;b&gt;</code></pre>\n"},
+		{"escaped html", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+	}
+
+	for _, e := range tests {
+		got := MarkdownToHTML(e.markdown)
+		if got != e.expected {
+			t.Errorf("%s: expected %q, got %q", e.name, e.expected, got)
+		}
+	}
+}
+
+func TestMail_BuildMarkdownMessage(t *testing.T) {
+	m := Mail{Templates: "./testdata/markdown"}
+	msg := Message{Template: "welcome", Data: map[string]string{"Name": "Ada <ada@example.com>", "Link": "https://example.com/confirm"}}
+
+	htmlMessage, err := m.buildHTMLMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"<h1", "Ada &lt;ada@example.com&gt;", `class="button"`, "max-width:570px"} {
+		if !strings.Contains(htmlMessage, expected) {
+			t.Errorf("expected %s in the html message, got %s", expected, htmlMessage)
+		}
+	}
+
+	plain, err := m.buildPlainTextMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(plain, "Confirm your account (https://example.com/confirm)") {
+		t.Errorf("expected the link spelled out in the plain message, got %s", plain)
+	}
+}
@@ -0,0 +1,103 @@
+package gemquick
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/jimmitjoo/gemquick/policies"
+)
+
+// Errors that handlers and models return, wrapped with fmt.Errorf("...: %w", ErrNotFound) where more
+// context helps. WriteError turns them into the matching HTTP status
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrConflict     = errors.New("conflict")
+)
+
+// ValidationError holds the failed fields of a Validation, and matches ErrValidation with errors.Is
+type ValidationError struct {
+	Errors map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Errors))
+	for field, message := range e.Errors {
+		fields = append(fields, field+": "+message)
+	}
+	sort.Strings(fields)
+
+	return ErrValidation.Error() + ": " + strings.Join(fields, ", ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Err returns a *ValidationError when there are validation errors, and nil otherwise
+func (v *Validation) Err() error {
+	if v.Valid() {
+		return nil
+	}
+
+	return &ValidationError{Errors: v.Errors}
+}
+
+// HTTPStatus returns the status code that fits err. sql.ErrNoRows counts as ErrNotFound and
+// policies.ErrForbidden as ErrForbidden, anything unknown is a 500 Internal Server Error
+func HTTPStatus(err error) int {
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, ErrNotFound), errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden), errors.Is(err, policies.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// WriteError answers with the status HTTPStatus picks for err. JSON clients get the error message,
+// and the failed fields for validation errors, while internal errors are logged and never shown
+func (g *Gemquick) WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	status := HTTPStatus(err)
+	message := http.StatusText(status)
+
+	if status == http.StatusInternalServerError {
+		g.ErrorLog.Println(err)
+	} else {
+		message = err.Error()
+	}
+
+	if !wantsJSON(r) {
+		g.ErrorStatus(w, status)
+		return
+	}
+
+	payload := map[string]interface{}{"error": message}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		payload["errors"] = validationErr.Errors
+	}
+
+	_ = g.WriteJson(w, status, payload)
+}
+
+func wantsJSON(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/") ||
+		strings.Contains(r.Header.Get("Accept"), "application/json") ||
+		strings.Contains(r.Header.Get("Content-Type"), "application/json")
+}
@@ -0,0 +1,73 @@
+package gemquick
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jimmitjoo/gemquick/policies"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	var tests = []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"nil", nil, http.StatusOK},
+		{"not found", fmt.Errorf("user 12: %w", ErrNotFound), http.StatusNotFound},
+		{"no rows", fmt.Errorf("finding user: %w", sql.ErrNoRows), http.StatusNotFound},
+		{"validation", &ValidationError{Errors: map[string]string{"email": "is required"}}, http.StatusUnprocessableEntity},
+		{"unauthorized", ErrUnauthorized, http.StatusUnauthorized},
+		{"forbidden", ErrForbidden, http.StatusForbidden},
+		{"policy", policies.ErrForbidden, http.StatusForbidden},
+		{"conflict", fmt.Errorf("email taken: %w", ErrConflict), http.StatusConflict},
+		{"unknown", errors.New("disk on fire"), http.StatusInternalServerError},
+	}
+
+	for _, e := range tests {
+		if got := HTTPStatus(e.err); got != e.expected {
+			t.Errorf("%s: expected %d, got %d", e.name, e.expected, got)
+		}
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	g := &Gemquick{ErrorLog: log.New(io.Discard, "", 0)}
+
+	v := g.Validator(nil)
+	v.AddError("email", "is required")
+
+	r := httptest.NewRequest("POST", "/api/users", nil)
+	w := httptest.NewRecorder()
+	g.WriteError(w, r, v.Err())
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422, got %d", w.Code)
+	}
+
+	var payload struct {
+		Error  string            `json:"error"`
+		Errors map[string]string `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	if payload.Errors["email"] != "is required" {
+		t.Errorf("expected field errors in %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	g.WriteError(w, httptest.NewRequest("GET", "/api/users", nil), errors.New("password=hunter2"))
+
+	if w.Code != http.StatusInternalServerError || json.Unmarshal(w.Body.Bytes(), &payload) != nil || payload.Error != "Internal Server Error" {
+		t.Errorf("expected internal errors to be hidden, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,165 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jimmitjoo/gemquick/pool"
+)
+
+// Event is an interface that defines the method an event must implement.
+// Listeners are registered for the name an event returns
+type Event interface {
+	Name() string
+}
+
+// Listener is an interface that defines the method a listener must implement
+type Listener interface {
+	Handle(event Event) error
+}
+
+// ContextListener is a listener that also wants the context the event was dispatched with.
+// For queued listeners the context is rebuilt in the worker and carries the request id of
+// the dispatching request, which chi's middleware.GetReqID reads back
+type ContextListener interface {
+	HandleContext(ctx context.Context, event Event) error
+}
+
+// ListenerFunc lets an ordinary function be used as a Listener
+type ListenerFunc func(event Event) error
+
+func (f ListenerFunc) Handle(event Event) error {
+	return f(event)
+}
+
+// Job is a queued listener waiting to handle an event. RequestID is the id of the request
+// that dispatched the event, if any
+type Job struct {
+	Event     Event
+	Listener  Listener
+	RequestID string
+}
+
+// Dispatcher delivers events to the listeners registered for them. Listeners registered with
+// Listen run during Dispatch, listeners registered with ListenQueued are sent to the Jobs channel
+// and run by ListenForEvents in the background
+type Dispatcher struct {
+	Jobs     chan Job
+	ErrorLog *log.Logger
+
+	mu       sync.RWMutex
+	sync     map[string][]Listener
+	queued   map[string][]Listener
+	inFlight sync.WaitGroup
+}
+
+// New returns a dispatcher whose queue can hold size jobs before Dispatch blocks
+func New(size int) *Dispatcher {
+	return &Dispatcher{
+		Jobs:     make(chan Job, size),
+		ErrorLog: log.New(os.Stdout, "ERROR\t", log.Ldate|log.Ltime|log.Lshortfile),
+		sync:     make(map[string][]Listener),
+		queued:   make(map[string][]Listener),
+	}
+}
+
+// Listen registers a listener that handles the named event during Dispatch
+func (d *Dispatcher) Listen(name string, listener Listener) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.sync[name] = append(d.sync[name], listener)
+}
+
+// ListenQueued registers a listener that handles the named event in the background
+func (d *Dispatcher) ListenQueued(name string, listener Listener) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.queued[name] = append(d.queued[name], listener)
+}
+
+// HasListeners reports whether anything listens for the named event
+func (d *Dispatcher) HasListeners(name string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return len(d.sync[name]) > 0 || len(d.queued[name]) > 0
+}
+
+// Dispatch queues event for its queued listeners and then runs its other listeners in the order
+// they were registered. Every listener runs even if an earlier one fails, and their errors are joined
+func (d *Dispatcher) Dispatch(event Event) error {
+	return d.DispatchContext(context.Background(), event)
+}
+
+// DispatchContext works like Dispatch, and stores the request id found in ctx with the
+// queued jobs so their failures are logged with the request that caused them
+func (d *Dispatcher) DispatchContext(ctx context.Context, event Event) error {
+	requestID := middleware.GetReqID(ctx)
+
+	d.mu.RLock()
+	listeners := d.sync[event.Name()]
+	queued := d.queued[event.Name()]
+	d.mu.RUnlock()
+
+	for _, listener := range queued {
+		d.inFlight.Add(1)
+		d.Jobs <- Job{Event: event, Listener: listener, RequestID: requestID}
+	}
+
+	var errs []error
+	for _, listener := range listeners {
+		if err := handle(ctx, listener, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s listener: %w", event.Name(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ListenForEvents runs queued listeners as their jobs arrive, logging the ones that fail
+func (d *Dispatcher) ListenForEvents() {
+	for job := range d.Jobs {
+		d.run(job)
+	}
+}
+
+// Wait blocks until every queued job dispatched so far has been handled
+func (d *Dispatcher) Wait() {
+	d.inFlight.Wait()
+}
+
+func (d *Dispatcher) run(job Job) {
+	defer d.inFlight.Done()
+
+	ctx := context.Background()
+	prefix := ""
+	if job.RequestID != "" {
+		ctx = context.WithValue(ctx, middleware.RequestIDKey, job.RequestID)
+		prefix = fmt.Sprintf("request %s: ", job.RequestID)
+	}
+
+	var err error
+	if p := pool.Recover(func() { err = handle(ctx, job.Listener, job.Event) }); p != nil {
+		d.ErrorLog.Printf("%squeued %s listener panicked: %v", prefix, job.Event.Name(), p.Value)
+		return
+	}
+
+	if err != nil {
+		d.ErrorLog.Printf("%squeued %s listener: %v", prefix, job.Event.Name(), err)
+	}
+}
+
+func handle(ctx context.Context, listener Listener, event Event) error {
+	if cl, ok := listener.(ContextListener); ok {
+		return cl.HandleContext(ctx, event)
+	}
+
+	return listener.Handle(event)
+}
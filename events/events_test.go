@@ -0,0 +1,119 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type userRegistered struct {
+	Email string
+}
+
+func (e userRegistered) Name() string {
+	return "user.registered"
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	d := New(10)
+
+	var handled []string
+	d.Listen("user.registered", ListenerFunc(func(event Event) error {
+		handled = append(handled, "first:"+event.(userRegistered).Email)
+		return nil
+	}))
+	d.Listen("user.registered", ListenerFunc(func(event Event) error {
+		handled = append(handled, "second")
+		return errors.New("could not send welcome mail")
+	}))
+
+	err := d.Dispatch(userRegistered{Email: "me@here.com"})
+	if err == nil {
+		t.Error("expected the error of the failing listener to be returned")
+	}
+
+	if len(handled) != 2 || handled[0] != "first:me@here.com" || handled[1] != "second" {
+		t.Error("listeners did not run in order, got", handled)
+	}
+
+	if !d.HasListeners("user.registered") {
+		t.Error("expected listeners for user.registered")
+	}
+
+	if d.HasListeners("user.deleted") {
+		t.Error("did not expect listeners for user.deleted")
+	}
+}
+
+func TestDispatcher_DispatchQueued(t *testing.T) {
+	d := New(10)
+	go d.ListenForEvents()
+	defer close(d.Jobs)
+
+	var count int32
+	d.ListenQueued("user.registered", ListenerFunc(func(event Event) error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	}))
+	d.ListenQueued("user.registered", ListenerFunc(func(event Event) error {
+		panic("listener exploded")
+	}))
+
+	for i := 0; i < 3; i++ {
+		err := d.Dispatch(userRegistered{})
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	d.Wait()
+
+	if atomic.LoadInt32(&count) != 3 {
+		t.Errorf("expected 3 queued runs, got %d", count)
+	}
+}
+
+type contextListener struct {
+	requestID string
+}
+
+func (l *contextListener) Handle(event Event) error {
+	return nil
+}
+
+func (l *contextListener) HandleContext(ctx context.Context, event Event) error {
+	l.requestID = middleware.GetReqID(ctx)
+	return errors.New("mailbox full")
+}
+
+func TestDispatcher_DispatchContext(t *testing.T) {
+	d := New(10)
+	var logged bytes.Buffer
+	d.ErrorLog = log.New(&logged, "", 0)
+	go d.ListenForEvents()
+	defer close(d.Jobs)
+
+	listener := &contextListener{}
+	d.ListenQueued("user.registered", listener)
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "host/abc-000001")
+	if err := d.DispatchContext(ctx, userRegistered{}); err != nil {
+		t.Error(err)
+	}
+
+	d.Wait()
+
+	if listener.requestID != "host/abc-000001" {
+		t.Errorf("expected the request id to reach the queued listener, got %q", listener.requestID)
+	}
+
+	if !strings.Contains(logged.String(), "request host/abc-000001: queued user.registered listener: mailbox full") {
+		t.Errorf("expected the failure to be logged with the request id, got %q", logged.String())
+	}
+}
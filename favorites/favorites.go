@@ -0,0 +1,175 @@
+// Package favorites keeps what users favorite or bookmark, like a post or a product, in the
+// favorites table. What is favorited is a polymorphic reference, a type and an id, so one table
+// holds the favorites of every kind of model
+package favorites
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/database"
+)
+
+// Store keeps the favorites in the table Table, favorites when it is empty. Favorited models are
+// given as a database.Ref or as a model with an ID, see database.RefTo
+type Store struct {
+	DB       *sql.DB
+	DataType string
+	Table    string
+}
+
+// New returns a store for the favorites table of db, which is of the given DATABASE_TYPE
+func New(db *sql.DB, dataType string) *Store {
+	return &Store{DB: db, DataType: dataType}
+}
+
+// Add makes model a favorite of the user, which it may already be
+func (s *Store) Add(ctx context.Context, userID int64, model interface{}) error {
+	ref, err := database.RefTo(model)
+	if err != nil {
+		return err
+	}
+
+	return database.Upsert(ctx, s.DB, s.DataType, s.table(),
+		map[string]interface{}{"user_id": userID, "favoritable_type": ref.Type, "favoritable_id": ref.ID, "created_at": time.Now()},
+		[]string{"user_id", "favoritable_type", "favoritable_id"}, nil)
+}
+
+// Remove makes model no longer a favorite of the user
+func (s *Store) Remove(ctx context.Context, userID int64, model interface{}) error {
+	ref, err := database.RefTo(model)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.DB.ExecContext(ctx, s.query("DELETE FROM %s WHERE user_id = ? AND favoritable_type = ? AND favoritable_id = ?"),
+		userID, ref.Type, ref.ID)
+
+	return err
+}
+
+// Toggle adds model to the user's favorites, or removes it when it is one, and reports whether
+// it is a favorite now
+func (s *Store) Toggle(ctx context.Context, userID int64, model interface{}) (bool, error) {
+	ref, err := database.RefTo(model)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := s.DB.ExecContext(ctx, s.query("DELETE FROM %s WHERE user_id = ? AND favoritable_type = ? AND favoritable_id = ?"),
+		userID, ref.Type, ref.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		return false, nil
+	}
+
+	return true, s.Add(ctx, userID, ref)
+}
+
+// Has reports whether model is a favorite of the user
+func (s *Store) Has(ctx context.Context, userID int64, model interface{}) (bool, error) {
+	ref, err := database.RefTo(model)
+	if err != nil {
+		return false, err
+	}
+
+	var n int64
+	err = s.DB.QueryRowContext(ctx, s.query("SELECT COUNT(*) FROM %s WHERE user_id = ? AND favoritable_type = ? AND favoritable_id = ?"),
+		userID, ref.Type, ref.ID).Scan(&n)
+
+	return n > 0, err
+}
+
+// Count returns how many users have model as a favorite
+func (s *Store) Count(ctx context.Context, model interface{}) (int64, error) {
+	ref, err := database.RefTo(model)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	err = s.DB.QueryRowContext(ctx, s.query("SELECT COUNT(*) FROM %s WHERE favoritable_type = ? AND favoritable_id = ?"),
+		ref.Type, ref.ID).Scan(&n)
+
+	return n, err
+}
+
+// Of returns the user's favorites of type modelType, newest first
+func (s *Store) Of(ctx context.Context, userID int64, modelType string) ([]database.Ref, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		s.query("SELECT favoritable_type, favoritable_id FROM %s WHERE user_id = ? AND favoritable_type = ? ORDER BY created_at DESC"),
+		userID, modelType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refs := []database.Ref{}
+	for rows.Next() {
+		var ref database.Ref
+		if err := rows.Scan(&ref.Type, &ref.ID); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, rows.Err()
+}
+
+// WhereFavoritedBy returns the condition and arguments that select the models of type modelType
+// the user favorited, to add to a query of their table with ? placeholders:
+//
+//	where, args := app.Favorites.WhereFavoritedBy(userID, "post")
+//	rows, err := db.Query(database.Rebind(dataType, "SELECT * FROM posts WHERE "+where), args...)
+func (s *Store) WhereFavoritedBy(userID int64, modelType string) (string, []interface{}) {
+	return fmt.Sprintf("id IN (SELECT favoritable_id FROM %s WHERE user_id = ? AND favoritable_type = ?)", s.table()),
+		[]interface{}{userID, modelType}
+}
+
+// ToggleHandler answers a favorite button: it toggles the model that target finds in the request
+// for the user it returns, and answers with {"favorited": true, "count": 12}. An error from target
+// answers 404
+func (s *Store) ToggleHandler(target func(r *http.Request) (userID int64, model interface{}, err error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, model, err := target(r)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		favorited, err := s.Toggle(r.Context(), userID, model)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		count, err := s.Count(r.Context(), model)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"favorited": favorited, "count": count})
+	}
+}
+
+func (s *Store) table() string {
+	if s.Table == "" {
+		return "favorites"
+	}
+
+	return s.Table
+}
+
+// query puts the table into a query and numbers its placeholders for postgres
+func (s *Store) query(format string) string {
+	return database.Rebind(s.DataType, fmt.Sprintf(format, s.table()))
+}
@@ -0,0 +1,75 @@
+package favorites
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jimmitjoo/gemquick/database"
+)
+
+func newStore(t *testing.T, dataType string) (*Store, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return New(db, dataType), mock
+}
+
+func TestStore_Toggle(t *testing.T) {
+	s, mock := newStore(t, "pgx")
+	product := database.Ref{Type: "product", ID: 3}
+
+	// not a favorite yet, so it is added
+	mock.ExpectExec(`DELETE FROM favorites WHERE user_id = \$1 AND favoritable_type = \$2 AND favoritable_id = \$3`).
+		WithArgs(int64(1), "product", int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO favorites .* ON CONFLICT \(user_id, favoritable_type, favoritable_id\) DO NOTHING`).
+		WithArgs(sqlmock.AnyArg(), int64(3), "product", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// a favorite, so it is removed
+	mock.ExpectExec(`DELETE FROM favorites`).
+		WithArgs(int64(1), "product", int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if on, err := s.Toggle(context.Background(), 1, product); !on || err != nil {
+		t.Errorf("expected the product to become a favorite, got %v, %v", on, err)
+	}
+
+	if on, err := s.Toggle(context.Background(), 1, product); on || err != nil {
+		t.Errorf("expected the product to be removed from the favorites, got %v, %v", on, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStore_ToggleHandler(t *testing.T) {
+	s, mock := newStore(t, "mysql")
+
+	mock.ExpectExec(`DELETE FROM favorites`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO favorites .* ON DUPLICATE KEY UPDATE user_id = user_id`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM favorites WHERE favoritable_type = \? AND favoritable_id = \?`).
+		WithArgs("post", int64(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+	handler := s.ToggleHandler(func(r *http.Request) (int64, interface{}, error) {
+		return 1, database.Ref{Type: "post", ID: 9}, nil
+	})
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest("POST", "/posts/9/favorite", nil))
+
+	if rr.Code != http.StatusOK || strings.TrimSpace(rr.Body.String()) != `{"count":4,"favorited":true}` {
+		t.Errorf("unexpected response %d: %s", rr.Code, rr.Body.String())
+	}
+}
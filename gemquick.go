@@ -1,16 +1,37 @@
 package gemquick
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"github.com/jimmitjoo/gemquick/captcha"
+	"github.com/jimmitjoo/gemquick/debugbar"
+	"github.com/jimmitjoo/gemquick/events"
+	"github.com/jimmitjoo/gemquick/filesystems"
 	"github.com/jimmitjoo/gemquick/filesystems/miniofilesystem"
 	"github.com/jimmitjoo/gemquick/filesystems/s3filesystem"
+	"github.com/jimmitjoo/gemquick/honeypot"
+	"github.com/jimmitjoo/gemquick/metrics"
+	"github.com/jimmitjoo/gemquick/notifications"
+	"github.com/jimmitjoo/gemquick/openapi"
+	"github.com/jimmitjoo/gemquick/policies"
+	"github.com/jimmitjoo/gemquick/pool"
+	"github.com/jimmitjoo/gemquick/prune"
+	"github.com/jimmitjoo/gemquick/ratelimit"
 	"github.com/jimmitjoo/gemquick/sms"
+	"github.com/jimmitjoo/gemquick/websocket"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/CloudyKit/jet/v6"
@@ -18,10 +39,17 @@ import (
 	"github.com/dgraph-io/badger/v3"
 	"github.com/go-chi/chi/v5"
 	"github.com/gomodule/redigo/redis"
+	"github.com/jimmitjoo/gemquick/activity"
+	"github.com/jimmitjoo/gemquick/assetcache"
 	"github.com/jimmitjoo/gemquick/cache"
+	"github.com/jimmitjoo/gemquick/database"
 	"github.com/jimmitjoo/gemquick/email"
+	"github.com/jimmitjoo/gemquick/favorites"
 	"github.com/jimmitjoo/gemquick/render"
+	"github.com/jimmitjoo/gemquick/reputation"
 	"github.com/jimmitjoo/gemquick/session"
+	"github.com/jimmitjoo/gemquick/settings"
+	"github.com/jimmitjoo/gemquick/tags"
 	"github.com/joho/godotenv"
 	"github.com/robfig/cron/v3"
 )
@@ -34,25 +62,59 @@ var redisPool *redis.Pool
 var badgerConn *badger.DB
 
 type Gemquick struct {
-	AppName       string
-	Debug         bool
-	Version       string
-	ErrorLog      *log.Logger
-	InfoLog       *log.Logger
-	RootPath      string
-	Routes        *chi.Mux
-	Render        *render.Render
-	Session       *scs.SessionManager
-	DB            Database
-	JetViews      *jet.Set
-	config        config
-	EncryptionKey string
-	Cache         cache.Cache
-	Scheduler     *cron.Cron
-	SMSProvider   sms.SMSProvider
-	Mail          email.Mail
-	Server        Server
-	FileSystems   map[string]interface{}
+	AppName        string
+	Debug          bool
+	Version        string
+	ErrorLog       *log.Logger
+	InfoLog        *log.Logger
+	RootPath       string
+	MigrationsPath string
+	Routes         *chi.Mux
+	Render         *render.Render
+	Session        *scs.SessionManager
+	Cookies        CookiePolicy
+	DB             Database
+	JetViews       *jet.Set
+	config         config
+	EncryptionKey  string
+	Cache          cache.Cache
+	Scheduler      *cron.Cron
+	SMSProvider    sms.SMSProvider
+	Mail           email.Mail
+	Server         Server
+	FileSystems    map[string]interface{}
+	Policies       *policies.Policies
+	Events         *events.Dispatcher
+	Notifications  *notifications.Notifier
+	Settings       *settings.Store
+	Activity       *activity.Logger
+	Tags           *tags.Store
+	Favorites      *favorites.Store
+	Pruner         *prune.Pruner
+	Metrics        *metrics.Registry
+	RateLimiter    RateLimitResetter
+	Guard          *ratelimit.Guard
+	Penalties      *ratelimit.Penalties
+	Reputation     *reputation.Service
+	Assets         *assetcache.Store
+	Captcha        *captcha.Provider
+	Honeypot       *honeypot.Trap
+	Hub            *websocket.Hub
+	LoadShedder    *LoadShedder
+	RequestTimeout time.Duration
+	OpenAPI        *openapi.Spec
+	dbPending      int32
+	dbHooks        *database.Hooks
+	debugLogs      *debugbar.Recorder
+	debugCache     *debugbar.Recorder
+	debugTail      string
+	warmupHooks    []warmupHook
+	warmupState    int32
+	warmupRetry    []warmupHook
+	listener       *connListener
+	grpcServer     GRPCServer
+	stopWorkers    context.CancelFunc
+	mailDone       <-chan struct{}
 }
 
 type Server struct {
@@ -98,10 +160,27 @@ func (g *Gemquick) New(rootPath string) error {
 
 	// create loggers
 	infoLog, errorLog := g.startLoggers()
+	g.InfoLog = infoLog
+	g.ErrorLog = errorLog
 
 	// connect to database
 	if os.Getenv("DATABASE_TYPE") != "" {
-		db, err := g.OpenDB(os.Getenv("DATABASE_TYPE"), g.BuildDSN())
+		retries, err := strconv.Atoi(os.Getenv("DATABASE_CONNECT_RETRIES"))
+		if err != nil {
+			retries = 3
+		}
+
+		backoff, err := time.ParseDuration(os.Getenv("DATABASE_CONNECT_BACKOFF"))
+		if err != nil {
+			backoff = time.Second
+		}
+
+		var db *sql.DB
+		if lazy, _ := strconv.ParseBool(os.Getenv("DATABASE_LAZY")); lazy {
+			db, err = g.OpenDBLazy(os.Getenv("DATABASE_TYPE"), backoff, g.BuildDSN(), g.FailoverDSNs()...)
+		} else {
+			db, err = g.OpenDBWithRetry(os.Getenv("DATABASE_TYPE"), retries, backoff, g.BuildDSN(), g.FailoverDSNs()...)
+		}
 
 		if err != nil {
 			errorLog.Println(err)
@@ -112,6 +191,14 @@ func (g *Gemquick) New(rootPath string) error {
 			DataType:    os.Getenv("DATABASE_TYPE"),
 			Pool:        db,
 			TablePrefix: os.Getenv("DATABASE_TABLE_PREFIX"),
+			Hooks:       g.queryHooks(),
+		}
+
+		g.DB.Retry = g.createRetry()
+
+		// statements that take DATABASE_SLOW_QUERY or longer, e.g. 500ms, are logged
+		if threshold, _ := time.ParseDuration(os.Getenv("DATABASE_SLOW_QUERY")); threshold > 0 {
+			g.DB.Hooks.Add(database.SlowQueryLogger{Threshold: threshold, Log: g.ErrorLog})
 		}
 	}
 
@@ -142,13 +229,62 @@ func (g *Gemquick) New(rootPath string) error {
 		}
 	}
 
-	g.InfoLog = infoLog
-	g.ErrorLog = errorLog
+	// counters are saved to the cache on METRICS_SNAPSHOT, e.g. @every 1m, and restored from it at boot
+	g.Metrics = metrics.New()
+	g.poolMetrics(g.DB.Pool)
+	if spec := os.Getenv("METRICS_SNAPSHOT"); spec != "" {
+		if g.Cache == nil {
+			return errors.New("METRICS_SNAPSHOT needs CACHE to be redis or badger")
+		}
+		if err := g.Metrics.Restore(g.Cache); err != nil {
+			g.ErrorLog.Println("restoring the metrics snapshot:", err)
+		}
+		err = g.Metrics.SaveOn(g.Scheduler, spec, g.Cache, g.ErrorLog)
+		if err != nil {
+			return err
+		}
+	}
+
 	g.Debug, _ = strconv.ParseBool(os.Getenv("DEBUG"))
 	g.Version = version
 	g.RootPath = rootPath
+	g.LoadShedder = g.createLoadShedder()
+	g.RequestTimeout, _ = time.ParseDuration(os.Getenv("REQUEST_TIMEOUT"))
+	g.Guard = g.createGuard()
+	g.RateLimiter = g.Guard
+	g.Penalties = ratelimit.NewPenalties()
+	g.Guard.Penalties = g.Penalties
+	g.Reputation = g.createReputation(g.Penalties)
+
+	g.Assets, err = g.createAssetCache(rootPath)
+	if err != nil {
+		return err
+	}
+
+	g.Captcha, err = g.createCaptcha()
+	if err != nil {
+		return err
+	}
+
+	g.OpenAPI, err = g.createOpenAPI()
+	if err != nil {
+		return err
+	}
+
+	if g.debugToolbarEnabled() {
+		g.setupDebugToolbar()
+	}
+
 	g.Routes = g.routes().(*chi.Mux)
 
+	// with Server-Timing on, the queries run with a request's context count towards its db time
+	if g.serverTimingEnabled() && g.DB.Hooks != nil {
+		g.DB.Hooks.Add(queryTiming{})
+	}
+	if g.debugToolbarEnabled() && g.DB.Hooks != nil {
+		g.DB.Hooks.Add(queryToolbar{})
+	}
+
 	g.config = config{
 		port:     os.Getenv("PORT"),
 		renderer: os.Getenv("RENDERER"),
@@ -156,8 +292,6 @@ func (g *Gemquick) New(rootPath string) error {
 			name:     os.Getenv("COOKIE_NAME"),
 			lifetime: os.Getenv("COOKIE_LIFETIME"),
 			persist:  os.Getenv("COOKIE_PERSISTS"),
-			secure:   os.Getenv("COOKIE_SECURE"),
-			domain:   os.Getenv("COOKIE_DOMAIN"),
 		},
 		sessionType: os.Getenv("SESSION_TYPE"),
 		database: databaseConfig{
@@ -184,25 +318,56 @@ func (g *Gemquick) New(rootPath string) error {
 		URL:        os.Getenv("APP_URL"),
 	}
 
+	// the attributes of the cookies follow APP_ENV, unless they are set one by one
+	g.Cookies, err = g.createCookiePolicy()
+	if err != nil {
+		return err
+	}
+
 	// create a session
 	sess := session.Session{
 		CookieLifetime: g.config.cookie.lifetime,
 		CookiePersist:  g.config.cookie.persist,
-		CookieName:     g.config.cookie.name,
+		CookieName:     g.Cookies.Name(g.config.cookie.name),
 		SessionType:    g.config.sessionType,
-		CookieDomain:   g.config.cookie.domain,
+		CookieDomain:   g.Cookies.Domain,
+		CookieSecure:   strconv.FormatBool(g.Cookies.Secure),
 		DBPool:         g.DB.Pool,
 	}
 
+	// sessions in redis, badger or a database are encrypted with KEY on SESSION_ENCRYPT=true,
+	// the keys of KEY_PREVIOUS still decrypt the ones from before make key rotate, and the ones
+	// from before encryption are only read until the date of SESSION_PLAINTEXT_UNTIL
+	if encrypt, _ := strconv.ParseBool(os.Getenv("SESSION_ENCRYPT")); encrypt {
+		if os.Getenv("KEY") == "" {
+			return errors.New("SESSION_ENCRYPT needs KEY to be set")
+		}
+		sess.EncryptionKey = os.Getenv("KEY")
+		sess.PreviousKeys = splitList(os.Getenv("KEY_PREVIOUS"))
+
+		if until := os.Getenv("SESSION_PLAINTEXT_UNTIL"); until != "" {
+			date, err := time.Parse("2006-01-02", until)
+			if err != nil {
+				return fmt.Errorf("SESSION_PLAINTEXT_UNTIL is not a date like 2006-01-02: %w", err)
+			}
+			sess.PlaintextUntil = date
+		}
+	}
+
 	switch g.config.sessionType {
 	case "redis":
 		sess.RedisPool = myRedisCache.Conn
-	case "mysql", "postgres", "mariadb", "postgresql", "pgx":
+	case "badger":
+		sess.BadgerConn = badgerConn
+	case "mysql", "postgres", "mariadb", "postgresql", "pgx", "sqlite", "sqlite3", "sqlserver", "mssql":
 		sess.DBPool = g.DB.Pool
 	}
 
 	g.Session = sess.InitSession()
+	g.Session.Cookie.HttpOnly = g.Cookies.HttpOnly
+	g.Session.Cookie.SameSite = g.Cookies.SameSite
 	g.EncryptionKey = os.Getenv("KEY")
+	g.Honeypot = g.createHoneypot()
 
 	var views *jet.Set
 	if g.Debug {
@@ -221,13 +386,101 @@ func (g *Gemquick) New(rootPath string) error {
 
 	g.createRenderer()
 
+	// in production a broken template stops the app from booting, instead of failing the first request that uses it
+	if !g.Debug {
+		stats, err := g.Render.Precompile()
+		if err != nil {
+			return fmt.Errorf("compiling templates: %w", err)
+		}
+		g.InfoLog.Printf("Compiled %d templates in %s (slowest %s in %s)", stats.Templates, stats.Duration, stats.Slowest, stats.SlowestDuration)
+	}
+
 	g.FileSystems = g.createFileSystems()
 
 	g.SMSProvider = sms.CreateSMSProvider(os.Getenv("SMS_PROVIDER"))
 
 	g.Mail = g.createMailer()
 
-	go g.Mail.ListenForMail()
+	// panics in goroutines started by the framework end up in the error log instead of crashing the app
+	pool.PanicHandler = func(err *pool.PanicError) {
+		g.ErrorLog.Println(err)
+	}
+
+	g.Policies = policies.New()
+
+	g.Events = events.New(100)
+	g.Events.ErrorLog = g.ErrorLog
+
+	g.Notifications = g.createNotifier()
+
+	// settings.Get reads the app's settings table, see gq make settings
+	if g.DB.Pool != nil {
+		g.Settings = &settings.Store{DB: g.DB.Pool, DataType: g.DB.DataType, Table: g.DB.TablePrefix + "settings"}
+		settings.Use(g.Settings)
+
+		// activities are pruned daily once they are older than ACTIVITY_RETENTION, e.g. 2160h
+		g.Activity = activity.New(g.DB.Pool, g.DB.DataType)
+		g.Activity.Table = g.DB.TablePrefix + "activities"
+		g.Activity.ErrorLog = g.ErrorLog
+		if retention, _ := time.ParseDuration(os.Getenv("ACTIVITY_RETENTION")); retention > 0 {
+			err = g.Activity.PruneOn(g.Scheduler, "@daily", retention)
+			if err != nil {
+				return err
+			}
+		}
+
+		g.Tags = &tags.Store{DB: g.DB.Pool, DataType: g.DB.DataType, Table: g.DB.TablePrefix + "taggables"}
+		g.Favorites = &favorites.Store{DB: g.DB.Pool, DataType: g.DB.DataType, Table: g.DB.TablePrefix + "favorites"}
+
+		// models registered with app.Pruner.Register are pruned on PRUNE_SCHEDULE, daily by default
+		g.Pruner = prune.New(g.DB.Pool, g.DB.DataType)
+		g.Pruner.InfoLog = g.InfoLog
+		g.Pruner.ErrorLog = g.ErrorLog
+		if dir := os.Getenv("ARCHIVE_DIR"); dir != "" {
+			// pruned rows are archived to ARCHIVE_DIR first, and uploaded to ARCHIVE_FILESYSTEM when it is set
+			g.Pruner.Archiver = &prune.Archiver{Dir: dir, Folder: os.Getenv("ARCHIVE_FOLDER")}
+			if name := os.Getenv("ARCHIVE_FILESYSTEM"); name != "" {
+				g.Pruner.Archiver.FS = g.fileSystem(name)
+				if g.Pruner.Archiver.FS == nil {
+					return fmt.Errorf("ARCHIVE_FILESYSTEM %s is not configured", name)
+				}
+			}
+		}
+		pruneSchedule := os.Getenv("PRUNE_SCHEDULE")
+		if pruneSchedule == "" {
+			pruneSchedule = "@daily"
+		}
+		err = g.Pruner.PruneOn(g.Scheduler, pruneSchedule)
+		if err != nil {
+			return err
+		}
+
+		// materialized views are refreshed on the cron spec in MATERIALIZED_VIEWS_REFRESH, e.g. @hourly
+		if spec := os.Getenv("MATERIALIZED_VIEWS_REFRESH"); spec != "" {
+			_, err = g.Scheduler.AddFunc(spec, func() {
+				_, err := database.RefreshMaterializedViews(context.Background(), g.DB.Pool, g.DB.DataType)
+				if err != nil {
+					g.ErrorLog.Println("refreshing materialized views:", err)
+				}
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	g.registerWarmups()
+
+	// the mail listener is restarted if it panics, and drains its queue when Shutdown is called
+	var workers context.Context
+	workers, g.stopWorkers = context.WithCancel(context.Background())
+	g.mailDone = pool.Supervise(workers, time.Second, g.Mail.ListenForMailContext)
+
+	pool.SafeGo(g.Events.ListenForEvents)
+
+	g.Hub = websocket.New(100)
+	g.Hub.ErrorLog = g.ErrorLog
+	pool.SafeGo(g.Hub.ListenForBroadcasts)
 
 	return nil
 }
@@ -284,9 +537,77 @@ func (g *Gemquick) ListenAndServe() {
 		}(badgerConn)
 	}
 
+	if state := atomic.LoadInt32(&g.warmupState); state == warmupPending || state == warmupFailed {
+		pool.SafeGo(func() {
+			g.warmupUntilDone(warmupBackoff)
+		})
+	}
+
+	l, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		g.ErrorLog.Fatal(err)
+	}
+
+	_, err = g.startGRPC()
+	if err != nil {
+		g.ErrorLog.Fatal(err)
+	}
+
+	maxConnections, _ := strconv.ParseInt(os.Getenv("MAX_CONNECTIONS"), 10, 64)
+	g.listener = newConnListener(l, maxConnections)
+
+	// on SIGINT or SIGTERM, finish the requests in flight and the queued mail before exiting
+	stopped := make(chan struct{})
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		<-quit
+
+		g.InfoLog.Println("Shutting down")
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := srv.Shutdown(ctx); err != nil {
+			g.ErrorLog.Println(err)
+		}
+		g.stopGRPC()
+		if err := g.Shutdown(ctx); err != nil {
+			g.ErrorLog.Println(err)
+		}
+		close(stopped)
+	}()
+
 	g.InfoLog.Printf("Listening on port %s", os.Getenv("PORT"))
-	err := srv.ListenAndServe()
-	g.ErrorLog.Fatal(err)
+	err = srv.Serve(g.listener)
+	if !errors.Is(err, http.ErrServerClosed) {
+		g.ErrorLog.Fatal(err)
+	}
+
+	<-stopped
+}
+
+// Shutdown stops the background workers started by New. The mail listener sends the
+// messages still queued first, unless ctx expires before it is done
+func (g *Gemquick) Shutdown(ctx context.Context) error {
+	if g.stopWorkers == nil {
+		return nil
+	}
+
+	g.stopWorkers()
+
+	// the counts since the last scheduled snapshot are saved on the way out
+	if os.Getenv("METRICS_SNAPSHOT") != "" && g.Cache != nil {
+		if err := g.Metrics.Save(g.Cache); err != nil {
+			g.ErrorLog.Println("saving the metrics snapshot:", err)
+		}
+	}
+
+	select {
+	case <-g.mailDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (g *Gemquick) checkDotEnv(path string) error {
@@ -318,6 +639,16 @@ func (g *Gemquick) createRenderer() {
 		Session:  g.Session,
 	}
 
+	if g.Honeypot != nil {
+		myRenderer.Honeypot = func() string {
+			return string(g.Honeypot.Fields())
+		}
+	}
+
+	if !sessionsEnabled() {
+		myRenderer.Session = nil
+	}
+
 	g.Render = &myRenderer
 }
 
@@ -346,6 +677,47 @@ func (g *Gemquick) createMailer() email.Mail {
 	return m
 }
 
+// createOpenAPI loads the document in OPENAPI_SPEC that requests are validated against, if it is set
+func (g *Gemquick) createOpenAPI() (*openapi.Spec, error) {
+	path := os.Getenv("OPENAPI_SPEC")
+	if path == "" {
+		return nil, nil
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(g.RootPath, path)
+	}
+
+	spec, err := openapi.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	spec.ValidateResponses, _ = strconv.ParseBool(os.Getenv("OPENAPI_VALIDATE_RESPONSES"))
+	spec.ErrorLog = g.ErrorLog
+
+	return spec, nil
+}
+
+func (g *Gemquick) createNotifier() *notifications.Notifier {
+	n := notifications.New()
+	n.Register(notifications.Mail, &notifications.MailChannel{Mailer: &g.Mail})
+
+	if g.SMSProvider != nil {
+		n.Register(notifications.SMS, &notifications.SMSChannel{Provider: g.SMSProvider, ErrorLog: g.ErrorLog})
+	}
+
+	if g.DB.Pool != nil {
+		n.Register(notifications.Database, &notifications.DatabaseChannel{
+			DB:       g.DB.Pool,
+			DataType: g.DB.DataType,
+			Table:    g.DB.TablePrefix + "notifications",
+		})
+	}
+
+	return n
+}
+
 func (g *Gemquick) createClientRedisCache() *cache.RedisCache {
 	cacheClient := cache.RedisCache{
 		Conn:   g.createRedisPool(),
@@ -402,21 +774,112 @@ func (g *Gemquick) createBadgerConn() *badger.DB {
 	return db
 }
 
+// BuildDSN builds the dsn of the primary database from the DATABASE_ settings in the environment
 func (g *Gemquick) BuildDSN() string {
+	return BuildDSNFrom(os.Getenv)
+}
+
+// BuildDSNFrom builds the dsn of the primary database from the DATABASE_ settings getenv returns,
+// for tools like gq that read a project's .env without loading it into the environment
+func BuildDSNFrom(getenv func(string) string) string {
+	return buildDSN(getenv, getenv("DATABASE_HOST"), getenv("DATABASE_PORT"))
+}
+
+// FailoverDSNs builds a dsn for every host:port listed in DATABASE_FAILOVER_HOSTS, using the
+// same credentials as the primary database. Hosts without a port use DATABASE_PORT. The hosts
+// are ignored, with an error in the log, for a DATABASE_TYPE that has no dsn to build
+func (g *Gemquick) FailoverDSNs() []string {
+	var dsns []string
+
+	for _, host := range strings.Split(os.Getenv("DATABASE_FAILOVER_HOSTS"), ",") {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+
+		// a host is host:port or [ipv6]:port, or else a host or ipv6 address on DATABASE_PORT
+		port := os.Getenv("DATABASE_PORT")
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			host, port = h, p
+		} else {
+			host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		}
+
+		dsn := buildDSN(os.Getenv, host, port)
+		if dsn == "" || database.Dialect(os.Getenv("DATABASE_TYPE")) == "sqlite" {
+			if g.ErrorLog != nil {
+				g.ErrorLog.Printf("DATABASE_FAILOVER_HOSTS is ignored, failover is not supported for DATABASE_TYPE %s", os.Getenv("DATABASE_TYPE"))
+			}
+			return nil
+		}
+
+		dsns = append(dsns, dsn)
+	}
+
+	return dsns
+}
+
+func buildDSN(getenv func(string) string, host, port string) string {
 	var dsn string
 
-	switch os.Getenv("DATABASE_TYPE") {
+	switch getenv("DATABASE_TYPE") {
 	case "postgres", "postgresql":
 		dsn = fmt.Sprintf("host=%s port=%s user=%s dbname=%s sslmode=%s timezone=UTC connect_timeout=5",
-			os.Getenv("DATABASE_HOST"),
-			os.Getenv("DATABASE_PORT"),
-			os.Getenv("DATABASE_USER"),
-			os.Getenv("DATABASE_NAME"),
-			os.Getenv("DATABASE_SSL_MODE"))
+			host,
+			port,
+			getenv("DATABASE_USER"),
+			getenv("DATABASE_NAME"),
+			getenv("DATABASE_SSL_MODE"))
+
+		if getenv("DATABASE_PASS") != "" {
+			dsn = fmt.Sprintf("%s password=%s", dsn, getenv("DATABASE_PASS"))
+		}
+
+	case "mysql", "mariadb":
+		// DATABASE_SSL_MODE takes the postgres values, anything that asks for tls turns it on
+		tls := "false"
+		if mode := getenv("DATABASE_SSL_MODE"); mode == "require" || strings.HasPrefix(mode, "verify") {
+			tls = "true"
+		}
+
+		dsn = fmt.Sprintf("%s:%s@tcp(%s)/%s?collation=utf8mb4_unicode_ci&timeout=5s&parseTime=true&tls=%s&readTimeout=5s",
+			getenv("DATABASE_USER"),
+			getenv("DATABASE_PASS"),
+			net.JoinHostPort(host, port),
+			getenv("DATABASE_NAME"),
+			tls)
+
+	case "sqlserver", "mssql":
+		// DATABASE_SSL_MODE takes the postgres values as well: disable turns encryption off, require
+		// encrypts without checking the certificate and verify-ca and verify-full check it
+		query := url.Values{}
+		query.Set("database", getenv("DATABASE_NAME"))
+		query.Set("dial timeout", "5")
+		switch mode := getenv("DATABASE_SSL_MODE"); {
+		case mode == "disable":
+			query.Set("encrypt", "disable")
+		case mode == "require":
+			query.Set("encrypt", "true")
+			query.Set("TrustServerCertificate", "true")
+		case strings.HasPrefix(mode, "verify"):
+			query.Set("encrypt", "true")
+		}
 
-		if os.Getenv("DATABASE_PASS") != "" {
-			dsn = fmt.Sprintf("%s password=%s", dsn, os.Getenv("DATABASE_PASS"))
+		if port != "" {
+			host = net.JoinHostPort(host, port)
 		}
+		u := url.URL{Scheme: "sqlserver", User: url.UserPassword(getenv("DATABASE_USER"), getenv("DATABASE_PASS")), Host: host, RawQuery: query.Encode()}
+		dsn = u.String()
+
+	case "sqlite", "sqlite3":
+		// DATABASE_NAME is the database file, which is created when it does not exist. An in-memory
+		// database is shared by the connections of the pool, which would each get their own otherwise
+		name := getenv("DATABASE_NAME")
+		if name == ":memory:" {
+			return "file::memory:?cache=shared&_foreign_keys=on"
+		}
+
+		dsn = fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL", name)
 
 	default:
 	}
@@ -424,6 +887,18 @@ func (g *Gemquick) BuildDSN() string {
 	return dsn
 }
 
+// fileSystem returns the filesystem called name, minio or s3, or nil when it is not configured
+func (g *Gemquick) fileSystem(name string) filesystems.FS {
+	switch fs := g.FileSystems[name].(type) {
+	case miniofilesystem.Minio:
+		return &fs
+	case s3filesystem.S3:
+		return &fs
+	}
+
+	return nil
+}
+
 func (g *Gemquick) createFileSystems() map[string]interface{} {
 	fileSystems := make(map[string]interface{})
 
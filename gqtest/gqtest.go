@@ -0,0 +1,238 @@
+// Package gqtest helps integration tests that run against a real database: TruncateAll empties
+// every table between tests, and TakeSnapshot keeps a copy of the database that Restore puts back
+package gqtest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Skipped are the tables TruncateAll leaves alone, so the migration version survives
+var Skipped = []string{"schema_migrations"}
+
+// dialect tells the databases the helpers know apart by the driver of the pool
+func dialect(db *sql.DB) string {
+	name := strings.ToLower(fmt.Sprintf("%T", db.Driver()))
+
+	switch {
+	case strings.Contains(name, "mysql"):
+		return "mysql"
+	case strings.Contains(name, "sqlite"):
+		return "sqlite"
+	case strings.Contains(name, "stdlib"), strings.Contains(name, "pq."), strings.Contains(name, "pgx"):
+		return "postgres"
+	default:
+		return name
+	}
+}
+
+// TruncateAll empties every table of the database except those in Skipped. Tables are emptied
+// children first, so foreign keys never point at a row that is already gone, and auto increment
+// counters start over
+func TruncateAll(db *sql.DB) error {
+	ctx := context.Background()
+
+	// foreign key checks are set per connection, so everything runs on the same one
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	d := dialect(db)
+
+	tables, err := listTables(ctx, conn, d)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	references, err := listReferences(ctx, conn, d)
+	if err != nil {
+		return err
+	}
+
+	ordered := truncateOrder(tables, references)
+
+	switch d {
+	case "postgres":
+		quoted := make([]string, len(ordered))
+		for i, table := range ordered {
+			quoted[i] = quote(d, table)
+		}
+		_, err = conn.ExecContext(ctx, "TRUNCATE TABLE "+strings.Join(quoted, ", ")+" RESTART IDENTITY CASCADE")
+		return err
+
+	case "mysql":
+		if _, err = conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+			return err
+		}
+		defer conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
+
+		for _, table := range ordered {
+			if _, err = conn.ExecContext(ctx, "TRUNCATE TABLE "+quote(d, table)); err != nil {
+				return fmt.Errorf("truncating %s: %w", table, err)
+			}
+		}
+		return nil
+
+	case "sqlite":
+		for _, table := range ordered {
+			if _, err = conn.ExecContext(ctx, "DELETE FROM "+quote(d, table)); err != nil {
+				return fmt.Errorf("emptying %s: %w", table, err)
+			}
+		}
+		// the counters live in sqlite_sequence, which only exists once a table uses AUTOINCREMENT
+		_, _ = conn.ExecContext(ctx, "DELETE FROM sqlite_sequence")
+		return nil
+	}
+
+	return fmt.Errorf("gqtest: %s databases are not supported", d)
+}
+
+func listTables(ctx context.Context, conn *sql.Conn, d string) ([]string, error) {
+	var query string
+	switch d {
+	case "postgres":
+		query = "SELECT tablename FROM pg_tables WHERE schemaname = current_schema()"
+	case "mysql":
+		query = "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'"
+	case "sqlite":
+		query = "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'"
+	default:
+		return nil, fmt.Errorf("gqtest: %s databases are not supported", d)
+	}
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		if !skipped(table) {
+			tables = append(tables, table)
+		}
+	}
+
+	return tables, rows.Err()
+}
+
+// listReferences returns, for every table with foreign keys, the tables it references
+func listReferences(ctx context.Context, conn *sql.Conn, d string) (map[string][]string, error) {
+	var query string
+	switch d {
+	case "postgres":
+		query = `SELECT tc.table_name, ccu.table_name
+			FROM information_schema.table_constraints tc
+			JOIN information_schema.constraint_column_usage ccu ON ccu.constraint_name = tc.constraint_name
+			WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema()`
+	case "mysql":
+		query = `SELECT table_name, referenced_table_name FROM information_schema.key_column_usage
+			WHERE table_schema = DATABASE() AND referenced_table_name IS NOT NULL`
+	case "sqlite":
+		query = `SELECT m.name, p."table" FROM sqlite_master m JOIN pragma_foreign_key_list(m.name) p
+			WHERE m.type = 'table'`
+	}
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	references := map[string][]string{}
+	for rows.Next() {
+		var table, referenced string
+		if err = rows.Scan(&table, &referenced); err != nil {
+			return nil, err
+		}
+		references[table] = append(references[table], referenced)
+	}
+
+	return references, rows.Err()
+}
+
+// truncateOrder sorts tables so that every table comes before the tables it references.
+// Tables in a reference cycle keep their alphabetical order among each other
+func truncateOrder(tables []string, references map[string][]string) []string {
+	sorted := append([]string(nil), tables...)
+	sort.Strings(sorted)
+
+	listed := map[string]bool{}
+	for _, table := range sorted {
+		listed[table] = true
+	}
+
+	// referencedBy counts the tables that still have to be emptied before a table can be
+	referencedBy := map[string]int{}
+	for _, table := range sorted {
+		for _, referenced := range references[table] {
+			if referenced != table && listed[referenced] {
+				referencedBy[referenced]++
+			}
+		}
+	}
+
+	ordered := make([]string, 0, len(sorted))
+	done := map[string]bool{}
+
+	for len(ordered) < len(sorted) {
+		progress := false
+		for _, table := range sorted {
+			if done[table] || referencedBy[table] > 0 {
+				continue
+			}
+
+			done[table] = true
+			ordered = append(ordered, table)
+			progress = true
+
+			for _, referenced := range references[table] {
+				if referenced != table && listed[referenced] {
+					referencedBy[referenced]--
+				}
+			}
+		}
+
+		if !progress {
+			// a cycle, which only works because foreign key checks are off or cascading
+			for _, table := range sorted {
+				if !done[table] {
+					done[table] = true
+					ordered = append(ordered, table)
+				}
+			}
+		}
+	}
+
+	return ordered
+}
+
+func skipped(table string) bool {
+	for _, s := range Skipped {
+		if strings.EqualFold(s, table) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func quote(d, name string) string {
+	if d == "mysql" {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,264 @@
+package gqtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v4/stdlib"
+)
+
+// Snapshot is a copy of a test database taken with TakeSnapshot. On Postgres the copy is a
+// template database, which Restore clones in one statement, so a suite can seed once and start
+// every test from the seeded state. On MySQL the tables are copied into a second database
+type Snapshot struct {
+	dbType   string
+	dsn      string
+	database string
+	copy     string
+}
+
+// TakeSnapshot copies the database in dsn, a postgres or mysql url like the ones in
+// DATABASE_<NAME>_DSN, or a postgres key=value dsn. The copy is named <database>_snapshot
+// and replaces an earlier snapshot with that name. Postgres cannot copy a database others are
+// connected to, so their connections are closed first and pools that used them reconnect
+func TakeSnapshot(dbType, dsn string) (*Snapshot, error) {
+	database, err := databaseName(dbType, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Snapshot{dbType: dbType, dsn: dsn, database: database, copy: database + "_snapshot"}
+
+	switch dbType {
+	case "postgres", "postgresql", "pgx":
+		return s, s.clonePostgres(s.database, s.copy)
+	case "mysql", "mariadb":
+		return s, s.copyMySQL(s.database, s.copy)
+	}
+
+	return nil, fmt.Errorf("gqtest: snapshots of %s databases are not supported", dbType)
+}
+
+// Restore puts the database back the way it was when the snapshot was taken. On Postgres the
+// connections to the database are closed here too
+func (s *Snapshot) Restore() error {
+	switch s.dbType {
+	case "mysql", "mariadb":
+		return s.copyMySQL(s.copy, s.database)
+	default:
+		return s.clonePostgres(s.copy, s.database)
+	}
+}
+
+// Drop removes the snapshot
+func (s *Snapshot) Drop() error {
+	admin, err := s.admin()
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	_, err = admin.Exec("DROP DATABASE IF EXISTS " + quote(s.dialect(), s.copy))
+
+	return err
+}
+
+// clonePostgres replaces the database to with a copy of from, using from as template
+func (s *Snapshot) clonePostgres(from, to string) error {
+	admin, err := s.admin()
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	// a database cannot be copied or dropped while anyone is connected to it
+	for _, name := range []string{from, to} {
+		_, err = admin.Exec("SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = $1 AND pid <> pg_backend_pid()", name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err = admin.Exec("DROP DATABASE IF EXISTS " + quote("postgres", to)); err != nil {
+		return err
+	}
+
+	_, err = admin.Exec("CREATE DATABASE " + quote("postgres", to) + " TEMPLATE " + quote("postgres", from))
+
+	return err
+}
+
+// copyMySQL replaces the database to with a copy of the tables and rows in from
+func (s *Snapshot) copyMySQL(from, to string) error {
+	admin, err := s.admin()
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	ctx := context.Background()
+	conn, err := admin.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, "SELECT table_name FROM information_schema.tables WHERE table_schema = ? AND table_type = 'BASE TABLE'", from)
+	if err != nil {
+		return err
+	}
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			rows.Close()
+			return err
+		}
+		tables = append(tables, table)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	statements := []string{
+		"SET FOREIGN_KEY_CHECKS = 0",
+		"DROP DATABASE IF EXISTS " + quote("mysql", to),
+		"CREATE DATABASE " + quote("mysql", to),
+	}
+	for _, table := range tables {
+		source := quote("mysql", from) + "." + quote("mysql", table)
+		target := quote("mysql", to) + "." + quote("mysql", table)
+		statements = append(statements,
+			"CREATE TABLE "+target+" LIKE "+source,
+			"INSERT INTO "+target+" SELECT * FROM "+source,
+		)
+	}
+	statements = append(statements, "SET FOREIGN_KEY_CHECKS = 1")
+
+	for _, statement := range statements {
+		if _, err = conn.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%s: %w", statement, err)
+		}
+	}
+
+	return nil
+}
+
+// admin connects to the server without using the database itself, which Postgres needs
+// to copy it and MySQL to drop it
+func (s *Snapshot) admin() (*sql.DB, error) {
+	var driver, dsn string
+	var err error
+
+	switch s.dialect() {
+	case "mysql":
+		driver = "mysql"
+		dsn, err = withDatabase(s.dbType, s.dsn, "")
+	default:
+		driver = "pgx"
+		dsn, err = withDatabase(s.dbType, s.dsn, "postgres")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sql.Open(driver, dsn)
+}
+
+func (s *Snapshot) dialect() string {
+	if s.dbType == "mysql" || s.dbType == "mariadb" {
+		return "mysql"
+	}
+
+	return "postgres"
+}
+
+// databaseName returns the database a dsn connects to
+func databaseName(dbType, dsn string) (string, error) {
+	if u, ok := parseURL(dsn); ok {
+		name := strings.TrimPrefix(u.Path, "/")
+		if name == "" {
+			return "", errors.New("gqtest: the dsn does not name a database")
+		}
+		return name, nil
+	}
+
+	if dbType == "mysql" || dbType == "mariadb" {
+		// user:password@tcp(host:3306)/name?parseTime=true
+		_, rest, ok := strings.Cut(dsn, "/")
+		name, _, _ := strings.Cut(rest, "?")
+		if !ok || name == "" {
+			return "", errors.New("gqtest: the dsn does not name a database")
+		}
+		return name, nil
+	}
+
+	for _, field := range strings.Fields(dsn) {
+		if name, ok := strings.CutPrefix(field, "dbname="); ok {
+			return name, nil
+		}
+	}
+
+	return "", errors.New("gqtest: the dsn does not name a database")
+}
+
+// withDatabase returns dsn connecting to another database on the same server, in the form the
+// database/sql driver expects
+func withDatabase(dbType, dsn, name string) (string, error) {
+	if u, ok := parseURL(dsn); ok {
+		if dbType == "mysql" || dbType == "mariadb" {
+			// the mysql driver does not take urls
+			password, _ := u.User.Password()
+			return fmt.Sprintf("%s:%s@tcp(%s)/%s?%s", u.User.Username(), password, u.Host, name, u.RawQuery), nil
+		}
+
+		u.Path = "/" + name
+		return u.String(), nil
+	}
+
+	if dbType == "mysql" || dbType == "mariadb" {
+		prefix, rest, ok := strings.Cut(dsn, "/")
+		if !ok {
+			return "", errors.New("gqtest: could not read the mysql dsn")
+		}
+		_, query, _ := strings.Cut(rest, "?")
+		if query != "" {
+			query = "?" + query
+		}
+		return prefix + "/" + name + query, nil
+	}
+
+	fields := strings.Fields(dsn)
+	replaced := false
+	for i, field := range fields {
+		if strings.HasPrefix(field, "dbname=") {
+			fields[i] = "dbname=" + name
+			replaced = true
+		}
+	}
+	if !replaced {
+		fields = append(fields, "dbname="+name)
+	}
+
+	return strings.Join(fields, " "), nil
+}
+
+func parseURL(dsn string) (*url.URL, bool) {
+	if !strings.Contains(dsn, "://") {
+		return nil, false
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, false
+	}
+
+	return u, true
+}
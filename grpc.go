@@ -0,0 +1,55 @@
+package gemquick
+
+import (
+	"net"
+	"os"
+)
+
+// GRPCServer is the part of a *grpc.Server the app needs to run it next to the web server. The
+// framework does not depend on grpc itself, only apps that serve gRPC do
+type GRPCServer interface {
+	Serve(l net.Listener) error
+	GracefulStop()
+}
+
+// ServeGRPC makes ListenAndServe run srv as well, on GRPC_PORT or 50051, and stop it gracefully
+// together with the web server
+func (g *Gemquick) ServeGRPC(srv GRPCServer) {
+	g.grpcServer = srv
+}
+
+// startGRPC starts serving the server given to ServeGRPC in the background, and returns the
+// listener it serves on, or nil when there is no server
+func (g *Gemquick) startGRPC() (net.Listener, error) {
+	if g.grpcServer == nil {
+		return nil, nil
+	}
+
+	port := os.Getenv("GRPC_PORT")
+	if port == "" {
+		port = "50051"
+	}
+
+	l, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		err := g.grpcServer.Serve(l)
+		if err != nil {
+			g.ErrorLog.Println("gRPC server:", err)
+		}
+	}()
+
+	g.InfoLog.Printf("Serving gRPC on port %s", port)
+
+	return l, nil
+}
+
+// stopGRPC lets the gRPC server finish the calls in flight and stops it
+func (g *Gemquick) stopGRPC() {
+	if g.grpcServer != nil {
+		g.grpcServer.GracefulStop()
+	}
+}
@@ -0,0 +1,69 @@
+package gemquick
+
+import (
+	"io"
+	"log"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeGRPCServer accepts connections until it is stopped, like a *grpc.Server
+type fakeGRPCServer struct {
+	l       net.Listener
+	served  chan struct{}
+	stopped bool
+}
+
+func (f *fakeGRPCServer) Serve(l net.Listener) error {
+	f.l = l
+	close(f.served)
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return nil
+		}
+		conn.Close()
+	}
+}
+
+func (f *fakeGRPCServer) GracefulStop() {
+	f.stopped = true
+	f.l.Close()
+}
+
+func TestGemquick_ServeGRPC(t *testing.T) {
+	t.Setenv("GRPC_PORT", "0")
+
+	g := &Gemquick{InfoLog: log.New(io.Discard, "", 0), ErrorLog: log.New(io.Discard, "", 0)}
+
+	l, err := g.startGRPC()
+	if err != nil || l != nil {
+		t.Fatal("expected nothing to be served without a gRPC server, got", l, err)
+	}
+
+	srv := &fakeGRPCServer{served: make(chan struct{})}
+	g.ServeGRPC(srv)
+
+	l, err = g.startGRPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-srv.served:
+	case <-time.After(time.Second):
+		t.Fatal("expected the gRPC server to be served")
+	}
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal("expected the gRPC port to accept connections:", err)
+	}
+	conn.Close()
+
+	g.stopGRPC()
+	if !srv.stopped {
+		t.Error("expected the gRPC server to be stopped gracefully")
+	}
+}
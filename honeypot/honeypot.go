@@ -0,0 +1,147 @@
+// Package honeypot catches the bots that fill in public forms, with a field people never see and a
+// minimum time to fill in the form in
+package honeypot
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeField is the hidden field that holds when the form was rendered, signed so that bots cannot
+// make it up
+const TimeField = "_gq_rendered"
+
+// the reasons a submission is spam
+const (
+	ReasonHoneypot = "honeypot"
+	ReasonNoTime   = "no render time"
+	ReasonTooFast  = "too fast"
+	ReasonExpired  = "expired"
+)
+
+// Mode is what Middleware does with spam
+type Mode int
+
+const (
+	// Drop answers spam as if it was accepted, without calling the handler, so that bots do not
+	// learn they were caught
+	Drop Mode = iota
+	// Flag calls the handler, which finds the reason in Flagged
+	Flag
+)
+
+type contextKey struct{}
+
+// Trap checks the submissions of forms that include its Fields
+type Trap struct {
+	// Field is the name of the honeypot field, one bots are tempted to fill in
+	Field string
+	// MinFill is the time people take at least to fill in a form
+	MinFill time.Duration
+	// MaxAge is how long after being rendered a form can be submitted
+	MaxAge time.Duration
+
+	key []byte
+	now func() time.Time
+}
+
+// New returns a trap with a website honeypot that refuses forms submitted within 3 seconds or
+// after a day, signing the render time with key
+func New(key string) *Trap {
+	return &Trap{Field: "website", MinFill: 3 * time.Second, MaxAge: 24 * time.Hour, key: []byte(key), now: time.Now}
+}
+
+// Fields returns the hidden fields to put in a form: the honeypot, out of sight and out of reach
+// of the keyboard and screen readers, and the signed render time
+func (t *Trap) Fields() template.HTML {
+	rendered := strconv.FormatInt(t.now().Unix(), 10)
+
+	return template.HTML(fmt.Sprintf(`<div aria-hidden="true" style="position:absolute;left:-10000px;top:auto;width:1px;height:1px;overflow:hidden">`+
+		`<label>Leave this empty <input type="text" name="%s" value="" tabindex="-1" autocomplete="off"></label></div>`+
+		`<input type="hidden" name="%s" value="%s.%s">`,
+		template.HTMLEscapeString(t.Field), TimeField, rendered, t.sign(rendered)))
+}
+
+// Check returns why the submission of r is spam, or nothing when it looks like a person's
+func (t *Trap) Check(r *http.Request) string {
+	if r.FormValue(t.Field) != "" {
+		return ReasonHoneypot
+	}
+
+	rendered, signature, ok := strings.Cut(r.FormValue(TimeField), ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(t.sign(rendered))) {
+		return ReasonNoTime
+	}
+
+	unix, err := strconv.ParseInt(rendered, 10, 64)
+	if err != nil {
+		return ReasonNoTime
+	}
+
+	took := t.now().Sub(time.Unix(unix, 0))
+	if took < t.MinFill {
+		return ReasonTooFast
+	}
+	if t.MaxAge > 0 && took > t.MaxAge {
+		return ReasonExpired
+	}
+
+	return ""
+}
+
+func (t *Trap) sign(value string) string {
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write([]byte(TimeField + value))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Middleware checks the submissions to next and drops or flags spam, see Mode. onSpam, when it is
+// set, is told about every spam submission and why, e.g. to count them
+func (t *Trap) Middleware(mode Mode, onSpam func(r *http.Request, reason string)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reason := t.Check(r)
+			if reason == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if onSpam != nil {
+				onSpam(r, reason)
+			}
+
+			if mode == Flag {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, reason)))
+				return
+			}
+
+			// what a form handler usually does after accepting a submission
+			back := r.Referer()
+			if back == "" {
+				back = r.URL.Path
+			}
+			http.Redirect(w, r, back, http.StatusSeeOther)
+		})
+	}
+}
+
+// Flagged returns why Middleware flagged the submission of the request in ctx as spam, or nothing
+// when it did not
+func Flagged(ctx context.Context) string {
+	reason, _ := ctx.Value(contextKey{}).(string)
+	return reason
+}
@@ -0,0 +1,94 @@
+package honeypot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// submit posts the fields a browser would send for the rendered fields, with changes applied
+func submit(t *testing.T, trap *Trap, changes url.Values) *http.Request {
+	t.Helper()
+
+	form := url.Values{"message": {"hello"}}
+	for _, m := range regexp.MustCompile(`name="([^"]+)" value="([^"]*)"`).FindAllStringSubmatch(string(trap.Fields()), -1) {
+		form.Set(m[1], m[2])
+	}
+	for k, v := range changes {
+		form[k] = v
+	}
+
+	r := httptest.NewRequest("POST", "/contact", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestTrap_Check(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	trap := New("secret")
+	trap.now = func() time.Time { return now }
+
+	tests := []struct {
+		name    string
+		after   time.Duration
+		changes url.Values
+		reason  string
+	}{
+		{"person", 10 * time.Second, nil, ""},
+		{"honeypot filled in", 10 * time.Second, url.Values{"website": {"http://spam.test"}}, ReasonHoneypot},
+		{"too fast", time.Second, nil, ReasonTooFast},
+		{"expired", 25 * time.Hour, nil, ReasonExpired},
+		{"no render time", 10 * time.Second, url.Values{TimeField: {""}}, ReasonNoTime},
+		{"forged render time", 10 * time.Second, url.Values{TimeField: {"1704067100.abc"}}, ReasonNoTime},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+			r := submit(t, trap, tt.changes)
+			now = now.Add(tt.after)
+
+			if reason := trap.Check(r); reason != tt.reason {
+				t.Errorf("expected %q, got %q", tt.reason, reason)
+			}
+		})
+	}
+}
+
+func TestTrap_Middleware(t *testing.T) {
+	trap := New("secret")
+	trap.MinFill = 0
+
+	var reasons []string
+	var flagged string
+	called := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called++
+		flagged = Flagged(r.Context())
+	})
+	onSpam := func(r *http.Request, reason string) { reasons = append(reasons, reason) }
+
+	rr := httptest.NewRecorder()
+	trap.Middleware(Drop, onSpam)(next).ServeHTTP(rr, submit(t, trap, url.Values{"website": {"x"}}))
+	if called != 0 || rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/contact" {
+		t.Errorf("expected spam to be dropped with a redirect, got %d to %q", rr.Code, rr.Header().Get("Location"))
+	}
+
+	trap.Middleware(Flag, onSpam)(next).ServeHTTP(httptest.NewRecorder(), submit(t, trap, url.Values{"website": {"x"}}))
+	if called != 1 || flagged != ReasonHoneypot {
+		t.Errorf("expected spam to be passed on flagged, got %d calls and %q", called, flagged)
+	}
+
+	trap.Middleware(Drop, onSpam)(next).ServeHTTP(httptest.NewRecorder(), submit(t, trap, nil))
+	if called != 2 || flagged != "" {
+		t.Errorf("expected a person's submission to pass unflagged, got %d calls and %q", called, flagged)
+	}
+
+	if len(reasons) != 2 {
+		t.Errorf("expected onSpam for both spam submissions, got %v", reasons)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !gojson
+
+package gemquick
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// newJSONEncoder is the encoder used by WriteJson. Build with -tags gojson to use go-json instead
+func newJSONEncoder(w io.Writer) jsonEncoder {
+	return json.NewEncoder(w)
+}
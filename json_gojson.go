@@ -0,0 +1,14 @@
+//go:build gojson
+
+package gemquick
+
+import (
+	"io"
+
+	gojson "github.com/goccy/go-json"
+)
+
+// newJSONEncoder is the encoder used by WriteJson, here backed by go-json
+func newJSONEncoder(w io.Writer) jsonEncoder {
+	return gojson.NewEncoder(w)
+}
@@ -0,0 +1,103 @@
+package gemquick
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnectionStats is a snapshot of the connections the web server has handled
+type ConnectionStats struct {
+	Active   int64
+	Accepted int64
+	Rejected int64
+}
+
+// connListener counts the connections it hands out. When max is above zero, connections over
+// the limit are answered with 503 Service Unavailable and closed instead of being served
+type connListener struct {
+	net.Listener
+	max int64
+
+	active   int64
+	accepted int64
+	rejected int64
+}
+
+// shedResponse is written straight to connections that are over the limit, before
+// any request has been read from them
+const shedResponse = "HTTP/1.1 503 Service Unavailable\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Length: 20\r\n" +
+	"Retry-After: 1\r\n" +
+	"Connection: close\r\n" +
+	"\r\n" +
+	"Service Unavailable\n"
+
+func newConnListener(l net.Listener, max int64) *connListener {
+	return &connListener{Listener: l, max: max}
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		atomic.AddInt64(&l.accepted, 1)
+
+		if l.max > 0 && atomic.AddInt64(&l.active, 1) > l.max {
+			atomic.AddInt64(&l.active, -1)
+			atomic.AddInt64(&l.rejected, 1)
+			go shed(conn)
+			continue
+		}
+
+		if l.max <= 0 {
+			atomic.AddInt64(&l.active, 1)
+		}
+
+		return &countedConn{Conn: conn, listener: l}, nil
+	}
+}
+
+func (l *connListener) stats() ConnectionStats {
+	return ConnectionStats{
+		Active:   atomic.LoadInt64(&l.active),
+		Accepted: atomic.LoadInt64(&l.accepted),
+		Rejected: atomic.LoadInt64(&l.rejected),
+	}
+}
+
+func shed(conn net.Conn) {
+	_ = conn.SetWriteDeadline(time.Now().Add(time.Second))
+	_, _ = conn.Write([]byte(shedResponse))
+	_ = conn.Close()
+}
+
+// countedConn gives its slot back to the listener the first time it is closed
+type countedConn struct {
+	net.Conn
+	listener *connListener
+	once     sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(func() {
+		atomic.AddInt64(&c.listener.active, -1)
+	})
+
+	return c.Conn.Close()
+}
+
+// ConnectionStats returns the number of open, accepted and rejected connections of the web
+// server started by ListenAndServe. Everything is zero until the server has started
+func (g *Gemquick) ConnectionStats() ConnectionStats {
+	if g.listener == nil {
+		return ConnectionStats{}
+	}
+
+	return g.listener.stats()
+}
@@ -0,0 +1,66 @@
+package gemquick
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestConnListener_Limit(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cl := newConnListener(l, 1)
+	defer cl.Close()
+
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := cl.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	first, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+
+	served := <-accepted
+
+	second, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	_ = second.SetReadDeadline(time.Now().Add(2 * time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(second), nil)
+	if err != nil {
+		t.Fatal("expected a response on the rejected connection:", err)
+	}
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Error("expected 503 for the connection over the limit, got", resp.StatusCode)
+	}
+
+	stats := cl.stats()
+	if stats.Active != 1 || stats.Accepted != 2 || stats.Rejected != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	_ = served.Close()
+	_ = served.Close()
+
+	if cl.stats().Active != 0 {
+		t.Error("expected the closed connection to free its slot, got", cl.stats().Active)
+	}
+}
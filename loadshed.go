@@ -0,0 +1,184 @@
+package gemquick
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Priority tells the load shedder how important the requests of a route are
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+// LoadShedder rejects a growing share of requests while the app is overloaded, which is when the
+// p99 latency of recent requests is above MaxLatency or more than MaxGoroutines goroutines are running.
+// Every Interval the share goes up by Step while overloaded and down by Step when not. Low priority
+// requests are rejected at the full rate, normal ones at half of it and high priority ones never.
+// Requests are normal priority unless their path falls under a prefix given to Prioritize
+type LoadShedder struct {
+	MaxLatency    time.Duration
+	MaxGoroutines int
+	Interval      time.Duration
+	Step          float64
+	MaxDropRate   float64
+
+	mu        sync.Mutex
+	samples   []time.Duration
+	next      int
+	full      bool
+	dropRate  float64
+	lastCheck time.Time
+	random    func() float64
+	prefixes  map[string]Priority
+}
+
+// NewLoadShedder returns a load shedder that keeps the latency of the last 1000 requests
+func NewLoadShedder(maxLatency time.Duration, maxGoroutines int) *LoadShedder {
+	return &LoadShedder{
+		MaxLatency:    maxLatency,
+		MaxGoroutines: maxGoroutines,
+		Interval:      time.Second,
+		Step:          0.1,
+		MaxDropRate:   0.9,
+		samples:       make([]time.Duration, 1000),
+		random:        rand.Float64,
+		prefixes:      map[string]Priority{},
+	}
+}
+
+// Prioritize sets the priority of every request whose path starts with prefix, e.g.
+// g.LoadShedder.Prioritize("/reports", gemquick.PriorityLow). The longest matching prefix wins
+func (ls *LoadShedder) Prioritize(prefix string, p Priority) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ls.prefixes[prefix] = p
+}
+
+// Priority returns the priority of a request
+func (ls *LoadShedder) Priority(r *http.Request) Priority {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	priority, longest := PriorityNormal, -1
+	for prefix, p := range ls.prefixes {
+		if len(prefix) > longest && strings.HasPrefix(r.URL.Path, prefix) {
+			priority, longest = p, len(prefix)
+		}
+	}
+
+	return priority
+}
+
+// Middleware sheds requests with 503 Service Unavailable while the app is overloaded
+func (ls *LoadShedder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ls.shouldShed(ls.Priority(r)) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		ls.record(time.Since(start))
+	})
+}
+
+// DropRate returns the share of low priority requests currently being shed
+func (ls *LoadShedder) DropRate() float64 {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	return ls.dropRate
+}
+
+func (ls *LoadShedder) shouldShed(p Priority) bool {
+	ls.mu.Lock()
+	if time.Since(ls.lastCheck) >= ls.Interval {
+		ls.adjust()
+	}
+	rate := ls.dropRate
+	ls.mu.Unlock()
+
+	switch p {
+	case PriorityHigh:
+		return false
+	case PriorityNormal:
+		rate /= 2
+	}
+
+	return rate > 0 && ls.random() < rate
+}
+
+func (ls *LoadShedder) record(d time.Duration) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ls.samples[ls.next] = d
+	ls.next = (ls.next + 1) % len(ls.samples)
+	if ls.next == 0 {
+		ls.full = true
+	}
+}
+
+// adjust must be called with the lock held
+func (ls *LoadShedder) adjust() {
+	ls.lastCheck = time.Now()
+
+	overloaded := ls.MaxGoroutines > 0 && runtime.NumGoroutine() > ls.MaxGoroutines
+	if !overloaded && ls.MaxLatency > 0 {
+		overloaded = ls.p99() > ls.MaxLatency
+	}
+
+	if overloaded {
+		ls.dropRate = math.Min(ls.dropRate+ls.Step, ls.MaxDropRate)
+	} else {
+		ls.dropRate = math.Max(ls.dropRate-ls.Step, 0)
+	}
+}
+
+func (ls *LoadShedder) p99() time.Duration {
+	count := ls.next
+	if ls.full {
+		count = len(ls.samples)
+	}
+
+	if count == 0 {
+		return 0
+	}
+
+	sorted := make([]time.Duration, count)
+	copy(sorted, ls.samples[:count])
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return sorted[(count*99+99)/100-1]
+}
+
+// createLoadShedder returns nil unless LOAD_SHED_MAX_LATENCY or LOAD_SHED_MAX_GOROUTINES is set
+func (g *Gemquick) createLoadShedder() *LoadShedder {
+	maxLatency, _ := time.ParseDuration(os.Getenv("LOAD_SHED_MAX_LATENCY"))
+	maxGoroutines, _ := strconv.Atoi(os.Getenv("LOAD_SHED_MAX_GOROUTINES"))
+
+	if maxLatency <= 0 && maxGoroutines <= 0 {
+		return nil
+	}
+
+	ls := NewLoadShedder(maxLatency, maxGoroutines)
+	// the readiness probe must keep answering, or the orchestrator restarts a busy app
+	ls.Prioritize("/readyz", PriorityHigh)
+
+	return ls
+}
@@ -0,0 +1,69 @@
+package gemquick
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLoadShedder_Priorities(t *testing.T) {
+	ls := NewLoadShedder(time.Millisecond, 0)
+	ls.Interval = 0
+	ls.random = func() float64 { return 0.3 }
+
+	slow := ls.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(2 * time.Millisecond)
+	}))
+
+	// build up the drop rate with slow requests until it reaches the maximum
+	for i := 0; i < 20; i++ {
+		slow.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+
+	if ls.DropRate() != ls.MaxDropRate {
+		t.Fatalf("expected drop rate %v, got %v", ls.MaxDropRate, ls.DropRate())
+	}
+
+	ls.Prioritize("/reports", PriorityLow)
+	ls.Prioritize("/admin", PriorityHigh)
+	ls.Prioritize("/admin/exports", PriorityLow)
+
+	var tests = []struct {
+		name     string
+		path     string
+		expected int
+	}{
+		{"low", "/reports/daily", http.StatusServiceUnavailable},
+		{"normal", "/", http.StatusServiceUnavailable},
+		{"high", "/admin/users", http.StatusOK},
+		{"longest prefix", "/admin/exports/1", http.StatusServiceUnavailable},
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, e := range tests {
+		ls.Interval = time.Hour
+		rr := httptest.NewRecorder()
+		ls.Middleware(ok).ServeHTTP(rr, httptest.NewRequest("GET", e.path, nil))
+
+		if rr.Code != e.expected {
+			t.Errorf("%s: expected status %d, got %d", e.name, e.expected, rr.Code)
+		}
+	}
+}
+
+func TestLoadShedder_Recovers(t *testing.T) {
+	ls := NewLoadShedder(time.Second, 0)
+	ls.Interval = 0
+	ls.dropRate = 0.5
+
+	ok := ls.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i := 0; i < 10; i++ {
+		ok.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+
+	if ls.DropRate() != 0 {
+		t.Errorf("expected drop rate to go back to 0, got %v", ls.DropRate())
+	}
+}
@@ -0,0 +1,207 @@
+// Package metrics keeps the app's counters and gauges, like orders placed or jobs queued, in
+// memory. A Registry, usually app.Metrics, serves them as JSON, and can save them to redis or
+// badger so that counters carry on where they were after a restart instead of starting over
+package metrics
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Counter is a value that only goes up, like the number of orders placed
+type Counter struct {
+	value    int64
+	restored int64
+}
+
+// Inc adds 1 to the counter
+func (c *Counter) Inc() {
+	atomic.AddInt64(&c.value, 1)
+}
+
+// Add adds n to the counter, which is ignored when it is negative
+func (c *Counter) Add(n int64) {
+	if n > 0 {
+		atomic.AddInt64(&c.value, n)
+	}
+}
+
+// Value returns the count, including what was restored from the snapshot of an earlier process
+func (c *Counter) Value() int64 {
+	return atomic.LoadInt64(&c.value)
+}
+
+// Restored returns the part of the count that was restored from a snapshot at boot, 0 when
+// the counter started from scratch
+func (c *Counter) Restored() int64 {
+	return atomic.LoadInt64(&c.restored)
+}
+
+// Gauge is a value that goes up and down, like the number of jobs in a queue. Gauges describe
+// the running process, so they are not restored from snapshots
+type Gauge struct {
+	bits uint64
+}
+
+// Set sets the gauge to v
+func (g *Gauge) Set(v float64) {
+	atomic.StoreUint64(&g.bits, math.Float64bits(v))
+}
+
+// Add adds v, which may be negative, to the gauge
+func (g *Gauge) Add(v float64) {
+	for {
+		old := atomic.LoadUint64(&g.bits)
+		if atomic.CompareAndSwapUint64(&g.bits, old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Value returns the gauge's value
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&g.bits))
+}
+
+// Snapshot holds the values of a registry at one time
+type Snapshot struct {
+	Taken    time.Time          `json:"taken"`
+	Counters map[string]int64   `json:"counters"`
+	Gauges   map[string]float64 `json:"gauges,omitempty"`
+	// Restored holds the part of each counter that was restored from the snapshot of an earlier
+	// process, for dashboards that need to tell it from what this process counted
+	Restored map[string]int64 `json:"restored,omitempty"`
+	// RestoredFrom is when the snapshot the counters were restored from was taken
+	RestoredFrom *time.Time `json:"restored_from,omitempty"`
+}
+
+// Registry holds counters and gauges by name
+type Registry struct {
+	mu           sync.RWMutex
+	counters     map[string]*Counter
+	gauges       map[string]*Gauge
+	funcs        map[string]func() float64
+	restoredFrom *time.Time
+}
+
+// New returns an empty registry
+func New() *Registry {
+	return &Registry{counters: map[string]*Counter{}, gauges: map[string]*Gauge{}, funcs: map[string]func() float64{}}
+}
+
+// Counter returns the counter with the name, which is created the first time it is asked for
+func (r *Registry) Counter(name string) *Counter {
+	r.mu.RLock()
+	c, ok := r.counters[name]
+	r.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.counters[name]; ok {
+		return c
+	}
+	c = &Counter{}
+	r.counters[name] = c
+
+	return c
+}
+
+// Gauge returns the gauge with the name, which is created the first time it is asked for
+func (r *Registry) Gauge(name string) *Gauge {
+	r.mu.RLock()
+	g, ok := r.gauges[name]
+	r.mu.RUnlock()
+	if ok {
+		return g
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if g, ok := r.gauges[name]; ok {
+		return g
+	}
+	g = &Gauge{}
+	r.gauges[name] = g
+
+	return g
+}
+
+// GaugeFunc adds a gauge with the name whose value fn returns whenever it is read, for values that
+// are kept somewhere else, like the stats of a connection pool. It replaces the gauge func with
+// the name that was there before
+func (r *Registry) GaugeFunc(name string, fn func() float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.funcs[name] = fn
+}
+
+// Names returns the names of the counters and gauges, sorted
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.counters)+len(r.gauges)+len(r.funcs))
+	for name := range r.counters {
+		names = append(names, name)
+	}
+	for name := range r.gauges {
+		names = append(names, name)
+	}
+	for name := range r.funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Snapshot returns the current values
+func (r *Registry) Snapshot() Snapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s := Snapshot{
+		Taken:        time.Now().UTC(),
+		Counters:     make(map[string]int64, len(r.counters)),
+		Gauges:       make(map[string]float64, len(r.gauges)+len(r.funcs)),
+		RestoredFrom: r.restoredFrom,
+	}
+	for name, c := range r.counters {
+		s.Counters[name] = c.Value()
+		if restored := c.Restored(); restored > 0 {
+			if s.Restored == nil {
+				s.Restored = map[string]int64{}
+			}
+			s.Restored[name] = restored
+		}
+	}
+	for name, g := range r.gauges {
+		s.Gauges[name] = g.Value()
+	}
+	for name, fn := range r.funcs {
+		s.Gauges[name] = fn()
+	}
+
+	return s
+}
+
+// Handler serves the snapshot of the registry as JSON, e.g.
+//
+//	a.App.Routes.With(a.Middleware.Auth).Get("/admin/metrics", a.App.Metrics.Handler().ServeHTTP)
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(r.Snapshot())
+	})
+}
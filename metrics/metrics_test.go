@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type memoryStore map[string]interface{}
+
+func (s memoryStore) Has(key string) (bool, error) {
+	_, ok := s[key]
+	return ok, nil
+}
+
+func (s memoryStore) Get(key string) (interface{}, error) {
+	return s[key], nil
+}
+
+func (s memoryStore) Set(key string, value interface{}, ttl ...int) error {
+	s[key] = value
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	r := New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Counter("orders").Inc()
+			r.Gauge("queued").Add(0.5)
+		}()
+	}
+	wg.Wait()
+
+	r.Counter("orders").Add(-3)
+	if n := r.Counter("orders").Value(); n != 10 {
+		t.Errorf("expected 10 orders, got %d", n)
+	}
+
+	if v := r.Gauge("queued").Value(); v != 5 {
+		t.Errorf("expected the gauge at 5, got %v", v)
+	}
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	var s Snapshot
+	if err := json.NewDecoder(w.Body).Decode(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Counters["orders"] != 10 || s.Gauges["queued"] != 5 || s.Restored != nil {
+		t.Errorf("expected the values as json, got %+v", s)
+	}
+}
+
+func TestRegistry_Restore(t *testing.T) {
+	store := memoryStore{}
+
+	if err := New().Restore(store); err != nil {
+		t.Errorf("expected nothing to restore from an empty store, got %v", err)
+	}
+
+	before := New()
+	before.Counter("orders").Add(40)
+	before.Gauge("queued").Set(3)
+	if err := before.Save(store); err != nil {
+		t.Fatal(err)
+	}
+
+	after := New()
+	if err := after.Restore(store); err != nil {
+		t.Fatal(err)
+	}
+	after.Counter("orders").Add(2)
+
+	s := after.Snapshot()
+	if s.Counters["orders"] != 42 || s.Restored["orders"] != 40 || s.RestoredFrom == nil {
+		t.Errorf("expected the counter to continue from 40, got %+v", s)
+	}
+
+	if _, ok := s.Gauges["queued"]; ok {
+		t.Error("expected gauges not to be restored")
+	}
+
+	if err := after.Restore(store); err == nil || after.Counter("orders").Value() != 42 {
+		t.Error("expected a second restore to be refused")
+	}
+}
+
+func TestRegistry_GaugeFunc(t *testing.T) {
+	r := New()
+
+	open := 3.0
+	r.GaugeFunc("db_open_connections", func() float64 { return open })
+
+	if s := r.Snapshot(); s.Gauges["db_open_connections"] != 3 {
+		t.Errorf("expected the gauge at 3, got %v", s.Gauges)
+	}
+
+	open = 5
+	if s := r.Snapshot(); s.Gauges["db_open_connections"] != 5 {
+		t.Errorf("expected the gauge to follow its func, got %v", s.Gauges)
+	}
+
+	if names := r.Names(); len(names) != 1 || names[0] != "db_open_connections" {
+		t.Errorf("expected the gauge func in the names, got %v", names)
+	}
+}
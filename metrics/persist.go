@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+
+	"github.com/robfig/cron/v3"
+)
+
+// SnapshotKey is the key a registry saves its snapshot under
+const SnapshotKey = "metrics:snapshot"
+
+// Store keeps the snapshot between restarts. app.Cache, on redis or badger, is one
+type Store interface {
+	Has(string) (bool, error)
+	Get(string) (interface{}, error)
+	Set(string, interface{}, ...int) error
+}
+
+// Save stores the snapshot of the registry in store
+func (r *Registry) Save(store Store) error {
+	b, err := json.Marshal(r.Snapshot())
+	if err != nil {
+		return err
+	}
+
+	return store.Set(SnapshotKey, string(b))
+}
+
+// Restore adds the counters of the snapshot in store to the registry, so that they continue
+// from the values of the process that saved it. It is meant to run once at boot, before the
+// counters are used, and does nothing when store holds no snapshot
+func (r *Registry) Restore(store Store) error {
+	ok, err := store.Has(SnapshotKey)
+	if err != nil || !ok {
+		return err
+	}
+
+	v, err := store.Get(SnapshotKey)
+	if err != nil {
+		return err
+	}
+	b, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("the metrics snapshot is a %T, not json", v)
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal([]byte(b), &s); err != nil {
+		return fmt.Errorf("reading the metrics snapshot: %w", err)
+	}
+
+	r.mu.Lock()
+	if r.restoredFrom != nil {
+		r.mu.Unlock()
+		return errors.New("the metrics have been restored already")
+	}
+	r.restoredFrom = &s.Taken
+	r.mu.Unlock()
+
+	for name, n := range s.Counters {
+		if n <= 0 {
+			continue
+		}
+		c := r.Counter(name)
+		c.Add(n)
+		atomic.AddInt64(&c.restored, n)
+	}
+
+	return nil
+}
+
+// SaveOn schedules Save with the cron spec, e.g. "@every 1m" on app.Scheduler, logging its
+// errors to errorLog when it is not nil
+func (r *Registry) SaveOn(scheduler *cron.Cron, spec string, store Store, errorLog *log.Logger) error {
+	_, err := scheduler.AddFunc(spec, func() {
+		if err := r.Save(store); err != nil && errorLog != nil {
+			errorLog.Println("saving the metrics snapshot:", err)
+		}
+	})
+
+	return err
+}
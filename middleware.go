@@ -1,31 +1,120 @@
 package gemquick
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
-	"strconv"
+	"net/http/httputil"
+	"runtime/debug"
+	"strings"
+	"syscall"
 
+	"github.com/jimmitjoo/gemquick/pool"
 	"github.com/justinas/nosurf"
 )
 
 func (g *Gemquick) SessionLoad(next http.Handler) http.Handler {
 	g.InfoLog.Println("SessionLoad called")
-	return g.Session.LoadAndSave(next)
+	return g.Cookies.partitionCookies(g.Session.LoadAndSave(next))
 }
 
 func (g *Gemquick) NoSurf(next http.Handler) http.Handler {
 	csrfHandler := nosurf.New(next)
-	secure, _ := strconv.ParseBool(g.config.cookie.secure)
 
 	// Exempt API from CSRF protection:
 	csrfHandler.ExemptGlob("/api/*")
 
-	csrfHandler.SetBaseCookie(http.Cookie{
-		HttpOnly: true,
-		Path:     "/",
-		Secure:   secure,
-		SameSite: http.SameSiteStrictMode,
-		Domain:   g.config.cookie.domain,
+	// browsers send CSP reports without a token
+	if clientErrorsEnabled() {
+		csrfHandler.ExemptPath("/client-errors")
+	}
+
+	// researchers report with their own tools, which have no token
+	if securityReportsEnabled() {
+		csrfHandler.ExemptPath("/.well-known/security")
+	}
+
+	// the support endpoints of AdminRoutes are called by scripts, which have no token. Browsers
+	// only send a DELETE to another site after a preflight its CORS policy allowed
+	admin := adminRoutesPath()
+	csrfHandler.ExemptFunc(func(r *http.Request) bool {
+		return r.Method == http.MethodDelete && (r.URL.Path == admin || strings.HasPrefix(r.URL.Path, admin+"/"))
 	})
 
+	// the token cookie is strict, unless the app is embedded on other sites
+	cookie := http.Cookie{Name: nosurf.CookieName, Path: "/", HttpOnly: true, SameSite: http.SameSiteStrictMode}
+	g.Cookies.Apply(&cookie)
+	csrfHandler.SetBaseCookie(cookie)
+
 	return csrfHandler
 }
+
+// Recoverer turns panics in handlers into a 500 Internal Server Error. Genuine panics go to
+// pool.PanicHandler with their stack, and the request is dumped to the error log. Panics caused by
+// the client going away, like http.ErrAbortHandler or a broken pipe, are only logged in debug mode
+func (g *Gemquick) Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rvr := recover()
+			if rvr == nil {
+				return
+			}
+
+			if isClientAbort(rvr, r) {
+				if g.Debug {
+					g.InfoLog.Printf("client went away during %s %s: %v", r.Method, r.URL.Path, rvr)
+				}
+				if rvr == http.ErrAbortHandler {
+					// let net/http abort the response, it does so without logging
+					panic(rvr)
+				}
+				return
+			}
+
+			pool.PanicHandler(&pool.PanicError{Value: rvr, Stack: debug.Stack()})
+			g.ErrorLog.Printf("panic serving %s %s\n%s", r.Method, r.URL.Path, dumpRequest(r))
+
+			if r.Header.Get("Connection") != "Upgrade" {
+				g.ErrorStatus(w, http.StatusInternalServerError)
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isClientAbort reports whether a panic comes from the client closing the connection
+func isClientAbort(rvr interface{}, r *http.Request) bool {
+	if rvr == http.ErrAbortHandler {
+		return true
+	}
+
+	err, ok := rvr.(error)
+	if !ok {
+		return false
+	}
+
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	return errors.Is(err, context.Canceled) && r.Context().Err() != nil
+}
+
+// dumpRequest returns the request line and headers, without credentials
+func dumpRequest(r *http.Request) string {
+	clone := r.Clone(r.Context())
+	for _, header := range []string{"Authorization", "Cookie", "Proxy-Authorization"} {
+		if clone.Header.Get(header) != "" {
+			clone.Header.Set(header, "[redacted]")
+		}
+	}
+
+	dump, err := httputil.DumpRequest(clone, false)
+	if err != nil {
+		return fmt.Sprintf("could not dump request: %v", err)
+	}
+
+	return string(dump)
+}
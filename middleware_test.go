@@ -0,0 +1,72 @@
+package gemquick
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/jimmitjoo/gemquick/pool"
+)
+
+func TestRecoverer(t *testing.T) {
+	var errorLog, infoLog bytes.Buffer
+	g := &Gemquick{Debug: true, ErrorLog: log.New(&errorLog, "", 0), InfoLog: log.New(&infoLog, "", 0)}
+
+	var panics []*pool.PanicError
+	old := pool.PanicHandler
+	pool.PanicHandler = func(err *pool.PanicError) { panics = append(panics, err) }
+	defer func() { pool.PanicHandler = old }()
+
+	handler := func(v interface{}) http.Handler {
+		return g.Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(v)
+		}))
+	}
+
+	r := httptest.NewRequest("GET", "/boom", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	handler("boom").ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", w.Code)
+	}
+
+	if len(panics) != 1 || panics[0].Value != "boom" || len(panics[0].Stack) == 0 {
+		t.Errorf("expected the panic with its stack to be handed to pool.PanicHandler, got %v", panics)
+	}
+
+	if !strings.Contains(errorLog.String(), "GET /boom") || strings.Contains(errorLog.String(), "secret") {
+		t.Errorf("expected a redacted request dump in the error log, got %q", errorLog.String())
+	}
+
+	// a client that hung up is not an error
+	w = httptest.NewRecorder()
+	handler(fmt.Errorf("write: %w", syscall.EPIPE)).ServeHTTP(w, httptest.NewRequest("GET", "/gone", nil))
+
+	if len(panics) != 1 {
+		t.Error("expected a broken pipe not to be reported as a panic")
+	}
+
+	if !strings.Contains(infoLog.String(), "client went away during GET /gone") {
+		t.Errorf("expected a debug message for the broken pipe, got %q", infoLog.String())
+	}
+
+	func() {
+		defer func() {
+			if rvr := recover(); rvr != http.ErrAbortHandler {
+				t.Errorf("expected http.ErrAbortHandler to be passed on to net/http, got %v", rvr)
+			}
+		}()
+		handler(http.ErrAbortHandler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+	}()
+
+	if len(panics) != 1 {
+		t.Error("expected http.ErrAbortHandler not to be reported as a panic")
+	}
+}
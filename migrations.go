@@ -1,6 +1,7 @@
 package gemquick
 
 import (
+	"errors"
 	"log"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -11,7 +12,7 @@ import (
 )
 
 func (g *Gemquick) MigrateUp(dsn string) error {
-	m, err := migrate.New("file://"+g.RootPath+"/migrations", dsn)
+	m, err := migrate.New(g.migrationsSource(), dsn)
 
 	if err != nil {
 		return err
@@ -28,7 +29,7 @@ func (g *Gemquick) MigrateUp(dsn string) error {
 }
 
 func (g *Gemquick) MigrateDownAll(dsn string) error {
-	m, err := migrate.New("file://"+g.RootPath+"/migrations", dsn)
+	m, err := migrate.New(g.migrationsSource(), dsn)
 
 	if err != nil {
 		return err
@@ -45,7 +46,7 @@ func (g *Gemquick) MigrateDownAll(dsn string) error {
 
 func (g *Gemquick) Steps(steps int, dsn string) error {
 
-	m, err := migrate.New("file://"+g.RootPath+"/migrations", dsn)
+	m, err := migrate.New(g.migrationsSource(), dsn)
 
 	if err != nil {
 		return err
@@ -61,7 +62,7 @@ func (g *Gemquick) Steps(steps int, dsn string) error {
 }
 
 func (g *Gemquick) MigrateForce(dsn string) error {
-	m, err := migrate.New("file://"+g.RootPath+"/migrations", dsn)
+	m, err := migrate.New(g.migrationsSource(), dsn)
 
 	if err != nil {
 		return err
@@ -75,3 +76,48 @@ func (g *Gemquick) MigrateForce(dsn string) error {
 
 	return nil
 }
+
+// MigrationVersion returns the version the database is migrated to, and whether the last migration
+// failed halfway. A database without migrations returns version 0
+func (g *Gemquick) MigrationVersion(dsn string) (uint, bool, error) {
+	m, err := migrate.New(g.migrationsSource(), dsn)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+
+	return version, dirty, err
+}
+
+// MigrateTo runs the migrations up or down until the database is at version
+func (g *Gemquick) MigrateTo(version uint, dsn string) error {
+	m, err := migrate.New(g.migrationsSource(), dsn)
+
+	if err != nil {
+		return err
+	}
+
+	defer m.Close()
+
+	if err = m.Migrate(version); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
+
+// migrationsSource is where the migrations are read from, MigrationsPath or the migrations directory
+func (g *Gemquick) migrationsSource() string {
+	if g.MigrationsPath != "" {
+		return "file://" + g.MigrationsPath
+	}
+
+	return "file://" + g.RootPath + "/migrations"
+}
@@ -0,0 +1,232 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jimmitjoo/gemquick/database"
+	"github.com/jimmitjoo/gemquick/email"
+	"github.com/jimmitjoo/gemquick/sms"
+)
+
+// The names of the channels a Notifier is created with
+const (
+	Mail     = "mail"
+	SMS      = "sms"
+	Database = "database"
+)
+
+// Notification is an interface that defines the method every notification must implement.
+// Via returns the names of the channels the notification should be delivered through, and for
+// each of those the notification implements the matching To... method below
+type Notification interface {
+	Via() []string
+}
+
+// MailNotification is a notification that can be delivered by email
+type MailNotification interface {
+	ToMail() email.Message
+}
+
+// SMSNotification is a notification that can be delivered by text message
+type SMSNotification interface {
+	ToSMS() SMSMessage
+}
+
+// DatabaseNotification is a notification that can be stored for the recipient to read later
+type DatabaseNotification interface {
+	ToDatabase() DatabaseMessage
+}
+
+// SMSMessage is a text message sent by the sms channel
+type SMSMessage struct {
+	To      string
+	Message string
+	Unicode bool
+}
+
+// DatabaseMessage is a row written to the notifications table by the database channel
+type DatabaseMessage struct {
+	UserID int
+	Type   string
+	Data   map[string]interface{}
+}
+
+// Channel is an interface that defines the method a delivery channel must implement
+type Channel interface {
+	Send(n Notification) error
+}
+
+// ContextChannel is a channel that also wants the context the notification was sent with,
+// e.g. to tag what it sends with the id of the request that sent it
+type ContextChannel interface {
+	SendContext(ctx context.Context, n Notification) error
+}
+
+// ErrUnsupported is returned by a channel when the notification does not implement
+// the To... method the channel needs
+var ErrUnsupported = errors.New("notification does not support this channel")
+
+// Notifier sends notifications through the channels they ask for
+type Notifier struct {
+	mu       sync.RWMutex
+	channels map[string]Channel
+}
+
+// New returns a notifier without any channels
+func New() *Notifier {
+	return &Notifier{channels: make(map[string]Channel)}
+}
+
+// Register makes a channel available under name, replacing any channel already using it
+func (n *Notifier) Register(name string, channel Channel) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.channels[name] = channel
+}
+
+// Send delivers the notification through every channel it is sent via. A failing channel
+// does not stop the others, and the errors of all failing channels are joined
+func (n *Notifier) Send(notification Notification) error {
+	return n.SendContext(context.Background(), notification)
+}
+
+// SendContext works like Send. Channels implementing ContextChannel get ctx, and when ctx
+// carries a request id the errors name the request so they can be traced back to it
+func (n *Notifier) SendContext(ctx context.Context, notification Notification) error {
+	requestID := middleware.GetReqID(ctx)
+
+	var errs []error
+
+	for _, name := range notification.Via() {
+		n.mu.RLock()
+		channel, exists := n.channels[name]
+		n.mu.RUnlock()
+
+		if !exists {
+			errs = append(errs, fmt.Errorf("notification channel %s is not configured", name))
+			continue
+		}
+
+		var err error
+		if cc, ok := channel.(ContextChannel); ok {
+			err = cc.SendContext(ctx, notification)
+		} else {
+			err = channel.Send(notification)
+		}
+
+		if err != nil {
+			if requestID != "" {
+				err = fmt.Errorf("request %s: %s channel: %w", requestID, name, err)
+			} else {
+				err = fmt.Errorf("%s channel: %w", name, err)
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// MailChannel sends notifications with the app's mailer
+type MailChannel struct {
+	Mailer *email.Mail
+}
+
+func (c *MailChannel) Send(n Notification) error {
+	return c.SendContext(context.Background(), n)
+}
+
+// SendContext sends the mail with the request id found in ctx, which the mailer adds as the
+// X-Request-ID header
+func (c *MailChannel) SendContext(ctx context.Context, n Notification) error {
+	mn, ok := n.(MailNotification)
+	if !ok {
+		return ErrUnsupported
+	}
+
+	msg := mn.ToMail()
+	if msg.From == "" {
+		msg.From = c.Mailer.From
+	}
+	if msg.FromName == "" {
+		msg.FromName = c.Mailer.FromName
+	}
+	if msg.RequestID == "" {
+		msg.RequestID = middleware.GetReqID(ctx)
+	}
+
+	return c.Mailer.Send(msg)
+}
+
+// SMSChannel sends notifications with the app's SMS provider. A text message has nowhere to
+// carry the request id, so failures are logged to ErrorLog with it instead
+type SMSChannel struct {
+	Provider sms.SMSProvider
+	ErrorLog *log.Logger
+}
+
+func (c *SMSChannel) Send(n Notification) error {
+	return c.SendContext(context.Background(), n)
+}
+
+// SendContext sends the text message and logs a failure with the request id found in ctx
+func (c *SMSChannel) SendContext(ctx context.Context, n Notification) error {
+	sn, ok := n.(SMSNotification)
+	if !ok {
+		return ErrUnsupported
+	}
+
+	msg := sn.ToSMS()
+
+	err := c.Provider.Send(msg.To, msg.Message, msg.Unicode)
+	if err != nil && c.ErrorLog != nil {
+		if requestID := middleware.GetReqID(ctx); requestID != "" {
+			c.ErrorLog.Printf("request %s: sending sms failed: %v", requestID, err)
+		} else {
+			c.ErrorLog.Printf("sending sms failed: %v", err)
+		}
+	}
+
+	return err
+}
+
+// DatabaseChannel stores notifications in the notifications table
+type DatabaseChannel struct {
+	DB       *sql.DB
+	DataType string
+	Table    string
+}
+
+func (c *DatabaseChannel) Send(n Notification) error {
+	dn, ok := n.(DatabaseNotification)
+	if !ok {
+		return ErrUnsupported
+	}
+
+	msg := dn.ToDatabase()
+
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		return err
+	}
+
+	table := c.Table
+	if table == "" {
+		table = "notifications"
+	}
+
+	query := database.Rebind(c.DataType, fmt.Sprintf("INSERT INTO %s (user_id, type, data, created_at) VALUES (?, ?, ?, ?)", table))
+
+	_, err = c.DB.Exec(query, msg.UserID, msg.Type, string(data), time.Now())
+
+	return err
+}
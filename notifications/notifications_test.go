@@ -0,0 +1,99 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type mockSMSProvider struct {
+	sent []string
+}
+
+func (m *mockSMSProvider) Send(to string, message string, unicode bool) error {
+	if to == "" {
+		return errors.New("a phone number is required")
+	}
+
+	m.sent = append(m.sent, to+":"+message)
+	return nil
+}
+
+type orderShipped struct {
+	Phone string
+	via   []string
+}
+
+func (o orderShipped) Via() []string {
+	return o.via
+}
+
+func (o orderShipped) ToSMS() SMSMessage {
+	return SMSMessage{To: o.Phone, Message: "Your order has shipped"}
+}
+
+type welcome struct{}
+
+func (w welcome) Via() []string {
+	return []string{SMS}
+}
+
+func TestNotifier_Send(t *testing.T) {
+	provider := &mockSMSProvider{}
+
+	n := New()
+	n.Register(SMS, &SMSChannel{Provider: provider})
+
+	err := n.Send(orderShipped{Phone: "0701234567", via: []string{SMS}})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(provider.sent) != 1 || provider.sent[0] != "0701234567:Your order has shipped" {
+		t.Error("expected the sms to be sent, got", provider.sent)
+	}
+
+	err = n.Send(orderShipped{Phone: "0701234567", via: []string{SMS, Mail}})
+	if err == nil {
+		t.Error("expected an error for the unconfigured mail channel")
+	}
+
+	if len(provider.sent) != 2 {
+		t.Error("expected the sms channel to deliver even though the mail channel failed")
+	}
+
+	err = n.Send(welcome{})
+	if !errors.Is(err, ErrUnsupported) {
+		t.Error("expected ErrUnsupported for a notification without ToSMS, got", err)
+	}
+}
+
+func TestNotifier_SendContext(t *testing.T) {
+	n := New()
+	n.Register(SMS, &SMSChannel{Provider: &mockSMSProvider{}})
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "host/abc-000001")
+	err := n.SendContext(ctx, orderShipped{via: []string{SMS}})
+	if err == nil || !strings.Contains(err.Error(), "request host/abc-000001: sms channel") {
+		t.Error("expected the error to name the request, got", err)
+	}
+}
+
+func TestSMSChannel_LogsRequestID(t *testing.T) {
+	var logged bytes.Buffer
+	channel := &SMSChannel{Provider: &mockSMSProvider{}, ErrorLog: log.New(&logged, "", 0)}
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "host/abc-000001")
+	if err := channel.SendContext(ctx, orderShipped{via: []string{SMS}}); err == nil {
+		t.Fatal("expected the provider's error")
+	}
+
+	if !strings.Contains(logged.String(), "request host/abc-000001: sending sms failed") {
+		t.Errorf("expected the failure to be logged with the request id, got %q", logged.String())
+	}
+}
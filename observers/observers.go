@@ -0,0 +1,58 @@
+// Package observers keeps the side effects of changing a model, like indexing it for search or
+// clearing its cache, in one place. A model calls its Registry after every change it writes, and
+// the registry tells the observers registered for the model
+package observers
+
+import (
+	"sync"
+)
+
+// Observer is an interface that defines the methods an observer of the model T must implement.
+// They are called after the change is written, and handle their own errors
+type Observer[T any] interface {
+	Created(m T)
+	Updated(m T)
+	Deleted(m T)
+}
+
+// Registry holds the observers of one model. The zero value is ready to use
+type Registry[T any] struct {
+	mu        sync.RWMutex
+	observers []Observer[T]
+}
+
+// Observe registers observers, which are called in the order they were registered
+func (r *Registry[T]) Observe(observers ...Observer[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.observers = append(r.observers, observers...)
+}
+
+// Created tells the observers that m was created
+func (r *Registry[T]) Created(m T) {
+	for _, o := range r.list() {
+		o.Created(m)
+	}
+}
+
+// Updated tells the observers that m was updated
+func (r *Registry[T]) Updated(m T) {
+	for _, o := range r.list() {
+		o.Updated(m)
+	}
+}
+
+// Deleted tells the observers that m was deleted
+func (r *Registry[T]) Deleted(m T) {
+	for _, o := range r.list() {
+		o.Deleted(m)
+	}
+}
+
+func (r *Registry[T]) list() []Observer[T] {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.observers
+}
@@ -0,0 +1,42 @@
+package observers
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type order struct {
+	ID int
+}
+
+type recorder struct {
+	name  string
+	calls *[]string
+}
+
+func (r recorder) Created(m order) { r.record("created", m) }
+func (r recorder) Updated(m order) { r.record("updated", m) }
+func (r recorder) Deleted(m order) { r.record("deleted", m) }
+
+func (r recorder) record(change string, m order) {
+	*r.calls = append(*r.calls, r.name+" "+change+" "+strconv.Itoa(m.ID))
+}
+
+func TestRegistry(t *testing.T) {
+	var calls []string
+	var orders Registry[order]
+
+	// without observers the changes go nowhere
+	orders.Created(order{ID: 1})
+
+	orders.Observe(recorder{"search", &calls}, recorder{"cache", &calls})
+	orders.Created(order{ID: 1})
+	orders.Updated(order{ID: 1})
+	orders.Deleted(order{ID: 2})
+
+	expected := "search created 1,cache created 1,search updated 1,cache updated 1,search deleted 2,cache deleted 2"
+	if got := strings.Join(calls, ","); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
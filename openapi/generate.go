@@ -0,0 +1,412 @@
+package openapi
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// routeMethods are the router methods Generate recognises, both the app's route helpers and chi's
+var routeMethods = map[string]string{
+	"get": "get", "post": "post", "put": "put", "patch": "patch", "delete": "delete",
+	"Get": "get", "Post": "post", "Put": "put", "Patch": "patch", "Delete": "delete",
+}
+
+// chiParam matches a chi path parameter with a pattern, e.g. {id:[0-9]+}
+var chiParam = regexp.MustCompile(`\{(\w+):[^}]*\}`)
+
+// Generator builds an OpenAPI document for an app from the routes registered in its routes.go and
+// the doc comments of its handlers, which can hold annotations like these:
+//
+//	// ShowOrder returns one order
+//	// @tag orders
+//	// @query expand string include related records
+//	// @body OrderRequest
+//	// @response 200 Order
+//	// @response 404
+//
+// The types named by @body and @response are looked up among the structs in the data and handlers
+// directories, and [] in front of a type makes it a list
+type Generator struct {
+	Root    string
+	Title   string
+	Version string
+	// Prefix limits the document to the routes below it, e.g. /api
+	Prefix string
+
+	structs map[string]*ast.StructType
+	schemas map[string]*Schema
+}
+
+type handlerDoc struct {
+	summary     string
+	description []string
+	tags        []string
+	body        string
+	responses   map[string]string
+	query       []Parameter
+}
+
+// Generate returns the document for the app in g.Root
+func (g *Generator) Generate() (*Spec, error) {
+	fset := token.NewFileSet()
+
+	routesFile, err := parser.ParseFile(fset, filepath.Join(g.Root, "routes.go"), nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := map[string]handlerDoc{}
+	g.structs = map[string]*ast.StructType{}
+	g.schemas = map[string]*Schema{}
+
+	for _, dir := range []string{"handlers", "data"} {
+		pkgs, err := parser.ParseDir(fset, filepath.Join(g.Root, dir), func(fi os.FileInfo) bool {
+			return !strings.HasSuffix(fi.Name(), "_test.go")
+		}, parser.ParseComments)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		for _, pkg := range pkgs {
+			for _, file := range pkg.Files {
+				g.collect(file, docs)
+			}
+		}
+	}
+
+	spec := &Spec{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: g.Title, Version: g.Version},
+		Paths:   map[string]PathItem{},
+	}
+
+	var walkErr error
+	g.walkRoutes(routesFile, "", func(method, path, handler string) {
+		if walkErr != nil || !strings.HasPrefix(path, g.Prefix) || strings.Contains(path, "*") {
+			return
+		}
+
+		op := g.operation(path, handler, docs[handler])
+		raw, err := json.Marshal(op)
+		if err != nil {
+			walkErr = err
+			return
+		}
+
+		if spec.Paths[path] == nil {
+			spec.Paths[path] = PathItem{}
+		}
+		spec.Paths[path][method] = raw
+	})
+	if walkErr != nil {
+		return nil, walkErr
+	}
+
+	if len(g.schemas) > 0 {
+		spec.Components.Schemas = g.schemas
+	}
+
+	return spec, nil
+}
+
+// collect remembers the doc comments of handler methods and the struct types of file
+func (g *Generator) collect(file *ast.File, docs map[string]handlerDoc) {
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil && d.Doc != nil {
+				docs[d.Name.Name] = parseDoc(d.Doc.Text())
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				if st, ok := ts.Type.(*ast.StructType); ok {
+					g.structs[ts.Name.Name] = st
+				}
+			}
+		}
+	}
+}
+
+// walkRoutes calls fn for every route registered in file, following chi's Route groups
+func (g *Generator) walkRoutes(node ast.Node, prefix string, fn func(method, path, handler string)) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) < 2 {
+			return true
+		}
+
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		path, ok := stringLiteral(call.Args[0])
+		if !ok {
+			return true
+		}
+
+		if sel.Sel.Name == "Route" {
+			if body, ok := call.Args[1].(*ast.FuncLit); ok {
+				g.walkRoutes(body.Body, prefix+path, fn)
+				return false
+			}
+		}
+
+		method, ok := routeMethods[sel.Sel.Name]
+		if !ok {
+			return true
+		}
+
+		handler := ""
+		switch h := call.Args[len(call.Args)-1].(type) {
+		case *ast.SelectorExpr:
+			handler = h.Sel.Name
+		case *ast.Ident:
+			handler = h.Name
+		}
+
+		fn(method, chiParam.ReplaceAllString(prefix+path, "{$1}"), handler)
+
+		return true
+	})
+}
+
+func (g *Generator) operation(path, handler string, doc handlerDoc) Operation {
+	op := Operation{
+		Summary:     doc.summary,
+		Description: strings.Join(doc.description, "\n"),
+		OperationID: handler,
+		Tags:        doc.tags,
+		Responses:   map[string]Response{},
+	}
+
+	for _, segment := range split(path) {
+		if isParam(segment) {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:     strings.Trim(segment, "{}"),
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+	}
+	op.Parameters = append(op.Parameters, doc.query...)
+
+	if doc.body != "" {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: g.typeSchema(doc.body)}},
+		}
+	}
+
+	for code, typeName := range doc.responses {
+		response := Response{Description: responseDescription(code)}
+		if typeName != "" {
+			response.Content = map[string]MediaType{"application/json": {Schema: g.typeSchema(typeName)}}
+		}
+		op.Responses[code] = response
+	}
+
+	if len(op.Responses) == 0 {
+		op.Responses["200"] = Response{Description: "OK"}
+	}
+
+	return op
+}
+
+// typeSchema returns the schema of a type named in an annotation, e.g. Order or []Order
+func (g *Generator) typeSchema(name string) *Schema {
+	if strings.HasPrefix(name, "[]") {
+		return &Schema{Type: "array", Items: g.typeSchema(strings.TrimPrefix(name, "[]"))}
+	}
+
+	if schema := basicSchema(name); schema != nil {
+		return schema
+	}
+
+	st, ok := g.structs[name]
+	if !ok {
+		return &Schema{Type: "object"}
+	}
+
+	if _, done := g.schemas[name]; !done {
+		// registered before the fields, so types that refer to themselves do not recurse forever
+		g.schemas[name] = &Schema{Type: "object"}
+		g.schemas[name] = g.structSchema(st)
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (g *Generator) structSchema(st *ast.StructType) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 || !field.Names[0].IsExported() {
+			continue
+		}
+
+		name := field.Names[0].Name
+		required := true
+		if field.Tag != nil {
+			tag, _ := strconv.Unquote(field.Tag.Value)
+			jsonTag := reflect.StructTag(tag).Get("json")
+			if jsonTag == "-" {
+				continue
+			}
+
+			tagName, options, _ := strings.Cut(jsonTag, ",")
+			if tagName != "" {
+				name = tagName
+			}
+			if strings.Contains(options, "omitempty") {
+				required = false
+			}
+		}
+
+		property := g.exprSchema(field.Type)
+		if _, pointer := field.Type.(*ast.StarExpr); pointer {
+			required = false
+		}
+
+		schema.Properties[name] = property
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	sort.Strings(schema.Required)
+
+	return schema
+}
+
+func (g *Generator) exprSchema(expr ast.Expr) *Schema {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return g.typeSchema(t.Name)
+	case *ast.StarExpr:
+		schema := g.exprSchema(t.X)
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.exprSchema(t.Elt)}
+	case *ast.MapType:
+		return &Schema{Type: "object", AdditionalProperties: &Additional{Allowed: true, Schema: g.exprSchema(t.Value)}}
+	case *ast.SelectorExpr:
+		if t.Sel.Name == "Time" {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		return g.typeSchema(t.Sel.Name)
+	}
+
+	return &Schema{}
+}
+
+func basicSchema(name string) *Schema {
+	switch name {
+	case "string":
+		return &Schema{Type: "string"}
+	case "bool":
+		return &Schema{Type: "boolean"}
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return &Schema{Type: "integer"}
+	case "float32", "float64":
+		return &Schema{Type: "number"}
+	case "interface{}", "any":
+		return &Schema{}
+	}
+
+	return nil
+}
+
+// parseDoc reads the summary, description and annotations of a handler's doc comment
+func parseDoc(text string) handlerDoc {
+	doc := handlerDoc{responses: map[string]string{}}
+
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "@") {
+			if doc.summary == "" {
+				doc.summary = line
+			} else if line != "" {
+				doc.description = append(doc.description, line)
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "@summary":
+			doc.summary = strings.TrimSpace(strings.TrimPrefix(line, "@summary"))
+		case "@tag":
+			doc.tags = append(doc.tags, fields[1:]...)
+		case "@body":
+			if len(fields) > 1 {
+				doc.body = fields[1]
+			}
+		case "@response":
+			if len(fields) > 2 {
+				doc.responses[fields[1]] = fields[2]
+			} else if len(fields) > 1 {
+				doc.responses[fields[1]] = ""
+			}
+		case "@query":
+			if len(fields) > 2 {
+				doc.query = append(doc.query, Parameter{
+					Name:        fields[1],
+					In:          "query",
+					Description: strings.Join(fields[3:], " "),
+					Schema:      basicSchema(fields[2]),
+				})
+			}
+		}
+	}
+
+	return doc
+}
+
+func responseDescription(code string) string {
+	status, err := strconv.Atoi(code)
+	if err != nil {
+		return "Response"
+	}
+
+	if text := http.StatusText(status); text != "" {
+		return text
+	}
+
+	return "Response"
+}
+
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+
+	return s, true
+}
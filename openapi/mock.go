@@ -0,0 +1,261 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Endpoint is a documented method and path, e.g. GET /users/{id}
+type Endpoint struct {
+	Method string
+	Path   string
+}
+
+// Endpoints returns every documented method and path, sorted by path
+func (s *Spec) Endpoints() []Endpoint {
+	var endpoints []Endpoint
+	for _, r := range s.routes {
+		for method := range r.operations {
+			endpoints = append(endpoints, Endpoint{Method: strings.ToUpper(method), Path: r.path})
+		}
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].Path != endpoints[j].Path {
+			return endpoints[i].Path < endpoints[j].Path
+		}
+		return endpoints[i].Method < endpoints[j].Method
+	})
+
+	return endpoints
+}
+
+// MockHandler answers documented requests with the example responses of the document, so that
+// clients can be built before the handlers are. The lowest documented 2xx status is used unless
+// the request asks for another one with a Prefer: code=404 header, and Prefer: example=name picks
+// one of several named examples. Responses without an example get one built from their schema
+func (s *Spec) MockHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", r.Header.Get("Access-Control-Request-Headers"))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		op := s.Operation(r.Method, r.URL.Path)
+		if op == nil {
+			writeErrors(w, http.StatusNotFound, r.Method+" "+r.URL.Path+" is not documented", nil)
+			return
+		}
+
+		if errs := s.validateRequest(op, r); len(errs) > 0 {
+			writeErrors(w, http.StatusBadRequest, "request does not match the api documentation", errs)
+			return
+		}
+
+		prefer := preferences(r.Header.Get("Prefer"))
+		status, response, ok := pickResponse(op, prefer["code"])
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		body, ok := s.example(response, prefer["example"])
+		if !ok {
+			w.WriteHeader(status)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+	})
+}
+
+// pickResponse returns the response for the code the client prefers, or the lowest 2xx one
+func pickResponse(op *Operation, code string) (int, Response, bool) {
+	if code != "" {
+		if response, ok := op.Responses[code]; ok {
+			status, _ := strconv.Atoi(code)
+			return status, response, true
+		}
+	}
+
+	var codes []string
+	for code := range op.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return 0, Response{}, false
+	}
+	sort.Strings(codes)
+
+	status, err := strconv.Atoi(codes[0])
+	if err != nil {
+		// a range like 2XX
+		status = http.StatusOK
+	}
+
+	return status, op.Responses[codes[0]], true
+}
+
+// example returns the JSON example of response, preferring the named one
+func (s *Spec) example(response Response, name string) ([]byte, bool) {
+	var media MediaType
+	found := false
+	for mediaType, m := range response.Content {
+		if isJSON(mediaType) {
+			media, found = m, true
+			break
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	if example, ok := media.Examples[name]; ok && len(example.Value) > 0 {
+		return example.Value, true
+	}
+	if len(media.Example) > 0 {
+		return media.Example, true
+	}
+
+	names := make([]string, 0, len(media.Examples))
+	for n := range media.Examples {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	if len(names) > 0 && len(media.Examples[names[0]].Value) > 0 {
+		return media.Examples[names[0]].Value, true
+	}
+
+	if media.Schema == nil {
+		return nil, false
+	}
+
+	body, err := json.Marshal(s.Sample(media.Schema))
+	if err != nil {
+		return nil, false
+	}
+
+	return body, true
+}
+
+// Sample builds a value that matches schema, using the examples, enums and formats it has
+func (s *Spec) Sample(schema *Schema) interface{} {
+	return s.sample(schema, 0)
+}
+
+func (s *Spec) sample(schema *Schema, depth int) interface{} {
+	schema, err := s.resolve(schema)
+	if err != nil || schema == nil || depth > 8 {
+		return nil
+	}
+
+	if schema.Example != nil {
+		return schema.Example
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+	if len(schema.AllOf) > 0 {
+		merged := map[string]interface{}{}
+		for _, sub := range schema.AllOf {
+			if object, ok := s.sample(sub, depth+1).(map[string]interface{}); ok {
+				for k, v := range object {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	if len(schema.OneOf) > 0 {
+		return s.sample(schema.OneOf[0], depth+1)
+	}
+	if len(schema.AnyOf) > 0 {
+		return s.sample(schema.AnyOf[0], depth+1)
+	}
+
+	switch schema.Type {
+	case "object", "":
+		object := map[string]interface{}{}
+		for name, property := range schema.Properties {
+			object[name] = s.sample(property, depth+1)
+		}
+		return object
+	case "array":
+		n := 1
+		if schema.MinItems != nil && *schema.MinItems > 1 {
+			n = *schema.MinItems
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			items[i] = s.sample(schema.Items, depth+1)
+		}
+		return items
+	case "string":
+		return sampleString(schema)
+	case "integer":
+		if schema.Minimum != nil {
+			return int(*schema.Minimum)
+		}
+		return 1
+	case "number":
+		if schema.Minimum != nil {
+			return *schema.Minimum
+		}
+		return 1.5
+	case "boolean":
+		return true
+	}
+
+	return nil
+}
+
+func sampleString(schema *Schema) string {
+	switch schema.Format {
+	case "date-time":
+		return "2024-01-01T12:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "uri", "url":
+		return "https://example.com"
+	}
+
+	str := "string"
+	if schema.MinLength != nil {
+		for len(str) < *schema.MinLength {
+			str += "string"
+		}
+	}
+	if schema.MaxLength != nil && len(str) > *schema.MaxLength {
+		str = str[:*schema.MaxLength]
+	}
+
+	return str
+}
+
+// preferences parses a Prefer header like "code=404, example=empty"
+func preferences(header string) map[string]string {
+	prefs := map[string]string{}
+	for _, part := range strings.FieldsFunc(header, func(r rune) bool { return r == ',' || r == ';' }) {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok {
+			prefs[key] = strings.Trim(value, `"`)
+		}
+	}
+
+	return prefs
+}
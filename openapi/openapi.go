@@ -0,0 +1,344 @@
+// Package openapi checks the JSON bodies of requests and responses against an OpenAPI 3 document,
+// so that the API and its documentation cannot drift apart unnoticed
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Spec is the part of an OpenAPI 3 document needed to validate payloads, mock the api and
+// generate the document
+type Spec struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+
+	// ValidateResponses makes Middleware check responses as well. A response that does not
+	// match the document is logged and replaced by a 500 Internal Server Error
+	ValidateResponses bool `json:"-"`
+	// ErrorLog receives the responses that do not match the document
+	ErrorLog *log.Logger `json:"-"`
+
+	routes []route
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem holds the operations of one path, keyed by lower case method
+type PathItem map[string]json.RawMessage
+
+// Operation is one method of a path
+type Operation struct {
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	OperationID string              `json:"operationId,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter is a path, query or header parameter of an operation
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema   *Schema            `json:"schema,omitempty"`
+	Example  json.RawMessage    `json:"example,omitempty"`
+	Examples map[string]Example `json:"examples,omitempty"`
+}
+
+type Example struct {
+	Value json.RawMessage `json:"value"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// route is a path of the document split into segments, where parameters match any segment
+type route struct {
+	path       string
+	segments   []string
+	params     int
+	operations map[string]*Operation
+}
+
+// Load reads the JSON OpenAPI document at path
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// Parse reads a JSON OpenAPI document
+func Parse(data []byte) (*Spec, error) {
+	spec := &Spec{ErrorLog: log.New(os.Stdout, "ERROR\t", log.Ldate|log.Ltime|log.Lshortfile)}
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("reading openapi document: %w", err)
+	}
+
+	for path, item := range spec.Paths {
+		r := route{path: path, segments: split(path), operations: make(map[string]*Operation)}
+		for _, segment := range r.segments {
+			if isParam(segment) {
+				r.params++
+			}
+		}
+
+		for method, raw := range item {
+			switch method {
+			case "get", "put", "post", "delete", "options", "head", "patch", "trace":
+			default:
+				continue
+			}
+
+			var op Operation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("reading %s %s: %w", strings.ToUpper(method), path, err)
+			}
+			r.operations[method] = &op
+		}
+
+		spec.routes = append(spec.routes, r)
+	}
+
+	// paths without parameters win over templated ones, so /users/me is found before /users/{id}
+	sort.Slice(spec.routes, func(i, j int) bool {
+		if spec.routes[i].params != spec.routes[j].params {
+			return spec.routes[i].params < spec.routes[j].params
+		}
+		return spec.routes[i].path < spec.routes[j].path
+	})
+
+	return spec, nil
+}
+
+// Operation returns the operation documented for method and path, or nil when there is none
+func (s *Spec) Operation(method, path string) *Operation {
+	segments := split(path)
+
+	for _, r := range s.routes {
+		if !r.match(segments) {
+			continue
+		}
+
+		if op, ok := r.operations[strings.ToLower(method)]; ok {
+			return op
+		}
+	}
+
+	return nil
+}
+
+// Middleware checks JSON request bodies against the document and answers 400 Bad Request with
+// the JSON pointer of every mismatch. Requests to undocumented paths pass through unchecked
+func (s *Spec) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op := s.Operation(r.Method, r.URL.Path)
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if errs := s.validateRequest(op, r); len(errs) > 0 {
+			writeErrors(w, http.StatusBadRequest, "request does not match the api documentation", errs)
+			return
+		}
+
+		if !s.ValidateResponses || len(op.Responses) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &recorder{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if errs := s.validateResponse(op, rec); len(errs) > 0 {
+			s.ErrorLog.Printf("%s %s responded with %d not matching the api documentation: %v", r.Method, r.URL.Path, rec.status, errs)
+			writeErrors(w, http.StatusInternalServerError, "response does not match the api documentation", errs)
+			return
+		}
+
+		rec.flush(w)
+	})
+}
+
+func (s *Spec) validateRequest(op *Operation, r *http.Request) Errors {
+	if op.RequestBody == nil {
+		return nil
+	}
+
+	schema, ok := jsonSchema(op.RequestBody.Content)
+	if !ok {
+		return nil
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType != "" && !isJSON(contentType) {
+		return nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return Errors{{Message: "could not read the body"}}
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			return Errors{{Message: "a body is required"}}
+		}
+		return nil
+	}
+
+	return s.validateBody(schema, body)
+}
+
+func (s *Spec) validateResponse(op *Operation, rec *recorder) Errors {
+	response, ok := op.Responses[strconv.Itoa(rec.status)]
+	if !ok {
+		response, ok = op.Responses[fmt.Sprintf("%dXX", rec.status/100)]
+	}
+	if !ok {
+		response, ok = op.Responses["default"]
+	}
+	if !ok {
+		return Errors{{Message: fmt.Sprintf("status %d is not documented", rec.status)}}
+	}
+
+	schema, ok := jsonSchema(response.Content)
+	if !ok || !isJSON(rec.header.Get("Content-Type")) {
+		return nil
+	}
+
+	return s.validateBody(schema, rec.body.Bytes())
+}
+
+func (s *Spec) validateBody(schema *Schema, body []byte) Errors {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return Errors{{Message: "body is not valid JSON: " + err.Error()}}
+	}
+
+	return s.Validate(schema, value)
+}
+
+// jsonSchema returns the schema of the first JSON media type in content
+func jsonSchema(content map[string]MediaType) (*Schema, bool) {
+	for mediaType, media := range content {
+		if isJSON(mediaType) && media.Schema != nil {
+			return media.Schema, true
+		}
+	}
+
+	return nil, false
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func writeErrors(w http.ResponseWriter, status int, message string, errs Errors) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": message, "errors": errs})
+}
+
+func (r route) match(segments []string) bool {
+	if len(segments) != len(r.segments) {
+		return false
+	}
+
+	for i, segment := range r.segments {
+		if isParam(segment) {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != segments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func split(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// recorder holds a response until it has been validated
+type recorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *recorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if rec.wroteHeader {
+		return
+	}
+	rec.status = status
+	rec.wroteHeader = true
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	return rec.body.Write(b)
+}
+
+func (rec *recorder) flush(w http.ResponseWriter) {
+	for k, v := range rec.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(rec.status)
+	_, _ = w.Write(rec.body.Bytes())
+}
@@ -0,0 +1,263 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var document = `{
+	"openapi": "3.0.3",
+	"paths": {
+		"/api/orders": {
+			"post": {
+				"requestBody": {
+					"required": true,
+					"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Order"}}}
+				},
+				"responses": {
+					"201": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Order"}}}}
+				}
+			}
+		},
+		"/api/orders/{id}": {
+			"get": {"responses": {
+				"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Order"}}}},
+				"404": {"content": {"application/json": {"example": {"error": "order not found"}}}}
+			}}
+		}
+	},
+	"components": {
+		"schemas": {
+			"Order": {
+				"type": "object",
+				"required": ["email", "items"],
+				"additionalProperties": false,
+				"properties": {
+					"id": {"type": "integer"},
+					"email": {"type": "string", "format": "email"},
+					"status": {"type": "string", "enum": ["open", "paid"]},
+					"items": {"type": "array", "minItems": 1, "items": {"$ref": "#/components/schemas/Item"}}
+				}
+			},
+			"Item": {
+				"type": "object",
+				"required": ["sku", "quantity"],
+				"properties": {
+					"sku": {"type": "string", "minLength": 3},
+					"quantity": {"type": "integer", "minimum": 1}
+				}
+			}
+		}
+	}
+}`
+
+func TestSpec_Validate(t *testing.T) {
+	spec, err := Parse([]byte(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name     string
+		body     string
+		pointers []string
+	}{
+		{"valid", `{"email": "me@here.com", "items": [{"sku": "abc", "quantity": 2}]}`, nil},
+		{"missing field", `{"items": [{"sku": "abc", "quantity": 2}]}`, []string{"/email"}},
+		{"wrong type", `{"email": "me@here.com", "items": {}}`, []string{"/items"}},
+		{"nested", `{"email": "me@here.com", "items": [{"sku": "ab", "quantity": 1.5}]}`, []string{"/items/0/quantity", "/items/0/sku"}},
+		{"enum and format", `{"email": "nope", "status": "lost", "items": [{"sku": "abc", "quantity": 1}]}`, []string{"/email", "/status"}},
+		{"unknown field", `{"email": "me@here.com", "items": [{"sku": "abc", "quantity": 1}], "note": "hi"}`, []string{"/note"}},
+	}
+
+	schema := &Schema{Ref: "#/components/schemas/Order"}
+	for _, e := range tests {
+		errs := spec.validateBody(schema, []byte(e.body))
+
+		var pointers []string
+		for _, err := range errs {
+			pointers = append(pointers, err.Pointer)
+		}
+
+		if strings.Join(pointers, ",") != strings.Join(e.pointers, ",") {
+			t.Errorf("%s: expected errors at %v, got %v", e.name, e.pointers, errs)
+		}
+	}
+}
+
+func TestSpec_Middleware(t *testing.T) {
+	spec, err := Parse([]byte(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.ValidateResponses = true
+	spec.ErrorLog = log.New(io.Discard, "", 0)
+
+	var response string
+	handler := spec.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if response == "" {
+			response = string(body)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(response))
+	}))
+
+	var tests = []struct {
+		name     string
+		path     string
+		body     string
+		response string
+		expected int
+	}{
+		{"valid", "/api/orders", `{"email": "me@here.com", "items": [{"sku": "abc", "quantity": 1}]}`, "", http.StatusCreated},
+		{"invalid request", "/api/orders", `{"email": "me@here.com", "items": []}`, "", http.StatusBadRequest},
+		{"empty body", "/api/orders", ``, "", http.StatusBadRequest},
+		{"invalid response", "/api/orders", `{"email": "me@here.com", "items": [{"sku": "abc", "quantity": 1}]}`, `{"id": "1"}`, http.StatusInternalServerError},
+		{"undocumented", "/api/customers", `{}`, "", http.StatusCreated},
+	}
+
+	for _, e := range tests {
+		response = e.response
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", e.path, bytes.NewBufferString(e.body))
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != e.expected {
+			t.Errorf("%s: expected status %d, got %d: %s", e.name, e.expected, rr.Code, rr.Body.String())
+		}
+
+		if rr.Code == http.StatusBadRequest {
+			var payload struct {
+				Errors []Error `json:"errors"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil || len(payload.Errors) == 0 {
+				t.Errorf("%s: expected the errors in the body, got %s", e.name, rr.Body.String())
+			}
+		}
+	}
+}
+
+func TestSpec_Operation(t *testing.T) {
+	spec, err := Parse([]byte(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if spec.Operation("GET", "/api/orders/12") == nil {
+		t.Error("expected /api/orders/{id} to match /api/orders/12")
+	}
+
+	if spec.Operation("DELETE", "/api/orders/12") != nil {
+		t.Error("did not expect an operation for an undocumented method")
+	}
+}
+
+func TestSpec_MockHandler(t *testing.T) {
+	spec, err := Parse([]byte(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock := spec.MockHandler()
+
+	var tests = []struct {
+		name     string
+		method   string
+		path     string
+		prefer   string
+		expected int
+		contains string
+	}{
+		{"sample from schema", "GET", "/api/orders/7", "", http.StatusOK, `"email":"user@example.com"`},
+		{"preferred code", "GET", "/api/orders/7", "code=404", http.StatusNotFound, `"order not found"`},
+		{"undocumented", "GET", "/api/customers", "", http.StatusNotFound, `is not documented`},
+		{"invalid request", "POST", "/api/orders", "", http.StatusBadRequest, `/email`},
+	}
+
+	for _, e := range tests {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(e.method, e.path, strings.NewReader(`{"items": []}`))
+		req.Header.Set("Prefer", e.prefer)
+		mock.ServeHTTP(rr, req)
+
+		if rr.Code != e.expected {
+			t.Errorf("%s: expected status %d, got %d", e.name, e.expected, rr.Code)
+		}
+
+		if !strings.Contains(rr.Body.String(), e.contains) {
+			t.Errorf("%s: expected %s in %s", e.name, e.contains, rr.Body.String())
+		}
+	}
+
+	sample := spec.Sample(&Schema{Ref: "#/components/schemas/Order"})
+	body, _ := json.Marshal(sample)
+	if errs := spec.validateBody(&Schema{Ref: "#/components/schemas/Order"}, body); len(errs) > 0 {
+		t.Errorf("expected the sample to match its schema, got %v", errs)
+	}
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	g := &Generator{Root: "testdata/app", Title: "shop", Version: "1.0.0", Prefix: "/api"}
+
+	spec, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := spec.Paths["/"]; ok {
+		t.Error("did not expect routes outside the prefix")
+	}
+
+	// the document is read back the way Middleware and MockHandler read it
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	show := parsed.Operation("GET", "/api/orders/12")
+	if show == nil {
+		t.Fatal("expected GET /api/orders/{id}")
+	}
+
+	if show.Summary != "ShowOrder returns one order" || show.Description != "Orders of other users are not found." {
+		t.Errorf("unexpected summary %q and description %q", show.Summary, show.Description)
+	}
+
+	if _, ok := show.Responses["404"]; !ok {
+		t.Error("expected the annotated 404 response")
+	}
+
+	order := parsed.Components.Schemas["Order"]
+	if order == nil {
+		t.Fatal("expected the Order schema")
+	}
+
+	if strings.Join(order.Required, ",") != "created_at,email,id" {
+		t.Errorf("unexpected required fields %v", order.Required)
+	}
+
+	if order.Properties["created_at"].Format != "date-time" || order.Properties["lines"].Items.Ref != "#/components/schemas/Line" {
+		t.Errorf("unexpected properties %+v", order.Properties)
+	}
+
+	create := parsed.Operation("POST", "/api/orders")
+	body := `{"email": "me@here.com", "items": ["abc"]}`
+	if errs := parsed.validateBody(create.RequestBody.Content["application/json"].Schema, []byte(body)); len(errs) > 0 {
+		t.Errorf("expected the body to match the generated schema, got %v", errs)
+	}
+}
@@ -0,0 +1,352 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schema is the part of an OpenAPI schema object that Validate checks
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Additional        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Example              interface{}        `json:"example,omitempty"`
+
+	patternOnce sync.Once
+	pattern     *regexp.Regexp
+}
+
+// Additional is the additionalProperties of a schema, which is either a boolean or a schema
+type Additional struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+func (a *Additional) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Allowed); err == nil {
+		return nil
+	}
+
+	a.Allowed = true
+	return json.Unmarshal(data, &a.Schema)
+}
+
+func (a Additional) MarshalJSON() ([]byte, error) {
+	if a.Schema != nil {
+		return json.Marshal(a.Schema)
+	}
+
+	return json.Marshal(a.Allowed)
+}
+
+// Error is a value that does not match its schema. Pointer is the JSON pointer to the value
+// in the document, e.g. /items/0/price, and is empty for the document itself
+type Error struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+func (e Error) Error() string {
+	if e.Pointer == "" {
+		return e.Message
+	}
+
+	return e.Pointer + ": " + e.Message
+}
+
+// Errors is every mismatch found in one document
+type Errors []Error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// Validate checks value, as decoded by encoding/json with UseNumber, against schema
+func (s *Spec) Validate(schema *Schema, value interface{}) Errors {
+	var errs Errors
+	s.validate(schema, value, "", &errs)
+
+	return errs
+}
+
+func (s *Spec) validate(schema *Schema, value interface{}, pointer string, errs *Errors) {
+	schema, err := s.resolve(schema)
+	if err != nil {
+		*errs = append(*errs, Error{Pointer: pointer, Message: err.Error()})
+		return
+	}
+	if schema == nil {
+		return
+	}
+
+	for _, sub := range schema.AllOf {
+		s.validate(sub, value, pointer, errs)
+	}
+
+	if len(schema.AnyOf) > 0 && s.matches(schema.AnyOf, value) == 0 {
+		*errs = append(*errs, Error{Pointer: pointer, Message: "does not match any of the allowed schemas"})
+	}
+
+	if len(schema.OneOf) > 0 {
+		if n := s.matches(schema.OneOf, value); n != 1 {
+			*errs = append(*errs, Error{Pointer: pointer, Message: fmt.Sprintf("must match exactly one schema, matches %d", n)})
+		}
+	}
+
+	if value == nil {
+		if !schema.Nullable && schema.Type != "" {
+			*errs = append(*errs, Error{Pointer: pointer, Message: "must not be null"})
+		}
+		return
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		*errs = append(*errs, Error{Pointer: pointer, Message: fmt.Sprintf("must be one of %s", enumList(schema.Enum))})
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			*errs = append(*errs, typeError(pointer, "an object", value))
+			return
+		}
+		s.validateObject(schema, object, pointer, errs)
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			*errs = append(*errs, typeError(pointer, "an array", value))
+			return
+		}
+		s.validateArray(schema, array, pointer, errs)
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			*errs = append(*errs, typeError(pointer, "a string", value))
+			return
+		}
+		validateString(schema, str, pointer, errs)
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			*errs = append(*errs, typeError(pointer, "a number", value))
+			return
+		}
+		validateNumber(schema, number, pointer, errs)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			*errs = append(*errs, typeError(pointer, "a boolean", value))
+		}
+	case "":
+		// schemas without a type only check what they do say, e.g. their properties
+		if object, ok := value.(map[string]interface{}); ok && len(schema.Properties) > 0 {
+			s.validateObject(schema, object, pointer, errs)
+		}
+	}
+}
+
+func (s *Spec) validateObject(schema *Schema, object map[string]interface{}, pointer string, errs *Errors) {
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			*errs = append(*errs, Error{Pointer: pointer + "/" + escape(name), Message: "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if property, ok := schema.Properties[name]; ok {
+			s.validate(property, object[name], pointer+"/"+escape(name), errs)
+			continue
+		}
+
+		if extra := schema.AdditionalProperties; extra != nil {
+			if !extra.Allowed {
+				*errs = append(*errs, Error{Pointer: pointer + "/" + escape(name), Message: "is not allowed"})
+			} else if extra.Schema != nil {
+				s.validate(extra.Schema, object[name], pointer+"/"+escape(name), errs)
+			}
+		}
+	}
+}
+
+func (s *Spec) validateArray(schema *Schema, array []interface{}, pointer string, errs *Errors) {
+	if schema.MinItems != nil && len(array) < *schema.MinItems {
+		*errs = append(*errs, Error{Pointer: pointer, Message: fmt.Sprintf("must have at least %d items", *schema.MinItems)})
+	}
+	if schema.MaxItems != nil && len(array) > *schema.MaxItems {
+		*errs = append(*errs, Error{Pointer: pointer, Message: fmt.Sprintf("must have at most %d items", *schema.MaxItems)})
+	}
+
+	if schema.Items == nil {
+		return
+	}
+
+	for i, item := range array {
+		s.validate(schema.Items, item, pointer+"/"+strconv.Itoa(i), errs)
+	}
+}
+
+func validateString(schema *Schema, str, pointer string, errs *Errors) {
+	length := len([]rune(str))
+	if schema.MinLength != nil && length < *schema.MinLength {
+		*errs = append(*errs, Error{Pointer: pointer, Message: fmt.Sprintf("must be at least %d characters", *schema.MinLength)})
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		*errs = append(*errs, Error{Pointer: pointer, Message: fmt.Sprintf("must be at most %d characters", *schema.MaxLength)})
+	}
+
+	if schema.Pattern != "" {
+		schema.patternOnce.Do(func() {
+			schema.pattern, _ = regexp.Compile(schema.Pattern)
+		})
+		if schema.pattern != nil && !schema.pattern.MatchString(str) {
+			*errs = append(*errs, Error{Pointer: pointer, Message: "must match " + schema.Pattern})
+		}
+	}
+
+	var err error
+	switch schema.Format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, str)
+	case "date":
+		_, err = time.Parse("2006-01-02", str)
+	case "email":
+		_, err = mail.ParseAddress(str)
+	case "uuid":
+		if !uuidPattern.MatchString(str) {
+			err = fmt.Errorf("invalid uuid")
+		}
+	}
+	if err != nil {
+		*errs = append(*errs, Error{Pointer: pointer, Message: "must be a valid " + schema.Format})
+	}
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func validateNumber(schema *Schema, number json.Number, pointer string, errs *Errors) {
+	f, err := number.Float64()
+	if err != nil {
+		*errs = append(*errs, Error{Pointer: pointer, Message: "must be a number"})
+		return
+	}
+
+	if schema.Type == "integer" && f != math.Trunc(f) {
+		*errs = append(*errs, Error{Pointer: pointer, Message: "must be an integer"})
+	}
+	if schema.Minimum != nil && f < *schema.Minimum {
+		*errs = append(*errs, Error{Pointer: pointer, Message: fmt.Sprintf("must be at least %v", *schema.Minimum)})
+	}
+	if schema.Maximum != nil && f > *schema.Maximum {
+		*errs = append(*errs, Error{Pointer: pointer, Message: fmt.Sprintf("must be at most %v", *schema.Maximum)})
+	}
+}
+
+// matches returns how many of schemas value matches
+func (s *Spec) matches(schemas []*Schema, value interface{}) int {
+	n := 0
+	for _, schema := range schemas {
+		if len(s.Validate(schema, value)) == 0 {
+			n++
+		}
+	}
+
+	return n
+}
+
+// resolve follows $ref to the schema in components/schemas
+func (s *Spec) resolve(schema *Schema) (*Schema, error) {
+	for i := 0; schema != nil && schema.Ref != ""; i++ {
+		if i > 32 {
+			return nil, fmt.Errorf("schema reference %s is circular", schema.Ref)
+		}
+
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		resolved, ok := s.Components.Schemas[name]
+		if !ok || name == schema.Ref {
+			return nil, fmt.Errorf("unknown schema reference %s", schema.Ref)
+		}
+		schema = resolved
+	}
+
+	return schema, nil
+}
+
+func typeError(pointer, expected string, value interface{}) Error {
+	return Error{Pointer: pointer, Message: fmt.Sprintf("must be %s, got %s", expected, jsonType(value))}
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case json.Number, float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return "null"
+	}
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func enumList(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, v := range enum {
+		values[i] = fmt.Sprint(v)
+	}
+
+	return strings.Join(values, ", ")
+}
+
+// escape encodes a property name for a JSON pointer as described in RFC 6901
+func escape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
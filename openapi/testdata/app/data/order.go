@@ -0,0 +1,18 @@
+package data
+
+import "time"
+
+type Order struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	Note      *string   `json:"note"`
+	Lines     []Line    `json:"lines,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	secret    string
+}
+
+type Line struct {
+	SKU      string  `json:"sku"`
+	Price    float64 `json:"price"`
+	Quantity int     `json:"quantity"`
+}
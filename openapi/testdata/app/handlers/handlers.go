@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+type Handlers struct{}
+
+// Home renders the home page
+func (h *Handlers) Home(w http.ResponseWriter, r *http.Request) {}
+
+// ListOrders returns the orders of the user
+// @tag orders
+// @query status string only orders with this status
+// @response 200 []Order
+func (h *Handlers) ListOrders(w http.ResponseWriter, r *http.Request) {}
+
+// CreateOrder places an order
+// @tag orders
+// @body OrderRequest
+// @response 201 Order
+// @response 422
+func (h *Handlers) CreateOrder(w http.ResponseWriter, r *http.Request) {}
+
+// ShowOrder returns one order
+//
+// Orders of other users are not found.
+// @tag orders
+// @response 200 Order
+// @response 404
+func (h *Handlers) ShowOrder(w http.ResponseWriter, r *http.Request) {}
+
+type OrderRequest struct {
+	Email string   `json:"email"`
+	Items []string `json:"items"`
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+func (route *application) routes() *chi.Mux {
+	route.get("/", route.Handlers.Home)
+
+	route.App.Routes.Route("/api", func(r chi.Router) {
+		r.Get("/orders", route.Handlers.ListOrders)
+		r.Post("/orders", route.Handlers.CreateOrder)
+		r.Get("/orders/{id:[0-9]+}", route.Handlers.ShowOrder)
+	})
+
+	return route.App.Routes
+}
@@ -0,0 +1,91 @@
+package policies
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// ErrForbidden is returned by Authorize when the user is not allowed to perform the action
+var ErrForbidden = errors.New("this action is not allowed")
+
+// Policy is an interface that defines the method a policy for one kind of resource must implement
+type Policy interface {
+	Can(user interface{}, action string, resource interface{}) bool
+}
+
+// PolicyFunc lets an ordinary function be used as a Policy
+type PolicyFunc func(user interface{}, action string, resource interface{}) bool
+
+func (f PolicyFunc) Can(user interface{}, action string, resource interface{}) bool {
+	return f(user, action, resource)
+}
+
+// Policies holds the registered policies, keyed by the type of resource they guard
+type Policies struct {
+	mu       sync.RWMutex
+	policies map[reflect.Type]Policy
+	before   []PolicyFunc
+}
+
+// New returns an empty set of policies
+func New() *Policies {
+	return &Policies{policies: make(map[reflect.Type]Policy)}
+}
+
+// Register sets the policy used for resources of the same type as resource.
+// Pointers and values of a type share the same policy, so &data.Post{} and data.Post{} are equal here
+func (p *Policies) Register(resource interface{}, policy Policy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.policies[typeOf(resource)] = policy
+}
+
+// Before adds a check that runs ahead of every policy, e.g. to let administrators do anything.
+// If any before check returns true the action is allowed without asking the resource's policy
+func (p *Policies) Before(check PolicyFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.before = append(p.before, check)
+}
+
+// Can reports whether user may perform action on resource. Resources without a registered
+// policy are always denied
+func (p *Policies) Can(user interface{}, action string, resource interface{}) bool {
+	p.mu.RLock()
+	before := p.before
+	policy, exists := p.policies[typeOf(resource)]
+	p.mu.RUnlock()
+
+	for _, check := range before {
+		if check(user, action, resource) {
+			return true
+		}
+	}
+
+	if !exists {
+		return false
+	}
+
+	return policy.Can(user, action, resource)
+}
+
+// Authorize is like Can, but returns ErrForbidden instead of false
+func (p *Policies) Authorize(user interface{}, action string, resource interface{}) error {
+	if !p.Can(user, action, resource) {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func typeOf(resource interface{}) reflect.Type {
+	t := reflect.TypeOf(resource)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
@@ -0,0 +1,77 @@
+package policies
+
+import (
+	"errors"
+	"testing"
+)
+
+type post struct {
+	UserID int
+}
+
+type user struct {
+	ID    int
+	Admin bool
+}
+
+var postPolicy = PolicyFunc(func(u interface{}, action string, resource interface{}) bool {
+	usr, ok := u.(*user)
+	if !ok {
+		return action == "view"
+	}
+
+	switch action {
+	case "view", "create":
+		return true
+	case "update", "delete":
+		return resource.(*post).UserID == usr.ID
+	}
+
+	return false
+})
+
+var canData = []struct {
+	name     string
+	user     interface{}
+	action   string
+	resource interface{}
+	expected bool
+}{
+	{"guest_can_view", nil, "view", &post{UserID: 1}, true},
+	{"guest_cannot_update", nil, "update", &post{UserID: 1}, false},
+	{"owner_can_update", &user{ID: 1}, "update", &post{UserID: 1}, true},
+	{"other_user_cannot_delete", &user{ID: 2}, "delete", &post{UserID: 1}, false},
+	{"unknown_action", &user{ID: 1}, "publish", &post{UserID: 1}, false},
+	{"admin_can_do_anything", &user{ID: 2, Admin: true}, "delete", &post{UserID: 1}, true},
+	{"unregistered_resource", &user{ID: 1}, "view", &user{ID: 1}, false},
+}
+
+func TestPolicies_Can(t *testing.T) {
+	p := New()
+	p.Register(post{}, postPolicy)
+	p.Before(func(u interface{}, action string, resource interface{}) bool {
+		usr, ok := u.(*user)
+		return ok && usr.Admin
+	})
+
+	for _, e := range canData {
+		if p.Can(e.user, e.action, e.resource) != e.expected {
+			t.Errorf("%s: expected %v", e.name, e.expected)
+		}
+	}
+}
+
+func TestPolicies_Authorize(t *testing.T) {
+	p := New()
+	p.Register(&post{}, postPolicy)
+
+	err := p.Authorize(&user{ID: 1}, "update", &post{UserID: 1})
+	if err != nil {
+		t.Error("expected owner to be authorized, got", err)
+	}
+
+	err = p.Authorize(&user{ID: 2}, "update", &post{UserID: 1})
+	if !errors.Is(err, ErrForbidden) {
+		t.Error("expected ErrForbidden, got", err)
+	}
+}
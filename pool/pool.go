@@ -0,0 +1,184 @@
+// Package pool runs functions in goroutines that can't take the whole process down when they panic
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PanicError is what a recovered panic is turned into
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n%s", e.Value, e.Stack)
+}
+
+// PanicHandler is called with every recovered panic. Gemquick points it at its error log at boot,
+// replace it to send panics to an error tracker
+var PanicHandler = func(err *PanicError) {
+	log.New(os.Stderr, "ERROR\t", log.Ldate|log.Ltime|log.Lshortfile).Println(err)
+}
+
+var panics int64
+
+// Panics returns the number of panics recovered since the process started
+func Panics() int64 {
+	return atomic.LoadInt64(&panics)
+}
+
+// Recover runs fn and hands a panic to PanicHandler instead of letting it crash the process.
+// It returns the panic, or nil if fn returned normally
+func Recover(fn func()) (err *PanicError) {
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddInt64(&panics, 1)
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+			PanicHandler(err)
+		}
+	}()
+
+	fn()
+
+	return nil
+}
+
+// SafeGo runs fn in a new goroutine with Recover
+func SafeGo(fn func()) {
+	go Recover(fn)
+}
+
+// Supervise runs fn in a goroutine and starts it again, after waiting backoff, whenever it panics.
+// It stops when fn returns normally or ctx is cancelled, and closes the returned channel when it has
+func Supervise(ctx context.Context, backoff time.Duration, fn func(ctx context.Context)) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for {
+			if Recover(func() { fn(ctx) }) == nil {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+		}
+	}()
+
+	return done
+}
+
+var ErrClosed = errors.New("pool: closed")
+
+// Pool runs submitted functions on a fixed number of workers, queueing up to a limit
+type Pool struct {
+	workers   int
+	jobs      chan func()
+	wg        sync.WaitGroup
+	mu        sync.RWMutex
+	closed    bool
+	running   int64
+	completed int64
+	panicked  int64
+}
+
+// Stats is a snapshot of what a pool is doing
+type Stats struct {
+	Workers   int
+	Running   int64
+	Queued    int
+	Completed int64
+	Panics    int64
+}
+
+// New starts a pool of workers goroutines with room for queue waiting functions
+func New(workers, queue int) *Pool {
+	p := &Pool{workers: workers, jobs: make(chan func(), queue)}
+
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+
+	return p
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	for fn := range p.jobs {
+		atomic.AddInt64(&p.running, 1)
+		if Recover(fn) != nil {
+			atomic.AddInt64(&p.panicked, 1)
+		}
+		atomic.AddInt64(&p.running, -1)
+		atomic.AddInt64(&p.completed, 1)
+	}
+}
+
+// Submit queues fn, waiting for room in the queue if it is full
+func (p *Pool) Submit(fn func()) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+
+	p.jobs <- fn
+
+	return nil
+}
+
+// TrySubmit queues fn if there is room, and reports whether it did
+func (p *Pool) TrySubmit(fn func()) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return false
+	}
+
+	select {
+	case p.jobs <- fn:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops accepting functions and waits for the queued ones to finish
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+// Stats returns what the pool is doing right now
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Workers:   p.workers,
+		Running:   atomic.LoadInt64(&p.running),
+		Queued:    len(p.jobs),
+		Completed: atomic.LoadInt64(&p.completed),
+		Panics:    atomic.LoadInt64(&p.panicked),
+	}
+}
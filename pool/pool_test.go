@@ -0,0 +1,108 @@
+package pool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRecover(t *testing.T) {
+	var handled *PanicError
+	old := PanicHandler
+	PanicHandler = func(err *PanicError) { handled = err }
+	defer func() { PanicHandler = old }()
+
+	before := Panics()
+
+	if err := Recover(func() {}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	err := Recover(func() { panic("boom") })
+	if err == nil || err.Value != "boom" {
+		t.Fatalf("expected panic boom, got %v", err)
+	}
+
+	if handled != err {
+		t.Error("expected PanicHandler to be called with the panic")
+	}
+
+	if Panics() != before+1 {
+		t.Errorf("expected panic count %d, got %d", before+1, Panics())
+	}
+}
+
+func TestPool(t *testing.T) {
+	old := PanicHandler
+	PanicHandler = func(err *PanicError) {}
+	defer func() { PanicHandler = old }()
+
+	p := New(4, 10)
+
+	var sum int64
+	for i := 1; i <= 100; i++ {
+		n := int64(i)
+		if err := p.Submit(func() { atomic.AddInt64(&sum, n) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := p.Submit(func() { panic("boom") }); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Close()
+
+	if sum != 5050 {
+		t.Errorf("expected sum 5050, got %d", sum)
+	}
+
+	stats := p.Stats()
+	if stats.Completed != 101 || stats.Panics != 1 || stats.Workers != 4 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	if err := p.Submit(func() {}); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+
+	if p.TrySubmit(func() {}) {
+		t.Error("expected TrySubmit to fail on a closed pool")
+	}
+}
+
+func TestSupervise(t *testing.T) {
+	old := PanicHandler
+	PanicHandler = func(err *PanicError) {}
+	defer func() { PanicHandler = old }()
+
+	var runs int64
+	done := Supervise(context.Background(), time.Millisecond, func(ctx context.Context) {
+		if atomic.AddInt64(&runs, 1) < 3 {
+			panic("boom")
+		}
+	})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("supervisor did not stop after fn returned")
+	}
+
+	if runs != 3 {
+		t.Errorf("expected 3 runs, got %d", runs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done = Supervise(ctx, time.Millisecond, func(ctx context.Context) {
+		<-ctx.Done()
+	})
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("supervisor did not stop after ctx was cancelled")
+	}
+}
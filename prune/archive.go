@@ -0,0 +1,233 @@
+package prune
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jimmitjoo/gemquick/database"
+	"github.com/jimmitjoo/gemquick/filesystems"
+)
+
+// archiveExt is the extension of the archives, which hold one JSON object per row, gzipped
+const archiveExt = ".ndjson.gz"
+
+// Archiver keeps the rows a Pruner deletes in cold storage. Every chunk is written to an archive
+// in Dir, named after its table and when it was written, e.g. audits-20261016T020000.000000000Z.ndjson.gz,
+// and uploaded to Folder on FS when it is set, before the rows are deleted. Restore puts them back
+//
+//	app.Pruner.Archiver = &prune.Archiver{Dir: "storage/archive"}
+type Archiver struct {
+	// Dir is where the archives are written, and kept when there is no FS
+	Dir string
+	// FS is the filesystem the archives are uploaded to, like minio or s3. The local copy is
+	// removed once it is uploaded
+	FS     filesystems.FS
+	Folder string
+
+	now func() time.Time
+}
+
+// Archive writes rows of table to a new archive and returns its name, the key on FS or the path
+// in Dir
+func (a *Archiver) Archive(table string, rows []map[string]interface{}) (string, error) {
+	if err := os.MkdirAll(a.Dir, 0755); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if a.now != nil {
+		now = a.now()
+	}
+
+	name := filepath.Join(a.Dir, table+"-"+now.UTC().Format("20060102T150405.000000000Z")+archiveExt)
+	if err := writeArchive(name, rows); err != nil {
+		_ = os.Remove(name)
+		return "", err
+	}
+
+	if a.FS == nil {
+		return name, nil
+	}
+
+	if err := a.FS.Put(name, a.Folder); err != nil {
+		return "", fmt.Errorf("uploading %s: %w", name, err)
+	}
+	_ = os.Remove(name)
+
+	return path.Join(a.Folder, filepath.Base(name)), nil
+}
+
+// Restore inserts the rows of an archive back into the table it was written for, all of them or
+// none, and returns that table and how many rows went back. An archive that is not in Dir is
+// downloaded from FS first
+func (a *Archiver) Restore(ctx context.Context, db *sql.DB, dataType, name string) (string, int64, error) {
+	table, _, found := strings.Cut(filepath.Base(name), "-")
+	if !found || !strings.HasSuffix(name, archiveExt) {
+		return "", 0, fmt.Errorf("%s is not an archive", name)
+	}
+
+	file := name
+	if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+		file = filepath.Join(a.Dir, filepath.Base(name))
+	}
+
+	if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) && a.FS != nil {
+		if err := os.MkdirAll(a.Dir, 0755); err != nil {
+			return table, 0, err
+		}
+		if err := a.FS.Get(a.Dir, name); err != nil {
+			return table, 0, fmt.Errorf("downloading %s: %w", name, err)
+		}
+		defer os.Remove(file)
+	}
+
+	rows, err := readArchive(file)
+	if err != nil {
+		return table, 0, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return table, 0, err
+	}
+
+	n, err := database.InsertMany(ctx, tx, dataType, table, rows, 0)
+	if err != nil {
+		_ = tx.Rollback()
+		return table, 0, err
+	}
+
+	return table, n, tx.Commit()
+}
+
+// writeArchive writes rows to a new gzipped file, one JSON object per line
+func writeArchive(name string, rows []map[string]interface{}) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw := gzip.NewWriter(f)
+	enc := json.NewEncoder(zw)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	// the rows are deleted once Archive returns, so the archive has to be on disk by then
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// readArchive reads the rows of an archive. Numbers come back as int64 or float64, and strings
+// that hold a time as time.Time, the way they were before they were written
+func readArchive(name string) ([]map[string]interface{}, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+	defer zr.Close()
+
+	dec := json.NewDecoder(zr)
+	dec.UseNumber()
+
+	var rows []map[string]interface{}
+	for {
+		var row map[string]interface{}
+		err := dec.Decode(&row)
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+
+		for column, v := range row {
+			row[column] = restoreValue(v)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func restoreValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t
+		}
+	case map[string]interface{}, []interface{}:
+		// json columns go back as the json they were read as
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+
+	return v
+}
+
+// rowsOf scans every row into a map of its columns. Text that mysql returns as bytes is turned
+// into strings, so that it is written as text rather than base64
+func rowsOf(rows *sql.Rows) ([]map[string]interface{}, error) {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		targets := make([]interface{}, len(columns))
+		for i := range values {
+			targets[i] = &values[i]
+		}
+
+		if err := rows.Scan(targets...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok && utf8.Valid(b) {
+				values[i] = string(b)
+			}
+			row[column] = values[i]
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
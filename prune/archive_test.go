@@ -0,0 +1,105 @@
+package prune
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var archivedAt = time.Date(2026, 10, 16, 2, 0, 0, 0, time.UTC)
+
+func TestPruner_Archive(t *testing.T) {
+	p, mock := newPruner(t, "mysql")
+	p.Archiver = &Archiver{Dir: t.TempDir(), now: func() time.Time { return archivedAt }}
+	p.Register(audit{})
+
+	created := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	mock.ExpectQuery(`^SELECT \* FROM audits WHERE created_at < \? ORDER BY id LIMIT 1000$`).WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "action", "created_at"}).
+			AddRow(1, []byte("login"), created).
+			AddRow(2, []byte("logout"), created))
+	mock.ExpectExec(`^DELETE FROM audits WHERE id IN \(\?, \?\)$`).WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	if _, err := p.Prune(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := readArchive(filepath.Join(p.Archiver.Dir, "audits-20261016T020000.000000000Z.ndjson.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 2 || rows[0]["id"] != int64(1) || rows[0]["action"] != "login" || !created.Equal(rows[0]["created_at"].(time.Time)) {
+		t.Errorf("expected the rows to be archived as they were, got %v", rows)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPruner_ArchiveFails(t *testing.T) {
+	p, mock := newPruner(t, "pgx")
+
+	// a file where the directory should be fails every archive
+	dir := filepath.Join(t.TempDir(), "archive")
+	if err := os.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	p.Archiver = &Archiver{Dir: dir}
+	p.Register(audit{})
+
+	mock.ExpectQuery(`^SELECT \* FROM audits`).WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	results, err := p.Prune(context.Background())
+	if err == nil || results[0].Rows != 0 {
+		t.Errorf("expected nothing to be deleted when archiving fails, got %+v, %v", results, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestArchiver_Restore(t *testing.T) {
+	a := &Archiver{Dir: t.TempDir(), now: func() time.Time { return archivedAt }}
+
+	name, err := a.Archive("audits", []map[string]interface{}{
+		{"id": 1, "action": "login"},
+		{"id": 2, "action": "logout"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`^INSERT INTO audits \(action, id\) VALUES \(\$1, \$2\), \(\$3, \$4\)$`).
+		WithArgs("login", int64(1), "logout", int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	table, n, err := a.Restore(context.Background(), db, "pgx", filepath.Base(name))
+	if err != nil || table != "audits" || n != 2 {
+		t.Errorf("expected 2 audits to be restored, got %s, %d, %v", table, n, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	if _, _, err := a.Restore(context.Background(), db, "pgx", "audits.csv"); err == nil {
+		t.Error("expected a file that is not an archive to be refused")
+	}
+}
@@ -0,0 +1,262 @@
+// Package prune keeps tables like audits, notifications and logs from growing without bound. A
+// model that implements Prunable says which of its rows are old enough to go, and a Pruner,
+// usually app.Pruner on the app's scheduler, deletes them in chunks so that no single statement
+// holds its locks for long
+package prune
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/database"
+	"github.com/robfig/cron/v3"
+)
+
+// DefaultChunkSize is how many rows a Pruner deletes per statement when it is given no size
+const DefaultChunkSize = 1000
+
+// Query selects the rows of Table to prune with the condition Where, which takes ? placeholders
+// for Args. Key is the primary key the rows are deleted by, id when it is empty
+type Query struct {
+	Table string
+	Key   string
+	Where string
+	Args  []interface{}
+}
+
+// Prunable is a model with rows that can be deleted once they are old, e.g.
+//
+//	func (a Audit) Prunable() prune.Query {
+//		return prune.Query{Table: "audits", Where: "created_at < ?", Args: []interface{}{time.Now().AddDate(0, -6, 0)}}
+//	}
+type Prunable interface {
+	Prunable() Query
+}
+
+// Result is what a Pruner did to one table, or would have done in a dry run
+type Result struct {
+	Table    string        `json:"table"`
+	Rows     int64         `json:"rows"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Pruner deletes the rows the registered models select for pruning
+type Pruner struct {
+	DB       *sql.DB
+	DataType string
+	// ChunkSize is how many rows are deleted per statement, DefaultChunkSize when it is 0
+	ChunkSize int
+	InfoLog   *log.Logger
+	ErrorLog  *log.Logger
+	// Archiver, when set, archives every chunk before it is deleted
+	Archiver *Archiver
+
+	mu     sync.RWMutex
+	models []Prunable
+}
+
+// New returns a pruner for db, which is of the given DATABASE_TYPE
+func New(db *sql.DB, dataType string) *Pruner {
+	return &Pruner{
+		DB:       db,
+		DataType: dataType,
+		ErrorLog: log.New(os.Stderr, "ERROR\t", log.Ldate|log.Ltime|log.Lshortfile),
+	}
+}
+
+// Register adds models to be pruned
+func (p *Pruner) Register(models ...Prunable) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.models = append(p.models, models...)
+}
+
+// Prune deletes the rows every registered model selects, a chunk at a time, and returns how many
+// rows each table lost. A failing table does not stop the others, and their errors are joined
+func (p *Pruner) Prune(ctx context.Context) ([]Result, error) {
+	return p.run(ctx, false)
+}
+
+// DryRun counts the rows Prune would delete, without deleting them
+func (p *Pruner) DryRun(ctx context.Context) ([]Result, error) {
+	return p.run(ctx, true)
+}
+
+// PruneOn schedules Prune with the cron spec, e.g. "@daily" on app.Scheduler, logging what it
+// deleted and its errors
+func (p *Pruner) PruneOn(scheduler *cron.Cron, spec string) error {
+	_, err := scheduler.AddFunc(spec, func() {
+		results, err := p.Prune(context.Background())
+		for _, res := range results {
+			if p.InfoLog != nil && res.Rows > 0 {
+				p.InfoLog.Printf("pruned %d rows from %s in %s", res.Rows, res.Table, res.Duration)
+			}
+		}
+		if err != nil && p.ErrorLog != nil {
+			p.ErrorLog.Println("pruning:", err)
+		}
+	})
+
+	return err
+}
+
+func (p *Pruner) run(ctx context.Context, dryRun bool) ([]Result, error) {
+	p.mu.RLock()
+	models := append([]Prunable(nil), p.models...)
+	p.mu.RUnlock()
+
+	var results []Result
+	var errs []error
+
+	for _, model := range models {
+		q := model.Prunable()
+		if q.Key == "" {
+			q.Key = "id"
+		}
+
+		start := time.Now()
+
+		var rows int64
+		var err error
+		if dryRun {
+			rows, err = p.count(ctx, q)
+		} else {
+			rows, err = p.prune(ctx, q)
+		}
+
+		results = append(results, Result{Table: q.Table, Rows: rows, Duration: time.Since(start)})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", q.Table, err))
+		}
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// prune deletes the rows q selects, ChunkSize at a time, until none are left
+func (p *Pruner) prune(ctx context.Context, q Query) (int64, error) {
+	if q.Where == "" {
+		return 0, errors.New("a prune query needs a condition")
+	}
+
+	chunkSize := p.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	columns := q.Key
+	if p.Archiver != nil {
+		columns = "*"
+	}
+	selectChunk := database.Rebind(p.DataType, fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s LIMIT %d",
+		columns, q.Table, q.Where, q.Key, chunkSize))
+
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		keys, err := p.chunk(ctx, selectChunk, q)
+		if err != nil {
+			return total, err
+		}
+
+		if len(keys) == 0 {
+			return total, nil
+		}
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ")
+		res, err := p.DB.ExecContext(ctx, database.Rebind(p.DataType,
+			fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)", q.Table, q.Key, placeholders)), keys...)
+		if err != nil {
+			return total, err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += n
+
+		// rows that are selected but not deleted would be selected again forever
+		if n == 0 || len(keys) < chunkSize {
+			return total, nil
+		}
+	}
+}
+
+// chunk runs the query selecting the next chunk and returns the keys of its rows. With an
+// Archiver the whole rows are selected, and archived before the keys are returned
+func (p *Pruner) chunk(ctx context.Context, query string, q Query) ([]interface{}, error) {
+	rows, err := p.DB.QueryContext(ctx, query, q.Args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Archiver == nil {
+		return keysOf(rows)
+	}
+
+	archived, err := rowsOf(rows)
+	if err != nil || len(archived) == 0 {
+		return nil, err
+	}
+
+	keys := make([]interface{}, 0, len(archived))
+	for _, row := range archived {
+		key, ok := row[q.Key]
+		if !ok {
+			return nil, fmt.Errorf("the rows have no %s column to delete them by", q.Key)
+		}
+		keys = append(keys, key)
+	}
+
+	name, err := p.Archiver.Archive(q.Table, archived)
+	if err != nil {
+		return nil, fmt.Errorf("archiving: %w", err)
+	}
+
+	if p.InfoLog != nil {
+		p.InfoLog.Printf("archived %d rows from %s to %s", len(archived), q.Table, name)
+	}
+
+	return keys, nil
+}
+
+// keysOf scans the keys of a chunk
+func keysOf(rows *sql.Rows) ([]interface{}, error) {
+	defer rows.Close()
+
+	var keys []interface{}
+	for rows.Next() {
+		var key interface{}
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// count returns how many rows q selects
+func (p *Pruner) count(ctx context.Context, q Query) (int64, error) {
+	if q.Where == "" {
+		return 0, errors.New("a prune query needs a condition")
+	}
+
+	var n int64
+	err := p.DB.QueryRowContext(ctx, database.Rebind(p.DataType,
+		fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", q.Table, q.Where)), q.Args...).Scan(&n)
+
+	return n, err
+}
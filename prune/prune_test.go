@@ -0,0 +1,102 @@
+package prune
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var cutoff = time.Date(2026, 4, 16, 0, 0, 0, 0, time.UTC)
+
+type audit struct{}
+
+func (audit) Prunable() Query {
+	return Query{Table: "audits", Where: "created_at < ?", Args: []interface{}{cutoff}}
+}
+
+type session struct{}
+
+func (session) Prunable() Query {
+	return Query{Table: "sessions", Key: "token", Where: "expiry < ?", Args: []interface{}{cutoff}}
+}
+
+func newPruner(t *testing.T, dataType string) (*Pruner, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return New(db, dataType), mock
+}
+
+func TestPruner_Prune(t *testing.T) {
+	p, mock := newPruner(t, "pgx")
+	p.ChunkSize = 2
+	p.Register(audit{})
+
+	mock.ExpectQuery(`^SELECT id FROM audits WHERE created_at < \$1 ORDER BY id LIMIT 2$`).WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectExec(`^DELETE FROM audits WHERE id IN \(\$1, \$2\)$`).WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`^SELECT id FROM audits`).WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectExec(`^DELETE FROM audits WHERE id IN \(\$1\)$`).WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	results, err := p.Prune(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 1 || results[0].Table != "audits" || results[0].Rows != 3 {
+		t.Errorf("expected 3 audits to be pruned, got %+v", results)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPruner_KeepsGoing(t *testing.T) {
+	p, mock := newPruner(t, "mysql")
+	p.Register(audit{}, session{})
+
+	mock.ExpectQuery(`^SELECT id FROM audits WHERE created_at < \? ORDER BY id LIMIT 1000$`).
+		WillReturnError(errors.New("table audits does not exist"))
+	mock.ExpectQuery(`^SELECT token FROM sessions WHERE expiry < \? ORDER BY token LIMIT 1000$`).
+		WillReturnRows(sqlmock.NewRows([]string{"token"}).AddRow("abc"))
+	mock.ExpectExec(`^DELETE FROM sessions WHERE token IN \(\?\)$`).WithArgs("abc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	results, err := p.Prune(context.Background())
+	if err == nil {
+		t.Error("expected the error of the audits")
+	}
+
+	if len(results) != 2 || results[1].Rows != 1 {
+		t.Errorf("expected the sessions to be pruned after the audits failed, got %+v", results)
+	}
+}
+
+func TestPruner_DryRun(t *testing.T) {
+	p, mock := newPruner(t, "pgx")
+	p.Register(audit{})
+
+	mock.ExpectQuery(`^SELECT COUNT\(\*\) FROM audits WHERE created_at < \$1$`).WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	results, err := p.DryRun(context.Background())
+	if err != nil || len(results) != 1 || results[0].Rows != 42 {
+		t.Errorf("expected 42 audits to be counted, got %+v, %v", results, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
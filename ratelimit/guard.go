@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type attempts struct {
+	start       time.Time
+	count       int
+	lockedUntil time.Time
+}
+
+// Guard is a strict throttle for endpoints attackers guess at, like password resets and one-time
+// codes. It counts attempts per key, usually the IP and the account they are for, allows MaxAttempts
+// of them per Window and then locks the key out for Cooloff. After ChallengeAfter attempts it asks
+// for a captcha, see NeedsChallenge. The keys of IPs in Penalties get fewer attempts
+type Guard struct {
+	MaxAttempts    int
+	Window         time.Duration
+	Cooloff        time.Duration
+	ChallengeAfter int
+	Penalties      *Penalties
+
+	mu        sync.Mutex
+	attempts  map[string]*attempts
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewGuard returns a guard that allows maxAttempts per window for every key, and locks a key out
+// for cooloff once it has used them up. It never asks for a captcha until ChallengeAfter is set
+func NewGuard(maxAttempts int, window, cooloff time.Duration) *Guard {
+	return &Guard{MaxAttempts: maxAttempts, Window: window, Cooloff: cooloff, attempts: map[string]*attempts{}, now: time.Now}
+}
+
+// Key is the key of an attempt at the endpoint of r on identifier, like an email address, from the
+// IP of r. Attempts at one account from many IPs and at many accounts from one IP are each counted
+// on their own, so that a user is not locked out by an attacker somewhere else
+func Key(r *http.Request, identifier string) string {
+	return r.URL.Path + "|" + ByIP(r) + "|" + strings.ToLower(strings.TrimSpace(identifier))
+}
+
+// Hit counts an attempt for key, and reports whether it is allowed. When it is not, it returns how
+// long until the key may try again
+func (g *Guard) Hit(key string) (bool, time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	g.sweep(now)
+
+	a := g.current(key, now)
+	if now.Before(a.lockedUntil) {
+		return false, a.lockedUntil.Sub(now)
+	}
+
+	a.count++
+	if a.count > g.Penalties.limit(keyIP(key), g.MaxAttempts) {
+		a.lockedUntil = now.Add(g.Cooloff)
+		return false, g.Cooloff
+	}
+
+	return true, 0
+}
+
+// NeedsChallenge reports whether the attempts of key have to come with a solved captcha
+func (g *Guard) NeedsChallenge(key string) bool {
+	if g.ChallengeAfter <= 0 {
+		return false
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.current(key, g.now()).count > g.Penalties.limit(keyIP(key), g.ChallengeAfter)
+}
+
+// Reset forgets the attempts of key, for handlers to call once an attempt succeeds, like a correct
+// one-time code
+func (g *Guard) Reset(key string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.attempts, key)
+	return nil
+}
+
+// current returns the attempts of key in the current window. Must be called with mu held
+func (g *Guard) current(key string, now time.Time) *attempts {
+	a, ok := g.attempts[key]
+	if !ok || (now.Sub(a.start) >= g.Window && !now.Before(a.lockedUntil)) {
+		a = &attempts{start: now}
+		g.attempts[key] = a
+	}
+
+	return a
+}
+
+// sweep drops the keys whose window and cooloff have ended, once per window. Must be called with
+// mu held
+func (g *Guard) sweep(now time.Time) {
+	if now.Sub(g.lastSweep) < g.Window {
+		return
+	}
+	g.lastSweep = now
+
+	for key, a := range g.attempts {
+		if now.Sub(a.start) >= g.Window && !now.Before(a.lockedUntil) {
+			delete(g.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGuard(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	g := NewGuard(3, 15*time.Minute, time.Hour)
+	g.ChallengeAfter = 2
+	g.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := g.Hit("ada"); !ok {
+			t.Fatalf("expected attempt %d to be allowed", i+1)
+		}
+		if want := i >= 2; g.NeedsChallenge("ada") != want {
+			t.Errorf("expected a challenge after attempt %d to be %v", i+1, want)
+		}
+	}
+
+	if ok, retry := g.Hit("ada"); ok || retry != time.Hour {
+		t.Errorf("expected the fourth attempt to lock the key out for an hour, got %v, %s", ok, retry)
+	}
+
+	// the lockout outlasts the window
+	now = now.Add(30 * time.Minute)
+	if ok, retry := g.Hit("ada"); ok || retry != 30*time.Minute {
+		t.Errorf("expected the key to stay locked out, got %v, %s", ok, retry)
+	}
+	if ok, _ := g.Hit("grace"); !ok {
+		t.Error("expected another key to have attempts of its own")
+	}
+
+	now = now.Add(30 * time.Minute)
+	if ok, _ := g.Hit("ada"); !ok {
+		t.Error("expected the key to be allowed again after the cooloff")
+	}
+
+	_ = g.Reset("ada")
+	if g.NeedsChallenge("ada") {
+		t.Error("expected a reset key to need no challenge")
+	}
+}
+
+func TestKey(t *testing.T) {
+	r := httptest.NewRequest("POST", "/forgot-password", nil)
+	r.RemoteAddr = "10.0.0.1:5123"
+
+	if key := Key(r, " Ada@Example.com"); key != "/forgot-password|10.0.0.1|ada@example.com" {
+		t.Errorf("unexpected key %q", key)
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Penalties holds the IPs that are known for abuse, like the ones a blocklist lists, for a while.
+// A Guard allows the keys of penalized IPs a Factor of its attempts
+type Penalties struct {
+	// Factor is what the attempts of penalized IPs are divided by, leaving them at least one
+	Factor int
+
+	mu    sync.Mutex
+	until map[string]time.Time
+	now   func() time.Time
+}
+
+// NewPenalties returns penalties that leave penalized IPs a quarter of their attempts
+func NewPenalties() *Penalties {
+	return &Penalties{Factor: 4, until: map[string]time.Time{}, now: time.Now}
+}
+
+// Penalize penalizes ip for d
+func (p *Penalties) Penalize(ip string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	for other, until := range p.until {
+		if !now.Before(until) {
+			delete(p.until, other)
+		}
+	}
+
+	p.until[ip] = now.Add(d)
+}
+
+// Penalized reports whether ip is penalized now
+func (p *Penalties) Penalized(ip string) bool {
+	if p == nil {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.now().Before(p.until[ip])
+}
+
+// limit returns what is left of limit for ip
+func (p *Penalties) limit(ip string, limit int) int {
+	if !p.Penalized(ip) || p.Factor <= 1 {
+		return limit
+	}
+
+	if limit /= p.Factor; limit < 1 {
+		return 1
+	}
+
+	return limit
+}
+
+// keyIP returns the IP of a key made by Key
+func keyIP(key string) string {
+	parts := strings.SplitN(key, "|", 3)
+	if len(parts) < 2 {
+		return key
+	}
+
+	return parts[1]
+}
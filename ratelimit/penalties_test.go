@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPenalties(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := NewPenalties()
+	p.now = func() time.Time { return now }
+
+	g := NewGuard(8, 15*time.Minute, time.Hour)
+	g.Penalties = p
+	g.now = p.now
+
+	p.Penalize("203.0.113.9", time.Hour)
+	if !p.Penalized("203.0.113.9") || p.Penalized("198.51.100.1") {
+		t.Fatal("expected only the penalized IP to be penalized")
+	}
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := g.Hit("/forgot-password|203.0.113.9|ada"); !ok {
+			t.Fatalf("expected attempt %d of the penalized IP to be allowed", i+1)
+		}
+	}
+	if ok, _ := g.Hit("/forgot-password|203.0.113.9|ada"); ok {
+		t.Error("expected the penalized IP to get a quarter of the attempts")
+	}
+	if ok, _ := g.Hit("/forgot-password|198.51.100.1|ada"); !ok {
+		t.Error("expected another IP to keep its attempts")
+	}
+
+	now = now.Add(time.Hour)
+	if p.Penalized("203.0.113.9") {
+		t.Error("expected the penalty to end")
+	}
+
+	var none *Penalties
+	if none.Penalized("203.0.113.9") {
+		t.Error("expected no penalties to penalize nothing")
+	}
+}
@@ -0,0 +1,113 @@
+// Package ratelimit counts requests per key, like a client's IP, and refuses them once a key has
+// used up its limit for the current window
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type window struct {
+	start time.Time
+	count int
+}
+
+// Limiter allows Limit requests per Window for every key. It keeps its counts in memory, so every
+// instance of the app counts on its own
+type Limiter struct {
+	Limit  int
+	Window time.Duration
+
+	mu        sync.Mutex
+	windows   map[string]*window
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// New returns a limiter that allows limit requests per window for every key
+func New(limit int, per time.Duration) *Limiter {
+	return &Limiter{Limit: limit, Window: per, windows: map[string]*window{}, now: time.Now}
+}
+
+// Allow counts a request for key, and reports whether it is within the limit. When it is not,
+// it returns how long until the key may try again
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.Window {
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+
+	if w.count >= l.Limit {
+		return false, w.start.Add(l.Window).Sub(now)
+	}
+	w.count++
+
+	return true, 0
+}
+
+// Reset forgets the requests counted for key
+func (l *Limiter) Reset(key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.windows, key)
+	return nil
+}
+
+// sweep drops the windows that have ended, once per window, so that keys that stop coming do not
+// stay in memory. Must be called with mu held
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.Window {
+		return
+	}
+	l.lastSweep = now
+
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.Window {
+			delete(l.windows, key)
+		}
+	}
+}
+
+// Middleware refuses the requests over the limit with 429 Too Many Requests and a Retry-After
+// header. key returns what requests are counted by, ByIP when it is nil
+func (l *Limiter) Middleware(key func(r *http.Request) string) func(http.Handler) http.Handler {
+	if key == nil {
+		key = ByIP
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, retry := l.Allow(key(r))
+			if !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ByIP is the IP the request came from, which is the client's behind a proxy once chi's RealIP
+// middleware has run, as it does in every gemquick app
+func ByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := New(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("10.0.0.1"); !ok {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+
+	if ok, retry := l.Allow("10.0.0.1"); ok || retry != time.Minute {
+		t.Errorf("expected the third request to wait a minute, got %v, %s", ok, retry)
+	}
+
+	if ok, _ := l.Allow("10.0.0.2"); !ok {
+		t.Error("expected another key to have a limit of its own")
+	}
+
+	now = now.Add(time.Minute)
+	if ok, _ := l.Allow("10.0.0.1"); !ok {
+		t.Error("expected the limit to start over in the next window")
+	}
+	if len(l.windows) != 1 {
+		t.Errorf("expected the ended windows to be swept, got %d", len(l.windows))
+	}
+
+	_, _ = l.Allow("10.0.0.1")
+	_ = l.Reset("10.0.0.1")
+	if ok, _ := l.Allow("10.0.0.1"); !ok {
+		t.Error("expected a reset key to be allowed again")
+	}
+}
+
+func TestLimiter_Middleware(t *testing.T) {
+	l := New(1, time.Minute)
+	handler := l.Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/", nil)
+		req.RemoteAddr = "10.0.0.1:5123"
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do(); rr.Code != http.StatusOK {
+		t.Errorf("expected the first request to pass, got %d", rr.Code)
+	}
+
+	if rr := do(); rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "60" {
+		t.Errorf("expected 429 with Retry-After, got %d and %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,143 @@
+package render
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/CloudyKit/jet/v6"
+)
+
+// ComposerFunc returns variables for a template, computed from the request that renders it
+type ComposerFunc func(r *http.Request) map[string]interface{}
+
+type composerState struct {
+	composers map[string][]ComposerFunc
+	// templates each jet template includes, extends or imports, read again when the file changes
+	deps map[string]jetDeps
+}
+
+type jetDeps struct {
+	modTime time.Time
+	names   []string
+}
+
+var jetReference = regexp.MustCompile(`{{-?\s*(?:include|extends|import)\s+"([^"]+)"`)
+
+// Composer registers fn to run whenever view is rendered, on its own or included, extended or imported
+// by the rendered page, e.g. g.Render.Composer("partials/sidebar", func(r *http.Request) map[string]interface{} {...}).
+// The variables it returns are set on jet pages and in TemplateData.Data on go pages,
+// without overwriting variables the handler passed itself
+func (g *Render) Composer(view string, fn ComposerFunc) {
+	g.composerMu.Lock()
+	defer g.composerMu.Unlock()
+
+	if g.composerState == nil {
+		g.composerState = &composerState{composers: map[string][]ComposerFunc{}, deps: map[string]jetDeps{}}
+	}
+
+	name := normalizeView(view)
+	g.composerState.composers[name] = append(g.composerState.composers[name], fn)
+}
+
+// compose runs the composers of view and the templates it uses, and merges their variables
+func (g *Render) compose(r *http.Request, view string, jetTemplate bool) map[string]interface{} {
+	g.composerMu.Lock()
+	if g.composerState == nil || len(g.composerState.composers) == 0 {
+		g.composerMu.Unlock()
+		return nil
+	}
+
+	names := []string{normalizeView(view)}
+	if jetTemplate {
+		names = g.jetDependencies(names[0], map[string]bool{})
+	}
+
+	var fns []ComposerFunc
+	for _, name := range names {
+		fns = append(fns, g.composerState.composers[name]...)
+	}
+	g.composerMu.Unlock()
+
+	// composers often query the database, so they run without holding the lock
+	vars := map[string]interface{}{}
+	for _, fn := range fns {
+		for k, v := range fn(r) {
+			vars[k] = v
+		}
+	}
+
+	return vars
+}
+
+// jetDependencies returns name and every template it references, must be called with composerMu held
+func (g *Render) jetDependencies(name string, seen map[string]bool) []string {
+	if seen[name] {
+		return nil
+	}
+	seen[name] = true
+
+	file := filepath.Join(g.RootPath, "views", name+".jet")
+	info, err := os.Stat(file)
+	if err != nil {
+		return []string{name}
+	}
+
+	deps, ok := g.composerState.deps[name]
+	if !ok || !deps.modTime.Equal(info.ModTime()) {
+		deps = jetDeps{modTime: info.ModTime(), names: g.readJetDependencies(name, file)}
+		g.composerState.deps[name] = deps
+	}
+
+	names := []string{name}
+	for _, dep := range deps.names {
+		names = append(names, g.jetDependencies(dep, seen)...)
+	}
+
+	return names
+}
+
+func (g *Render) readJetDependencies(name, file string) []string {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+
+	var deps []string
+	for _, m := range jetReference.FindAllStringSubmatch(string(content), -1) {
+		ref := m[1]
+		// like jet, look next to the template first, then from the views root
+		if !strings.HasPrefix(ref, "/") {
+			sibling := normalizeView(path.Join(path.Dir(name), ref))
+			if _, err := os.Stat(filepath.Join(g.RootPath, "views", sibling+".jet")); err == nil {
+				deps = append(deps, sibling)
+				continue
+			}
+		}
+		deps = append(deps, normalizeView(ref))
+	}
+
+	return deps
+}
+
+// normalizeView turns "/partials/sidebar.jet" and "partials/sidebar" into the same name
+func normalizeView(view string) string {
+	view = strings.TrimPrefix(path.Clean("/"+view), "/")
+	view = strings.TrimSuffix(view, ".jet")
+	view = strings.TrimSuffix(view, ".page.tmpl")
+
+	return view
+}
+
+// setComposed adds the composed variables to vars, keeping the ones already set
+func setComposed(vars jet.VarMap, composed map[string]interface{}) {
+	for k, v := range composed {
+		if _, ok := vars[k]; !ok {
+			vars.Set(k, v)
+		}
+	}
+}
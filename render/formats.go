@@ -0,0 +1,183 @@
+package render
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Respond renders view for browsers, and the same data as JSON, XML or plain text for clients
+// whose Accept header asks for it. For *TemplateData only its Data map is sent to those clients
+func (g *Render) Respond(w http.ResponseWriter, r *http.Request, view string, variables, data interface{}) error {
+	switch Negotiate(r, "text/html", "application/json", "application/xml", "text/plain") {
+	case "application/json":
+		return g.JSON(w, http.StatusOK, data)
+	case "application/xml":
+		return g.XML(w, http.StatusOK, data)
+	case "text/plain":
+		return g.Text(w, http.StatusOK, data)
+	default:
+		return g.Page(w, r, view, variables, data)
+	}
+}
+
+// JSON writes data as JSON
+func (g *Render) JSON(w http.ResponseWriter, status int, data interface{}) error {
+	out, err := json.Marshal(payload(data))
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(out)
+
+	return err
+}
+
+// XML writes data as XML. Maps, which encoding/xml can't handle, become an element per key
+func (g *Render) XML(w http.ResponseWriter, status int, data interface{}) error {
+	v := payload(data)
+	if m, ok := v.(map[string]interface{}); ok {
+		v = xmlMap(m)
+	}
+
+	out, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, err = w.Write(append([]byte(xml.Header), out...))
+
+	return err
+}
+
+// Text writes data as plain text, maps as one "key: value" line per key
+func (g *Render) Text(w http.ResponseWriter, status int, data interface{}) error {
+	var out string
+
+	switch v := payload(data).(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var b strings.Builder
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s: %v\n", k, v[k])
+		}
+		out = b.String()
+	case nil:
+		out = ""
+	default:
+		out = fmt.Sprintln(v)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	_, err := w.Write([]byte(out))
+
+	return err
+}
+
+// Negotiate returns the type in offers the Accept header of r prefers, or the first offer
+// when there is no Accept header or none of the offers are acceptable
+func Negotiate(r *http.Request, offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+
+	best, bestQ := offers[0], 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, q := parseAccept(part)
+		if mediaType == "" || q <= bestQ {
+			continue
+		}
+
+		for _, offer := range offers {
+			if acceptMatches(mediaType, offer) {
+				best, bestQ = offer, q
+				break
+			}
+		}
+	}
+
+	return best
+}
+
+func parseAccept(part string) (string, float64) {
+	fields := strings.Split(part, ";")
+	mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+	q := 1.0
+
+	for _, param := range fields[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if ok && key == "q" {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+	}
+
+	return mediaType, q
+}
+
+func acceptMatches(mediaType, offer string) bool {
+	if mediaType == "*/*" || mediaType == offer {
+		return true
+	}
+
+	if prefix, ok := strings.CutSuffix(mediaType, "/*"); ok {
+		return strings.HasPrefix(offer, prefix+"/")
+	}
+
+	return false
+}
+
+// payload is what is sent to API clients: the Data of TemplateData, anything else as is
+func payload(data interface{}) interface{} {
+	if td, ok := data.(*TemplateData); ok {
+		return td.Data
+	}
+
+	return data
+}
+
+type xmlMap map[string]interface{}
+
+func (m xmlMap) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	// the top level element is named after the type unless it is nested under a key
+	if start.Name.Local == "xmlMap" {
+		start.Name.Local = "data"
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := m[k]
+		if nested, ok := v.(map[string]interface{}); ok {
+			v = xmlMap(nested)
+		}
+
+		if err := e.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: k}}); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
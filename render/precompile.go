@@ -0,0 +1,77 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// CompileStats is what Precompile did and how long it took
+type CompileStats struct {
+	Templates       int
+	Duration        time.Duration
+	Slowest         string
+	SlowestDuration time.Duration
+}
+
+// Precompile parses every template in the views directory, so broken templates are reported at
+// boot with their file and line instead of at the first request that renders them. Jet templates
+// stay cached in the set, unless it is in development mode
+func (g *Render) Precompile() (CompileStats, error) {
+	var stats CompileStats
+	var errs []error
+
+	root := filepath.Join(g.RootPath, "views")
+	start := time.Now()
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		name, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+
+		compileStart := time.Now()
+		switch {
+		case strings.HasSuffix(name, ".jet") && g.JetViews != nil:
+			_, err = g.JetViews.GetTemplate(name)
+		case strings.HasSuffix(name, ".tmpl"):
+			_, err = template.ParseFiles(path)
+		default:
+			return nil
+		}
+		took := time.Since(compileStart)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			return nil
+		}
+
+		stats.Templates++
+		if took > stats.SlowestDuration {
+			stats.Slowest, stats.SlowestDuration = name, took
+		}
+
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Duration = time.Since(start)
+	g.Compiled = stats
+
+	return stats, errors.Join(errs...)
+}
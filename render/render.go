@@ -1,15 +1,21 @@
 package render
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"text/template"
+	"time"
 
 	"github.com/CloudyKit/jet/v6"
 	"github.com/alexedwards/scs/v2"
+	"github.com/jimmitjoo/gemquick/debugbar"
+	"github.com/jimmitjoo/gemquick/servertiming"
 	"github.com/justinas/nosurf"
 )
 
@@ -21,6 +27,12 @@ type Render struct {
 	ServerName string
 	JetViews   *jet.Set
 	Session    *scs.SessionManager
+	Compiled   CompileStats
+	// Honeypot returns the spam trap fields forms include as .Honeypot, see app.SpamFilter
+	Honeypot func() string
+
+	composerMu    sync.Mutex
+	composerState *composerState
 }
 
 type TemplateData struct {
@@ -35,6 +47,7 @@ type TemplateData struct {
 	Secure          bool
 	Error           string
 	Flash           string
+	Honeypot        string
 }
 
 func (g *Render) defaultData(td *TemplateData, r *http.Request) *TemplateData {
@@ -43,6 +56,9 @@ func (g *Render) defaultData(td *TemplateData, r *http.Request) *TemplateData {
 	td.ServerName = g.ServerName
 	td.Port = g.Port
 	td.CSRFToken = nosurf.Token(r)
+	if g.Honeypot != nil {
+		td.Honeypot = g.Honeypot()
+	}
 
 	if g.Session != nil {
 		if g.Session.Exists(r.Context(), "userID") {
@@ -84,7 +100,21 @@ func (g *Render) GoPage(w http.ResponseWriter, r *http.Request, view string, dat
 		td = data.(*TemplateData)
 	}
 
-	err = tmpl.Execute(w, &td)
+	if composed := g.compose(r, view, false); len(composed) > 0 {
+		if td.Data == nil {
+			td.Data = map[string]interface{}{}
+		}
+		for k, v := range composed {
+			if _, ok := td.Data[k]; !ok {
+				td.Data[k] = v
+			}
+		}
+	}
+
+	debugbar.Template(r.Context(), view+".page.tmpl")
+	err = execute(w, r, func(w io.Writer) error {
+		return tmpl.Execute(w, &td)
+	})
 
 	if err != nil {
 		return err
@@ -109,6 +139,7 @@ func (g *Render) JetPage(w http.ResponseWriter, r *http.Request, templateName st
 	}
 
 	td = g.defaultData(td, r)
+	setComposed(vars, g.compose(r, templateName, true))
 
 	t, err := g.JetViews.GetTemplate(fmt.Sprintf("%s.jet", templateName))
 	if err != nil {
@@ -116,10 +147,31 @@ func (g *Render) JetPage(w http.ResponseWriter, r *http.Request, templateName st
 		return err
 	}
 
-	if err = t.Execute(w, vars, td); err != nil {
+	debugbar.Template(r.Context(), templateName+".jet")
+	if err = execute(w, r, func(w io.Writer) error { return t.Execute(w, vars, td) }); err != nil {
 		log.Println(err)
 		return err
 	}
 
 	return nil
 }
+
+// execute runs a template into w. For a request that is being timed, the page is rendered into a
+// buffer first, so that the render phase is complete when the Server-Timing header is sent
+func execute(w io.Writer, r *http.Request, run func(w io.Writer) error) error {
+	t := servertiming.FromContext(r.Context())
+	if t == nil {
+		return run(w)
+	}
+
+	start := time.Now()
+	var buf bytes.Buffer
+	err := run(&buf)
+	t.Add("render", time.Since(start))
+	if err != nil {
+		return err
+	}
+
+	_, err = buf.WriteTo(w)
+	return err
+}
@@ -3,7 +3,11 @@ package render
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/CloudyKit/jet/v6"
+	"github.com/jimmitjoo/gemquick/servertiming"
 )
 
 var pageData = []struct {
@@ -66,6 +70,24 @@ func TestRender_GoPage(t *testing.T) {
 	}
 }
 
+func TestRender_GoPage_Timed(t *testing.T) {
+	timing := servertiming.New()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/url", nil)
+	r = r.WithContext(servertiming.NewContext(r.Context(), timing))
+
+	testRenderer.Renderer = "go"
+	testRenderer.RootPath = "./testdata"
+
+	if err := testRenderer.Page(w, r, "home", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := timing.Duration("render"); !ok || w.Body.Len() == 0 {
+		t.Errorf("expected the page to be rendered and timed, got %d bytes", w.Body.Len())
+	}
+}
+
 func TestRender_JetPage(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, err := http.NewRequest("GET", "/url", nil)
@@ -83,3 +105,138 @@ func TestRender_JetPage(t *testing.T) {
 		t.Error("Error rendering page", err)
 	}
 }
+
+func TestRender_Precompile(t *testing.T) {
+	r := Render{RootPath: "./testdata", JetViews: views}
+
+	stats, err := r.Precompile()
+	if err != nil {
+		t.Error("error precompiling templates", err)
+	}
+
+	if stats.Templates != 4 || r.Compiled.Templates != 4 {
+		t.Errorf("expected 4 compiled templates, got %d", stats.Templates)
+	}
+
+	broken := Render{
+		RootPath: "./testdata/broken",
+		JetViews: jet.NewSet(jet.NewOSFileSystemLoader("./testdata/broken/views")),
+	}
+
+	_, err = broken.Precompile()
+	if err == nil || !strings.Contains(err.Error(), "broken.jet") {
+		t.Errorf("expected error naming broken.jet, got %v", err)
+	}
+}
+
+func TestRender_Composer(t *testing.T) {
+	r := Render{Renderer: "jet", RootPath: "./testdata", JetViews: views}
+
+	r.Composer("partials/sidebar", func(r *http.Request) map[string]interface{} {
+		return map[string]interface{}{"unread": 3}
+	})
+	r.Composer("/composed.jet", func(r *http.Request) map[string]interface{} {
+		return map[string]interface{}{"title": "composed"}
+	})
+
+	req, _ := http.NewRequest("GET", "/url", nil)
+
+	w := httptest.NewRecorder()
+	if err := r.Page(w, req, "composed", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if body := w.Body.String(); !strings.Contains(body, "<nav>3</nav>") || !strings.Contains(body, "<p>composed</p>") {
+		t.Errorf("expected composed variables in %q", body)
+	}
+
+	vars := make(jet.VarMap)
+	vars.Set("title", "from handler")
+
+	w = httptest.NewRecorder()
+	if err := r.Page(w, req, "composed", vars, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if body := w.Body.String(); !strings.Contains(body, "<p>from handler</p>") {
+		t.Errorf("expected variables from the handler to win in %q", body)
+	}
+}
+
+func TestRender_Respond(t *testing.T) {
+	r := Render{Renderer: "jet", RootPath: "./testdata", JetViews: views}
+	td := &TemplateData{Data: map[string]interface{}{"name": "gem", "meta": map[string]interface{}{"count": 2}}}
+
+	var tests = []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", "", "<h1>Home</h1>"},
+		{"text/html,application/xhtml+xml,*/*;q=0.8", "", "<h1>Home</h1>"},
+		{"application/json", "application/json", `{"meta":{"count":2},"name":"gem"}`},
+		{"application/xml;q=0.9, application/json;q=0.5", "application/xml", "<data><meta><count>2</count></meta><name>gem</name></data>"},
+		{"text/*", "", "<h1>Home</h1>"},
+		{"text/plain", "text/plain; charset=utf-8", "meta: map[count:2]\nname: gem\n"},
+	}
+
+	for _, e := range tests {
+		req, _ := http.NewRequest("GET", "/url", nil)
+		if e.accept != "" {
+			req.Header.Set("Accept", e.accept)
+		}
+
+		w := httptest.NewRecorder()
+		if err := r.Respond(w, req, "home", nil, td); err != nil {
+			t.Fatal(err)
+		}
+
+		if e.contentType != "" && w.Header().Get("Content-Type") != e.contentType {
+			t.Errorf("%s: expected content type %s, got %s", e.accept, e.contentType, w.Header().Get("Content-Type"))
+		}
+
+		if !strings.Contains(w.Body.String(), e.body) {
+			t.Errorf("%s: expected %q in %q", e.accept, e.body, w.Body.String())
+		}
+	}
+}
+
+func BenchmarkRender_JetPage(b *testing.B) {
+	testRenderer.Renderer = "jet"
+	testRenderer.RootPath = "./testdata"
+
+	r, err := http.NewRequest("GET", "/url", nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		if err := testRenderer.Page(w, r, "home", nil, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRender_GoPage(b *testing.B) {
+	testRenderer.Renderer = "go"
+	testRenderer.RootPath = "./testdata"
+
+	r, err := http.NewRequest("GET", "/url", nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		if err := testRenderer.Page(w, r, "home", nil, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package gemquick
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/database"
+	"github.com/jimmitjoo/gemquick/ratelimit"
+	"github.com/jimmitjoo/gemquick/reputation"
+)
+
+// createReputation returns the service that looks up suspect IPs in the DNS blocklists of
+// REPUTATION_DNSBL, like zen.spamhaus.org, and in AbuseIPDB with ABUSEIPDB_KEY, or nil without
+// either. IPs are looked up after REPUTATION_THRESHOLD suspicions, 3 when it is empty, verdicts are
+// kept for REPUTATION_TTL, a day when it is empty, and listed IPs are penalized in penalties for
+// REPUTATION_PENALTY, a day when it is empty
+func (g *Gemquick) createReputation(penalties *ratelimit.Penalties) *reputation.Service {
+	var checkers []reputation.Checker
+	for _, zone := range splitList(os.Getenv("REPUTATION_DNSBL")) {
+		checkers = append(checkers, reputation.DNSBL{Zone: zone})
+	}
+
+	if key := os.Getenv("ABUSEIPDB_KEY"); key != "" {
+		minScore, _ := strconv.Atoi(os.Getenv("ABUSEIPDB_MIN_SCORE"))
+		checkers = append(checkers, reputation.AbuseIPDB{Key: key, MinScore: minScore})
+	}
+
+	if len(checkers) == 0 {
+		return nil
+	}
+
+	threshold, err := strconv.Atoi(os.Getenv("REPUTATION_THRESHOLD"))
+	if err != nil || threshold <= 0 {
+		threshold = 3
+	}
+
+	ttl, err := time.ParseDuration(os.Getenv("REPUTATION_TTL"))
+	if err != nil || ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	penalty, err := time.ParseDuration(os.Getenv("REPUTATION_PENALTY"))
+	if err != nil || penalty <= 0 {
+		penalty = 24 * time.Hour
+	}
+
+	service := reputation.New(threshold, ttl, checkers...)
+	service.OnError = func(ip string, err error) {
+		g.ErrorLog.Printf("looking up the reputation of %s: %s", ip, err)
+	}
+	service.OnVerdict = func(v reputation.Verdict) {
+		if !v.Listed {
+			return
+		}
+
+		penalties.Penalize(v.IP, penalty)
+		g.InfoLog.Printf("%s is listed by %s, penalizing it for %s", v.IP, v.Source, penalty)
+		g.recordListing(v)
+	}
+
+	return service
+}
+
+// recordListing counts a listed IP in the ip_listed metric and records it in the activity log,
+// when there is one
+func (g *Gemquick) recordListing(v reputation.Verdict) {
+	if g.Metrics != nil {
+		g.Metrics.Counter("ip_listed").Inc()
+	}
+
+	if g.Activity == nil {
+		return
+	}
+
+	_, err := g.Activity.Log("ip.listed").
+		By(database.Ref{Type: "reputation"}).
+		On(database.Ref{Type: "ip"}).
+		With("ip", v.IP).
+		With("source", v.Source).
+		With("score", v.Score).
+		Save(context.Background())
+	if err != nil {
+		g.ErrorLog.Println("recording a listed IP:", err)
+	}
+}
+
+// suspect tells app.Reputation that ip did something suspicious, when there is one
+func (g *Gemquick) suspect(ip string) {
+	if g.Reputation != nil {
+		g.Reputation.Suspect(ip)
+	}
+}
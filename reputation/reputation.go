@@ -0,0 +1,283 @@
+// Package reputation looks up whether an IP is known for abuse, in DNS blocklists like Spamhaus
+// and in AbuseIPDB, for the IPs that make themselves suspect
+package reputation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Verdict is what a lookup found out about an IP
+type Verdict struct {
+	IP     string
+	Listed bool
+	// Source is the blocklist or service that listed the IP
+	Source string
+	// Score is the abuse confidence from 0 to 100 of services that score
+	Score   int
+	Checked time.Time
+}
+
+// Checker looks up an IP in one blocklist or service
+type Checker interface {
+	Check(ctx context.Context, ip net.IP) (Verdict, error)
+}
+
+// DNSBL is a DNS blocklist, like zen.spamhaus.org. An IP is listed when the reversed IP under the
+// zone resolves, to an address in 127.0.0.0/8
+type DNSBL struct {
+	Zone     string
+	Resolver *net.Resolver
+}
+
+// Check looks ip up in the zone
+func (d DNSBL) Check(ctx context.Context, ip net.IP) (Verdict, error) {
+	v := Verdict{IP: ip.String(), Source: d.Zone, Checked: time.Now()}
+
+	resolver := d.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	addrs, err := resolver.LookupHost(ctx, reverse(ip)+"."+d.Zone)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return v, nil
+	}
+	if err != nil {
+		return v, err
+	}
+
+	for _, addr := range addrs {
+		// other answers are the list's errors, like Spamhaus refusing public resolvers
+		if a := net.ParseIP(addr); a != nil && a.To4() != nil && a.To4()[0] == 127 && !strings.HasPrefix(addr, "127.255.255.") {
+			v.Listed = true
+		}
+	}
+
+	return v, nil
+}
+
+// reverse returns the name of ip in a DNS blocklist: the octets of an IPv4 address in reverse
+// order, and the nibbles of an IPv6 address
+func reverse(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d", v4[3], v4[2], v4[1], v4[0])
+	}
+
+	const hex = "0123456789abcdef"
+	v6 := ip.To16()
+	nibbles := make([]string, 0, 32)
+	for i := len(v6) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, string(hex[v6[i]&0xf]), string(hex[v6[i]>>4]))
+	}
+
+	return strings.Join(nibbles, ".")
+}
+
+// AbuseIPDB asks the AbuseIPDB API how confident its reporters are that an IP is abusive. An IP
+// is listed from MinScore on, 50 when it is 0
+type AbuseIPDB struct {
+	Key      string
+	MinScore int
+	URL      string
+	Client   *http.Client
+}
+
+// Check asks AbuseIPDB about ip
+func (a AbuseIPDB) Check(ctx context.Context, ip net.IP) (Verdict, error) {
+	v := Verdict{IP: ip.String(), Source: "abuseipdb", Checked: time.Now()}
+
+	endpoint := a.URL
+	if endpoint == "" {
+		endpoint = "https://api.abuseipdb.com/api/v2/check"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+url.Values{"ipAddress": {v.IP}, "maxAgeInDays": {"90"}}.Encode(), nil)
+	if err != nil {
+		return v, err
+	}
+	req.Header.Set("Key", a.Key)
+	req.Header.Set("Accept", "application/json")
+
+	client := a.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return v, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return v, fmt.Errorf("abuseipdb answered %s", resp.Status)
+	}
+
+	var result struct {
+		Data struct {
+			AbuseConfidenceScore int `json:"abuseConfidenceScore"`
+		} `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return v, err
+	}
+
+	minScore := a.MinScore
+	if minScore <= 0 {
+		minScore = 50
+	}
+
+	v.Score = result.Data.AbuseConfidenceScore
+	v.Listed = v.Score >= minScore
+
+	return v, nil
+}
+
+// Service checks the IPs that cross a suspicion threshold in the background, with every checker
+// until one lists the IP, and keeps the verdicts for a while
+type Service struct {
+	Checkers []Checker
+	// Threshold is how often an IP must be suspected before it is looked up
+	Threshold int
+	// TTL is how long verdicts are kept, and suspicions counted
+	TTL time.Duration
+	// OnVerdict is told about every verdict, e.g. to penalize or record listed IPs
+	OnVerdict func(Verdict)
+	// OnError is told about the lookups that failed
+	OnError func(ip string, err error)
+
+	mu         sync.Mutex
+	suspicions map[string]int
+	verdicts   map[string]Verdict
+	pending    map[string]bool
+	lastSweep  time.Time
+	wg         sync.WaitGroup
+	now        func() time.Time
+}
+
+// New returns a service that looks IPs up after threshold suspicions and keeps the verdicts for ttl
+func New(threshold int, ttl time.Duration, checkers ...Checker) *Service {
+	return &Service{
+		Checkers:   checkers,
+		Threshold:  threshold,
+		TTL:        ttl,
+		suspicions: map[string]int{},
+		verdicts:   map[string]Verdict{},
+		pending:    map[string]bool{},
+		now:        time.Now,
+	}
+}
+
+// Suspect counts something suspicious the IP did, like being locked out or sending spam, and
+// starts looking it up once it crosses the threshold. The lookup does not hold up the caller
+func (s *Service) Suspect(ip string) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.IsLoopback() || parsed.IsPrivate() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	if _, ok := s.verdicts[ip]; ok || s.pending[ip] {
+		return
+	}
+
+	s.suspicions[ip]++
+	if s.suspicions[ip] < s.Threshold {
+		return
+	}
+
+	s.pending[ip] = true
+	s.wg.Add(1)
+	go s.lookup(parsed)
+}
+
+func (s *Service) lookup(ip net.IP) {
+	defer s.wg.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	v := Verdict{IP: ip.String()}
+	checked := false
+	for _, checker := range s.Checkers {
+		found, err := checker.Check(ctx, ip)
+		if err != nil {
+			if s.OnError != nil {
+				s.OnError(v.IP, err)
+			}
+			continue
+		}
+
+		v, checked = found, true
+		if v.Listed {
+			break
+		}
+	}
+	v.Checked = s.now()
+
+	s.mu.Lock()
+	delete(s.pending, v.IP)
+	if checked {
+		delete(s.suspicions, v.IP)
+		s.verdicts[v.IP] = v
+	}
+	s.mu.Unlock()
+
+	// without an answer the IP is looked up again when it is suspected next
+	if !checked {
+		return
+	}
+
+	if s.OnVerdict != nil {
+		s.OnVerdict(v)
+	}
+}
+
+// Verdict returns the verdict on ip, and false when it has not been looked up lately
+func (s *Service) Verdict(ip string) (Verdict, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.verdicts[ip]
+	if ok && s.now().Sub(v.Checked) >= s.TTL {
+		return Verdict{}, false
+	}
+
+	return v, ok
+}
+
+// Wait waits for the lookups that are running, so that the app can stop
+func (s *Service) Wait() {
+	s.wg.Wait()
+}
+
+// sweep forgets the verdicts and suspicions older than TTL, once per TTL. Must be called with mu
+// held
+func (s *Service) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.TTL {
+		return
+	}
+	s.lastSweep = now
+
+	for ip, v := range s.verdicts {
+		if now.Sub(v.Checked) >= s.TTL {
+			delete(s.verdicts, ip)
+		}
+	}
+	s.suspicions = map[string]int{}
+}
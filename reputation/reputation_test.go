@@ -0,0 +1,137 @@
+package reputation
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReverse(t *testing.T) {
+	tests := map[string]string{
+		"192.0.2.99":  "99.2.0.192",
+		"2001:db8::1": "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2",
+	}
+
+	for ip, want := range tests {
+		if got := reverse(net.ParseIP(ip)); got != want {
+			t.Errorf("expected %s to be %s, got %s", ip, want, got)
+		}
+	}
+}
+
+func TestAbuseIPDB(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		score := "0"
+		if r.URL.Query().Get("ipAddress") == "203.0.113.9" {
+			score = "87"
+		}
+		_, _ = w.Write([]byte(`{"data":{"abuseConfidenceScore":` + score + `}}`))
+	}))
+	defer srv.Close()
+
+	a := AbuseIPDB{Key: "secret", URL: srv.URL}
+
+	v, err := a.Check(context.Background(), net.ParseIP("203.0.113.9"))
+	if err != nil || !v.Listed || v.Score != 87 || v.Source != "abuseipdb" {
+		t.Errorf("expected the IP to be listed with 87, got %+v, %v", v, err)
+	}
+
+	v, err = a.Check(context.Background(), net.ParseIP("198.51.100.1"))
+	if err != nil || v.Listed {
+		t.Errorf("expected the IP not to be listed, got %+v, %v", v, err)
+	}
+
+	a.Key = "wrong"
+	if _, err = a.Check(context.Background(), net.ParseIP("198.51.100.1")); err == nil {
+		t.Error("expected an error for a wrong key")
+	}
+}
+
+type fakeChecker struct {
+	mu     sync.Mutex
+	listed map[string]bool
+	err    error
+	calls  int
+}
+
+func (f *fakeChecker) Check(ctx context.Context, ip net.IP) (Verdict, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	return Verdict{IP: ip.String(), Listed: f.listed[ip.String()], Source: "fake"}, f.err
+}
+
+func TestService(t *testing.T) {
+	checker := &fakeChecker{listed: map[string]bool{"203.0.113.9": true}}
+	s := New(2, time.Hour, checker)
+
+	var verdicts []Verdict
+	s.OnVerdict = func(v Verdict) { verdicts = append(verdicts, v) }
+
+	s.Suspect("203.0.113.9")
+	s.Wait()
+	if checker.calls != 0 {
+		t.Fatal("expected no lookup below the threshold")
+	}
+
+	s.Suspect("203.0.113.9")
+	s.Wait()
+	v, ok := s.Verdict("203.0.113.9")
+	if !ok || !v.Listed || len(verdicts) != 1 {
+		t.Fatalf("expected the IP to be looked up and listed, got %+v, %v", v, ok)
+	}
+
+	// the verdict is cached
+	s.Suspect("203.0.113.9")
+	s.Suspect("203.0.113.9")
+	s.Wait()
+	if checker.calls != 1 {
+		t.Errorf("expected one lookup, got %d", checker.calls)
+	}
+
+	// private addresses are never looked up
+	for i := 0; i < 3; i++ {
+		s.Suspect("10.0.0.1")
+		s.Suspect("127.0.0.1")
+	}
+	s.Wait()
+	if checker.calls != 1 {
+		t.Errorf("expected no lookups of private addresses, got %d", checker.calls)
+	}
+
+	s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, ok = s.Verdict("203.0.113.9"); ok {
+		t.Error("expected the verdict to expire")
+	}
+}
+
+func TestServiceError(t *testing.T) {
+	checker := &fakeChecker{err: errors.New("timeout")}
+	s := New(1, time.Hour, checker)
+
+	var failed []string
+	s.OnError = func(ip string, err error) { failed = append(failed, ip) }
+
+	s.Suspect("203.0.113.9")
+	s.Wait()
+	if _, ok := s.Verdict("203.0.113.9"); ok || len(failed) != 1 {
+		t.Fatalf("expected no verdict after a failed lookup, got %v", failed)
+	}
+
+	s.Suspect("203.0.113.9")
+	s.Wait()
+	if checker.calls != 2 {
+		t.Errorf("expected a failed lookup to be tried again, got %d", checker.calls)
+	}
+}
@@ -1,6 +1,7 @@
 package gemquick
 
 import (
+	"bytes"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"path"
 	"path/filepath"
+	"sync"
 )
 
 func (g *Gemquick) ReadJson(w http.ResponseWriter, r *http.Request, data interface{}) error {
@@ -28,14 +30,42 @@ func (g *Gemquick) ReadJson(w http.ResponseWriter, r *http.Request, data interfa
 	return nil
 }
 
+// jsonEncoder is the part of encoding/json's Encoder that WriteJson needs, so other
+// implementations can be swapped in with a build tag
+type jsonEncoder interface {
+	Encode(v interface{}) error
+	SetIndent(prefix, indent string)
+}
+
+// jsonBuffers holds the buffers responses are encoded into, so every response
+// does not allocate a new one
+var jsonBuffers = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// maxPooledJSONBuffer keeps the odd huge response from pinning its buffer in the pool
+const maxPooledJSONBuffer = 64 << 10
+
 func (g *Gemquick) WriteJson(w http.ResponseWriter, status int, data interface{}, headers ...http.Header) error {
-	out, err := json.MarshalIndent(data, "", "\t")
+	buf := jsonBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledJSONBuffer {
+			jsonBuffers.Put(buf)
+		}
+	}()
+
+	enc := newJSONEncoder(buf)
+	enc.SetIndent("", "\t")
+	err := enc.Encode(data)
 	if err != nil {
 		return err
 	}
 
 	w = setHeaders(w, status, headers, "application/json")
-	_, err = w.Write(out)
+	_, err = w.Write(buf.Bytes())
 	if err != nil {
 		return err
 	}
@@ -69,6 +99,20 @@ func (g *Gemquick) DownloadFile(w http.ResponseWriter, r *http.Request, pathToFi
 	return nil
 }
 
+// Readiness answers readiness probes, with 503 Service Unavailable until the app is Ready and
+// whenever its database does not answer a ping
+func (g *Gemquick) Readiness(w http.ResponseWriter, r *http.Request) {
+	status := http.StatusOK
+	if !g.Ready() {
+		status = http.StatusServiceUnavailable
+	} else if err := g.databaseHealthy(r.Context()); err != nil {
+		g.ErrorLog.Println("the database is unhealthy:", err)
+		status = http.StatusServiceUnavailable
+	}
+
+	_ = g.WriteJson(w, status, map[string]bool{"ready": status == http.StatusOK})
+}
+
 func (g *Gemquick) Error404(w http.ResponseWriter, r *http.Request) {
 	g.ErrorStatus(w, http.StatusNotFound)
 }
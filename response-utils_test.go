@@ -0,0 +1,36 @@
+package gemquick
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+type benchPayload struct {
+	ID    int               `json:"id"`
+	Name  string            `json:"name"`
+	Tags  []string          `json:"tags"`
+	Attrs map[string]string `json:"attrs"`
+}
+
+func BenchmarkWriteJson(b *testing.B) {
+	g := &Gemquick{}
+	payload := make([]benchPayload, 50)
+	for i := range payload {
+		payload[i] = benchPayload{
+			ID:    i,
+			Name:  "item",
+			Tags:  []string{"a", "b", "c"},
+			Attrs: map[string]string{"color": "red", "size": "large"},
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		err := g.WriteJson(httptest.NewRecorder(), 200, payload)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -2,6 +2,9 @@ package gemquick
 
 import (
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -9,6 +12,9 @@ import (
 
 func (g *Gemquick) routes() http.Handler {
 	mux := chi.NewRouter()
+	if g.serverTimingEnabled() {
+		mux.Use(g.ServerTiming)
+	}
 	mux.Use(middleware.RequestID)
 	mux.Use(middleware.RealIP)
 
@@ -16,9 +22,72 @@ func (g *Gemquick) routes() http.Handler {
 		mux.Use(middleware.Logger)
 	}
 
-	mux.Use(middleware.Recoverer)
-	mux.Use(g.SessionLoad)
-	mux.Use(g.NoSurf)
+	mux.Use(g.Recoverer)
+
+	if g.LoadShedder != nil {
+		mux.Use(g.LoadShedder.Middleware)
+	}
+
+	if g.RequestTimeout > 0 {
+		mux.Use(Timeout(g.RequestTimeout))
+	}
+
+	if g.OpenAPI != nil {
+		mux.Use(g.OpenAPI.Middleware)
+	}
+
+	if sessionsEnabled() {
+		mux.Use(g.SessionLoad)
+		mux.Use(g.NoSurf)
+	}
+
+	// the toolbar comes after the session is loaded, so it can show what is in it
+	if g.debugToolbarEnabled() {
+		mux.Use(g.DebugToolbar)
+	}
+
+	if g.serverTimingEnabled() {
+		mux.Use(handlerTiming)
+	}
+
+	mux.Get("/readyz", g.Readiness)
+
+	// the pages report their javascript errors and CSP violations here, see gq make client-errors
+	if clientErrorsEnabled() {
+		limit, err := strconv.Atoi(os.Getenv("CLIENT_ERRORS_LIMIT"))
+		if err != nil || limit <= 0 {
+			limit = 10
+		}
+		mux.Method(http.MethodPost, "/client-errors", g.ClientErrors(limit))
+	}
+
+	// vulnerability reports, see gq make security-reports
+	if securityReportsEnabled() {
+		limit, err := strconv.Atoi(os.Getenv("SECURITY_REPORTS_LIMIT"))
+		if err != nil || limit <= 0 {
+			limit = 5
+		}
+		mux.Method(http.MethodPost, "/.well-known/security", g.SecurityReports(limit))
+		mux.Get("/.well-known/security.txt", g.SecurityTxt)
+	}
 
 	return mux
 }
+
+// securityReportsEnabled is true with SECURITY_REPORTS=true
+func securityReportsEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("SECURITY_REPORTS"))
+	return enabled
+}
+
+// clientErrorsEnabled is true with CLIENT_ERRORS=true
+func clientErrorsEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("CLIENT_ERRORS"))
+	return enabled
+}
+
+// sessionsEnabled is false when SESSION_TYPE is none, as in API only apps, which then get neither
+// session cookies nor CSRF protection
+func sessionsEnabled() bool {
+	return !strings.EqualFold(os.Getenv("SESSION_TYPE"), "none")
+}
@@ -0,0 +1,95 @@
+package gemquick
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+)
+
+func BenchmarkRoutes(b *testing.B) {
+	g := &Gemquick{
+		InfoLog:  log.New(io.Discard, "", 0),
+		ErrorLog: log.New(io.Discard, "", 0),
+		Session:  scs.New(),
+	}
+
+	mux := g.routes().(*chi.Mux)
+	mux.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(chi.URLParam(r, "id")))
+	})
+
+	r := httptest.NewRequest("GET", "/users/42", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	g := &Gemquick{
+		InfoLog:  log.New(io.Discard, "", 0),
+		ErrorLog: log.New(io.Discard, "", 0),
+		Session:  scs.New(),
+	}
+	mux := g.routes()
+
+	readyz := func() int {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+		return w.Code
+	}
+
+	g.setDBReady(false)
+	if g.Ready() || readyz() != http.StatusServiceUnavailable {
+		t.Error("expected 503 while the database is pending")
+	}
+
+	g.setDBReady(true)
+	if !g.Ready() || readyz() != http.StatusOK {
+		t.Error("expected 200 once the database is ready")
+	}
+}
+
+func TestReadyz_LazyDatabase(t *testing.T) {
+	fake.setDown("lazy", true)
+	defer fake.setDown("lazy", false)
+
+	g := &Gemquick{
+		InfoLog:  log.New(io.Discard, "", 0),
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+
+	db, err := g.OpenDBLazy("gqfake", time.Millisecond, "lazy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	w := httptest.NewRecorder()
+	g.Readiness(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while the database does not answer, got %d", w.Code)
+	}
+
+	fake.setDown("lazy", false)
+
+	deadline := time.Now().Add(time.Second)
+	for !g.Ready() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	w = httptest.NewRecorder()
+	g.Readiness(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 once the database answers, got %d", w.Code)
+	}
+}
@@ -0,0 +1,46 @@
+package scaffold
+
+import (
+	"path/filepath"
+)
+
+// Activity creates the migration for the activities table that app.Activity records to, once
+func Activity(opts Options) (*Result, error) {
+	return polymorphicTable(opts, "activities",
+		"Run gq migrate to create the activities table, and record with app.Activity.Log(\"commented\").By(user).On(post).Save(ctx)")
+}
+
+// Tags creates the migration for the taggables table that app.Tags keeps the tags of models in, once
+func Tags(opts Options) (*Result, error) {
+	return polymorphicTable(opts, "taggables",
+		"Run gq migrate to create the taggables table, and tag with app.Tags.Tag(ctx, post, \"go\", \"web\")")
+}
+
+// Favorites creates the migration for the favorites table that app.Favorites keeps what users
+// favorite in, once
+func Favorites(opts Options) (*Result, error) {
+	return polymorphicTable(opts, "favorites",
+		"Run gq migrate to create the favorites table, and answer a favorite button with app.Favorites.ToggleHandler")
+}
+
+// polymorphicTable creates the migration for one of the framework's tables that refer to models
+// of any type, unless the project already has it
+func polymorphicTable(opts Options, table, note string) (*Result, error) {
+	res := &Result{}
+
+	existing, _ := filepath.Glob(filepath.Join(opts.migrationsDir(), "*_create_"+table+"_table.*"))
+	if len(existing) > 0 {
+		res.note("The project already has a migration for the %s table", table)
+		return res, nil
+	}
+
+	err := opts.migration(res, "create_"+table+"_table",
+		"templates/migrations/"+table+"_table.DIALECT.up.sql", "", "DROP TABLE IF EXISTS "+table+";", table)
+	if err != nil {
+		return res, err
+	}
+
+	res.note("%s", note)
+
+	return res, nil
+}
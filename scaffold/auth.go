@@ -0,0 +1,100 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+)
+
+// authFiles are the templates Auth copies and where it copies them to
+var authFiles = [][2]string{
+	{"templates/data/user.go.txt", "data/user.go"},
+	{"templates/data/token.go.txt", "data/token.go"},
+	{"templates/data/remember_token.go.txt", "data/remember_token.go"},
+	{"templates/middleware/auth.go.txt", "middleware/auth.go"},
+	{"templates/middleware/auth-token.go.txt", "middleware/auth-token.go"},
+	{"templates/middleware/remember.go.txt", "middleware/remember.go"},
+	{"templates/handlers/auth-handlers.go.txt", "handlers/auth-handlers.go"},
+	{"templates/email/welcome.html.tmpl", "email/welcome.html.tmpl"},
+	{"templates/email/welcome.plain.tmpl", "email/welcome.plain.tmpl"},
+	{"templates/email/password-reset.html.tmpl", "email/password-reset.html.tmpl"},
+	{"templates/email/password-reset.plain.tmpl", "email/password-reset.plain.tmpl"},
+	{"templates/views/login.jet", "views/login.jet"},
+	{"templates/views/register.jet", "views/register.jet"},
+	{"templates/views/forgot.jet", "views/forgot.jet"},
+	{"templates/views/reset-password.jet", "views/reset-password.jet"},
+}
+
+// Auth creates everything authentication needs: the migration for the users, tokens and
+// remember_tokens tables, their models, the auth middleware, handlers, emails and views, and the
+// auth routes. The migration still has to be run
+func Auth(opts Options) (*Result, error) {
+	if opts.DatabaseType == "" {
+		return nil, errors.New("you have to define a database type to be able to use authentication")
+	}
+
+	res := &Result{}
+	err := opts.migration(res, "create_auth_tables", "templates/migrations/auth_tables.DIALECT.up.sql", "",
+		"DROP TABLE IF EXISTS users CASCADE;DROP TABLE IF EXISTS tokens CASCADE;DROP TABLE IF EXISTS remember_tokens CASCADE;", "")
+	if err != nil {
+		return res, err
+	}
+
+	for _, file := range authFiles {
+		err = opts.render(res, file[0], opts.path(file[1]))
+		if err != nil {
+			return res, err
+		}
+	}
+
+	models := opts.path("data", "models.go")
+	modelsContent, err := opts.read(res, models)
+	if err != nil {
+		return res, err
+	}
+
+	if bytes.Contains(modelsContent, []byte("// authentication models - added by make auth command")) {
+		return res, errors.New("auth models are probably already added to data/models.go")
+	}
+
+	authModels, err := opts.Template("templates/data/auth.models.txt")
+	if err != nil {
+		return res, err
+	}
+
+	returnAuthModels, err := opts.Template("templates/data/return.auth.models.txt")
+	if err != nil {
+		return res, err
+	}
+
+	modelsContent = bytes.Replace(modelsContent, []byte("type Models struct {"), []byte("type Models struct {\n\t"+string(authModels)+"\n"), 1)
+	modelsContent = bytes.Replace(modelsContent, []byte("return Models{"), []byte("return Models{\n\t"+string(returnAuthModels)+"\n\t"), 1)
+	err = opts.update(res, models, modelsContent)
+	if err != nil {
+		return res, err
+	}
+
+	routesFile := opts.path("routes.go")
+	routesContent, err := opts.read(res, routesFile)
+	if err != nil {
+		return res, err
+	}
+
+	if bytes.Contains(routesContent, []byte("// authentication routes - added by make auth command")) {
+		return res, errors.New("auth routes are probably already added to routes.go")
+	}
+
+	authRoutes, err := opts.Template("templates/auth.routes.txt")
+	if err != nil {
+		return res, err
+	}
+
+	routesContent = bytes.Replace(routesContent, []byte("return route.App.Routes"), []byte(string(authRoutes)+"\n\n\treturn route.App.Routes"), 1)
+	err = opts.update(res, routesFile, routesContent)
+	if err != nil {
+		return res, err
+	}
+
+	res.note("Don't forget to add appropriate middlewares to your routes.")
+
+	return res, nil
+}
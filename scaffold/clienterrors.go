@@ -0,0 +1,17 @@
+package scaffold
+
+// ClientErrors creates public/js/client-errors.js, which reports the javascript errors of the
+// pages that include it to the /client-errors endpoint of the app
+func ClientErrors(opts Options) (*Result, error) {
+	res := &Result{}
+
+	err := opts.render(res, "templates/clienterrors/client-errors.js.txt", opts.path("public", "js", "client-errors.js"))
+	if err != nil {
+		return res, err
+	}
+
+	res.note("Set CLIENT_ERRORS=true in .env and include <script src=\"/public/js/client-errors.js\"></script> in your layout")
+	res.note("Send CSP violations there too with the directive report-uri /client-errors")
+
+	return res, nil
+}
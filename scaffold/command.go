@@ -0,0 +1,72 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// CommandsMarker is the line in commands/commands.go that new commands are registered below
+const CommandsMarker = "// commands - added by make command"
+
+// CommandOptions are the options of Command
+type CommandOptions struct {
+	Options
+	Name string
+}
+
+// Command creates an application command in the commands directory and registers it. The registry
+// and the cmd/console entrypoint, which boots the app and runs the commands, are created with the
+// first command
+func Command(opts CommandOptions) (*Result, error) {
+	if opts.Name == "" {
+		return nil, errors.New("you must give the command a name")
+	}
+
+	commandName := strcase.ToCamel(opts.Name)
+	if !strings.HasSuffix(commandName, "Command") {
+		commandName += "Command"
+	}
+
+	res := &Result{}
+	err := opts.render(res, "templates/commands/command.go.txt",
+		opts.path("commands", strcase.ToSnake(opts.Name)+".go"),
+		"$COMMANDNAME$", commandName,
+		"$COMMANDKEY$", strcase.ToKebab(strings.TrimSuffix(strcase.ToCamel(opts.Name), "Command")))
+	if err != nil {
+		return res, err
+	}
+
+	registry := opts.path("commands", "commands.go")
+	if !Exists(registry) {
+		err = opts.render(res, "templates/commands/commands.go.txt", registry)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	entrypoint := opts.path("cmd", "console", "main.go")
+	if !Exists(entrypoint) {
+		err = opts.render(res, "templates/commands/main.go.txt", entrypoint, "myapp", ModuleName(opts.Root))
+		if err != nil {
+			return res, err
+		}
+	}
+
+	content, err := opts.read(res, registry)
+	if err != nil {
+		return res, err
+	}
+
+	// registries from before commands had signatures register them one by one, without the app
+	if !bytes.Contains(content, []byte(CommandsMarker)) || !bytes.Contains(content, []byte("RegisterSigned(")) {
+		res.note("Could not find where to register the command, add &%s{App: app} to c.RegisterSigned in %s", commandName, registry)
+		return res, nil
+	}
+
+	content = bytes.Replace(content, []byte(CommandsMarker), []byte(CommandsMarker+"\n\t\t&"+commandName+"{App: app},"), 1)
+
+	return res, opts.update(res, registry, content)
+}
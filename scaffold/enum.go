@@ -0,0 +1,196 @@
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"go/format"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gertd/go-pluralize"
+	"github.com/iancoleman/strcase"
+)
+
+// column matches the table.column an enum's CHECK constraint is added to
+var column = regexp.MustCompile(`^(\w+)\.(\w+)$`)
+
+// EnumOptions are the options of Enum
+type EnumOptions struct {
+	Options
+	Name   string
+	Values []string
+	// Iota stores the enum as a number, counting from 1, instead of as its value
+	Iota bool
+	// Check is a table.column that a migration restricts to the values of the enum
+	Check string
+	// Lookup creates a migration for a table named after the enum that holds its values, for
+	// columns to reference with a foreign key
+	Lookup bool
+}
+
+// Enum creates a typed enum with the given values in the data directory. It is stored in the
+// database and encoded in JSON and forms as its value, or as a number with Iota, and the
+// validator checks fields of its type tagged validate:"enum"
+func Enum(opts EnumOptions) (*Result, error) {
+	if opts.Name == "" {
+		return nil, errors.New("you must give the enum a name")
+	}
+
+	if len(opts.Values) == 0 {
+		return nil, errors.New("you must give the enum at least one value, e.g. gq make enum status draft published")
+	}
+
+	if opts.Check != "" && !column.MatchString(opts.Check) {
+		return nil, fmt.Errorf("the column to check must be given as table.column, got %s", opts.Check)
+	}
+
+	if (opts.Check != "" || opts.Lookup) && opts.DatabaseType == "" {
+		return nil, errors.New("you have to define a database type to create migrations")
+	}
+
+	// sqlite only takes CHECK constraints in CREATE TABLE
+	if opts.Check != "" && opts.dialect() == "sqlite" {
+		return nil, errors.New("sqlite cannot add a CHECK constraint to an existing table, use --lookup instead")
+	}
+
+	enumName := strcase.ToCamel(opts.Name)
+
+	var constants, names, list []string
+	seen := map[string]bool{}
+	for i, value := range opts.Values {
+		if seen[value] {
+			return nil, fmt.Errorf("the value %s is given more than once", value)
+		}
+		seen[value] = true
+
+		constant := enumName + strcase.ToCamel(value)
+		list = append(list, constant)
+
+		switch {
+		case !opts.Iota:
+			constants = append(constants, fmt.Sprintf("\t%s %s = %q", constant, enumName, value))
+		case i == 0:
+			constants = append(constants, fmt.Sprintf("\t%s %s = iota + 1", constant, enumName))
+		default:
+			constants = append(constants, "\t"+constant)
+		}
+		names = append(names, fmt.Sprintf("\t%s: %q,", constant, value))
+	}
+
+	template := "templates/data/enum.go.txt"
+	if opts.Iota {
+		template = "templates/data/enum_iota.go.txt"
+	}
+
+	data, err := opts.Template(template)
+	if err != nil {
+		return nil, err
+	}
+
+	enum := strings.NewReplacer(
+		"$ENUMCONSTANTS$", strings.Join(constants, "\n"),
+		"$ENUMNAMES$", strings.Join(names, "\n"),
+		"$ENUMLIST$", strings.Join(list, ", "),
+		"$ENUMNAME$", enumName,
+		"$ENUMVAR$", strcase.ToLowerCamel(opts.Name),
+	).Replace(string(data))
+
+	// line up the constants the way gofmt would
+	formatted, err := format.Source([]byte(enum))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{}
+	err = opts.create(res, opts.path("data", strcase.ToSnake(opts.Name)+"_enum.go"), formatted)
+	if err != nil {
+		return res, err
+	}
+
+	if opts.Check != "" {
+		err = opts.enumCheck(res, opts.Check, opts.sqlValues())
+		if err != nil {
+			return res, err
+		}
+	}
+
+	if opts.Lookup {
+		err = opts.enumLookup(res)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
+
+// sqlValues are the values of the enum as they are stored, as SQL literals
+func (opts EnumOptions) sqlValues() []string {
+	values := make([]string, len(opts.Values))
+	for i, value := range opts.Values {
+		if opts.Iota {
+			values[i] = strconv.Itoa(i + 1)
+		} else {
+			values[i] = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+		}
+	}
+
+	return values
+}
+
+// enumCheck creates a migration adding a CHECK constraint that only lets the values into the column
+func (o Options) enumCheck(res *Result, check string, values []string) error {
+	match := column.FindStringSubmatch(check)
+	table, col := match[1], match[2]
+	constraint := table + "_" + col + "_check"
+
+	up := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s IN (%s));\n", table, constraint, col, strings.Join(values, ", "))
+	down := fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;\n", table, constraint)
+	if o.dialect() == "mysql" {
+		down = fmt.Sprintf("ALTER TABLE %s DROP CHECK %s;\n", table, constraint)
+	}
+
+	return o.writeMigration(res, "add_"+constraint, up, down)
+}
+
+// enumLookup creates a migration for a table holding every value of the enum, with its number
+// as well for Iota enums
+func (opts EnumOptions) enumLookup(res *Result) error {
+	table := strcase.ToSnake(pluralize.NewClient().Plural(opts.Name))
+	values := opts.sqlValues()
+
+	key := "value"
+	var up strings.Builder
+	if opts.Iota {
+		key = "id"
+		fmt.Fprintf(&up, "CREATE TABLE %s (\n    id integer PRIMARY KEY,\n    name varchar(255) NOT NULL UNIQUE\n);\n\n", table)
+		fmt.Fprintf(&up, "INSERT INTO %s (id, name) VALUES\n", table)
+		for i, value := range opts.Values {
+			sep := ",\n"
+			if i == len(values)-1 {
+				sep = ";\n"
+			}
+			fmt.Fprintf(&up, "    (%s, '%s')%s", values[i], strings.ReplaceAll(value, "'", "''"), sep)
+		}
+	} else {
+		fmt.Fprintf(&up, "CREATE TABLE %s (\n    value varchar(255) PRIMARY KEY\n);\n\n", table)
+		fmt.Fprintf(&up, "INSERT INTO %s (value) VALUES\n", table)
+		for i, value := range values {
+			sep := ",\n"
+			if i == len(values)-1 {
+				sep = ";\n"
+			}
+			fmt.Fprintf(&up, "    (%s)%s", value, sep)
+		}
+	}
+
+	err := opts.writeMigration(res, "create_"+table+"_table", up.String(), "DROP TABLE IF EXISTS "+table+";\n")
+	if err != nil {
+		return err
+	}
+
+	res.note("Columns can reference the values with REFERENCES %s(%s)", table, key)
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// ServicesMarker is the line in rpc/rpc.go that new gRPC services are registered below
+const ServicesMarker = "// services - added by make grpc"
+
+// GRPCOptions are the options of GRPC
+type GRPCOptions struct {
+	Options
+	Name string
+}
+
+// GRPC creates a gRPC service: its proto file in proto, a server implementing it in rpc and its
+// registration in rpc/rpc.go. The first service also creates rpc/rpc.go and adds a proto target
+// that runs protoc to the Makefile
+func GRPC(opts GRPCOptions) (*Result, error) {
+	if opts.Name == "" {
+		return nil, errors.New("you must give the service a name")
+	}
+
+	name := strcase.ToCamel(strings.TrimSuffix(strcase.ToCamel(opts.Name), "Service"))
+	pkg := strings.ToLower(name)
+	file := strcase.ToSnake(name)
+	module := ModuleName(opts.Root)
+	replacements := []string{
+		"$GRPCNAME$", name,
+		"$GRPCPACKAGE$", pkg,
+		"$GRPCFILE$", file,
+		"myapp", module,
+	}
+
+	res := &Result{}
+	err := opts.render(res, "templates/grpc/service.proto.txt", opts.path("proto", pkg, file+".proto"), replacements...)
+	if err != nil {
+		return res, err
+	}
+
+	err = opts.render(res, "templates/grpc/server.go.txt", opts.path("rpc", file+"_server.go"), replacements...)
+	if err != nil {
+		return res, err
+	}
+
+	registry := opts.path("rpc", "rpc.go")
+	if !Exists(registry) {
+		err = opts.render(res, "templates/grpc/rpc.go.txt", registry)
+		if err != nil {
+			return res, err
+		}
+
+		res.note("Serve the services next to the web server with app.ServeGRPC(rpc.NewServer(app)) before app.ListenAndServe()")
+		res.note("Add grpc to the project with go get google.golang.org/grpc")
+	}
+
+	err = opts.protoTarget(res)
+	if err != nil {
+		return res, err
+	}
+
+	content, err := opts.read(res, registry)
+	if err != nil {
+		return res, err
+	}
+
+	if !bytes.Contains(content, []byte(ServicesMarker)) {
+		res.note("Could not find where to register the service, add register%s(s, app) to Register in %s", name, registry)
+	} else {
+		content = bytes.Replace(content, []byte(ServicesMarker), []byte(ServicesMarker+"\n\tregister"+name+"(s, app)"), 1)
+		err = opts.update(res, registry, content)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	res.note("Generate the Go code for %s with make proto", opts.path("proto", pkg, file+".proto"))
+
+	return res, nil
+}
+
+// protoTarget adds the proto target to the Makefile, unless it has one
+func (o Options) protoTarget(res *Result) error {
+	makefile := o.path("Makefile")
+	content, err := o.read(res, makefile)
+	if errors.Is(err, os.ErrNotExist) {
+		res.note("There is no Makefile, generate the Go code with protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative proto/*/*.proto")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if bytes.HasPrefix(content, []byte("proto:")) || bytes.Contains(content, []byte("\nproto:")) {
+		return nil
+	}
+
+	target, err := o.Template("templates/grpc/Makefile.txt")
+	if err != nil {
+		return err
+	}
+
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+
+	return o.update(res, makefile, append(content, target...))
+}
@@ -0,0 +1,36 @@
+package scaffold
+
+import (
+	"errors"
+	"strings"
+)
+
+// MailOptions are the options of Mail
+type MailOptions struct {
+	Options
+	Name string
+	// Markdown writes a single markdown template that the email package renders to both html and
+	// plain text, instead of one of each
+	Markdown bool
+}
+
+// Mail creates the templates of an email in the email directory
+func Mail(opts MailOptions) (*Result, error) {
+	if opts.Name == "" || strings.HasPrefix(opts.Name, "-") {
+		return nil, errors.New("you must give the mail a name")
+	}
+
+	name := strings.ToLower(opts.Name)
+	res := &Result{}
+
+	if opts.Markdown {
+		return res, opts.render(res, "templates/email/markdown.tmpl.txt", opts.path("email", name+".md.tmpl"))
+	}
+
+	err := opts.render(res, "templates/email/html.tmpl.txt", opts.path("email", name+".html.tmpl"))
+	if err != nil {
+		return res, err
+	}
+
+	return res, opts.render(res, "templates/email/plain.tmpl.txt", opts.path("email", name+".plain.tmpl"))
+}
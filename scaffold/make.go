@@ -0,0 +1,273 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/gertd/go-pluralize"
+	"github.com/iancoleman/strcase"
+)
+
+// HandlerOptions are the options of Handler
+type HandlerOptions struct {
+	Options
+	Name string
+}
+
+// Handler creates a stub handler in the handlers directory
+func Handler(opts HandlerOptions) (*Result, error) {
+	if opts.Name == "" {
+		return nil, errors.New("you must give the handler a name")
+	}
+
+	res := &Result{}
+	err := opts.render(res, "templates/handlers/handler.go.txt",
+		opts.path("handlers", strings.ToLower(opts.Name)+".go"),
+		"$HANDLERNAME$", strcase.ToCamel(opts.Name))
+
+	return res, err
+}
+
+// MigrationOptions are the options of Migration
+type MigrationOptions struct {
+	Options
+	Name string
+}
+
+// Migration creates an up and a down migration for the project's database
+func Migration(opts MigrationOptions) (*Result, error) {
+	if opts.Name == "" {
+		return nil, errors.New("migration name is required")
+	}
+
+	res := &Result{}
+	err := opts.migration(res, opts.Name,
+		"templates/migrations/migration.DIALECT.up.sql", "templates/migrations/migration.DIALECT.down.sql", "", opts.Name)
+
+	return res, err
+}
+
+// ModelOptions are the options of Model
+type ModelOptions struct {
+	Options
+	Name string
+}
+
+// Model creates a model in the data directory, embedding the data.Model of data/base.go, which it
+// creates with the first model. With a database type it also creates the migration for the model's
+// table and adds the model to data/models.go
+func Model(opts ModelOptions) (*Result, error) {
+	if opts.Name == "" {
+		return nil, errors.New("model name is required")
+	}
+
+	fileName, modelCamelName, tableName := modelNames(opts.Name)
+	modelCamelNamePlural := pluralize.NewClient().Plural(modelCamelName)
+
+	res := &Result{}
+	err := opts.render(res, "templates/data/model.go.txt",
+		opts.path("data", fileName+".go"),
+		"$MODELNAME$", modelCamelName, "$TABLENAME$", tableName)
+	if err != nil {
+		return res, err
+	}
+
+	// the models embed data.Model, which the first model brings along
+	base := opts.path("data", "base.go")
+	if !opts.exists(res, base) {
+		err = opts.render(res, "templates/data/base.go.txt", base)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	if opts.DatabaseType == "" {
+		return res, nil
+	}
+
+	err = opts.migration(res, "create_"+tableName+"_table",
+		"templates/migrations/migration.DIALECT.up.sql", "templates/migrations/migration.DIALECT.down.sql", "", tableName)
+	if err != nil {
+		return res, err
+	}
+
+	models := opts.path("data", "models.go")
+	modelsContent, err := opts.read(res, models)
+	if err != nil {
+		return res, err
+	}
+
+	if bytes.Contains(modelsContent, []byte(modelCamelName)) {
+		return res, errors.New(modelCamelName + " already exists in models.go")
+	}
+
+	modelsContent = bytes.Replace(modelsContent, []byte("type Models struct {"), []byte("type Models struct {\n\t"+modelCamelNamePlural+" "+modelCamelName+"\n"), 1)
+	modelsContent = bytes.Replace(modelsContent, []byte("return Models{"), []byte("return Models{\n\t\t"+modelCamelNamePlural+": "+modelCamelName+"{},\n"), 1)
+
+	return res, opts.update(res, models, modelsContent)
+}
+
+// modelNames returns the file, type and table name of the model called name
+func modelNames(name string) (fileName, modelName, tableName string) {
+	plural := pluralize.NewClient()
+
+	if plural.IsPlural(name) {
+		fileName = strings.ToLower(plural.Singular(name))
+		tableName = strings.ToLower(name)
+	} else {
+		fileName = strings.ToLower(name)
+		tableName = strings.ToLower(plural.Plural(name))
+	}
+
+	return fileName, strcase.ToCamel(name), tableName
+}
+
+// RequestOptions are the options of Request
+type RequestOptions struct {
+	Options
+	Name string
+}
+
+// Request creates a validated form request in the requests directory
+func Request(opts RequestOptions) (*Result, error) {
+	if opts.Name == "" {
+		return nil, errors.New("you must give the request a name")
+	}
+
+	requestName := strcase.ToCamel(opts.Name)
+	if !strings.HasSuffix(requestName, "Request") {
+		requestName += "Request"
+	}
+
+	res := &Result{}
+	err := opts.render(res, "templates/requests/request.go.txt",
+		opts.path("requests", strings.ToLower(opts.Name)+".go"),
+		"$REQUESTNAME$", requestName)
+
+	return res, err
+}
+
+// PolicyOptions are the options of Policy
+type PolicyOptions struct {
+	Options
+	// Model is the model the policy authorizes
+	Model string
+}
+
+// Policy creates an authorization policy for a model in the policies directory
+func Policy(opts PolicyOptions) (*Result, error) {
+	if opts.Model == "" {
+		return nil, errors.New("you must give the policy a model name")
+	}
+
+	modelName := strcase.ToCamel(pluralize.NewClient().Singular(opts.Model))
+	policyName := modelName + "Policy"
+
+	res := &Result{}
+	err := opts.render(res, "templates/policies/policy.go.txt",
+		opts.path("policies", strings.ToLower(modelName)+".go"),
+		"$POLICYNAME$", policyName,
+		"$MODELNAME$", modelName,
+		"$VAR$", strings.ToLower(policyName[:1]),
+		"myapp", ModuleName(opts.Root))
+	if err != nil {
+		return res, err
+	}
+
+	res.note("Register it with app.Policies.Register(&data.%s{}, policies.%s{})", modelName, policyName)
+
+	return res, nil
+}
+
+// EventOptions are the options of Event
+type EventOptions struct {
+	Options
+	Name string
+}
+
+// Event creates an event in the events directory
+func Event(opts EventOptions) (*Result, error) {
+	if opts.Name == "" {
+		return nil, errors.New("you must give the event a name")
+	}
+
+	res := &Result{}
+	err := opts.render(res, "templates/events/event.go.txt",
+		opts.path("events", strcase.ToSnake(opts.Name)+".go"),
+		"$EVENTNAME$", strcase.ToCamel(opts.Name),
+		"$EVENTKEY$", strcase.ToDelimited(opts.Name, '.'))
+
+	return res, err
+}
+
+// ListenersMarker is the line in listeners/providers.go that new listeners are registered below
+const ListenersMarker = "// listeners - added by make listener"
+
+// ListenerOptions are the options of Listener
+type ListenerOptions struct {
+	Options
+	Name string
+	// Event is the event the listener is registered for in listeners/providers.go, by the name
+	// make event gave it
+	Event string
+	// Queued registers the listener with ListenQueued, so it handles the event in the background
+	Queued bool
+}
+
+// Listener creates an event listener in the listeners directory. With an Event it is registered
+// for it in listeners/providers.go, which is created with the first listener that is
+func Listener(opts ListenerOptions) (*Result, error) {
+	if opts.Name == "" {
+		return nil, errors.New("you must give the listener a name")
+	}
+
+	listenerName := strcase.ToCamel(opts.Name)
+	listen := "Listen"
+	if opts.Queued {
+		listen = "ListenQueued"
+	}
+
+	doc := "handles the " + strcase.ToDelimited(opts.Event, '.') + " event"
+	switch {
+	case opts.Event == "":
+		doc = "comment goes here.\n// Register it in listeners/providers.go with d." + listen + "(\"event.name\", " + listenerName + "{})"
+	case opts.Queued:
+		doc += ", in the background as it is queued"
+	}
+
+	res := &Result{}
+	err := opts.render(res, "templates/events/listener.go.txt",
+		opts.path("listeners", strcase.ToSnake(opts.Name)+".go"),
+		"$LISTENERNAME$", listenerName,
+		"$LISTENERDOC$", doc)
+	if err != nil || opts.Event == "" {
+		return res, err
+	}
+
+	providers := opts.path("listeners", "providers.go")
+	if !Exists(providers) {
+		err = opts.render(res, "templates/events/providers.go.txt", providers)
+		if err != nil {
+			return res, err
+		}
+
+		res.note("Register the listeners when the app boots with listeners.Register(app.Events)")
+	}
+
+	registration := "d." + listen + "(\"" + strcase.ToDelimited(opts.Event, '.') + "\", " + listenerName + "{})"
+
+	content, err := opts.read(res, providers)
+	if err != nil {
+		return res, err
+	}
+
+	if !bytes.Contains(content, []byte(ListenersMarker)) {
+		res.note("Could not find where to register the listener, add %s to %s", registration, providers)
+		return res, nil
+	}
+
+	content = bytes.Replace(content, []byte(ListenersMarker), []byte(ListenersMarker+"\n\t"+registration), 1)
+
+	return res, opts.update(res, providers, content)
+}
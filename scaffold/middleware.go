@@ -0,0 +1,65 @@
+package scaffold
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// routesFunc finds the start of the routes function in routes.go, capturing the receiver name
+var routesFunc = regexp.MustCompile(`func \((\w+) \*application\) routes\(\)[^{]*\{\n`)
+
+// MiddlewareOptions are the options of Middleware
+type MiddlewareOptions struct {
+	Options
+	Name string
+	// Register adds the middleware to the top of the routes function so that it runs for every route
+	Register bool
+}
+
+// Middleware creates a middleware in the middleware directory
+func Middleware(opts MiddlewareOptions) (*Result, error) {
+	if opts.Name == "" || strings.HasPrefix(opts.Name, "-") {
+		return nil, errors.New("you must give the middleware a name")
+	}
+
+	middlewareName := strcase.ToCamel(opts.Name)
+
+	res := &Result{}
+	err := opts.render(res, "templates/middleware/middleware.go.txt",
+		opts.path("middleware", strcase.ToSnake(opts.Name)+".go"),
+		"$MIDDLEWARENAME$", middlewareName)
+	if err != nil || !opts.Register {
+		return res, err
+	}
+
+	return res, opts.registerMiddleware(res, middlewareName)
+}
+
+// registerMiddleware inserts a use call for the middleware as the first line of the routes function.
+// chi panics when middleware is added after a route, so it cannot go anywhere else
+func (o Options) registerMiddleware(res *Result, middlewareName string) error {
+	routesFile := o.path("routes.go")
+	content, err := o.read(res, routesFile)
+	if err != nil {
+		return err
+	}
+
+	match := routesFunc.FindSubmatchIndex(content)
+	if match == nil {
+		res.note("Could not find the routes function, add the middleware yourself: route.use(route.Middleware.%s)", middlewareName)
+		return nil
+	}
+
+	receiver := string(content[match[2]:match[3]])
+	use := receiver + ".use(" + receiver + ".Middleware." + middlewareName + ")"
+	if strings.Contains(string(content), use) {
+		return nil
+	}
+
+	output := string(content[:match[1]]) + "\t" + use + "\n" + string(content[match[1]:])
+
+	return o.update(res, routesFile, []byte(output))
+}
@@ -0,0 +1,282 @@
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/jimmitjoo/gemquick"
+)
+
+// SkeletonURL is the repository new projects are cloned from
+const SkeletonURL = "https://github.com/jimmitjoo/gemquick-bare.git"
+
+// starter is a variant of the skeleton that New can create. Its paths are removed from the cloned
+// skeleton, its files in templates/new/<name> are added and its settings are set in .env. Starters
+// with a database come with the settings module
+type starter struct {
+	remove     []string
+	env        map[string]string
+	noDatabase bool
+}
+
+var starters = map[string]starter{
+	"full": {},
+	"api": {
+		remove: []string{"views", "public"},
+		env: map[string]string{
+			"SESSION_TYPE":                 "none",
+			"RENDERER":                     "",
+			"CORS_PUBLIC_ALLOWED_ORIGINS":  "*",
+			"CORS_ADMIN_ALLOWED_ORIGINS":   "http://localhost:3000",
+			"CORS_ADMIN_ALLOW_CREDENTIALS": "true",
+		},
+	},
+	"htmx": {
+		remove: []string{"views"},
+		env:    map[string]string{"RENDERER": "jet"},
+	},
+	"minimal": {
+		remove:     []string{"views", "public", "migrations", "email"},
+		env:        map[string]string{"DATABASE_TYPE": "", "CACHE": "", "SESSION_TYPE": "cookie"},
+		noDatabase: true,
+	},
+}
+
+// StarterNames are the templates New can start a project from
+func StarterNames() []string {
+	names := make([]string, 0, len(starters))
+	for name := range starters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NewOptions are the options of New. Root is the directory the project is created in
+type NewOptions struct {
+	Options
+	// Name is the name of the project, or its module path, like github.com/me/shop
+	Name string
+	// Starter is the template to start from, see StarterNames, full when it is empty
+	Starter string
+	// Skeleton is the git repository to clone, SkeletonURL when it is empty
+	Skeleton string
+	// Key is the encryption key written to .env, a random one when it is empty
+	Key string
+	// Progress receives the progress of the clone and of every step, when it is set
+	Progress io.Writer
+}
+
+// New creates a project from the skeleton: it clones it, writes .env and go.mod, applies the starter
+// template, adds the settings module, replaces the skeleton's module name in the source and starts
+// go mod tidy
+func New(opts NewOptions) (*Result, error) {
+	if opts.Name == "" {
+		return nil, errors.New("new requires a project name")
+	}
+
+	if opts.Starter == "" {
+		opts.Starter = "full"
+	}
+
+	start, ok := starters[opts.Starter]
+	if !ok {
+		return nil, fmt.Errorf("unknown template %s, choose one of %s", opts.Starter, strings.Join(StarterNames(), ", "))
+	}
+
+	if opts.Skeleton == "" {
+		opts.Skeleton = SkeletonURL
+	}
+
+	if opts.Key == "" {
+		opts.Key = gemquick.Gemquick{}.RandomString(32)
+	}
+
+	progress := opts.Progress
+	if progress == nil {
+		progress = io.Discard
+	}
+
+	module := strings.ToLower(opts.Name)
+	appname := module
+	if strings.Contains(appname, "/") {
+		exploded := strings.SplitAfter(appname, "/")
+		appname = exploded[len(exploded)-1]
+	}
+
+	dir := opts.path(appname)
+	res := &Result{}
+
+	fmt.Fprintln(progress, "Cloning skeleton application...")
+	_, err := git.PlainClone(dir, false, &git.CloneOptions{
+		URL:      opts.Skeleton,
+		Progress: progress,
+		Depth:    1,
+	})
+	if err != nil {
+		return res, err
+	}
+
+	err = os.RemoveAll(filepath.Join(dir, ".git"))
+	if err != nil {
+		return res, err
+	}
+
+	fmt.Fprintln(progress, "Creating .env file...")
+	data, err := opts.Template("templates/env.txt")
+	if err != nil {
+		return res, err
+	}
+
+	env := strings.NewReplacer("${APP_NAME}", appname, "${KEY}", opts.Key).Replace(string(data))
+	for key, value := range start.env {
+		env = setEnvValue(env, key, value)
+	}
+
+	err = opts.create(res, filepath.Join(dir, ".env"), []byte(env))
+	if err != nil {
+		return res, err
+	}
+
+	fmt.Fprintln(progress, "Creating Makefile...")
+	makefile := "Makefile.mac"
+	if runtime.GOOS == "windows" {
+		makefile = "Makefile.windows"
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, makefile))
+	if err != nil {
+		return res, err
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "Makefile"), data, 0644)
+	if err != nil {
+		return res, err
+	}
+
+	os.Remove(filepath.Join(dir, "Makefile.windows"))
+	os.Remove(filepath.Join(dir, "Makefile.mac"))
+
+	if opts.Starter != "full" {
+		fmt.Fprintf(progress, "Applying the %s template...\n", opts.Starter)
+		err = opts.applyStarter(res, dir, opts.Starter, start)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	if !start.noDatabase {
+		fmt.Fprintln(progress, "Adding the settings module...")
+		mod, err := settings(Options{Root: dir, FS: opts.FS}, false)
+		if mod != nil {
+			res.Files = append(res.Files, mod.Files...)
+			res.Updated = append(res.Updated, mod.Updated...)
+			res.Notes = append(res.Notes, mod.Notes...)
+		}
+		if err != nil {
+			return res, err
+		}
+	}
+
+	fmt.Fprintln(progress, "Creating go.mod file...")
+	os.Remove(filepath.Join(dir, "go.mod"))
+
+	err = opts.render(res, "templates/go.mod.txt", filepath.Join(dir, "go.mod"), "${APP_NAME}", appname)
+	if err != nil {
+		return res, err
+	}
+
+	fmt.Fprintln(progress, "Updating source files...")
+	err = UpdateSource(dir, module, progress)
+	if err != nil {
+		return res, err
+	}
+
+	fmt.Fprintln(progress, "Running go mod tidy...")
+	cmd := exec.Command("go", "mod", "tidy")
+	cmd.Dir = dir
+
+	return res, cmd.Start()
+}
+
+// applyStarter turns the skeleton cloned into dir into the named starter
+func (o Options) applyStarter(res *Result, dir, name string, start starter) error {
+	for _, p := range start.remove {
+		err := os.RemoveAll(filepath.Join(dir, p))
+		if err != nil {
+			return err
+		}
+	}
+
+	root := path.Join("templates/new", name)
+	templates := o.FS
+	if templates == nil {
+		templates = Templates
+	}
+
+	return fs.WalkDir(templates, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := o.Template(p)
+		if err != nil {
+			return err
+		}
+
+		// the starter's files replace the skeleton's
+		target := filepath.Join(dir, filepath.FromSlash(strings.TrimSuffix(strings.TrimPrefix(p, root+"/"), ".txt")))
+		os.Remove(target)
+
+		return o.create(res, target, data)
+	})
+}
+
+// UpdateSource replaces the skeleton's module name myapp with module in every file below dir,
+// listing the go files it updates on progress
+func UpdateSource(dir, module string, progress io.Writer) error {
+	return filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if fi.IsDir() {
+			return nil
+		}
+
+		if filepath.Ext(path) == ".go" {
+			fmt.Fprintf(progress, "Updating %s\n", path)
+		}
+
+		read, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(path, []byte(strings.ReplaceAll(string(read), "myapp", module)), 0)
+	})
+}
+
+// setEnvValue sets key to value in the contents of a .env file, adding it when it is missing
+func setEnvValue(env, key, value string) string {
+	lines := strings.Split(env, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, key+"=") {
+			lines[i] = key + "=" + value
+			return strings.Join(lines, "\n")
+		}
+	}
+
+	return env + "\n" + key + "=" + value + "\n"
+}
@@ -0,0 +1,48 @@
+package scaffold
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// NotificationOptions are the options of Notification
+type NotificationOptions struct {
+	Options
+	Name string
+}
+
+// Notification creates a notification in the notifications directory. With a database type it
+// also creates the migration for the notifications table of the database channel, once
+func Notification(opts NotificationOptions) (*Result, error) {
+	if opts.Name == "" {
+		return nil, errors.New("you must give the notification a name")
+	}
+
+	words := strcase.ToDelimited(opts.Name, ' ')
+
+	res := &Result{}
+	err := opts.render(res, "templates/notifications/notification.go.txt",
+		opts.path("notifications", strcase.ToSnake(opts.Name)+".go"),
+		"$NOTIFICATIONNAME$", strcase.ToCamel(opts.Name),
+		"$NOTIFICATIONKEY$", strcase.ToKebab(opts.Name),
+		"$NOTIFICATIONTITLE$", strings.ToUpper(words[:1])+words[1:])
+	if err != nil {
+		return res, err
+	}
+
+	if opts.DatabaseType == "" {
+		res.note("No database configured, the database channel will not be available")
+		return res, nil
+	}
+
+	existing, _ := filepath.Glob(filepath.Join(opts.migrationsDir(), "*_create_notifications_table.*"))
+	if len(existing) > 0 {
+		return res, nil
+	}
+
+	return res, opts.migration(res, "create_notifications_table",
+		"templates/migrations/notifications_table.DIALECT.up.sql", "", "DROP TABLE IF EXISTS notifications;", "notifications")
+}
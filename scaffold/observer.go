@@ -0,0 +1,179 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+// RegistriesMarker is the line in data/observers.go that the registries of observed models are
+// declared below, and ObserversMarker the line in observers/observers.go that observers are
+// registered below
+const (
+	RegistriesMarker = "// registries - added by make observer"
+	ObserversMarker  = "// observers - added by make observer"
+)
+
+// ObserverOptions are the options of Observer
+type ObserverOptions struct {
+	Options
+	// Model is the model to observe, named like it was given to Model
+	Model string
+}
+
+// Observer creates an observer with Created, Updated and Deleted methods for a model in the
+// observers directory. The model gets a registry of observers in data/observers.go, which its
+// Create, Update and Delete methods call after writing, and the observer is registered with it
+// in observers/observers.go
+func Observer(opts ObserverOptions) (*Result, error) {
+	if opts.Model == "" {
+		return nil, errors.New("you must give the model to observe")
+	}
+
+	fileName, modelName, _ := modelNames(opts.Model)
+	modelFile := opts.path("data", fileName+".go")
+	if !Exists(modelFile) {
+		return nil, errors.New("there is no " + modelName + " model in the data directory, create it with gq make model " + opts.Model)
+	}
+
+	module := ModuleName(opts.Root)
+	registry := modelName + "Observers"
+
+	res := &Result{}
+	err := opts.render(res, "templates/observers/observer.go.txt",
+		opts.path("observers", fileName+"_observer.go"),
+		"$MODELNAME$", modelName,
+		"myapp", module)
+	if err != nil {
+		return res, err
+	}
+
+	registries := opts.path("data", "observers.go")
+	if !Exists(registries) {
+		err = opts.render(res, "templates/observers/registries.go.txt", registries)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	err = opts.insertBelow(res, registries, RegistriesMarker,
+		"\t"+registry+" observers.Registry["+modelName+"]")
+	if err != nil {
+		return res, err
+	}
+
+	err = opts.observeModel(res, modelFile, modelName, registry)
+	if err != nil {
+		return res, err
+	}
+
+	register := opts.path("observers", "observers.go")
+	if !Exists(register) {
+		err = opts.render(res, "templates/observers/observers.go.txt", register, "myapp", module)
+		if err != nil {
+			return res, err
+		}
+
+		res.note("Register the observers when the app boots with observers.Register(app)")
+	}
+
+	return res, opts.insertBelow(res, register, ObserversMarker,
+		"\tdata."+registry+".Observe(&"+modelName+"Observer{App: app})")
+}
+
+// insertBelow adds line below the marker in path, unless path has it already
+func (o Options) insertBelow(res *Result, path, marker, line string) error {
+	content, err := o.read(res, path)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Contains(content, []byte(line)) {
+		return nil
+	}
+
+	if !bytes.Contains(content, []byte(marker)) {
+		res.note("Could not find %q, add %s to %s", marker, strings.TrimSpace(line), path)
+		return nil
+	}
+
+	return o.update(res, path, bytes.Replace(content, []byte(marker), []byte(marker+"\n"+line), 1))
+}
+
+// observeModel makes the Create, Update and Delete methods of a model made by make model call
+// the registry after they write. Methods that no longer look generated are left for the developer
+func (o Options) observeModel(res *Result, path, modelName, registry string) error {
+	content, err := o.read(res, path)
+	if err != nil {
+		return err
+	}
+
+	// models that embed data.Model have their id in it
+	deleted := modelName + "{ID: id}"
+	if bytes.Contains(content, []byte("Model `db:\",inline\"`")) {
+		deleted = modelName + "{Model: Model{ID: id}}"
+	}
+
+	hooks := []struct {
+		method, header, ret string
+		calls               []string
+	}{
+		{"Create", "func (t *" + modelName + ") Create(m " + modelName + ") (int, error) {", "return id, nil",
+			[]string{"m.ID = id", registry + ".Created(m)"}},
+		{"Update", "func (t *" + modelName + ") Update(m " + modelName + ") error {", "return nil",
+			[]string{registry + ".Updated(m)"}},
+		{"Delete", "func (t *" + modelName + ") Delete(id int) error {", "return nil",
+			[]string{registry + ".Deleted(" + deleted + ")"}},
+	}
+
+	src := string(content)
+	for _, hook := range hooks {
+		var ok bool
+		src, ok = insertBeforeReturn(src, hook.header, hook.ret, hook.calls)
+		if !ok {
+			res.note("Could not find where %s.%s returns, call %s after it writes", modelName, hook.method, hook.calls[len(hook.calls)-1])
+		}
+	}
+
+	if src == string(content) {
+		return nil
+	}
+
+	return o.update(res, path, []byte(src))
+}
+
+// insertBeforeReturn adds calls before the last ret in the function that starts with header, at
+// the indentation of ret. It reports false when it cannot find where to put them
+func insertBeforeReturn(src, header, ret string, calls []string) (string, bool) {
+	start := strings.Index(src, header)
+	if start < 0 {
+		return src, false
+	}
+
+	end := strings.Index(src[start:], "\n}")
+	if end < 0 {
+		return src, false
+	}
+	body := src[start : start+end]
+
+	if strings.Contains(body, calls[len(calls)-1]) {
+		return src, true
+	}
+
+	at := strings.LastIndex(body, ret)
+	if at < 0 {
+		return src, false
+	}
+
+	line := strings.LastIndex(body[:at], "\n") + 1
+	indent := body[line:at]
+
+	var inserted strings.Builder
+	for _, call := range calls {
+		inserted.WriteString(indent + call + "\n")
+	}
+	inserted.WriteString("\n")
+
+	pos := start + line
+	return src[:pos] + inserted.String() + src[pos:], true
+}
@@ -0,0 +1,70 @@
+package scaffold
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// PartitionedTableOptions are the options of PartitionedTable
+type PartitionedTableOptions struct {
+	Options
+	Table string
+	// Column is the time column the table is partitioned by, created_at when it is empty
+	Column string
+	// Interval is how much time a partition covers, day or month
+	Interval string
+}
+
+// PartitionedTable creates the migration for a postgres table partitioned by range on a time
+// column, for rows like logs, metrics or audits, and tells how to have its partitions maintained
+func PartitionedTable(opts PartitionedTableOptions) (*Result, error) {
+	if opts.Table == "" || strings.HasPrefix(opts.Table, "-") {
+		return nil, errors.New("you must give the table a name")
+	}
+
+	if opts.DatabaseType != "" && opts.dialect() != "postgres" {
+		return nil, errors.New("partitioned tables are only supported on postgres")
+	}
+
+	column := strcase.ToSnake(opts.Column)
+	if column == "" {
+		column = "created_at"
+	}
+
+	interval := "Daily"
+	switch opts.Interval {
+	case "", "day":
+	case "month":
+		interval = "Monthly"
+	default:
+		return nil, errors.New("a partition covers a day or a month")
+	}
+
+	table := strcase.ToSnake(opts.Table)
+
+	up, err := opts.Template("templates/migrations/partitioned_table.postgres.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{}
+	err = opts.writeMigration(res, "create_"+table+"_table",
+		strings.NewReplacer("TABLENAME", table, "COLUMNNAME", column).Replace(string(up)),
+		"DROP TABLE IF EXISTS "+table+";\n")
+	if err != nil {
+		return res, err
+	}
+
+	res.note(`Create and drop the partitions of %s from the app, e.g. in init:
+
+	partitions := &database.Partitioner{DB: app.DB.Pool, DataType: app.DB.DataType, Table: "%s", Interval: database.%s, Retention: 90 * 24 * time.Hour}
+	app.OnWarmup("%s partitions", func() error {
+		_, _, err := partitions.Maintain(context.Background())
+		return err
+	})
+	err = partitions.MaintainOn(app.Scheduler, "@daily")`, table, table, interval, table)
+
+	return res, nil
+}
@@ -0,0 +1,56 @@
+package scaffold
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gertd/go-pluralize"
+	"github.com/iancoleman/strcase"
+)
+
+// RepositoryOptions are the options of Repository
+type RepositoryOptions struct {
+	Options
+	// Model is the model the repository stores
+	Model string
+}
+
+// Repository creates a repository interface for a model in the data directory, with a database
+// and an in-memory implementation
+func Repository(opts RepositoryOptions) (*Result, error) {
+	if opts.Model == "" {
+		return nil, errors.New("you must give the model of the repository")
+	}
+
+	name := opts.Model
+	plural := pluralize.NewClient()
+	if plural.IsPlural(name) {
+		name = plural.Singular(name)
+	}
+
+	modelName := strcase.ToCamel(name)
+	files := [][2]string{
+		{"templates/repositories/repository.go.txt", opts.path("data", strcase.ToSnake(name)+"_repository.go")},
+		{"templates/repositories/fake.go.txt", opts.path("data", strcase.ToSnake(name)+"_repository_fake.go")},
+	}
+
+	for _, file := range files {
+		if Exists(file[1]) {
+			return nil, errors.New(file[1] + " already exists.")
+		}
+	}
+
+	res := &Result{}
+	for _, file := range files {
+		err := opts.render(res, file[0], file[1], "$MODELNAME$", modelName)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	if !Exists(opts.path("data", strings.ToLower(name)+".go")) {
+		res.note("There is no %s model in the data directory yet, create it with gq make model %s", modelName, name)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,250 @@
+// Package scaffold generates the code of a Gemquick project: handlers, models, migrations, mails
+// and the other files gq make creates, and new projects like gq new. Editors, web interfaces and
+// company tooling can call it directly instead of running the gq binary
+package scaffold
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Templates are the templates the generators write from. A project can override any of them with
+// a copy under .gemquick, mirroring its path, e.g. .gemquick/templates/handlers/handler.go.txt
+//
+//go:embed templates
+var Templates embed.FS
+
+// OverrideDir is where a project keeps its own copies of the templates
+const OverrideDir = ".gemquick"
+
+// Options tell a generator which project to write to
+type Options struct {
+	// Root is the project directory
+	Root string
+	// FS holds the templates, Templates when it is nil
+	FS fs.FS
+	// DatabaseType is the project's DATABASE_TYPE. Generators that come with a migration skip it
+	// when it is empty, and make migration needs it
+	DatabaseType string
+	// MigrationsDir is where migrations are written, the migrations directory when it is empty
+	MigrationsDir string
+	// DryRun leaves the project as it is. The generator still fills in the Result, with what it
+	// would have written in Contents
+	DryRun bool
+}
+
+// Result lists what a generator did
+type Result struct {
+	// Files are the files that were created
+	Files []string
+	// Updated are existing files that were changed, like data/models.go or routes.go
+	Updated []string
+	// Notes are what the developer still has to do by hand
+	Notes []string
+	// Contents holds what was written to each created and updated file, and Previous what the
+	// updated files held before
+	Contents map[string][]byte
+	Previous map[string][]byte
+}
+
+func (r *Result) note(format string, a ...interface{}) {
+	r.Notes = append(r.Notes, fmt.Sprintf(format, a...))
+}
+
+// Template returns the project's override of a template if there is one, and the template from
+// FS otherwise
+func (o Options) Template(path string) ([]byte, error) {
+	override := filepath.Join(o.Root, OverrideDir, filepath.FromSlash(path))
+	if _, err := os.Stat(override); err == nil {
+		return os.ReadFile(override)
+	}
+
+	if o.FS == nil {
+		return fs.ReadFile(Templates, path)
+	}
+
+	return fs.ReadFile(o.FS, path)
+}
+
+// path joins elements to the project root
+func (o Options) path(elem ...string) string {
+	return filepath.Join(append([]string{o.Root}, elem...)...)
+}
+
+func (o Options) migrationsDir() string {
+	if o.MigrationsDir != "" {
+		return o.MigrationsDir
+	}
+
+	return o.path("migrations")
+}
+
+// dialect is the database type the migration templates are named after
+func (o Options) dialect() string {
+	switch o.DatabaseType {
+	case "pgx", "postgresql":
+		return "postgres"
+	case "mariadb":
+		return "mysql"
+	case "sqlite3":
+		return "sqlite"
+	case "mssql":
+		return "sqlserver"
+	}
+
+	return o.DatabaseType
+}
+
+// create writes a new file, refusing to overwrite one
+func (o Options) create(res *Result, path string, data []byte) error {
+	if o.exists(res, path) {
+		return errors.New(path + " already exists.")
+	}
+
+	if !o.DryRun {
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(path, data, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	res.Files = append(res.Files, path)
+	res.written(path, data)
+
+	return nil
+}
+
+// render reads a template, replaces its placeholders, given as pairs, and creates path with it
+func (o Options) render(res *Result, template, path string, replacements ...string) error {
+	data, err := o.Template(template)
+	if err != nil {
+		return err
+	}
+
+	return o.create(res, path, []byte(strings.NewReplacer(replacements...).Replace(string(data))))
+}
+
+// update replaces the contents of an existing file
+func (o Options) update(res *Result, path string, data []byte) error {
+	previous, err := o.read(res, path)
+	if err != nil {
+		return err
+	}
+
+	if !o.DryRun {
+		err = os.WriteFile(path, data, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	// a file updated twice, or created first, keeps what it held before the generator ran
+	if _, written := res.Contents[path]; !written {
+		if res.Previous == nil {
+			res.Previous = map[string][]byte{}
+		}
+		res.Previous[path] = previous
+	}
+
+	res.Updated = append(res.Updated, path)
+	res.written(path, data)
+
+	return nil
+}
+
+// read returns a file of the project as the generator left it, which in a dry run is only in res
+func (o Options) read(res *Result, path string) ([]byte, error) {
+	if data, ok := res.Contents[path]; ok {
+		return data, nil
+	}
+
+	return os.ReadFile(path)
+}
+
+// exists reports whether path exists, or would in a dry run
+func (o Options) exists(res *Result, path string) bool {
+	_, ok := res.Contents[path]
+	return ok || Exists(path)
+}
+
+func (r *Result) written(path string, data []byte) {
+	if r.Contents == nil {
+		r.Contents = map[string][]byte{}
+	}
+	r.Contents[path] = data
+}
+
+// migration creates the up and down migration name, from the templates named after the project's
+// database, with TABLENAME in them replaced by table. An empty down template writes down instead
+func (o Options) migration(res *Result, name, upTemplate, downTemplate, down, table string) error {
+	if o.DatabaseType == "" {
+		return errors.New("you have to define a database type to create migrations")
+	}
+
+	up, err := o.Template(strings.ReplaceAll(upTemplate, "DIALECT", o.dialect()))
+	if err != nil {
+		return err
+	}
+
+	if downTemplate != "" {
+		data, err := o.Template(strings.ReplaceAll(downTemplate, "DIALECT", o.dialect()))
+		if err != nil {
+			return err
+		}
+		down = string(data)
+	}
+
+	return o.writeMigration(res, name,
+		strings.ReplaceAll(string(up), "TABLENAME", table),
+		strings.ReplaceAll(down, "TABLENAME", table))
+}
+
+// writeMigration creates the up and down migration name with the given statements
+func (o Options) writeMigration(res *Result, name, up, down string) error {
+	if o.DatabaseType == "" {
+		return errors.New("you have to define a database type to create migrations")
+	}
+
+	base := filepath.Join(o.migrationsDir(), fmt.Sprintf("%d_%s.%s", time.Now().UnixMicro(), name, o.dialect()))
+
+	err := o.create(res, base+".up.sql", []byte(up))
+	if err != nil {
+		return err
+	}
+
+	return o.create(res, base+".down.sql", []byte(down))
+}
+
+// ModuleName reads the module path of the project in root from its go.mod, falling back to myapp,
+// which is what the templates use
+func ModuleName(root string) string {
+	content, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "myapp"
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, "module ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "module "))
+		}
+	}
+
+	return "myapp"
+}
+
+// Exists reports whether path exists
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return !os.IsNotExist(err)
+}
@@ -0,0 +1,459 @@
+package scaffold
+
+import (
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const testModels = `package data
+
+type Models struct {
+}
+
+func New() Models {
+	return Models{
+	}
+}
+`
+
+const testRoutes = `package main
+
+func (a *application) routes() *chi.Mux {
+	a.get("/", a.Handlers.Home)
+
+	return route.App.Routes
+}
+`
+
+// newProject creates a project with the files the generators update
+func newProject(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":         "module shop\n\ngo 1.21\n",
+		"data/models.go": testModels,
+		"routes.go":      testRoutes,
+		"Makefile":       "## test: runs all tests\ntest:\n\t@go test ./...\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+func TestGenerators(t *testing.T) {
+	var tests = []struct {
+		name     string
+		generate func(opts Options) (*Result, error)
+		files    []string
+		updated  []string
+		contains map[string]string
+	}{
+		{
+			name:     "handler",
+			generate: func(opts Options) (*Result, error) { return Handler(HandlerOptions{Options: opts, Name: "orders"}) },
+			files:    []string{"handlers/orders.go"},
+		},
+		{
+			name:     "model with migration",
+			generate: func(opts Options) (*Result, error) { return Model(ModelOptions{Options: opts, Name: "order"}) },
+			files:    []string{"data/order.go", "data/base.go", "migrations/*_create_orders_table.postgres.up.sql", "migrations/*_create_orders_table.postgres.down.sql"},
+			updated:  []string{"data/models.go"},
+			contains: map[string]string{"data/models.go": "Orders: Order{}", "data/order.go": "Model `db:\",inline\"`"},
+		},
+		{
+			name:     "policy",
+			generate: func(opts Options) (*Result, error) { return Policy(PolicyOptions{Options: opts, Model: "orders"}) },
+			files:    []string{"policies/order.go"},
+			contains: map[string]string{"policies/order.go": "shop/data"},
+		},
+		{
+			name: "markdown mail",
+			generate: func(opts Options) (*Result, error) {
+				return Mail(MailOptions{Options: opts, Name: "welcome", Markdown: true})
+			},
+			files: []string{"email/welcome.md.tmpl"},
+		},
+		{
+			name: "enum",
+			generate: func(opts Options) (*Result, error) {
+				return Enum(EnumOptions{Options: opts, Name: "status", Values: []string{"draft", "published"}})
+			},
+			files:    []string{"data/status_enum.go"},
+			contains: map[string]string{"data/status_enum.go": `StatusPublished Status = "published"`},
+		},
+		{
+			name: "iota enum with check and lookup",
+			generate: func(opts Options) (*Result, error) {
+				return Enum(EnumOptions{Options: opts, Name: "status", Values: []string{"draft", "published"}, Iota: true, Check: "orders.status", Lookup: true})
+			},
+			files: []string{
+				"data/status_enum.go",
+				"migrations/*_add_orders_status_check.postgres.up.sql",
+				"migrations/*_add_orders_status_check.postgres.down.sql",
+				"migrations/*_create_statuses_table.postgres.up.sql",
+				"migrations/*_create_statuses_table.postgres.down.sql",
+			},
+			contains: map[string]string{"data/status_enum.go": "StatusDraft Status = iota + 1"},
+		},
+		{
+			name: "registered middleware",
+			generate: func(opts Options) (*Result, error) {
+				return Middleware(MiddlewareOptions{Options: opts, Name: "audit", Register: true})
+			},
+			files:    []string{"middleware/audit.go"},
+			updated:  []string{"routes.go"},
+			contains: map[string]string{"routes.go": "a.use(a.Middleware.Audit)"},
+		},
+		{
+			name:     "command",
+			generate: func(opts Options) (*Result, error) { return Command(CommandOptions{Options: opts, Name: "prune"}) },
+			files:    []string{"commands/prune.go", "commands/commands.go", "cmd/console/main.go"},
+			updated:  []string{"commands/commands.go"},
+			contains: map[string]string{"cmd/console/main.go": `"shop/commands"`, "commands/commands.go": "&PruneCommand{App: app},"},
+		},
+		{
+			name: "model test",
+			generate: func(opts Options) (*Result, error) {
+				return ModelTest(ModelTestOptions{Options: opts, Model: "orders"})
+			},
+			files:    []string{"data/order_test.go", "data/setup_test.go"},
+			contains: map[string]string{"data/order_test.go": "func factoryOrders(t *testing.T"},
+		},
+		{
+			name:     "grpc",
+			generate: func(opts Options) (*Result, error) { return GRPC(GRPCOptions{Options: opts, Name: "orders"}) },
+			files:    []string{"proto/orders/orders.proto", "rpc/orders_server.go", "rpc/rpc.go"},
+			updated:  []string{"Makefile", "rpc/rpc.go"},
+			contains: map[string]string{
+				"rpc/rpc.go":                "registerOrders(s, app)",
+				"Makefile":                  "\nproto:\n",
+				"proto/orders/orders.proto": `option go_package = "shop/proto/orders";`,
+			},
+		},
+		{
+			name:     "settings",
+			generate: func(opts Options) (*Result, error) { return Settings(opts) },
+			files:    []string{"migrations/*_create_settings_table.postgres.up.sql", "migrations/*_create_settings_table.postgres.down.sql", "handlers/settings.go"},
+			updated:  []string{"routes.go"},
+			contains: map[string]string{"routes.go": `// r.Put("/admin/settings/{key}", route.Handlers.SettingsUpdate)`},
+		},
+		{
+			name:     "view",
+			generate: func(opts Options) (*Result, error) { return View(ViewOptions{Options: opts, Name: "customerTotals"}) },
+			files:    []string{"migrations/*_create_customer_totals_view.postgres.up.sql", "migrations/*_create_customer_totals_view.postgres.down.sql"},
+		},
+		{
+			name: "materialized view",
+			generate: func(opts Options) (*Result, error) {
+				return View(ViewOptions{Options: opts, Name: "daily_sales", Materialized: true})
+			},
+			files: []string{"migrations/*_create_daily_sales_materialized_view.postgres.up.sql", "migrations/*_create_daily_sales_materialized_view.postgres.down.sql"},
+		},
+		{
+			name:     "websocket",
+			generate: func(opts Options) (*Result, error) { return Websocket(WebsocketOptions{Options: opts, Name: "chat"}) },
+			files:    []string{"handlers/chat_websocket.go", "public/js/chat.js"},
+			updated:  []string{"routes.go"},
+		},
+		{
+			name:     "client errors",
+			generate: ClientErrors,
+			files:    []string{"public/js/client-errors.js"},
+		},
+		{
+			name:     "security reports",
+			generate: SecurityReports,
+			files: []string{"email/security-report.html.tmpl", "email/security-report.plain.tmpl",
+				"migrations/*_create_security_reports_table.postgres.up.sql", "migrations/*_create_security_reports_table.postgres.down.sql"},
+		},
+	}
+
+	for _, e := range tests {
+		root := newProject(t)
+
+		res, err := e.generate(Options{Root: root, DatabaseType: "pgx"})
+		if err != nil {
+			t.Errorf("%s: %s", e.name, err)
+			continue
+		}
+
+		if len(res.Files) != len(e.files) || len(res.Updated) != len(e.updated) {
+			t.Errorf("%s: expected %d created and %d updated files, got %+v", e.name, len(e.files), len(e.updated), res)
+		}
+
+		for _, pattern := range e.files {
+			if matches, _ := filepath.Glob(filepath.Join(root, pattern)); len(matches) != 1 {
+				t.Errorf("%s: expected %s to be created", e.name, pattern)
+			}
+		}
+
+		for file, expected := range e.contains {
+			content, _ := os.ReadFile(filepath.Join(root, file))
+			if !strings.Contains(string(content), expected) {
+				t.Errorf("%s: expected %s in %s, got %s", e.name, expected, file, content)
+			}
+		}
+
+		if _, err := e.generate(Options{Root: root, DatabaseType: "pgx"}); err == nil {
+			t.Errorf("%s: expected an error when the files already exist", e.name)
+		}
+	}
+}
+
+func TestSettings_Routes(t *testing.T) {
+	root := newProject(t)
+	if err := os.MkdirAll(filepath.Join(root, "middleware"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "middleware", "auth.go"), []byte("package middleware\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Settings(Options{Root: root, DatabaseType: "pgx"}); err != nil {
+		t.Fatal(err)
+	}
+
+	routes, _ := os.ReadFile(filepath.Join(root, "routes.go"))
+	for _, expected := range []string{"\t\tr.Use(route.Middleware.Auth)\n", "\t\tr.Put(\"/admin/settings/{key}\", route.Handlers.SettingsUpdate)\n"} {
+		if !strings.Contains(string(routes), expected) {
+			t.Errorf("expected %q in routes.go, got %s", expected, routes)
+		}
+	}
+	if _, err := format.Source(routes); err != nil {
+		t.Errorf("expected routes.go to stay valid Go, got %s", err)
+	}
+
+	root = newProject(t)
+	if _, err := settings(Options{Root: root, DatabaseType: "pgx"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	routes, _ = os.ReadFile(filepath.Join(root, "routes.go"))
+	if strings.Contains(string(routes), "\t\tr.Put(") || !strings.Contains(string(routes), "\t\t// r.Put(") {
+		t.Errorf("expected a new project's settings routes to be commented out, got %s", routes)
+	}
+}
+
+func TestOptions_Template(t *testing.T) {
+	root := t.TempDir()
+	opts := Options{Root: root, FS: fstest.MapFS{
+		"templates/handlers/handler.go.txt": {Data: []byte("from fs")},
+	}}
+
+	data, err := opts.Template("templates/handlers/handler.go.txt")
+	if err != nil || string(data) != "from fs" {
+		t.Errorf("expected the template from FS, got %q, %v", data, err)
+	}
+
+	override := filepath.Join(root, OverrideDir, "templates", "handlers", "handler.go.txt")
+	if err := os.MkdirAll(filepath.Dir(override), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(override, []byte("from project"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err = opts.Template("templates/handlers/handler.go.txt")
+	if err != nil || string(data) != "from project" {
+		t.Errorf("expected the project's override, got %q, %v", data, err)
+	}
+}
+
+func TestView_MaterializedOnlyOnPostgres(t *testing.T) {
+	_, err := View(ViewOptions{Options: Options{Root: newProject(t), DatabaseType: "mysql"}, Name: "daily_sales", Materialized: true})
+	if err == nil {
+		t.Error("expected an error for a materialized view on mysql")
+	}
+}
+
+func TestPartitionedTable(t *testing.T) {
+	root := newProject(t)
+
+	res, err := PartitionedTable(PartitionedTableOptions{Options: Options{Root: root, DatabaseType: "pgx"}, Table: "logs", Column: "loggedAt", Interval: "month"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up, _ := os.ReadFile(res.Files[0])
+	if !strings.Contains(string(up), "PARTITION BY RANGE (logged_at);") || !strings.Contains(res.Notes[0], "Interval: database.Monthly") {
+		t.Errorf("expected a logs table partitioned by logged_at, got %s and %s", up, res.Notes)
+	}
+
+	if _, err := PartitionedTable(PartitionedTableOptions{Options: Options{Root: root, DatabaseType: "mysql"}, Table: "logs"}); err == nil {
+		t.Error("expected an error for mysql")
+	}
+}
+
+func TestMigration_RequiresDatabaseType(t *testing.T) {
+	_, err := Migration(MigrationOptions{Options: Options{Root: t.TempDir()}, Name: "add_index"})
+	if err == nil {
+		t.Error("expected an error without a database type")
+	}
+}
+
+func TestMigration_SQLite(t *testing.T) {
+	root := newProject(t)
+	if _, err := Migration(MigrationOptions{Options: Options{Root: root, DatabaseType: "sqlite3"}, Name: "create_posts"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if matches, _ := filepath.Glob(filepath.Join(root, "migrations/*_create_posts.sqlite.*.sql")); len(matches) != 2 {
+		t.Errorf("expected the sqlite up and down migrations, got %v", matches)
+	}
+}
+
+func TestMigrations_SQLServer(t *testing.T) {
+	generators := map[string]func(Options) (*Result, error){
+		"auth_tables":            Auth,
+		"sessions_table":         Session,
+		"settings_table":         Settings,
+		"activities_table":       Activity,
+		"taggables_table":        Tags,
+		"favorites_table":        Favorites,
+		"security_reports_table": SecurityReports,
+	}
+
+	for table, generate := range generators {
+		root := newProject(t)
+		if _, err := generate(Options{Root: root, DatabaseType: "mssql"}); err != nil {
+			t.Errorf("%s: %s", table, err)
+			continue
+		}
+
+		matches, _ := filepath.Glob(filepath.Join(root, "migrations/*_create_"+table+".sqlserver.up.sql"))
+		if len(matches) != 1 {
+			t.Errorf("expected the sql server migration for %s, got %v", table, matches)
+			continue
+		}
+
+		up, _ := os.ReadFile(matches[0])
+		if strings.Contains(string(up), "IF NOT EXISTS") || strings.Contains(strings.ToLower(string(up)), " timestamp ") {
+			t.Errorf("%s: expected sql server syntax, got\n%s", table, up)
+		}
+	}
+}
+
+func TestPolymorphicTables_Once(t *testing.T) {
+	generators := map[string]func(Options) (*Result, error){
+		"activities": Activity,
+		"taggables":  Tags,
+		"favorites":  Favorites,
+	}
+
+	for table, generate := range generators {
+		root := newProject(t)
+		opts := Options{Root: root, DatabaseType: "mariadb"}
+
+		res, err := generate(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if matches, _ := filepath.Glob(filepath.Join(root, "migrations/*_create_"+table+"_table.mysql.up.sql")); len(res.Files) != 2 || len(matches) != 1 {
+			t.Errorf("expected the mysql migration for the %s table, got %+v", table, res)
+		}
+
+		res, err = generate(opts)
+		if err != nil || len(res.Files) != 0 {
+			t.Errorf("%s: expected the second run to leave the migration alone, got %+v, %v", table, res, err)
+		}
+	}
+}
+
+func TestOptions_DryRun(t *testing.T) {
+	root := newProject(t)
+	opts := Options{Root: root, DatabaseType: "pgx", DryRun: true}
+
+	res, err := Model(ModelOptions{Options: opts, Name: "order"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Files) != 4 || len(res.Updated) != 1 {
+		t.Fatalf("expected the model, data/base.go, its migrations and models.go to be listed, got %+v", res)
+	}
+
+	for _, file := range res.Files {
+		if Exists(file) {
+			t.Errorf("expected %s not to be written in a dry run", file)
+		}
+	}
+
+	models := filepath.Join(root, "data", "models.go")
+	content, _ := os.ReadFile(models)
+	if string(content) != testModels || string(res.Previous[models]) != testModels {
+		t.Error("expected models.go to be left as it was, and to be the previous content")
+	}
+	if !strings.Contains(string(res.Contents[models]), "Orders") {
+		t.Errorf("expected the new content of models.go, got %s", res.Contents[models])
+	}
+
+	// the registry is created and then updated, which a dry run has to keep track of
+	res, err = Command(CommandOptions{Options: opts, Name: "prune"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := filepath.Join(root, "commands", "commands.go")
+	if Exists(registry) || !strings.Contains(string(res.Contents[registry]), "&PruneCommand{App: app},") {
+		t.Errorf("expected the registry to only be in the result, got %s", res.Contents[registry])
+	}
+	if _, ok := res.Previous[registry]; ok {
+		t.Error("expected no previous content for a file the generator created")
+	}
+}
+
+func TestObserver(t *testing.T) {
+	root := newProject(t)
+	opts := Options{Root: root, DatabaseType: "pgx"}
+
+	if _, err := Observer(ObserverOptions{Options: opts, Model: "order"}); err == nil {
+		t.Error("expected an error for a model that does not exist")
+	}
+
+	if _, err := Model(ModelOptions{Options: opts, Name: "order"}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Observer(ObserverOptions{Options: opts, Model: "order"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Notes) != 1 {
+		t.Errorf("expected only the note to register the observers, got %v", res.Notes)
+	}
+
+	expected := map[string][]string{
+		"data/order.go":          {"m.ID = id\n    OrderObservers.Created(m)", "OrderObservers.Updated(m)", "OrderObservers.Deleted(Order{Model: Model{ID: id}})"},
+		"data/observers.go":      {"OrderObservers observers.Registry[Order]"},
+		"observers/observers.go": {"data.OrderObservers.Observe(&OrderObserver{App: app})", `"shop/data"`},
+	}
+
+	for file, parts := range expected {
+		content, _ := os.ReadFile(filepath.Join(root, file))
+		if _, err := format.Source(content); err != nil {
+			t.Errorf("expected %s to stay valid Go, got %s", file, err)
+		}
+
+		for _, part := range parts {
+			if !strings.Contains(string(content), part) {
+				t.Errorf("expected %q in %s, got %s", part, file, content)
+			}
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package scaffold
+
+import (
+	"path/filepath"
+)
+
+// SecurityReports creates the migration for the security_reports table that the reports sent to
+// /.well-known/security are stored in, once, and the email maintainers are notified with
+func SecurityReports(opts Options) (*Result, error) {
+	res := &Result{}
+
+	for _, name := range []string{"security-report.html.tmpl", "security-report.plain.tmpl"} {
+		if err := opts.render(res, "templates/email/"+name, opts.path("email", name)); err != nil {
+			return res, err
+		}
+	}
+
+	existing, _ := filepath.Glob(filepath.Join(opts.migrationsDir(), "*_create_security_reports_table.*"))
+	if len(existing) == 0 {
+		err := opts.migration(res, "create_security_reports_table",
+			"templates/migrations/security_reports_table.DIALECT.up.sql", "", "DROP TABLE IF EXISTS security_reports;", "security_reports")
+		if err != nil {
+			return res, err
+		}
+	}
+
+	res.note("Run gq migrate, and set SECURITY_REPORTS=true and SECURITY_CONTACT in .env to take reports on POST /.well-known/security")
+
+	return res, nil
+}
@@ -0,0 +1,16 @@
+package scaffold
+
+import "errors"
+
+// Session creates the migration for the sessions table, for storing sessions in the database.
+// The migration still has to be run
+func Session(opts Options) (*Result, error) {
+	if opts.DatabaseType == "" {
+		return nil, errors.New("you have to define a database type to be able to use other session types than cookies")
+	}
+
+	res := &Result{}
+
+	return res, opts.migration(res, "create_sessions_table",
+		"templates/migrations/DIALECT_session.sql", "", "DROP TABLE IF EXISTS sessions;", "sessions")
+}
@@ -0,0 +1,115 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// settingsRoutesMarker starts the routes Settings adds to routes.go
+const settingsRoutesMarker = "// settings routes - added by make settings"
+
+// Settings adds the settings module to a project: the migration for the settings table that
+// app.Settings and settings.Get read, and handlers to list, show, change and delete settings
+// under /admin/settings, with their routes in routes.go behind the auth middleware. When the
+// project has no auth middleware yet the routes are added commented out
+func Settings(opts Options) (*Result, error) {
+	return settings(opts, true)
+}
+
+// settings adds the settings module, registering its routes only when register is set. New adds
+// it to every project with a database without them, so that a new app does not serve settings
+// nobody can protect yet
+func settings(opts Options, register bool) (*Result, error) {
+	res := &Result{}
+
+	// the table is plain SQL that postgres and mysql both run, so the migration can be written
+	// before the project has chosen its database. Sql server has no timestamp columns of that kind
+	template := "templates/settings/settings.up.sql"
+	if opts.dialect() == "sqlserver" {
+		template = "templates/settings/settings.sqlserver.up.sql"
+	}
+	up, err := opts.Template(template)
+	if err != nil {
+		return res, err
+	}
+
+	base := filepath.Join(opts.migrationsDir(), fmt.Sprintf("%d_create_settings_table", time.Now().UnixMicro()))
+	if dialect := opts.dialect(); dialect != "" {
+		base += "." + dialect
+	}
+
+	err = opts.create(res, base+".up.sql", up)
+	if err != nil {
+		return res, err
+	}
+
+	err = opts.create(res, base+".down.sql", []byte("DROP TABLE IF EXISTS settings;\n"))
+	if err != nil {
+		return res, err
+	}
+
+	err = opts.render(res, "templates/settings/handlers.go.txt", opts.path("handlers", "settings.go"))
+	if err != nil {
+		return res, err
+	}
+
+	routesFile := opts.path("routes.go")
+	routes, err := opts.read(res, routesFile)
+	if err != nil {
+		return res, err
+	}
+
+	if bytes.Contains(routes, []byte(settingsRoutesMarker)) {
+		return res, nil
+	}
+
+	settingsRoutes, err := opts.Template("templates/settings/routes.txt")
+	if err != nil {
+		return res, err
+	}
+
+	if !bytes.Contains(routes, []byte("return route.App.Routes")) {
+		res.note("Add the settings routes to routes.go yourself:\n%s", settingsRoutes)
+		return res, nil
+	}
+
+	protected := opts.exists(res, opts.path("middleware", "auth.go"))
+	if !register || !protected {
+		settingsRoutes = commentOut(settingsRoutes)
+	}
+
+	routes = bytes.Replace(routes, []byte("return route.App.Routes"), append(bytes.TrimRight(settingsRoutes, "\n"), []byte("\n\n\treturn route.App.Routes")...), 1)
+
+	err = opts.update(res, routesFile, routes)
+	if err != nil {
+		return res, err
+	}
+
+	switch {
+	case !register:
+		res.note("The settings routes in routes.go are commented out, uncomment them once the app has auth, and run gq migrate to create the settings table")
+	case !protected:
+		res.note("The settings routes in routes.go are commented out until the app has auth, run gq make auth and uncomment them, and run gq migrate to create the settings table")
+	default:
+		res.note("Run gq migrate to create the settings table")
+	}
+
+	return res, nil
+}
+
+// commentOut turns every line of code into a comment, keeping its indentation
+func commentOut(code []byte) []byte {
+	lines := bytes.Split(bytes.TrimRight(code, "\n"), []byte("\n"))
+	for i, line := range lines {
+		trimmed := bytes.TrimLeft(line, "\t ")
+		if len(trimmed) == 0 || bytes.HasPrefix(trimmed, []byte("//")) {
+			continue
+		}
+		indent := line[:len(line)-len(trimmed)]
+		lines[i] = append(append(append([]byte{}, indent...), "// "...), trimmed...)
+	}
+
+	return append(bytes.Join(lines, []byte("\n")), '\n')
+}
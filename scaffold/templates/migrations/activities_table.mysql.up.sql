@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS activities (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  actor_type VARCHAR(255) NOT NULL,
+  actor_id BIGINT NOT NULL,
+  verb VARCHAR(255) NOT NULL,
+  subject_type VARCHAR(255) NOT NULL,
+  subject_id BIGINT NOT NULL,
+  target_type VARCHAR(255) NOT NULL DEFAULT '',
+  target_id BIGINT NOT NULL DEFAULT 0,
+  properties TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX activities_actor_idx (actor_type, actor_id),
+  INDEX activities_subject_idx (subject_type, subject_id),
+  INDEX activities_target_idx (target_type, target_id),
+  INDEX activities_created_at_idx (created_at)
+);
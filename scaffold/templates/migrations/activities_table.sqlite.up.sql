@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS activities (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  actor_type VARCHAR(255) NOT NULL,
+  actor_id BIGINT NOT NULL,
+  verb VARCHAR(255) NOT NULL,
+  subject_type VARCHAR(255) NOT NULL,
+  subject_id BIGINT NOT NULL,
+  target_type VARCHAR(255) NOT NULL DEFAULT '',
+  target_id BIGINT NOT NULL DEFAULT 0,
+  properties TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS activities_actor_idx ON activities (actor_type, actor_id);
+CREATE INDEX IF NOT EXISTS activities_subject_idx ON activities (subject_type, subject_id);
+CREATE INDEX IF NOT EXISTS activities_target_idx ON activities (target_type, target_id);
+CREATE INDEX IF NOT EXISTS activities_created_at_idx ON activities (created_at);
@@ -0,0 +1,17 @@
+CREATE TABLE activities (
+  id BIGINT IDENTITY(1,1) PRIMARY KEY,
+  actor_type NVARCHAR(255) NOT NULL,
+  actor_id BIGINT NOT NULL,
+  verb NVARCHAR(255) NOT NULL,
+  subject_type NVARCHAR(255) NOT NULL,
+  subject_id BIGINT NOT NULL,
+  target_type NVARCHAR(255) NOT NULL DEFAULT '',
+  target_id BIGINT NOT NULL DEFAULT 0,
+  properties NVARCHAR(MAX) NOT NULL,
+  created_at DATETIME2 NOT NULL DEFAULT SYSDATETIME()
+);
+
+CREATE INDEX activities_actor_idx ON activities (actor_type, actor_id);
+CREATE INDEX activities_subject_idx ON activities (subject_type, subject_id);
+CREATE INDEX activities_target_idx ON activities (target_type, target_id);
+CREATE INDEX activities_created_at_idx ON activities (created_at);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS favorites (
+  user_id BIGINT NOT NULL,
+  favoritable_type VARCHAR(255) NOT NULL,
+  favoritable_id BIGINT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, favoritable_type, favoritable_id)
+);
+
+CREATE INDEX IF NOT EXISTS favorites_favoritable_idx ON favorites (favoritable_type, favoritable_id);
@@ -0,0 +1,9 @@
+CREATE TABLE favorites (
+  user_id BIGINT NOT NULL,
+  favoritable_type NVARCHAR(255) NOT NULL,
+  favoritable_id BIGINT NOT NULL,
+  created_at DATETIME2 NOT NULL DEFAULT SYSDATETIME(),
+  PRIMARY KEY (user_id, favoritable_type, favoritable_id)
+);
+
+CREATE INDEX favorites_favoritable_idx ON favorites (favoritable_type, favoritable_id);
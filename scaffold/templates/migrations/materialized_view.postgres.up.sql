@@ -0,0 +1,8 @@
+-- CREATE MATERIALIZED VIEW TABLENAME AS
+-- SELECT user_id, count(*) AS orders, sum(total) AS spent
+-- FROM orders
+-- GROUP BY user_id
+-- WITH DATA;
+
+-- with a unique index the view is refreshed without locking out the queries reading it
+-- CREATE UNIQUE INDEX TABLENAME_user_id_index ON TABLENAME (user_id);
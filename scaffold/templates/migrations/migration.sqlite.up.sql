@@ -0,0 +1,13 @@
+-- CREATE TABLE IF NOT EXISTS TABLENAME (
+--   id INTEGER PRIMARY KEY AUTOINCREMENT,
+--   created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+--   updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+--   deleted_at TIMESTAMP
+-- );
+
+-- CREATE TRIGGER TABLENAME_update_timestamp
+-- AFTER UPDATE ON TABLENAME
+-- FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+-- BEGIN
+--   UPDATE TABLENAME SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+-- END;
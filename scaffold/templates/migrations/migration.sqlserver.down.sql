@@ -0,0 +1 @@
+-- DROP TABLE TABLENAME;
\ No newline at end of file
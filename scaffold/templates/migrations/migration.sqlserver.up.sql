@@ -0,0 +1,15 @@
+-- CREATE TABLE TABLENAME (
+--   id BIGINT IDENTITY(1,1) PRIMARY KEY,
+--   created_at DATETIME2 NOT NULL DEFAULT SYSDATETIME(),
+--   updated_at DATETIME2 NOT NULL DEFAULT SYSDATETIME(),
+--   deleted_at DATETIME2 NULL
+-- );
+
+-- a trigger has to be the only statement of its migration in sql server
+-- CREATE TRIGGER TABLENAME_update_timestamp
+-- ON TABLENAME
+-- AFTER UPDATE AS
+-- BEGIN
+--   SET NOCOUNT ON;
+--   UPDATE t SET updated_at = SYSDATETIME() FROM TABLENAME t JOIN inserted i ON t.id = i.id;
+-- END;
@@ -0,0 +1,10 @@
+CREATE TABLE notifications (
+  id INT IDENTITY(1,1) PRIMARY KEY,
+  user_id INT NOT NULL,
+  type NVARCHAR(255) NOT NULL,
+  data NVARCHAR(MAX) NOT NULL,
+  read_at DATETIME2 NULL,
+  created_at DATETIME2 NOT NULL DEFAULT SYSDATETIME()
+);
+
+CREATE INDEX notifications_user_id_idx ON notifications (user_id);
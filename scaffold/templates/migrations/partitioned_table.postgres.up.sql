@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS TABLENAME (
+    id bigserial,
+    COLUMNNAME timestamp NOT NULL DEFAULT now(),
+    -- the columns of the rows, e.g.
+    -- level varchar(10) NOT NULL,
+    -- message text NOT NULL,
+    PRIMARY KEY (id, COLUMNNAME)
+) PARTITION BY RANGE (COLUMNNAME);
+
+-- the partitions are created and dropped by a database.Partitioner. A default partition would
+-- take rows no partition covers, but stops the partitioner from creating partitions for them
+-- CREATE TABLE IF NOT EXISTS TABLENAME_default PARTITION OF TABLENAME DEFAULT;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS security_reports (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  title VARCHAR(255) NOT NULL,
+  description TEXT NOT NULL,
+  severity VARCHAR(20) NOT NULL DEFAULT '',
+  contact VARCHAR(255) NOT NULL DEFAULT '',
+  user_id INT UNSIGNED NOT NULL DEFAULT 0,
+  ip VARCHAR(45) NOT NULL DEFAULT '',
+  user_agent VARCHAR(500) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX security_reports_created_at_idx (created_at)
+);
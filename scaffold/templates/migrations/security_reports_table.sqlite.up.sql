@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS security_reports (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  title VARCHAR(255) NOT NULL,
+  description TEXT NOT NULL,
+  severity VARCHAR(20) NOT NULL DEFAULT '',
+  contact VARCHAR(255) NOT NULL DEFAULT '',
+  user_id INTEGER NOT NULL DEFAULT 0,
+  ip VARCHAR(45) NOT NULL DEFAULT '',
+  user_agent VARCHAR(500) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS security_reports_created_at_idx ON security_reports (created_at);
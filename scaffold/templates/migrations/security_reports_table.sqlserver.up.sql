@@ -0,0 +1,13 @@
+CREATE TABLE security_reports (
+  id BIGINT IDENTITY(1,1) PRIMARY KEY,
+  title NVARCHAR(255) NOT NULL,
+  description NVARCHAR(MAX) NOT NULL,
+  severity NVARCHAR(20) NOT NULL DEFAULT '',
+  contact NVARCHAR(255) NOT NULL DEFAULT '',
+  user_id INT NOT NULL DEFAULT 0,
+  ip NVARCHAR(45) NOT NULL DEFAULT '',
+  user_agent NVARCHAR(500) NOT NULL DEFAULT '',
+  created_at DATETIME2 NOT NULL DEFAULT SYSDATETIME()
+);
+
+CREATE INDEX security_reports_created_at_idx ON security_reports (created_at);
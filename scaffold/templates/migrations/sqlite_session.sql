@@ -0,0 +1,7 @@
+CREATE TABLE sessions (
+                          token TEXT PRIMARY KEY,
+                          data BLOB NOT NULL,
+                          expiry REAL NOT NULL
+);
+
+CREATE INDEX sessions_expiry_idx ON sessions (expiry);
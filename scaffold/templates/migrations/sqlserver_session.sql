@@ -0,0 +1,7 @@
+CREATE TABLE sessions (
+    token NVARCHAR(43) NOT NULL PRIMARY KEY,
+    data VARBINARY(MAX) NOT NULL,
+    expiry DATETIME2(6) NOT NULL
+);
+
+CREATE INDEX sessions_expiry_idx ON sessions (expiry);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS taggables (
+  tag VARCHAR(255) NOT NULL,
+  taggable_type VARCHAR(255) NOT NULL,
+  taggable_id BIGINT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (tag, taggable_type, taggable_id),
+  INDEX taggables_taggable_idx (taggable_type, taggable_id)
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS taggables (
+  tag VARCHAR(255) NOT NULL,
+  taggable_type VARCHAR(255) NOT NULL,
+  taggable_id BIGINT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (tag, taggable_type, taggable_id)
+);
+
+CREATE INDEX IF NOT EXISTS taggables_taggable_idx ON taggables (taggable_type, taggable_id);
@@ -0,0 +1,9 @@
+CREATE TABLE taggables (
+  tag NVARCHAR(255) NOT NULL,
+  taggable_type NVARCHAR(255) NOT NULL,
+  taggable_id BIGINT NOT NULL,
+  created_at DATETIME2 NOT NULL DEFAULT SYSDATETIME(),
+  PRIMARY KEY (tag, taggable_type, taggable_id)
+);
+
+CREATE INDEX taggables_taggable_idx ON taggables (taggable_type, taggable_id);
@@ -0,0 +1,4 @@
+-- CREATE VIEW TABLENAME AS
+-- SELECT user_id, count(*) AS orders, sum(total) AS spent
+-- FROM orders
+-- GROUP BY user_id;
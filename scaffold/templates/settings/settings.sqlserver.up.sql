@@ -0,0 +1,6 @@
+CREATE TABLE settings (
+    name NVARCHAR(255) NOT NULL PRIMARY KEY,
+    value NVARCHAR(MAX) NOT NULL,
+    created_at DATETIME2 NOT NULL DEFAULT SYSDATETIME(),
+    updated_at DATETIME2 NOT NULL DEFAULT SYSDATETIME()
+);
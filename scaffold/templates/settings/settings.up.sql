@@ -0,0 +1,6 @@
+CREATE TABLE settings (
+    name varchar(255) NOT NULL PRIMARY KEY,
+    value text NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
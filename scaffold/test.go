@@ -0,0 +1,51 @@
+package scaffold
+
+import (
+	"errors"
+	"strings"
+)
+
+// ModelTestOptions are the options of ModelTest
+type ModelTestOptions struct {
+	Options
+	// Model is the model to test, named like it was given to Model
+	Model string
+}
+
+// ModelTest creates tests for the create, read, update and delete methods of a model in the data
+// directory, with a factory that inserts the model. Every test runs in a transaction on the
+// database in TEST_DATABASE_DSN that is rolled back afterwards, which data/setup_test.go, created
+// with the first model test, sets up
+func ModelTest(opts ModelTestOptions) (*Result, error) {
+	if opts.Model == "" {
+		return nil, errors.New("you must give the model to test")
+	}
+
+	fileName, modelName, tableName := modelNames(opts.Model)
+
+	res := &Result{}
+	err := opts.render(res, "templates/tests/model_test.go.txt",
+		opts.path("data", fileName+"_test.go"),
+		"$MODELNAME$", modelName,
+		"$TABLENAME$", tableName,
+		"$VAR$", strings.ToLower(modelName))
+	if err != nil {
+		return res, err
+	}
+
+	setup := opts.path("data", "setup_test.go")
+	if !Exists(setup) {
+		err = opts.render(res, "templates/tests/setup_test.go.txt", setup)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	if !Exists(opts.path("data", fileName+".go")) {
+		res.note("There is no %s model in the data directory yet, create it with gq make model %s", modelName, opts.Model)
+	}
+
+	res.note("Run the tests against a migrated test database: TEST_DATABASE_TYPE=postgres TEST_DATABASE_DSN=... go test ./data")
+
+	return res, nil
+}
@@ -0,0 +1,49 @@
+package scaffold
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// ViewOptions are the options of View
+type ViewOptions struct {
+	Options
+	Name string
+	// Materialized creates a materialized view, which postgres stores and gq db:refresh-views or
+	// MATERIALIZED_VIEWS_REFRESH refresh
+	Materialized bool
+}
+
+// View creates the migration for a view, or a materialized view on postgres
+func View(opts ViewOptions) (*Result, error) {
+	if opts.Name == "" || strings.HasPrefix(opts.Name, "-") {
+		return nil, errors.New("you must give the view a name")
+	}
+
+	name := strcase.ToSnake(opts.Name)
+
+	// a view and a materialized view of the same name would clash as well
+	for _, kind := range []string{"view", "materialized_view"} {
+		existing, _ := filepath.Glob(filepath.Join(opts.migrationsDir(), "*_create_"+name+"_"+kind+".*.up.sql"))
+		if len(existing) > 0 {
+			return nil, errors.New("there is a migration for the view " + name + " already: " + filepath.Base(existing[0]))
+		}
+	}
+
+	kind, up, down := "view", "templates/migrations/view.DIALECT.up.sql", "DROP VIEW IF EXISTS "+name+";\n"
+	if opts.Materialized {
+		if opts.DatabaseType != "" && opts.dialect() != "postgres" {
+			return nil, errors.New("materialized views are only supported on postgres")
+		}
+
+		kind, up, down = "materialized_view", "templates/migrations/materialized_view.DIALECT.up.sql", "DROP MATERIALIZED VIEW IF EXISTS "+name+";\n"
+	}
+
+	res := &Result{}
+	err := opts.migration(res, "create_"+name+"_"+kind, up, "", down, name)
+
+	return res, err
+}
@@ -0,0 +1,67 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/iancoleman/strcase"
+)
+
+// WebsocketOptions are the options of Websocket
+type WebsocketOptions struct {
+	Options
+	Name string
+}
+
+// Websocket creates a websocket handler, a javascript client for it in public/js and its route in
+// routes.go
+func Websocket(opts WebsocketOptions) (*Result, error) {
+	if opts.Name == "" {
+		return nil, errors.New("you must give the websocket a name")
+	}
+
+	websocketName := strcase.ToCamel(opts.Name)
+	key := strcase.ToKebab(opts.Name)
+	path := "/ws/" + key
+	replacements := []string{
+		"$WEBSOCKETNAME$", websocketName,
+		"$WEBSOCKETKEY$", key,
+		"$WEBSOCKETPATH$", path,
+		"$WEBSOCKETJSNAME$", strcase.ToLowerCamel(opts.Name),
+	}
+
+	res := &Result{}
+	err := opts.render(res, "templates/websocket/handler.go.txt",
+		opts.path("handlers", strcase.ToSnake(opts.Name)+"_websocket.go"), replacements...)
+	if err != nil {
+		return res, err
+	}
+
+	jsFile := opts.path("public", "js", key+".js")
+	if !Exists(jsFile) {
+		err = opts.render(res, "templates/websocket/client.js.txt", jsFile, replacements...)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	routesFile := opts.path("routes.go")
+	routesContent, err := opts.read(res, routesFile)
+	if err != nil {
+		return res, err
+	}
+
+	route := "route.get(\"" + path + "\", route.Handlers." + websocketName + "Websocket)"
+	if bytes.Contains(routesContent, []byte(route)) {
+		return res, nil
+	}
+
+	if !bytes.Contains(routesContent, []byte("return route.App.Routes")) {
+		res.note("Add the route yourself: %s", route)
+		return res, nil
+	}
+
+	output := bytes.Replace(routesContent, []byte("return route.App.Routes"), []byte(route+"\n\n\treturn route.App.Routes"), 1)
+
+	return res, opts.update(res, routesFile, output)
+}
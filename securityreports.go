@@ -0,0 +1,258 @@
+package gemquick
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/database"
+	"github.com/jimmitjoo/gemquick/email"
+	"github.com/jimmitjoo/gemquick/notifications"
+	"github.com/jimmitjoo/gemquick/ratelimit"
+)
+
+// SecurityReport is a vulnerability reported to the app on /.well-known/security, by a user who
+// is logged in or by anyone. It is dispatched on app.Events once it is stored
+type SecurityReport struct {
+	ID          int64  `json:"id,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Severity is low, medium, high, critical or nothing
+	Severity string `json:"severity,omitempty"`
+	// Contact is how the reporter wants to be reached, an email address
+	Contact   string    `json:"contact,omitempty"`
+	UserID    int       `json:"user_id,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Name is the name listeners are registered for
+func (r SecurityReport) Name() string {
+	return "security.reported"
+}
+
+// maxSecurityReportBody is the size of the largest report SecurityReports accepts
+const maxSecurityReportBody = 64 << 10
+
+var severities = map[string]bool{"": true, "low": true, "medium": true, "high": true, "critical": true}
+
+// securityReportNotification tells a maintainer about a report, by mail to an address or in the
+// notifications of a user
+type securityReportNotification struct {
+	report SecurityReport
+	to     string
+	userID int
+}
+
+func (n securityReportNotification) Via() []string {
+	if n.to != "" {
+		return []string{notifications.Mail}
+	}
+
+	return []string{notifications.Database}
+}
+
+func (n securityReportNotification) ToMail() email.Message {
+	return email.Message{
+		To:       n.to,
+		Subject:  "Vulnerability report: " + n.report.Title,
+		Template: "security-report",
+		Data:     n.report,
+	}
+}
+
+func (n securityReportNotification) ToDatabase() notifications.DatabaseMessage {
+	return notifications.DatabaseMessage{
+		UserID: n.userID,
+		Type:   "security-report",
+		Data: map[string]interface{}{
+			"id":       n.report.ID,
+			"title":    n.report.Title,
+			"severity": n.report.Severity,
+		},
+	}
+}
+
+// SecurityReports takes vulnerability reports, as json or a form with a title, a description, a
+// severity and a contact address, and answers 202 Accepted once it has stored one in the
+// security_reports table of gq make security-reports. Reports are refused with 400 Bad Request
+// when they are incomplete, and every IP may send limit reports an hour. The maintainers at the
+// addresses of SECURITY_CONTACT get an email about every report, and the users with the ids of
+// SECURITY_MAINTAINERS a notification in the database
+func (g *Gemquick) SecurityReports(limit int) http.Handler {
+	limiter := ratelimit.New(limit, time.Hour)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report, err := readSecurityReport(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		report.IP = ratelimit.ByIP(r)
+		report.UserAgent = truncate(r.UserAgent(), 500)
+		report.CreatedAt = time.Now()
+		if sessionsEnabled() && g.Session != nil {
+			report.UserID = g.Session.GetInt(r.Context(), "userID")
+		}
+
+		if err = g.storeSecurityReport(r.Context(), &report); err != nil {
+			g.ErrorLog.Println("storing a security report:", err)
+			g.ErrorStatus(w, http.StatusInternalServerError)
+			return
+		}
+
+		g.InfoLog.Printf("security report %d from %s: %q", report.ID, report.IP, report.Title)
+		g.notifyMaintainers(r.Context(), report)
+
+		if g.Events != nil {
+			if err := g.Events.DispatchContext(r.Context(), report); err != nil {
+				g.ErrorLog.Println(err)
+			}
+		}
+
+		_ = g.WriteJson(w, http.StatusAccepted, map[string]interface{}{
+			"id":      report.ID,
+			"message": "Thank you, the maintainers have been told about your report.",
+		})
+	})
+
+	return limiter.Middleware(nil)(handler)
+}
+
+func readSecurityReport(w http.ResponseWriter, r *http.Request) (SecurityReport, error) {
+	var report SecurityReport
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSecurityReportBody)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType == "application/json" {
+		var body struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			Severity    string `json:"severity"`
+			Contact     string `json:"contact"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return report, fmt.Errorf("invalid report: %w", err)
+		}
+		report = SecurityReport{Title: body.Title, Description: body.Description, Severity: body.Severity, Contact: body.Contact}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return report, fmt.Errorf("invalid report: %w", err)
+		}
+		report = SecurityReport{
+			Title:       r.PostForm.Get("title"),
+			Description: r.PostForm.Get("description"),
+			Severity:    r.PostForm.Get("severity"),
+			Contact:     r.PostForm.Get("contact"),
+		}
+	}
+
+	report.Title = strings.TrimSpace(report.Title)
+	report.Description = strings.TrimSpace(report.Description)
+	report.Severity = strings.ToLower(strings.TrimSpace(report.Severity))
+	report.Contact = strings.TrimSpace(report.Contact)
+
+	switch {
+	case report.Title == "" || len(report.Title) > 255:
+		return report, errors.New("invalid report: a title of at most 255 characters is required")
+	case report.Description == "":
+		return report, errors.New("invalid report: a description is required")
+	case !severities[report.Severity]:
+		return report, errors.New("invalid report: severity must be low, medium, high or critical")
+	}
+
+	if report.Contact != "" {
+		if _, err := mail.ParseAddress(report.Contact); err != nil || len(report.Contact) > 255 {
+			return report, errors.New("invalid report: contact must be an email address")
+		}
+	}
+
+	return report, nil
+}
+
+func (g *Gemquick) storeSecurityReport(ctx context.Context, report *SecurityReport) error {
+	if g.DB.Pool == nil {
+		return errors.New("security reports need a database")
+	}
+
+	query := database.Rebind(g.DB.DataType, fmt.Sprintf(
+		"INSERT INTO %ssecurity_reports (title, description, severity, contact, user_id, ip, user_agent, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		g.DB.TablePrefix))
+	args := []interface{}{report.Title, report.Description, report.Severity, report.Contact, report.UserID, report.IP, report.UserAgent, report.CreatedAt}
+
+	if database.Postgres(g.DB.DataType) {
+		return g.DB.Pool.QueryRowContext(ctx, query+" RETURNING id", args...).Scan(&report.ID)
+	}
+
+	res, err := g.DB.Pool.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	report.ID, err = res.LastInsertId()
+
+	return err
+}
+
+// notifyMaintainers sends the report to the addresses of SECURITY_CONTACT and the users of
+// SECURITY_MAINTAINERS, logging what fails. The report is stored already, so it is not lost
+func (g *Gemquick) notifyMaintainers(ctx context.Context, report SecurityReport) {
+	if g.Notifications == nil {
+		return
+	}
+
+	var recipients []securityReportNotification
+	for _, to := range splitList(os.Getenv("SECURITY_CONTACT")) {
+		recipients = append(recipients, securityReportNotification{report: report, to: to})
+	}
+	for _, id := range splitList(os.Getenv("SECURITY_MAINTAINERS")) {
+		userID, err := strconv.Atoi(id)
+		if err != nil {
+			g.ErrorLog.Printf("SECURITY_MAINTAINERS: %s is not a user id", id)
+			continue
+		}
+		recipients = append(recipients, securityReportNotification{report: report, userID: userID})
+	}
+
+	for _, n := range recipients {
+		if err := g.Notifications.SendContext(ctx, n); err != nil {
+			g.ErrorLog.Printf("notifying about security report %d: %s", report.ID, err)
+		}
+	}
+}
+
+// SecurityTxt serves the security.txt of RFC 9116, which tells researchers to report
+// vulnerabilities to /.well-known/security or to the addresses of SECURITY_CONTACT. It links the
+// disclosure policy at SECURITY_POLICY when it is set
+func (g *Gemquick) SecurityTxt(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+
+	for _, to := range splitList(os.Getenv("SECURITY_CONTACT")) {
+		fmt.Fprintf(&b, "Contact: mailto:%s\n", to)
+	}
+	base := strings.TrimSuffix(g.Server.URL, "/")
+	if base == "" {
+		base = "https://" + r.Host
+	}
+	fmt.Fprintf(&b, "Contact: %s/.well-known/security\n", base)
+	// researchers are told to distrust a file that has expired, so it never does
+	fmt.Fprintf(&b, "Expires: %s\n", time.Now().UTC().AddDate(1, 0, 0).Truncate(24*time.Hour).Format(time.RFC3339))
+	if policy := os.Getenv("SECURITY_POLICY"); policy != "" {
+		fmt.Fprintf(&b, "Policy: %s\n", policy)
+	}
+	b.WriteString("Preferred-Languages: en\n")
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(b.String()))
+}
@@ -0,0 +1,96 @@
+package gemquick
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jimmitjoo/gemquick/notifications"
+)
+
+type recordingChannel struct {
+	sent []notifications.Notification
+}
+
+func (c *recordingChannel) Send(n notifications.Notification) error {
+	c.sent = append(c.sent, n)
+	return nil
+}
+
+func TestSecurityReports(t *testing.T) {
+	t.Setenv("SESSION_TYPE", "none")
+	t.Setenv("SECURITY_CONTACT", "security@example.com, ops@example.com")
+	t.Setenv("SECURITY_MAINTAINERS", "1")
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mailed, stored := &recordingChannel{}, &recordingChannel{}
+	g := &Gemquick{
+		InfoLog:       log.New(io.Discard, "", 0),
+		ErrorLog:      log.New(io.Discard, "", 0),
+		DB:            Database{DataType: "postgres", Pool: db},
+		Notifications: notifications.New(),
+	}
+	g.Notifications.Register(notifications.Mail, mailed)
+	g.Notifications.Register(notifications.Database, stored)
+
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO security_reports (title, description, severity, contact, user_id, ip, user_agent, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id")).
+		WithArgs("XSS in search", "The q parameter is echoed", "high", "ada@example.com", 0, "203.0.113.9", "curl", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
+	handler := g.SecurityReports(2)
+	send := func(contentType, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/.well-known/security", strings.NewReader(body))
+		r.RemoteAddr = "203.0.113.9:4000"
+		r.Header.Set("Content-Type", contentType)
+		r.Header.Set("User-Agent", "curl")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := send("application/json", `{"title":"XSS in search","description":"The q parameter is echoed","severity":"High","contact":"ada@example.com"}`)
+	if w.Code != http.StatusAccepted || !strings.Contains(w.Body.String(), `"id": 4`) {
+		t.Fatalf("expected the report to be accepted, got %d %s", w.Code, w.Body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if len(mailed.sent) != 2 || len(stored.sent) != 1 {
+		t.Errorf("expected two mails and a database notification, got %d and %d", len(mailed.sent), len(stored.sent))
+	}
+
+	if w = send("application/x-www-form-urlencoded", "title=Broken&severity=urgent&description=x"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown severity to be refused, got %d", w.Code)
+	}
+
+	if w = send("application/json", `{"title":"Broken"}`); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the third report within the hour to be refused, got %d", w.Code)
+	}
+}
+
+func TestSecurityTxt(t *testing.T) {
+	t.Setenv("SECURITY_CONTACT", "security@example.com")
+	t.Setenv("SECURITY_POLICY", "https://example.com/security")
+
+	g := &Gemquick{Server: Server{URL: "https://example.com/"}}
+
+	w := httptest.NewRecorder()
+	g.SecurityTxt(w, httptest.NewRequest("GET", "/.well-known/security.txt", nil))
+
+	body := w.Body.String()
+	for _, expected := range []string{"Contact: mailto:security@example.com\n", "Contact: https://example.com/.well-known/security\n", "Expires: ", "Policy: https://example.com/security\n"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %q in\n%s", expected, body)
+		}
+	}
+}
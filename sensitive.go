@@ -0,0 +1,116 @@
+package gemquick
+
+import (
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/captcha"
+	"github.com/jimmitjoo/gemquick/ratelimit"
+)
+
+// createGuard returns the throttle of Sensitive. It allows SENSITIVE_MAX_ATTEMPTS per
+// SENSITIVE_WINDOW and then locks out for SENSITIVE_COOLOFF, 5 in 15 minutes and 15 minutes when
+// they are empty, asking for a captcha after SENSITIVE_CHALLENGE_AFTER attempts, 3 when it is empty
+func (g *Gemquick) createGuard() *ratelimit.Guard {
+	maxAttempts, err := strconv.Atoi(os.Getenv("SENSITIVE_MAX_ATTEMPTS"))
+	if err != nil || maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+
+	window, err := time.ParseDuration(os.Getenv("SENSITIVE_WINDOW"))
+	if err != nil || window <= 0 {
+		window = 15 * time.Minute
+	}
+
+	cooloff, err := time.ParseDuration(os.Getenv("SENSITIVE_COOLOFF"))
+	if err != nil || cooloff <= 0 {
+		cooloff = 15 * time.Minute
+	}
+
+	guard := ratelimit.NewGuard(maxAttempts, window, cooloff)
+	guard.ChallengeAfter, err = strconv.Atoi(os.Getenv("SENSITIVE_CHALLENGE_AFTER"))
+	if err != nil {
+		guard.ChallengeAfter = 3
+	}
+
+	return guard
+}
+
+// createCaptcha returns the CAPTCHA_PROVIDER, recaptcha, turnstile or hcaptcha, with
+// CAPTCHA_SITE_KEY and CAPTCHA_SECRET, or nil without one. CAPTCHA_MIN_SCORE changes the score
+// reCAPTCHA tokens need, and CAPTCHA_BYPASS_TOKEN is a token that passes in debug mode, for tests
+func (g *Gemquick) createCaptcha() (*captcha.Provider, error) {
+	name := os.Getenv("CAPTCHA_PROVIDER")
+	if name == "" {
+		return nil, nil
+	}
+
+	p, err := captcha.New(name, os.Getenv("CAPTCHA_SITE_KEY"), os.Getenv("CAPTCHA_SECRET"))
+	if err != nil {
+		return nil, err
+	}
+
+	if score, err := strconv.ParseFloat(os.Getenv("CAPTCHA_MIN_SCORE"), 64); err == nil {
+		p.MinScore = score
+	}
+
+	if token := os.Getenv("CAPTCHA_BYPASS_TOKEN"); token != "" {
+		if g.Debug {
+			p.BypassToken = token
+		} else {
+			g.ErrorLog.Println("CAPTCHA_BYPASS_TOKEN is ignored outside debug mode")
+		}
+	}
+
+	return p, nil
+}
+
+// Sensitive protects endpoints attackers guess at, like password resets and one-time codes, with
+// app.Guard, separate from any throttle of the whole app. Attempts are counted per IP and the
+// value of the form field, like email, and a key that has used up its attempts gets
+// 429 Too Many Requests until its cooloff is over, and its IP is suspected in app.Reputation. Once
+// a key needs a challenge its requests must come with a captcha solved in app.Captcha's widget, or
+// they get 403 Forbidden. Handlers reset the attempts once one succeeds:
+//
+//	h.App.Guard.Reset(ratelimit.Key(r, r.Form.Get("email")))
+func (g *Gemquick) Sensitive(field string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if g.Guard == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var identifier string
+			if field != "" {
+				identifier = r.FormValue(field)
+			}
+			key := ratelimit.Key(r, identifier)
+
+			ok, retry := g.Guard.Hit(key)
+			if !ok {
+				g.InfoLog.Printf("too many attempts at %s from %s", r.URL.Path, ratelimit.ByIP(r))
+				g.suspect(ratelimit.ByIP(r))
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+				g.ErrorStatus(w, http.StatusTooManyRequests)
+				return
+			}
+
+			if g.Captcha != nil && g.Guard.NeedsChallenge(key) {
+				solved, err := g.Captcha.VerifyRequest(r, ratelimit.ByIP(r))
+				if err != nil {
+					g.ErrorLog.Println("verifying the captcha:", err)
+				}
+				if !solved {
+					g.ErrorForbidden(w, r)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
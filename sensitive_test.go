@@ -0,0 +1,64 @@
+package gemquick
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/captcha"
+	"github.com/jimmitjoo/gemquick/ratelimit"
+)
+
+func TestSensitive(t *testing.T) {
+	verifier := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("response") == "solved" {
+			_, _ = w.Write([]byte(`{"success": true}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+	}))
+	defer verifier.Close()
+
+	g := &Gemquick{
+		InfoLog:  log.New(io.Discard, "", 0),
+		ErrorLog: log.New(io.Discard, "", 0),
+		Guard:    ratelimit.NewGuard(3, time.Minute, time.Hour),
+		Captcha:  captcha.Turnstile("site", "secret"),
+	}
+	g.Guard.ChallengeAfter = 1
+	g.Captcha.VerifyURL = verifier.URL
+
+	handler := g.Sensitive("email")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	post := func(email, token string) *httptest.ResponseRecorder {
+		form := url.Values{"email": {email}, "cf-turnstile-response": {token}}
+		req := httptest.NewRequest("POST", "/forgot-password", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := post("ada@example.com", ""); rr.Code != http.StatusOK {
+		t.Errorf("expected the first attempt to pass without a captcha, got %d", rr.Code)
+	}
+	if rr := post("ada@example.com", ""); rr.Code != http.StatusForbidden {
+		t.Errorf("expected the second attempt to need a captcha, got %d", rr.Code)
+	}
+	if rr := post("ada@example.com", "solved"); rr.Code != http.StatusOK {
+		t.Errorf("expected a solved captcha to pass, got %d", rr.Code)
+	}
+	if rr := post("grace@example.com", ""); rr.Code != http.StatusOK {
+		t.Errorf("expected another account to be counted on its own, got %d", rr.Code)
+	}
+	if rr := post("ada@example.com", "solved"); rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "3600" {
+		t.Errorf("expected the fourth attempt to be locked out, got %d and %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+}
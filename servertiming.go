@@ -0,0 +1,107 @@
+package gemquick
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/database"
+	"github.com/jimmitjoo/gemquick/servertiming"
+)
+
+// serverTimingEnabled is true in debug mode, or with SERVER_TIMING=true. The header tells
+// anyone how long the queries of a page took, so it stays off in production by default
+func (g *Gemquick) serverTimingEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("SERVER_TIMING"))
+	return g.Debug || enabled
+}
+
+// ServerTiming times the request and sends the Server-Timing header with the time spent in the
+// middleware, the handler until it started the response, the queries run with the request
+// context, the rendering of templates and whatever the app tracks with servertiming.Track. In
+// debug mode the breakdown is logged as well. Websocket upgrades are passed through
+func (g *Gemquick) ServerTiming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		t := servertiming.New()
+		tw := &timingWriter{ResponseWriter: w, timing: t}
+		next.ServeHTTP(tw, r.WithContext(servertiming.NewContext(r.Context(), t)))
+
+		if g.Debug && g.InfoLog != nil {
+			g.InfoLog.Printf("%s %s %s", r.Method, r.URL.Path, t)
+		}
+	})
+}
+
+// handlerTiming ends the middleware phase. It is the last middleware of the framework, so the
+// middleware an app adds to its routes counts as handler time
+func handlerTiming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if t := servertiming.FromContext(r.Context()); t != nil {
+			t.Add("middleware", time.Since(t.Start))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// timingWriter sets the Server-Timing header just before the response starts, which is when
+// the handler phase ends
+type timingWriter struct {
+	http.ResponseWriter
+	timing      *servertiming.Timing
+	wroteHeader bool
+}
+
+func (w *timingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		// the handler started when the middleware was done, unless the middleware answered itself
+		if mw, ok := w.timing.Duration("middleware"); ok {
+			w.timing.Add("handler", time.Since(w.timing.Start)-mw)
+		}
+		w.Header().Set("Server-Timing", w.timing.Header())
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *timingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the writer of the server
+func (w *timingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// queryTiming is the query hook that adds the queries run with a request context to its db phase
+type queryTiming struct{}
+
+func (queryTiming) BeforeQuery(ctx context.Context, e *database.QueryEvent) context.Context {
+	return ctx
+}
+
+func (queryTiming) AfterQuery(ctx context.Context, e *database.QueryEvent) {
+	servertiming.FromContext(ctx).Add("db", e.Duration)
+}
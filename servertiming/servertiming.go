@@ -0,0 +1,136 @@
+// Package servertiming records where a request spends its time, like in its middleware, handler,
+// queries, cache and templates, for the Server-Timing header that browsers show in their devtools.
+// A Timing travels in the request context, and code that does not know whether the request is
+// being timed calls Track, which does nothing when it is not
+package servertiming
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type contextKey struct{}
+
+type phase struct {
+	name  string
+	dur   time.Duration
+	count int
+}
+
+// Timing holds the phases of one request, in the order they were first recorded. Phases with
+// the same name add up, so db is the total of all queries
+type Timing struct {
+	Start time.Time
+
+	mu     sync.Mutex
+	phases []*phase
+}
+
+// New returns a timing that starts now
+func New() *Timing {
+	return &Timing{Start: time.Now()}
+}
+
+// NewContext returns ctx carrying t
+func NewContext(ctx context.Context, t *Timing) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the timing in ctx, nil when the request is not being timed
+func FromContext(ctx context.Context) *Timing {
+	t, _ := ctx.Value(contextKey{}).(*Timing)
+	return t
+}
+
+// Track starts timing the phase for the request in ctx, and returns the function that stops it
+//
+//	defer servertiming.Track(r.Context(), "cache")()
+func Track(ctx context.Context, name string) func() {
+	t := FromContext(ctx)
+	if t == nil {
+		return func() {}
+	}
+
+	start := time.Now()
+	return func() {
+		t.Add(name, time.Since(start))
+	}
+}
+
+// Add adds d to the phase, which is safe to do from several goroutines
+func (t *Timing) Add(name string, d time.Duration) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, p := range t.phases {
+		if p.name == name {
+			p.dur += d
+			p.count++
+			return
+		}
+	}
+
+	t.phases = append(t.phases, &phase{name: name, dur: d, count: 1})
+}
+
+// Duration returns the time recorded for the phase, and false when none was
+func (t *Timing) Duration(name string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, p := range t.phases {
+		if p.name == name {
+			return p.dur, true
+		}
+	}
+
+	return 0, false
+}
+
+// Header returns the value of the Server-Timing header, e.g.
+// middleware;dur=1.2, db;dur=8.4;desc="3 calls", total;dur=12.9
+func (t *Timing) Header() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	metrics := make([]string, 0, len(t.phases)+1)
+	for _, p := range t.phases {
+		m := fmt.Sprintf("%s;dur=%s", p.name, millis(p.dur))
+		if p.count > 1 {
+			m += fmt.Sprintf(`;desc="%d calls"`, p.count)
+		}
+		metrics = append(metrics, m)
+	}
+	metrics = append(metrics, "total;dur="+millis(time.Since(t.Start)))
+
+	return strings.Join(metrics, ", ")
+}
+
+// String returns the breakdown for a log line, e.g.
+// total 12.9ms: middleware 1.2ms, db 8.4ms (3 calls)
+func (t *Timing) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	parts := make([]string, 0, len(t.phases))
+	for _, p := range t.phases {
+		part := fmt.Sprintf("%s %sms", p.name, millis(p.dur))
+		if p.count > 1 {
+			part += fmt.Sprintf(" (%d calls)", p.count)
+		}
+		parts = append(parts, part)
+	}
+
+	return fmt.Sprintf("total %sms: %s", millis(time.Since(t.Start)), strings.Join(parts, ", "))
+}
+
+func millis(d time.Duration) string {
+	return fmt.Sprintf("%.1f", float64(d)/float64(time.Millisecond))
+}
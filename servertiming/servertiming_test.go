@@ -0,0 +1,42 @@
+package servertiming
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTiming(t *testing.T) {
+	timing := New()
+	timing.Add("db", 2*time.Millisecond)
+	timing.Add("render", 4*time.Millisecond)
+	timing.Add("db", 1500*time.Microsecond)
+
+	if d, ok := timing.Duration("db"); !ok || d != 3500*time.Microsecond {
+		t.Errorf("expected the queries to add up to 3.5ms, got %s", d)
+	}
+
+	header := timing.Header()
+	if !strings.HasPrefix(header, `db;dur=3.5;desc="2 calls", render;dur=4.0, total;dur=`) {
+		t.Errorf("expected the phases in the order they were recorded, got %q", header)
+	}
+
+	if s := timing.String(); !strings.Contains(s, "db 3.5ms (2 calls), render 4.0ms") {
+		t.Errorf("expected a breakdown for the log, got %q", s)
+	}
+}
+
+func TestTrack(t *testing.T) {
+	// without a timing in the context there is nothing to record to
+	Track(context.Background(), "cache")()
+
+	timing := New()
+	stop := Track(NewContext(context.Background(), timing), "cache")
+	time.Sleep(time.Millisecond)
+	stop()
+
+	if d, ok := timing.Duration("cache"); !ok || d < time.Millisecond {
+		t.Errorf("expected the cache phase to be tracked, got %s", d)
+	}
+}
@@ -0,0 +1,51 @@
+package gemquick
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/database"
+	"github.com/jimmitjoo/gemquick/servertiming"
+)
+
+func TestServerTiming(t *testing.T) {
+	var logged bytes.Buffer
+	g := &Gemquick{Debug: true, InfoLog: log.New(&logged, "", 0)}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queryTiming{}.AfterQuery(r.Context(), &database.QueryEvent{Duration: 3 * time.Millisecond})
+		queryTiming{}.AfterQuery(r.Context(), &database.QueryEvent{Duration: 2 * time.Millisecond})
+
+		stop := servertiming.Track(r.Context(), "cache")
+		stop()
+
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	rr := httptest.NewRecorder()
+	g.ServerTiming(handlerTiming(handler)).ServeHTTP(rr, httptest.NewRequest("GET", "/orders", nil))
+
+	header := rr.Header().Get("Server-Timing")
+	for _, metric := range []string{"middleware;dur=", `db;dur=5.0;desc="2 calls"`, "cache;dur=", "handler;dur=", "total;dur="} {
+		if !strings.Contains(header, metric) {
+			t.Errorf("expected %s in the Server-Timing header, got %q", metric, header)
+		}
+	}
+
+	if !strings.Contains(logged.String(), "GET /orders total ") {
+		t.Errorf("expected the breakdown to be logged in debug mode, got %q", logged.String())
+	}
+
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/ws", nil)
+	req.Header.Set("Upgrade", "websocket")
+	g.ServerTiming(handler).ServeHTTP(rr, req)
+	if rr.Header().Get("Server-Timing") != "" {
+		t.Error("expected websocket upgrades not to be timed")
+	}
+}
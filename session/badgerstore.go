@@ -0,0 +1,86 @@
+package session
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// badgerPrefix starts the keys of sessions, so they can be listed apart from the cache
+var badgerPrefix = []byte("scs:session:")
+
+// BadgerStore keeps sessions in badger, the embedded database that CACHE=badger uses as well.
+// Badger expires them itself, so there is nothing to clean up
+type BadgerStore struct {
+	DB *badger.DB
+}
+
+// NewBadgerStore returns a store that keeps sessions in db
+func NewBadgerStore(db *badger.DB) *BadgerStore {
+	return &BadgerStore{DB: db}
+}
+
+// Find returns the data of the session with the token, and false when it does not exist or has
+// expired
+func (s *BadgerStore) Find(token string) ([]byte, bool, error) {
+	var b []byte
+	err := s.DB.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(badgerKey(token))
+		if err != nil {
+			return err
+		}
+
+		b, err = item.ValueCopy(nil)
+		return err
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	return b, true, nil
+}
+
+// Commit saves the session with the token until expiry, replacing it when it exists
+func (s *BadgerStore) Commit(token string, b []byte, expiry time.Time) error {
+	return s.DB.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(badger.NewEntry(badgerKey(token), b).WithTTL(time.Until(expiry)))
+	})
+}
+
+// Delete removes the session with the token
+func (s *BadgerStore) Delete(token string) error {
+	return s.DB.Update(func(txn *badger.Txn) error {
+		return txn.Delete(badgerKey(token))
+	})
+}
+
+// All returns the data of the sessions that have not expired, by token
+func (s *BadgerStore) All() (map[string][]byte, error) {
+	sessions := make(map[string][]byte)
+	err := s.DB.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = badgerPrefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(badgerPrefix); it.ValidForPrefix(badgerPrefix); it.Next() {
+			item := it.Item()
+			b, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			sessions[string(item.Key()[len(badgerPrefix):])] = b
+		}
+
+		return nil
+	})
+
+	return sessions, err
+}
+
+func badgerKey(token string) []byte {
+	return append(append(make([]byte, 0, len(badgerPrefix)+len(token)), badgerPrefix...), token...)
+}
@@ -0,0 +1,43 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+func TestBadgerStore(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	store := NewBadgerStore(db)
+	expiry := time.Now().Add(time.Hour)
+
+	if _, found, err := store.Find("token"); found || err != nil {
+		t.Errorf("expected no session, got %v, %v", found, err)
+	}
+
+	_ = store.Commit("token", []byte("userID=7"), expiry)
+	_ = store.Commit("other", []byte("userID=8"), expiry)
+	_ = db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("cached"), []byte("not a session"))
+	})
+
+	if b, found, err := store.Find("token"); !found || err != nil || string(b) != "userID=7" {
+		t.Errorf("expected the session, got %q, %v, %v", b, found, err)
+	}
+
+	all, err := store.All()
+	if err != nil || len(all) != 2 || string(all["other"]) != "userID=8" {
+		t.Errorf("expected both sessions and nothing else, got %q, %v", all, err)
+	}
+
+	_ = store.Delete("token")
+	if _, found, _ := store.Find("token"); found {
+		t.Error("expected the session to be deleted")
+	}
+}
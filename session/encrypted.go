@@ -0,0 +1,165 @@
+package session
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+)
+
+// encryptedPrefix starts the data of encrypted sessions, followed by the id of the key and the
+// encrypted data. Data without it was stored before encryption was turned on, or written into the
+// store by someone else
+var encryptedPrefix = []byte("gqenc1:")
+
+// EncryptedStore encrypts the data of sessions with AES-GCM before it reaches Store, so that a
+// dump of redis, badger or the sessions table does not give away what is in them. The data is
+// bound to its token, so it cannot be moved to another session. It is stored with the id of the
+// key, so sessions encrypted with a previous key can still be read after KEY is rotated
+type EncryptedStore struct {
+	Store scs.Store
+	// PlaintextUntil reads the sessions stored before encryption was turned on as they are until
+	// then, and encrypts them the next time they are saved, so that users stay logged in while it
+	// is turned on. They are not found when it is zero or past, since anyone who can write to the
+	// store could otherwise plant a session that is read as it is
+	PlaintextUntil time.Time
+
+	current string
+	aeads   map[string]cipher.AEAD
+}
+
+// NewEncryptedStore returns a store that encrypts the sessions of store with key, and decrypts
+// them with key or one of the previous keys
+func NewEncryptedStore(store scs.Store, key string, previous ...string) (*EncryptedStore, error) {
+	if key == "" {
+		return nil, errors.New("encrypting sessions needs a key")
+	}
+
+	s := &EncryptedStore{Store: store, aeads: map[string]cipher.AEAD{}}
+	for i, k := range append([]string{key}, previous...) {
+		if k == "" {
+			continue
+		}
+
+		id, aead, err := sessionCipher(k)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			s.current = id
+		}
+		if _, ok := s.aeads[id]; !ok {
+			s.aeads[id] = aead
+		}
+	}
+
+	return s, nil
+}
+
+// sessionCipher returns the cipher of the sessions for key, keyed with a hash of it so that keys
+// of any length work, and the id it is known by
+func sessionCipher(key string) (string, cipher.AEAD, error) {
+	sum := sha256.Sum256([]byte("gemquick session " + key))
+	id := sha256.Sum256(sum[:])
+
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return "", nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return hex.EncodeToString(id[:4]), aead, nil
+}
+
+// Find returns the decrypted data of the session with the token. Sessions that cannot be
+// decrypted, because their key is gone or they were tampered with, are not found, and the user
+// gets a new session
+func (s *EncryptedStore) Find(token string) ([]byte, bool, error) {
+	b, found, err := s.Store.Find(token)
+	if err != nil || !found {
+		return b, found, err
+	}
+
+	b, ok := s.decrypt(token, b)
+	return b, ok, nil
+}
+
+// Commit encrypts the data of the session with the current key before saving it
+func (s *EncryptedStore) Commit(token string, b []byte, expiry time.Time) error {
+	aead := s.aeads[s.current]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	data := make([]byte, 0, len(encryptedPrefix)+len(s.current)+1+len(nonce)+len(b)+aead.Overhead())
+	data = append(data, encryptedPrefix...)
+	data = append(data, s.current...)
+	data = append(data, ':')
+	data = append(data, nonce...)
+	data = aead.Seal(data, nonce, b, []byte(token))
+
+	return s.Store.Commit(token, data, expiry)
+}
+
+// Delete removes the session with the token
+func (s *EncryptedStore) Delete(token string) error {
+	return s.Store.Delete(token)
+}
+
+// All returns the decrypted data of the sessions, when Store can list them
+func (s *EncryptedStore) All() (map[string][]byte, error) {
+	iterable, ok := s.Store.(scs.IterableStore)
+	if !ok {
+		return nil, errors.New("the session store cannot list its sessions")
+	}
+
+	all, err := iterable.All()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make(map[string][]byte, len(all))
+	for token, b := range all {
+		if b, ok := s.decrypt(token, b); ok {
+			sessions[token] = b
+		}
+	}
+
+	return sessions, nil
+}
+
+func (s *EncryptedStore) decrypt(token string, b []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(b, encryptedPrefix) {
+		return b, time.Now().Before(s.PlaintextUntil)
+	}
+
+	id, sealed, ok := bytes.Cut(b[len(encryptedPrefix):], []byte(":"))
+	if !ok {
+		return nil, false
+	}
+
+	aead, ok := s.aeads[string(id)]
+	if !ok || len(sealed) < aead.NonceSize() {
+		return nil, false
+	}
+
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, []byte(token))
+	if err != nil {
+		return nil, false
+	}
+
+	return plain, true
+}
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2/memstore"
+)
+
+func TestEncryptedStore(t *testing.T) {
+	mem := memstore.NewWithCleanupInterval(0)
+	expiry := time.Now().Add(time.Hour)
+
+	store, err := NewEncryptedStore(mem, "old-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = store.Commit("token", []byte("userID=7"), expiry); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _, _ := mem.Find("token")
+	if bytes.Contains(raw, []byte("userID")) || !bytes.HasPrefix(raw, encryptedPrefix) {
+		t.Errorf("expected the session to be stored encrypted, got %q", raw)
+	}
+
+	b, found, err := store.Find("token")
+	if err != nil || !found || string(b) != "userID=7" {
+		t.Errorf("expected the session to be decrypted, got %q, %v, %v", b, found, err)
+	}
+
+	// data moved to another session does not decrypt
+	_ = mem.Commit("other", raw, expiry)
+	if _, found, _ = store.Find("other"); found {
+		t.Error("expected the data of another session not to be found")
+	}
+
+	// after a rotation the old key still decrypts, and new commits use the new key
+	rotated, err := NewEncryptedStore(mem, "new-key", "old-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, found, _ = rotated.Find("token"); !found || string(b) != "userID=7" {
+		t.Errorf("expected the previous key to decrypt the session, got %q, %v", b, found)
+	}
+
+	_ = rotated.Commit("token", []byte("userID=7"), expiry)
+	if _, found, _ = store.Find("token"); found {
+		t.Error("expected the session to be encrypted with the new key")
+	}
+
+	// sessions from before encryption are only read as they are while PlaintextUntil allows it
+	_ = mem.Commit("plain", []byte("userID=8"), expiry)
+	if _, found, _ = rotated.Find("plain"); found {
+		t.Error("expected an unencrypted session not to be found by default")
+	}
+
+	rotated.PlaintextUntil = time.Now().Add(-time.Minute)
+	if _, found, _ = rotated.Find("plain"); found {
+		t.Error("expected an unencrypted session not to be found after PlaintextUntil")
+	}
+
+	rotated.PlaintextUntil = time.Now().Add(time.Hour)
+	if b, found, _ = rotated.Find("plain"); !found || string(b) != "userID=8" {
+		t.Errorf("expected an unencrypted session to be read before PlaintextUntil, got %q, %v", b, found)
+	}
+
+	all, err := rotated.All()
+	if err != nil || len(all) != 2 || string(all["token"]) != "userID=7" {
+		t.Errorf("expected the decrypted sessions, got %q, %v", all, err)
+	}
+}
+
+func TestSession_InitSession_Encrypted(t *testing.T) {
+	s := &Session{SessionType: "sqlite", EncryptionKey: "secret"}
+
+	if _, ok := s.InitSession().Store.(*EncryptedStore); !ok {
+		t.Error("expected the sqlite store to be encrypted")
+	}
+
+	until := time.Now().Add(time.Hour)
+	s = &Session{SessionType: "badger", EncryptionKey: "secret", PlaintextUntil: until}
+	if store, ok := s.InitSession().Store.(*EncryptedStore); !ok || !store.PlaintextUntil.Equal(until) {
+		t.Error("expected the badger store to be encrypted, reading plaintext until the given time")
+	}
+
+	s = &Session{SessionType: "cookie", EncryptionKey: "secret"}
+	if _, ok := s.InitSession().Store.(*EncryptedStore); ok {
+		t.Error("expected the in-memory store not to be encrypted")
+	}
+}
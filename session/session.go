@@ -11,6 +11,7 @@ import (
 	"github.com/alexedwards/scs/postgresstore"
 	"github.com/alexedwards/scs/redisstore"
 	"github.com/alexedwards/scs/v2"
+	"github.com/dgraph-io/badger/v3"
 	"github.com/gomodule/redigo/redis"
 )
 
@@ -23,6 +24,13 @@ type Session struct {
 	CookieSecure   string
 	DBPool         *sql.DB
 	RedisPool      *redis.Pool
+	BadgerConn     *badger.DB
+	// EncryptionKey encrypts the sessions kept in redis, badger or a database when it is set, see
+	// EncryptedStore, PreviousKeys decrypt the ones encrypted before the key was rotated, and
+	// PlaintextUntil reads the ones stored before encryption was turned on until then
+	EncryptionKey  string
+	PreviousKeys   []string
+	PlaintextUntil time.Time
 }
 
 func (g *Session) InitSession() *scs.SessionManager {
@@ -55,16 +63,33 @@ func (g *Session) InitSession() *scs.SessionManager {
 	session.Cookie.SameSite = http.SameSiteLaxMode
 
 	// which session store?
+	var store scs.Store
 	switch strings.ToLower(g.SessionType) {
 	case "redis":
-		session.Store = redisstore.New(g.RedisPool)
+		store = redisstore.New(g.RedisPool)
 	case "mysql", "mariadb":
-		session.Store = mysqlstore.New(g.DBPool)
+		store = mysqlstore.New(g.DBPool)
 	case "postgres", "postgresql":
-		session.Store = postgresstore.New(g.DBPool)
+		store = postgresstore.New(g.DBPool)
+	case "sqlite", "sqlite3":
+		store = NewSQLiteStore(g.DBPool)
+	case "sqlserver", "mssql":
+		store = NewSQLServerStore(g.DBPool)
+	case "badger":
+		store = NewBadgerStore(g.BadgerConn)
 	default:
 		// cookie
 	}
 
+	if store != nil {
+		if g.EncryptionKey != "" {
+			if encrypted, err := NewEncryptedStore(store, g.EncryptionKey, g.PreviousKeys...); err == nil {
+				encrypted.PlaintextUntil = g.PlaintextUntil
+				store = encrypted
+			}
+		}
+		session.Store = store
+	}
+
 	return session
 }
@@ -0,0 +1,107 @@
+package session
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// SQLiteStore keeps sessions in the sessions table of a sqlite database, which the sqlite
+// session migration of the auth scaffolding creates. Expiry is stored as a julian day, so that
+// sqlite can compare it to the current time itself
+type SQLiteStore struct {
+	DB          *sql.DB
+	stopCleanup chan bool
+}
+
+// NewSQLiteStore returns a store that removes expired sessions every 5 minutes
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return NewSQLiteStoreWithCleanupInterval(db, 5*time.Minute)
+}
+
+// NewSQLiteStoreWithCleanupInterval returns a store that removes expired sessions every interval,
+// or never when interval is 0
+func NewSQLiteStoreWithCleanupInterval(db *sql.DB, interval time.Duration) *SQLiteStore {
+	s := &SQLiteStore{DB: db}
+	if interval > 0 {
+		s.stopCleanup = make(chan bool)
+		go s.startCleanup(interval)
+	}
+
+	return s
+}
+
+// Find returns the data of the session with the token, and false when it does not exist or has
+// expired
+func (s *SQLiteStore) Find(token string) ([]byte, bool, error) {
+	var b []byte
+	err := s.DB.QueryRow("SELECT data FROM sessions WHERE token = ? AND julianday('now') < expiry", token).Scan(&b)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	return b, true, nil
+}
+
+// Commit saves the session with the token, replacing its data and expiry when it exists
+func (s *SQLiteStore) Commit(token string, b []byte, expiry time.Time) error {
+	_, err := s.DB.Exec("REPLACE INTO sessions (token, data, expiry) VALUES (?, ?, julianday(?))", token, b, julianTime(expiry))
+	return err
+}
+
+// Delete removes the session with the token
+func (s *SQLiteStore) Delete(token string) error {
+	_, err := s.DB.Exec("DELETE FROM sessions WHERE token = ?", token)
+	return err
+}
+
+// All returns the data of the sessions that have not expired, by token
+func (s *SQLiteStore) All() (map[string][]byte, error) {
+	rows, err := s.DB.Query("SELECT token, data FROM sessions WHERE julianday('now') < expiry")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make(map[string][]byte)
+	for rows.Next() {
+		var token string
+		var data []byte
+		if err := rows.Scan(&token, &data); err != nil {
+			return nil, err
+		}
+		sessions[token] = data
+	}
+
+	return sessions, rows.Err()
+}
+
+// StopCleanup stops removing expired sessions, for stores that do not live as long as the app
+func (s *SQLiteStore) StopCleanup() {
+	if s.stopCleanup != nil {
+		s.stopCleanup <- true
+	}
+}
+
+func (s *SQLiteStore) startCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.DB.Exec("DELETE FROM sessions WHERE expiry < julianday('now')"); err != nil {
+				log.Println(err)
+			}
+		case <-s.stopCleanup:
+			return
+		}
+	}
+}
+
+// julianTime formats t as the utc time string sqlite's julianday function reads
+func julianTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05.000")
+}
@@ -0,0 +1,50 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	store := NewSQLiteStoreWithCleanupInterval(db, 0)
+	expiry := time.Date(2024, 5, 1, 12, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	mock.ExpectExec(`^REPLACE INTO sessions \(token, data, expiry\) VALUES \(\?, \?, julianday\(\?\)\)$`).
+		WithArgs("abc", []byte("data"), "2024-05-01 10:30:00.000").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := store.Commit("abc", []byte("data"), expiry); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(`^SELECT data FROM sessions WHERE token = \? AND julianday\('now'\) < expiry$`).
+		WithArgs("abc").
+		WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow([]byte("data")))
+	if b, found, err := store.Find("abc"); err != nil || !found || string(b) != "data" {
+		t.Errorf("expected the session to be found, got %q, %v, %v", b, found, err)
+	}
+
+	mock.ExpectQuery(`^SELECT data FROM sessions`).
+		WithArgs("expired").
+		WillReturnRows(sqlmock.NewRows([]string{"data"}))
+	if _, found, err := store.Find("expired"); err != nil || found {
+		t.Errorf("expected an expired session not to be found, got %v, %v", found, err)
+	}
+
+	mock.ExpectQuery(`^SELECT token, data FROM sessions`).
+		WillReturnRows(sqlmock.NewRows([]string{"token", "data"}).AddRow("abc", []byte("data")).AddRow("def", []byte("more")))
+	if all, err := store.All(); err != nil || len(all) != 2 || string(all["def"]) != "more" {
+		t.Errorf("expected both sessions, got %v, %v", all, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,104 @@
+package session
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// SQLServerStore keeps sessions in the sessions table of a sql server database, which the
+// sqlserver session migration of gq make session creates
+type SQLServerStore struct {
+	DB          *sql.DB
+	stopCleanup chan bool
+}
+
+// NewSQLServerStore returns a store that removes expired sessions every 5 minutes
+func NewSQLServerStore(db *sql.DB) *SQLServerStore {
+	return NewSQLServerStoreWithCleanupInterval(db, 5*time.Minute)
+}
+
+// NewSQLServerStoreWithCleanupInterval returns a store that removes expired sessions every
+// interval, or never when interval is 0
+func NewSQLServerStoreWithCleanupInterval(db *sql.DB, interval time.Duration) *SQLServerStore {
+	s := &SQLServerStore{DB: db}
+	if interval > 0 {
+		s.stopCleanup = make(chan bool)
+		go s.startCleanup(interval)
+	}
+
+	return s
+}
+
+// Find returns the data of the session with the token, and false when it does not exist or has
+// expired
+func (s *SQLServerStore) Find(token string) ([]byte, bool, error) {
+	var b []byte
+	err := s.DB.QueryRow("SELECT data FROM sessions WHERE token = @p1 AND SYSUTCDATETIME() < expiry", token).Scan(&b)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	return b, true, nil
+}
+
+// Commit saves the session with the token, replacing its data and expiry when it exists
+func (s *SQLServerStore) Commit(token string, b []byte, expiry time.Time) error {
+	_, err := s.DB.Exec(`MERGE sessions WITH (HOLDLOCK) AS s
+USING (SELECT @p1 AS token, @p2 AS data, @p3 AS expiry) AS n ON s.token = n.token
+WHEN MATCHED THEN UPDATE SET data = n.data, expiry = n.expiry
+WHEN NOT MATCHED THEN INSERT (token, data, expiry) VALUES (n.token, n.data, n.expiry);`, token, b, expiry.UTC())
+	return err
+}
+
+// Delete removes the session with the token
+func (s *SQLServerStore) Delete(token string) error {
+	_, err := s.DB.Exec("DELETE FROM sessions WHERE token = @p1", token)
+	return err
+}
+
+// All returns the data of the sessions that have not expired, by token
+func (s *SQLServerStore) All() (map[string][]byte, error) {
+	rows, err := s.DB.Query("SELECT token, data FROM sessions WHERE SYSUTCDATETIME() < expiry")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make(map[string][]byte)
+	for rows.Next() {
+		var token string
+		var data []byte
+		if err := rows.Scan(&token, &data); err != nil {
+			return nil, err
+		}
+		sessions[token] = data
+	}
+
+	return sessions, rows.Err()
+}
+
+// StopCleanup stops removing expired sessions, for stores that do not live as long as the app
+func (s *SQLServerStore) StopCleanup() {
+	if s.stopCleanup != nil {
+		s.stopCleanup <- true
+	}
+}
+
+func (s *SQLServerStore) startCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.DB.Exec("DELETE FROM sessions WHERE expiry < SYSUTCDATETIME()"); err != nil {
+				log.Println(err)
+			}
+		case <-s.stopCleanup:
+			return
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSQLServerStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	store := NewSQLServerStoreWithCleanupInterval(db, 0)
+	expiry := time.Date(2024, 5, 1, 12, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	mock.ExpectExec(`^MERGE sessions WITH \(HOLDLOCK\) AS s`).
+		WithArgs("abc", []byte("data"), expiry.UTC()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := store.Commit("abc", []byte("data"), expiry); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(`^SELECT data FROM sessions WHERE token = @p1 AND SYSUTCDATETIME\(\) < expiry$`).
+		WithArgs("abc").
+		WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow([]byte("data")))
+	if b, found, err := store.Find("abc"); err != nil || !found || string(b) != "data" {
+		t.Errorf("expected the session to be found, got %q, %v, %v", b, found, err)
+	}
+
+	mock.ExpectExec(`^DELETE FROM sessions WHERE token = @p1$`).
+		WithArgs("abc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := store.Delete("abc"); err != nil {
+		t.Error(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,207 @@
+// Package settings keeps the key-value settings of an application, like site.name, in the
+// database table settings. Reading them is served from memory, which is reloaded from the
+// table every TTL so that changes made by other instances of the app show up
+package settings
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/database"
+)
+
+// DefaultTTL is how long a Store serves settings from memory without a TTL of its own
+const DefaultTTL = time.Minute
+
+// Setting is a key with its value
+type Setting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Store reads and writes the settings in the table Table, settings when it is empty
+type Store struct {
+	DB       *sql.DB
+	DataType string
+	Table    string
+	// TTL is how long the settings are served from memory before they are loaded again
+	TTL time.Duration
+
+	mu     sync.RWMutex
+	values map[string]string
+	loaded time.Time
+}
+
+// New returns a store for the settings table of db, which is of the given DATABASE_TYPE
+func New(db *sql.DB, dataType string) *Store {
+	return &Store{DB: db, DataType: dataType}
+}
+
+// Get returns the value of key, and an empty string when it is not set or the settings cannot
+// be loaded
+func (s *Store) Get(key string) string {
+	value, _, _ := s.Lookup(key)
+	return value
+}
+
+// Lookup returns the value of key and whether it is set
+func (s *Store) Lookup(key string) (string, bool, error) {
+	err := s.load()
+	if err != nil {
+		return "", false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.values[key]
+
+	return value, ok, nil
+}
+
+// All returns every setting, sorted by key
+func (s *Store) All() ([]Setting, error) {
+	err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	all := make([]Setting, 0, len(s.values))
+	for key, value := range s.values {
+		all = append(all, Setting{Key: key, Value: value})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Key < all[j].Key })
+
+	return all, nil
+}
+
+// Set stores value for key, adding the setting when it is new in a single upsert
+func (s *Store) Set(key, value string) error {
+	if key == "" {
+		return errors.New("a setting needs a key")
+	}
+
+	now := time.Now()
+	row := map[string]interface{}{"name": key, "value": value, "created_at": now, "updated_at": now}
+	err := database.Upsert(context.Background(), s.DB, s.DataType, s.table(), row, []string{"name"}, []string{"value", "updated_at"})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.values != nil {
+		s.values[key] = value
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Delete removes the setting key
+func (s *Store) Delete(key string) error {
+	_, err := s.DB.Exec(s.query("DELETE FROM %s WHERE name = ?"), key)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.values, key)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Refresh loads the settings from the table now, instead of when the TTL runs out
+func (s *Store) Refresh() error {
+	rows, err := s.DB.Query(s.query("SELECT name, value FROM %s"))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	values := map[string]string{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return err
+		}
+		values[key] = value
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.values = values
+	s.loaded = time.Now()
+	s.mu.Unlock()
+
+	return nil
+}
+
+// load refreshes the settings when they have not been loaded within the TTL
+func (s *Store) load() error {
+	ttl := s.TTL
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+
+	s.mu.RLock()
+	fresh := s.values != nil && time.Since(s.loaded) < ttl
+	s.mu.RUnlock()
+
+	if fresh {
+		return nil
+	}
+
+	return s.Refresh()
+}
+
+// table is the name of the settings table
+func (s *Store) table() string {
+	if s.Table == "" {
+		return "settings"
+	}
+
+	return s.Table
+}
+
+// query puts the table into a query and numbers its placeholders for postgres
+func (s *Store) query(format string) string {
+	return database.Rebind(s.DataType, fmt.Sprintf(format, s.table()))
+}
+
+var std struct {
+	sync.RWMutex
+	store *Store
+}
+
+// Use makes s the store that Get reads from. Gemquick uses the app's store when it boots
+func Use(s *Store) {
+	std.Lock()
+	std.store = s
+	std.Unlock()
+}
+
+// Get returns the value of key from the store given to Use, e.g. settings.Get("site.name"), and
+// an empty string when it is not set or there is no store
+func Get(key string) string {
+	std.RLock()
+	s := std.store
+	std.RUnlock()
+
+	if s == nil {
+		return ""
+	}
+
+	return s.Get(key)
+}
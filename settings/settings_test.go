@@ -0,0 +1,136 @@
+package settings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func newStore(t *testing.T) (*Store, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return New(db, "pgx"), mock
+}
+
+func TestStore_Get(t *testing.T) {
+	s, mock := newStore(t)
+
+	mock.ExpectQuery(`SELECT name, value FROM settings`).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "value"}).AddRow("site.name", "Shop"))
+
+	if got := s.Get("site.name"); got != "Shop" {
+		t.Errorf("expected Shop, got %q", got)
+	}
+
+	// served from memory, the mock would fail a second query
+	if _, ok, err := s.Lookup("site.missing"); ok || err != nil {
+		t.Errorf("expected a missing setting without error, got %v, %v", ok, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStore_TTL(t *testing.T) {
+	s, mock := newStore(t)
+	s.TTL = time.Nanosecond
+
+	mock.ExpectQuery(`SELECT name, value FROM settings`).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "value"}).AddRow("site.name", "Shop"))
+	mock.ExpectQuery(`SELECT name, value FROM settings`).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "value"}).AddRow("site.name", "Store"))
+
+	s.Get("site.name")
+	time.Sleep(time.Millisecond)
+
+	if got := s.Get("site.name"); got != "Store" {
+		t.Errorf("expected the settings to be loaded again after the TTL, got %q", got)
+	}
+}
+
+func TestStore_Set(t *testing.T) {
+	s, mock := newStore(t)
+
+	mock.ExpectQuery(`SELECT name, value FROM settings`).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "value"}))
+	mock.ExpectExec(`INSERT INTO settings \(created_at, name, updated_at, value\) VALUES \(\$1, \$2, \$3, \$4\) ON CONFLICT \(name\) DO UPDATE SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at$`).
+		WithArgs(sqlmock.AnyArg(), "site.name", sqlmock.AnyArg(), "Shop").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`DELETE FROM settings WHERE name = \$1`).
+		WithArgs("site.name").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if all, err := s.All(); err != nil || len(all) != 0 {
+		t.Fatalf("expected no settings, got %v, %v", all, err)
+	}
+
+	if err := s.Set("site.name", "Shop"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := s.Get("site.name"); got != "Shop" {
+		t.Errorf("expected the new value to be served from memory, got %q", got)
+	}
+
+	if err := s.Delete("site.name"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, _ := s.Lookup("site.name"); ok {
+		t.Error("expected the setting to be gone")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStore_Set_MySQL(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	s := New(db, "mysql")
+
+	// mysql reports no affected rows when the value did not change, which must not turn into an
+	// insert of a key that exists
+	mock.ExpectExec(`INSERT INTO settings \(created_at, name, updated_at, value\) VALUES \(\?, \?, \?, \?\) ON DUPLICATE KEY UPDATE value = VALUES\(value\), updated_at = VALUES\(updated_at\)$`).
+		WithArgs(sqlmock.AnyArg(), "site.name", sqlmock.AnyArg(), "Shop").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := s.Set("site.name", "Shop"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGet(t *testing.T) {
+	Use(nil)
+	if got := Get("site.name"); got != "" {
+		t.Errorf("expected nothing without a store, got %q", got)
+	}
+
+	s, mock := newStore(t)
+	mock.ExpectQuery(`SELECT name, value FROM settings`).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "value"}).AddRow("site.name", "Shop"))
+
+	Use(s)
+	defer Use(nil)
+
+	if got := Get("site.name"); got != "Shop" {
+		t.Errorf("expected Shop, got %q", got)
+	}
+}
@@ -0,0 +1,52 @@
+package gemquick
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/honeypot"
+	"github.com/jimmitjoo/gemquick/ratelimit"
+)
+
+// createHoneypot returns the trap of SpamFilter, signed with KEY. HONEYPOT_FIELD renames the
+// honeypot field and HONEYPOT_MIN_FILL changes the time a person takes at least to fill in a form
+func (g *Gemquick) createHoneypot() *honeypot.Trap {
+	trap := honeypot.New(g.EncryptionKey)
+
+	if field := os.Getenv("HONEYPOT_FIELD"); field != "" {
+		trap.Field = field
+	}
+
+	if minFill, err := time.ParseDuration(os.Getenv("HONEYPOT_MIN_FILL")); err == nil {
+		trap.MinFill = minFill
+	}
+
+	return trap
+}
+
+// SpamFilter protects public forms, like a contact form, from bots. The forms include the fields
+// of app.Honeypot, which templates get as .Honeypot, and submissions that fill in the honeypot,
+// come too fast or have no signed render time are dropped, or passed on flagged with
+// honeypot.Flagged in honeypot.Flag mode. Spam is logged and counted in the spam_dropped and
+// spam_flagged metrics, and the IPs that sent it are suspected in app.Reputation
+func (g *Gemquick) SpamFilter(mode honeypot.Mode) func(http.Handler) http.Handler {
+	metric := "spam_dropped"
+	if mode == honeypot.Flag {
+		metric = "spam_flagged"
+	}
+
+	return func(next http.Handler) http.Handler {
+		if g.Honeypot == nil {
+			return next
+		}
+
+		return g.Honeypot.Middleware(mode, func(r *http.Request, reason string) {
+			g.InfoLog.Printf("spam submitted to %s from %s: %s", r.URL.Path, ratelimit.ByIP(r), reason)
+			g.suspect(ratelimit.ByIP(r))
+			if g.Metrics != nil {
+				g.Metrics.Counter(metric).Inc()
+			}
+		})(next)
+	}
+}
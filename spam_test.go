@@ -0,0 +1,29 @@
+package gemquick
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jimmitjoo/gemquick/honeypot"
+	"github.com/jimmitjoo/gemquick/metrics"
+)
+
+func TestSpamFilter(t *testing.T) {
+	g := &Gemquick{InfoLog: log.New(io.Discard, "", 0), Metrics: metrics.New(), Honeypot: honeypot.New("secret")}
+
+	handler := g.SpamFilter(honeypot.Drop)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expected spam not to reach the handler")
+	}))
+
+	req := httptest.NewRequest("POST", "/contact", strings.NewReader("website=http://spam.test"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if n := g.Metrics.Counter("spam_dropped").Value(); n != 1 {
+		t.Errorf("expected the spam to be counted, got %d", n)
+	}
+}
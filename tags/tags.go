@@ -0,0 +1,238 @@
+// Package tags tags models of any type, like posts tagged go and web, in the taggables table.
+// Models are polymorphic references, a type and an id, so one table holds the tags of every model
+package tags
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jimmitjoo/gemquick/database"
+)
+
+// Store keeps the tags in the table Table, taggables when it is empty. Models are given as a
+// database.Ref or as a model with an ID, see database.RefTo
+type Store struct {
+	DB       *sql.DB
+	DataType string
+	Table    string
+}
+
+// New returns a store for the taggables table of db, which is of the given DATABASE_TYPE
+func New(db *sql.DB, dataType string) *Store {
+	return &Store{DB: db, DataType: dataType}
+}
+
+// Tag adds tags to model, keeping the ones it already has
+func (s *Store) Tag(ctx context.Context, model interface{}, tags ...string) error {
+	ref, err := database.RefTo(model)
+	if err != nil {
+		return err
+	}
+
+	return s.insert(ctx, s.DB, ref, Normalize(tags))
+}
+
+// Untag removes tags from model
+func (s *Store) Untag(ctx context.Context, model interface{}, tags ...string) error {
+	ref, err := database.RefTo(model)
+	if err != nil {
+		return err
+	}
+
+	tags = Normalize(tags)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	where, args := in("tag", tags)
+	_, err = s.DB.ExecContext(ctx, s.query("DELETE FROM %s WHERE taggable_type = ? AND taggable_id = ? AND "+where),
+		append([]interface{}{ref.Type, ref.ID}, args...)...)
+
+	return err
+}
+
+// Sync makes tags the only tags of model
+func (s *Store) Sync(ctx context.Context, model interface{}, tags ...string) error {
+	ref, err := database.RefTo(model)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.ExecContext(ctx, s.query("DELETE FROM %s WHERE taggable_type = ? AND taggable_id = ?"), ref.Type, ref.ID)
+	if err != nil {
+		return err
+	}
+
+	err = s.insert(ctx, tx, ref, Normalize(tags))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Of returns the tags of model, sorted
+func (s *Store) Of(ctx context.Context, model interface{}) ([]string, error) {
+	ref, err := database.RefTo(model)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.DB.QueryContext(ctx, s.query("SELECT tag FROM %s WHERE taggable_type = ? AND taggable_id = ? ORDER BY tag"), ref.Type, ref.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// Count is a tag with the number of models it is on
+type Count struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+// Popular returns the limit most used tags on models of type modelType, e.g. post, for tag clouds
+func (s *Store) Popular(ctx context.Context, modelType string, limit int) ([]Count, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		s.query(fmt.Sprintf("SELECT tag, COUNT(*) AS uses FROM %%s WHERE taggable_type = ? GROUP BY tag ORDER BY uses DESC, tag LIMIT %d", limit)),
+		modelType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []Count{}
+	for rows.Next() {
+		var c Count
+		if err := rows.Scan(&c.Tag, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
+
+// WhereTagged returns the condition and arguments that select the models of type modelType with
+// any of tags, to add to a query of their table with ? placeholders:
+//
+//	where, args := app.Tags.WhereTagged("post", "go")
+//	rows, err := db.Query(database.Rebind(dataType, "SELECT * FROM posts WHERE "+where), args...)
+func (s *Store) WhereTagged(modelType string, tags ...string) (string, []interface{}) {
+	return s.whereTagged(modelType, tags, false)
+}
+
+// WhereTaggedAll is WhereTagged for the models that have every one of tags
+func (s *Store) WhereTaggedAll(modelType string, tags ...string) (string, []interface{}) {
+	return s.whereTagged(modelType, tags, true)
+}
+
+func (s *Store) whereTagged(modelType string, tags []string, all bool) (string, []interface{}) {
+	tags = Normalize(tags)
+	if len(tags) == 0 {
+		return "1 = 0", nil
+	}
+
+	where, args := in("tag", tags)
+	subquery := fmt.Sprintf("SELECT taggable_id FROM %s WHERE taggable_type = ? AND %s", s.table(), where)
+	if all {
+		subquery += fmt.Sprintf(" GROUP BY taggable_id HAVING COUNT(*) = %d", len(tags))
+	}
+
+	return "id IN (" + subquery + ")", append([]interface{}{modelType}, args...)
+}
+
+// FromRequest reads the tags of a form, given as tags=go&tags=web or as tags=go,web
+func FromRequest(r *http.Request) []string {
+	_ = r.ParseForm()
+
+	var tags []string
+	for _, value := range r.Form["tags"] {
+		tags = append(tags, strings.Split(value, ",")...)
+	}
+
+	return Normalize(tags)
+}
+
+// Normalize trims and lower cases tags and drops the empty and repeated ones
+func Normalize(tags []string) []string {
+	seen := map[string]bool{}
+	normalized := []string{}
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	sort.Strings(normalized)
+
+	return normalized
+}
+
+// insert adds the tags to ref, skipping the ones it has
+func (s *Store) insert(ctx context.Context, db database.Execer, ref database.Ref, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([]map[string]interface{}, len(tags))
+	for i, tag := range tags {
+		rows[i] = map[string]interface{}{"tag": tag, "taggable_type": ref.Type, "taggable_id": ref.ID, "created_at": now}
+	}
+
+	_, err := database.UpsertMany(ctx, db, s.DataType, s.table(), rows, []string{"tag", "taggable_type", "taggable_id"}, nil, 0)
+
+	return err
+}
+
+func (s *Store) table() string {
+	if s.Table == "" {
+		return "taggables"
+	}
+
+	return s.Table
+}
+
+// query puts the table into a query and numbers its placeholders for postgres
+func (s *Store) query(format string) string {
+	return database.Rebind(s.DataType, fmt.Sprintf(format, s.table()))
+}
+
+// in returns column IN (?, ?, ...) with an argument for each value
+func in(column string, values []string) (string, []interface{}) {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+
+	return column + " IN (?" + strings.Repeat(", ?", len(values)-1) + ")", args
+}
@@ -0,0 +1,92 @@
+package tags
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jimmitjoo/gemquick/database"
+)
+
+type post struct {
+	ID int
+}
+
+func newStore(t *testing.T, dataType string) (*Store, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return New(db, dataType), mock
+}
+
+func TestStore_Tag(t *testing.T) {
+	s, mock := newStore(t, "pgx")
+
+	mock.ExpectExec(`INSERT INTO taggables \(created_at, tag, taggable_id, taggable_type\) VALUES \(\$1, \$2, \$3, \$4\), \(\$5, \$6, \$7, \$8\) ON CONFLICT \(tag, taggable_type, taggable_id\) DO NOTHING`).
+		WithArgs(sqlmock.AnyArg(), "go", int64(7), "post", sqlmock.AnyArg(), "web", int64(7), "post").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	if err := s.Tag(context.Background(), &post{ID: 7}, " Web", "go", "GO"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStore_Sync(t *testing.T) {
+	s, mock := newStore(t, "mysql")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM taggables WHERE taggable_type = \? AND taggable_id = \?`).
+		WithArgs("post", int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`INSERT INTO taggables .* ON DUPLICATE KEY UPDATE tag = tag`).
+		WithArgs(sqlmock.AnyArg(), "go", int64(7), "post").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := s.Sync(context.Background(), database.Ref{Type: "post", ID: 7}, "go"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStore_WhereTagged(t *testing.T) {
+	s, _ := newStore(t, "mysql")
+
+	where, args := s.WhereTagged("post", "go", "web")
+	expected := "id IN (SELECT taggable_id FROM taggables WHERE taggable_type = ? AND tag IN (?, ?))"
+	if where != expected || !reflect.DeepEqual(args, []interface{}{"post", "go", "web"}) {
+		t.Errorf("unexpected condition %q with %v", where, args)
+	}
+
+	where, _ = s.WhereTaggedAll("post", "go", "web")
+	if !strings.HasSuffix(where, "GROUP BY taggable_id HAVING COUNT(*) = 2)") {
+		t.Errorf("expected every tag to be required, got %q", where)
+	}
+
+	if where, args = s.WhereTagged("post"); where != "1 = 0" || args != nil {
+		t.Errorf("expected no tags to match nothing, got %q", where)
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/posts?tags=go,web&tags=Go&tags=", nil)
+
+	if tags := FromRequest(r); !reflect.DeepEqual(tags, []string{"go", "web"}) {
+		t.Errorf("expected go and web, got %v", tags)
+	}
+}
@@ -0,0 +1,132 @@
+package gemquick
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type timeoutKey struct{}
+
+// requestTimer is shared through the request context, so a Timeout on a route can move the
+// deadline set by the global REQUEST_TIMEOUT, which runs before the route is known
+type requestTimer struct {
+	start time.Time
+	timer *time.Timer
+}
+
+func (rt *requestTimer) reset(d time.Duration) {
+	rt.timer.Reset(d - time.Since(rt.start))
+}
+
+// Timeout cancels the request context after d and answers 503 Service Unavailable if the handler has
+// not finished by then. Used on a route, e.g. r.With(gemquick.Timeout(5*time.Minute)).Get("/report", ...),
+// it replaces the global REQUEST_TIMEOUT for that route, counted from when the request came in.
+// The response is buffered until the handler returns, so don't use it for streaming handlers.
+// Websocket upgrades are passed through, since the connection outlives the request
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if rt, ok := r.Context().Value(timeoutKey{}).(*requestTimer); ok {
+				rt.reset(d)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			serveWithTimeout(d, next, w, r)
+		})
+	}
+}
+
+func serveWithTimeout(d time.Duration, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancelCause(r.Context())
+	defer cancel(nil)
+
+	rt := &requestTimer{start: time.Now()}
+	rt.timer = time.AfterFunc(d, func() { cancel(context.DeadlineExceeded) })
+	defer rt.timer.Stop()
+
+	tw := &timeoutWriter{header: make(http.Header)}
+	done := make(chan struct{})
+	panicked := make(chan interface{}, 1)
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicked <- p
+			}
+		}()
+		next.ServeHTTP(tw, r.WithContext(context.WithValue(ctx, timeoutKey{}, rt)))
+		close(done)
+	}()
+
+	select {
+	case p := <-panicked:
+		panic(p)
+	case <-done:
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+
+		for k, v := range tw.header {
+			w.Header()[k] = v
+		}
+		if tw.code == 0 {
+			tw.code = http.StatusOK
+		}
+		w.WriteHeader(tw.code)
+		_, _ = w.Write(tw.buf.Bytes())
+	case <-ctx.Done():
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+
+		tw.timedOut = true
+		if context.Cause(ctx) == context.DeadlineExceeded {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		}
+	}
+}
+
+// timeoutWriter holds the response until the handler is done, and throws it away after a timeout
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
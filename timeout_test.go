@@ -0,0 +1,43 @@
+package gemquick
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(50 * time.Millisecond):
+			w.Header().Set("X-Done", "yes")
+			w.WriteHeader(http.StatusCreated)
+		}
+	})
+
+	var tests = []struct {
+		name     string
+		handler  http.Handler
+		expected int
+	}{
+		{"within timeout", Timeout(time.Second)(slow), http.StatusCreated},
+		{"timed out", Timeout(10 * time.Millisecond)(slow), http.StatusServiceUnavailable},
+		{"route extends global", Timeout(10 * time.Millisecond)(Timeout(time.Second)(slow)), http.StatusCreated},
+		{"route shortens global", Timeout(time.Second)(Timeout(10 * time.Millisecond)(slow)), http.StatusServiceUnavailable},
+	}
+
+	for _, e := range tests {
+		rr := httptest.NewRecorder()
+		e.handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+		if rr.Code != e.expected {
+			t.Errorf("%s: expected status %d, got %d", e.name, e.expected, rr.Code)
+		}
+
+		if e.expected == http.StatusCreated && rr.Header().Get("X-Done") != "yes" {
+			t.Errorf("%s: expected headers from the handler", e.name)
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package gemquick
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jimmitjoo/gemquick/database"
+)
 
 type initPaths struct {
 	rootPath    string
@@ -11,8 +16,6 @@ type cookieConfig struct {
 	name     string
 	lifetime string
 	persist  string
-	secure   string
-	domain   string
 }
 
 type databaseConfig struct {
@@ -24,6 +27,22 @@ type Database struct {
 	DataType    string
 	Pool        *sql.DB
 	TablePrefix string
+	// Hooks are called around every statement Pool runs, see database.QueryHook
+	Hooks *database.Hooks
+	// Retry is how Transaction and WithRetry retry after transient errors
+	Retry database.Retry
+}
+
+// Transaction runs fn in a transaction on Pool and commits it, running the whole transaction
+// again after a deadlock or serialization failure, see database.Transient
+func (d Database) Transaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return d.Retry.Tx(ctx, d.Pool, nil, fn)
+}
+
+// WithRetry runs fn again after a deadlock, serialization failure or a connection that could not
+// be made. fn must be idempotent, like a single statement
+func (d Database) WithRetry(ctx context.Context, fn func(ctx context.Context) error) error {
+	return d.Retry.Do(ctx, fn)
 }
 
 type redisConfig struct {
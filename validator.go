@@ -1,13 +1,19 @@
 package gemquick
 
 import (
+	"encoding"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/asaskevich/govalidator"
+	"github.com/iancoleman/strcase"
 )
 
 type Validation struct {
@@ -92,3 +98,170 @@ func (v *Validation) NoSpaces(field, value string) {
 		v.AddError(field, "This field cannot contain spaces")
 	}
 }
+
+// Bind copies the submitted values into the fields of dst, which must be a pointer to a struct.
+// Fields are matched by their form tag, or by their snake_cased name when no tag is given.
+// Fields of a type that implements encoding.TextUnmarshaler, like the enums of gq make enum,
+// decode the value themselves
+func (v *Validation) Bind(dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("bind destination must be a pointer to a struct")
+	}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		key := fieldKey(field)
+		if key == "-" || !v.Data.Has(key) {
+			continue
+		}
+
+		value := strings.TrimSpace(v.Data.Get(key))
+		fv := rv.Field(i)
+
+		if u, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); ok && fv.Kind() != reflect.Struct {
+			if value != "" && u.UnmarshalText([]byte(value)) != nil {
+				v.AddError(key, "This field must be one of the allowed values")
+			}
+			continue
+		}
+
+		switch fv.Kind() {
+		case reflect.String:
+			fv.SetString(value)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if value == "" {
+				continue
+			}
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				v.AddError(key, "This field must be an integer")
+				continue
+			}
+			fv.SetInt(n)
+		case reflect.Float32, reflect.Float64:
+			if value == "" {
+				continue
+			}
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				v.AddError(key, "This field must be a floating point number")
+				continue
+			}
+			fv.SetFloat(f)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				b = value == "on"
+			}
+			fv.SetBool(b)
+		}
+	}
+
+	return nil
+}
+
+// ValidateStruct checks every field of data against the comma separated rules in its validate tag.
+// Supported rules are required, email, int, float, date, nospaces, min=n, max=n and enum, which
+// checks a field with an IsValid method, like the enums of gq make enum. A number or bool that was
+// submitted is required even when it is 0 or false, and the other rules skip blank fields
+func (v *Validation) ValidateStruct(data interface{}) {
+	rv := reflect.Indirect(reflect.ValueOf(data))
+	if rv.Kind() != reflect.Struct {
+		return
+	}
+
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		rules := field.Tag.Get("validate")
+		if rules == "" || !field.IsExported() {
+			continue
+		}
+
+		key := fieldKey(field)
+		fv := rv.Field(i)
+		value := fmt.Sprint(fv.Interface())
+
+		// a submitted 0 or false is a value, which only the form tells from a field left blank
+		blank := fv.IsZero() || strings.TrimSpace(value) == ""
+		if fv.Kind() != reflect.String && v.Data.Has(key) {
+			blank = strings.TrimSpace(v.Data.Get(key)) == ""
+		}
+
+		for _, rule := range strings.Split(rules, ",") {
+			name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+			if name != "required" && blank {
+				continue
+			}
+
+			switch name {
+			case "required":
+				v.Check(!blank, key, "This field cannot be blank")
+			case "email":
+				v.IsEmail(key, value)
+			case "int":
+				v.IsInt(key, value)
+			case "float":
+				v.IsFloat(key, value)
+			case "date":
+				v.IsDateISO(key, value)
+			case "nospaces":
+				v.NoSpaces(key, value)
+			case "min":
+				v.minMax(fv, key, param, true)
+			case "max":
+				v.minMax(fv, key, param, false)
+			case "enum":
+				if e, ok := fv.Interface().(interface{ IsValid() bool }); ok {
+					v.Check(e.IsValid(), key, "This field must be one of the allowed values")
+				}
+			}
+		}
+	}
+}
+
+func (v *Validation) minMax(fv reflect.Value, key, param string, min bool) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	var size float64
+	var message string
+
+	switch fv.Kind() {
+	case reflect.String:
+		size = float64(utf8.RuneCountInString(fv.String()))
+		message = "characters"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(fv.Int())
+	case reflect.Float32, reflect.Float64:
+		size = fv.Float()
+	default:
+		return
+	}
+
+	if min && size < limit {
+		v.AddError(key, strings.TrimSpace(fmt.Sprintf("This field must be at least %s %s", param, message)))
+	} else if !min && size > limit {
+		v.AddError(key, strings.TrimSpace(fmt.Sprintf("This field must be at most %s %s", param, message)))
+	}
+}
+
+func fieldKey(field reflect.StructField) string {
+	if tag := field.Tag.Get("form"); tag != "" {
+		return tag
+	}
+
+	return strcase.ToSnake(field.Name)
+}
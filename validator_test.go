@@ -0,0 +1,173 @@
+package gemquick
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+type testColor int
+
+const (
+	testColorRed testColor = iota + 1
+	testColorBlue
+)
+
+func (c testColor) IsValid() bool {
+	return c == testColorRed || c == testColorBlue
+}
+
+func (c *testColor) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "red":
+		*c = testColorRed
+	case "blue":
+		*c = testColorBlue
+	default:
+		return fmt.Errorf("%q is not a color", text)
+	}
+
+	return nil
+}
+
+func TestValidation_BindTextUnmarshaler(t *testing.T) {
+	var tests = []struct {
+		name     string
+		value    string
+		expected testColor
+		valid    bool
+	}{
+		{"known value", "blue", testColorBlue, true},
+		{"unknown value", "green", 0, false},
+		{"empty value", "", 0, true},
+	}
+
+	for _, e := range tests {
+		var form struct {
+			Color testColor `form:"color"`
+		}
+
+		v := &Validation{Data: url.Values{"color": {e.value}}, Errors: map[string]string{}}
+		if err := v.Bind(&form); err != nil {
+			t.Fatal(err)
+		}
+
+		if form.Color != e.expected || v.Valid() != e.valid {
+			t.Errorf("%s: expected %d and valid %t, got %d and %v", e.name, e.expected, e.valid, form.Color, v.Errors)
+		}
+	}
+}
+
+func TestValidation_ValidateStructEnum(t *testing.T) {
+	var tests = []struct {
+		name  string
+		color testColor
+		valid bool
+	}{
+		{"valid", testColorRed, true},
+		{"invalid", testColor(7), false},
+		{"unset", 0, true},
+	}
+
+	for _, e := range tests {
+		data := struct {
+			Color testColor `validate:"enum"`
+		}{e.color}
+
+		v := &Validation{Errors: map[string]string{}}
+		v.ValidateStruct(data)
+
+		if v.Valid() != e.valid {
+			t.Errorf("%s: expected valid %t, got %v", e.name, e.valid, v.Errors)
+		}
+	}
+}
+
+func TestValidation_Bind(t *testing.T) {
+	var form struct {
+		Name    string  `form:"name"`
+		Age     int     `form:"age"`
+		Price   float64 `form:"price"`
+		Terms   bool    `form:"terms"`
+		Active  bool    `form:"active"`
+		Count   int     `form:"count"`
+		Ignored string  `form:"-"`
+		private string
+	}
+	form.Count = 3
+
+	v := &Validation{Data: url.Values{
+		"name":    {"  Ada "},
+		"age":     {"36"},
+		"price":   {"9.5"},
+		"terms":   {"on"},
+		"active":  {"false"},
+		"count":   {""},
+		"ignored": {"x"},
+		"private": {"x"},
+	}, Errors: map[string]string{}}
+	if err := v.Bind(&form); err != nil {
+		t.Fatal(err)
+	}
+
+	if form.Name != "Ada" || form.Age != 36 || form.Price != 9.5 || !form.Terms || form.Active || form.Count != 3 || form.Ignored != "" || form.private != "" {
+		t.Errorf("unexpected bound values %+v", form)
+	}
+	if !v.Valid() {
+		t.Errorf("expected no errors, got %v", v.Errors)
+	}
+
+	v = &Validation{Data: url.Values{"age": {"old"}}, Errors: map[string]string{}}
+	if err := v.Bind(&form); err != nil || v.Errors["age"] == "" {
+		t.Errorf("expected an error for age, got %v, %v", v.Errors, err)
+	}
+
+	if err := v.Bind(form); err == nil {
+		t.Error("expected a struct that is not a pointer to be refused")
+	}
+}
+
+func TestValidation_ValidateStructRequired(t *testing.T) {
+	type form struct {
+		Name     string `form:"name" validate:"required"`
+		Quantity int    `form:"quantity" validate:"required,min=1"`
+		Terms    bool   `form:"terms" validate:"required"`
+	}
+
+	var tests = []struct {
+		name   string
+		data   url.Values
+		errors []string
+	}{
+		{"all submitted", url.Values{"name": {"Ada"}, "quantity": {"2"}, "terms": {"true"}}, nil},
+		{"zero and false submitted", url.Values{"name": {"Ada"}, "quantity": {"0"}, "terms": {"false"}}, []string{"quantity"}},
+		{"left out", url.Values{"name": {" "}}, []string{"name", "quantity", "terms"}},
+		{"blank", url.Values{"name": {"Ada"}, "quantity": {""}, "terms": {"true"}}, []string{"quantity"}},
+	}
+
+	for _, e := range tests {
+		var f form
+		v := &Validation{Data: e.data, Errors: map[string]string{}}
+		if err := v.Bind(&f); err != nil {
+			t.Fatal(err)
+		}
+		v.ValidateStruct(f)
+
+		if len(v.Errors) != len(e.errors) {
+			t.Errorf("%s: expected errors for %v, got %v", e.name, e.errors, v.Errors)
+			continue
+		}
+		for _, key := range e.errors {
+			if _, ok := v.Errors[key]; !ok {
+				t.Errorf("%s: expected an error for %s, got %v", e.name, key, v.Errors)
+			}
+		}
+	}
+
+	// without a form, a struct is checked by its values
+	v := &Validation{Errors: map[string]string{}}
+	v.ValidateStruct(form{Name: "Ada", Quantity: 2})
+	if _, ok := v.Errors["terms"]; !ok || len(v.Errors) != 1 {
+		t.Errorf("expected an error for terms only, got %v", v.Errors)
+	}
+}
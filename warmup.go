@@ -0,0 +1,116 @@
+package gemquick
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	warmupPending int32 = iota
+	warmupRunning
+	warmupDone
+	warmupFailed
+)
+
+// WarmupFunc prepares a component before the app takes traffic, e.g. priming a cache
+// or opening a connection to a provider
+type WarmupFunc func() error
+
+type warmupHook struct {
+	name string
+	fn   WarmupFunc
+}
+
+// OnWarmup registers fn to be run by Warmup. Hooks run in the order they were registered
+func (g *Gemquick) OnWarmup(name string, fn WarmupFunc) {
+	g.warmupHooks = append(g.warmupHooks, warmupHook{name: name, fn: fn})
+}
+
+// Warmup runs every registered warmup hook and logs how long each one took. Ready reports false
+// until all hooks have run without error. ListenAndServe calls Warmup in the background if it has
+// not been called yet, so call it yourself when the app should not accept connections before it is done.
+// Calling Warmup again after it failed runs the hooks that failed once more
+func (g *Gemquick) Warmup() error {
+	if !atomic.CompareAndSwapInt32(&g.warmupState, warmupPending, warmupRunning) &&
+		!atomic.CompareAndSwapInt32(&g.warmupState, warmupFailed, warmupRunning) {
+		return nil
+	}
+
+	hooks := g.warmupHooks
+	if g.warmupRetry != nil {
+		hooks = g.warmupRetry
+	}
+
+	start := time.Now()
+	var errs []error
+	var failed []warmupHook
+
+	for _, hook := range hooks {
+		hookStart := time.Now()
+
+		err := hook.fn()
+		if err != nil {
+			g.ErrorLog.Printf("warmup %s failed after %s: %v", hook.name, time.Since(hookStart), err)
+			errs = append(errs, fmt.Errorf("warmup %s: %w", hook.name, err))
+			failed = append(failed, hook)
+			continue
+		}
+
+		g.InfoLog.Printf("warmup %s took %s", hook.name, time.Since(hookStart))
+	}
+
+	if len(errs) > 0 {
+		g.warmupRetry = failed
+		atomic.StoreInt32(&g.warmupState, warmupFailed)
+		return errors.Join(errs...)
+	}
+
+	g.warmupRetry = nil
+	atomic.StoreInt32(&g.warmupState, warmupDone)
+	g.InfoLog.Printf("warmup finished in %s", time.Since(start))
+
+	return nil
+}
+
+// warmupUntilDone calls Warmup until every hook has succeeded, doubling the wait between attempts
+// starting at backoff, like OpenDBLazy does for the database
+func (g *Gemquick) warmupUntilDone(backoff time.Duration) {
+	wait := backoff
+	for g.Warmup() != nil {
+		g.ErrorLog.Printf("warmup failed, retrying in %s", wait)
+
+		time.Sleep(wait)
+		if wait < maxLazyBackoff {
+			wait *= 2
+		}
+	}
+}
+
+// warmupBackoff is the first wait between the attempts ListenAndServe makes to finish the warmup
+const warmupBackoff = time.Second
+
+func (g *Gemquick) warmedUp() bool {
+	return len(g.warmupHooks) == 0 || atomic.LoadInt32(&g.warmupState) == warmupDone
+}
+
+// registerWarmups adds the hooks for the services configured in New
+func (g *Gemquick) registerWarmups() {
+	// a lazily opened database reports its own readiness once it answers
+	if g.DB.Pool != nil && atomic.LoadInt32(&g.dbPending) == 0 {
+		g.OnWarmup("database", func() error {
+			return g.DB.Pool.Ping()
+		})
+	}
+
+	if redisPool != nil {
+		g.OnWarmup("redis", func() error {
+			conn := redisPool.Get()
+			defer conn.Close()
+
+			_, err := conn.Do("PING")
+			return err
+		})
+	}
+}
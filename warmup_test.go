@@ -0,0 +1,84 @@
+package gemquick
+
+import (
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+func newWarmupApp() *Gemquick {
+	return &Gemquick{
+		InfoLog:  log.New(io.Discard, "", 0),
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+}
+
+func TestWarmup_RetriesFailedHooks(t *testing.T) {
+	g := newWarmupApp()
+
+	var cacheRuns, providerRuns int
+	g.OnWarmup("cache", func() error {
+		cacheRuns++
+		return nil
+	})
+	g.OnWarmup("provider", func() error {
+		providerRuns++
+		if providerRuns == 1 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	if err := g.Warmup(); err == nil {
+		t.Fatal("expected the failing hook to fail the warmup")
+	}
+	if g.Ready() {
+		t.Error("expected the app not to be ready after a failed warmup")
+	}
+
+	if err := g.Warmup(); err != nil {
+		t.Fatalf("expected the second warmup to succeed, got %v", err)
+	}
+	if !g.Ready() {
+		t.Error("expected the app to be ready once every hook succeeded")
+	}
+
+	if cacheRuns != 1 || providerRuns != 2 {
+		t.Errorf("expected only the failed hook to run again, got cache %d and provider %d runs", cacheRuns, providerRuns)
+	}
+
+	if err := g.Warmup(); err != nil || providerRuns != 2 {
+		t.Errorf("expected a finished warmup not to run again, got %v after %d runs", err, providerRuns)
+	}
+}
+
+func TestWarmupUntilDone(t *testing.T) {
+	g := newWarmupApp()
+
+	runs := 0
+	g.OnWarmup("provider", func() error {
+		runs++
+		if runs < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		g.warmupUntilDone(time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the warmup to recover")
+	}
+
+	if runs != 3 || !g.Ready() {
+		t.Errorf("expected the app to be ready after 3 runs, got %d runs, ready %v", runs, g.Ready())
+	}
+}
@@ -0,0 +1,219 @@
+// Package websocket keeps track of websocket clients, grouped in channels, and delivers messages to them
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// Message is what the server and the clients send each other, encoded as JSON
+type Message struct {
+	Channel string          `json:"channel"`
+	Event   string          `json:"event"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// HandlerFunc is called for every message a client sends
+type HandlerFunc func(c *Client, msg Message)
+
+// Client is one open websocket connection
+type Client struct {
+	Request *http.Request
+	hub     *Hub
+	conn    *websocket.Conn
+	send    chan Message
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// Send queues a message for the client, and returns false if its queue is full or it has disconnected
+func (c *Client) Send(msg Message) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+
+	select {
+	case c.send <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+// Join subscribes the client to the broadcasts of channel
+func (c *Client) Join(channel string) {
+	c.hub.join(c, channel)
+}
+
+// Leave unsubscribes the client from the broadcasts of channel
+func (c *Client) Leave(channel string) {
+	c.hub.leave(c, channel)
+}
+
+// Hub holds the clients of every channel. Messages pushed onto Broadcasts are delivered
+// by ListenForBroadcasts, which is started by gemquick at boot
+type Hub struct {
+	Broadcasts chan Message
+	ErrorLog   *log.Logger
+	// CheckOrigin decides whether a connection is accepted, by default only from the same host
+	CheckOrigin func(r *http.Request) bool
+
+	mu       sync.RWMutex
+	channels map[string]map[*Client]bool
+}
+
+// New returns a hub that buffers size broadcasts
+func New(size int) *Hub {
+	return &Hub{
+		Broadcasts:  make(chan Message, size),
+		CheckOrigin: sameOrigin,
+		channels:    map[string]map[*Client]bool{},
+	}
+}
+
+// ListenForBroadcasts delivers the messages on Broadcasts to the clients of their channel
+func (h *Hub) ListenForBroadcasts() {
+	for msg := range h.Broadcasts {
+		h.deliver(msg)
+	}
+}
+
+// Broadcast encodes data as JSON and sends it to every client in channel
+func (h *Hub) Broadcast(channel, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	h.Broadcasts <- Message{Channel: channel, Event: event, Data: payload}
+
+	return nil
+}
+
+// Clients returns the number of clients in channel
+func (h *Hub) Clients(channel string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.channels[channel])
+}
+
+// Serve upgrades the request to a websocket, joins the client to channel and calls onMessage for every
+// message it sends until the connection closes. Messages without a channel are given the channel joined here
+func (h *Hub) Serve(w http.ResponseWriter, r *http.Request, channel string, onMessage HandlerFunc) {
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			if h.CheckOrigin != nil && !h.CheckOrigin(r) {
+				return fmt.Errorf("websocket: origin %q not allowed", r.Header.Get("Origin"))
+			}
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+			c := &Client{Request: r, hub: h, conn: conn, send: make(chan Message, 16)}
+			c.Join(channel)
+			defer h.remove(c)
+
+			go c.writeMessages()
+
+			for {
+				var msg Message
+				if err := websocket.JSON.Receive(conn, &msg); err != nil {
+					return
+				}
+
+				if msg.Channel == "" {
+					msg.Channel = channel
+				}
+
+				if onMessage != nil {
+					onMessage(c, msg)
+				}
+			}
+		},
+	}
+
+	server.ServeHTTP(w, r)
+}
+
+func (c *Client) writeMessages() {
+	for msg := range c.send {
+		if err := websocket.JSON.Send(c.conn, msg); err != nil {
+			_ = c.conn.Close()
+			return
+		}
+	}
+}
+
+func (h *Hub) deliver(msg Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for c := range h.channels[msg.Channel] {
+		if !c.Send(msg) && h.ErrorLog != nil {
+			h.ErrorLog.Printf("websocket: dropped message to slow client %s", c.Request.RemoteAddr)
+		}
+	}
+}
+
+func (h *Hub) join(c *Client, channel string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.channels[channel] == nil {
+		h.channels[channel] = map[*Client]bool{}
+	}
+	h.channels[channel][c] = true
+}
+
+func (h *Hub) leave(c *Client, channel string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.channels[channel], c)
+	if len(h.channels[channel]) == 0 {
+		delete(h.channels, channel)
+	}
+}
+
+// remove takes the client out of every channel and stops its writer
+func (h *Hub) remove(c *Client) {
+	h.mu.Lock()
+	for channel, clients := range h.channels {
+		delete(clients, c)
+		if len(clients) == 0 {
+			delete(h.channels, channel)
+		}
+	}
+	h.mu.Unlock()
+
+	c.mu.Lock()
+	c.closed = true
+	close(c.send)
+	c.mu.Unlock()
+
+	_ = c.conn.Close()
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return u.Host == r.Host
+}
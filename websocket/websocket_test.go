@@ -0,0 +1,98 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func dial(t *testing.T, srv *httptest.Server) *websocket.Conn {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	conn, err := websocket.Dial(url, "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return conn
+}
+
+func waitForClients(t *testing.T, h *Hub, channel string, n int) {
+	t.Helper()
+
+	for i := 0; i < 100 && h.Clients(channel) != n; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if h.Clients(channel) != n {
+		t.Fatalf("expected %d clients in %s, got %d", n, channel, h.Clients(channel))
+	}
+}
+
+func TestHub_Broadcast(t *testing.T) {
+	h := New(10)
+	go h.ListenForBroadcasts()
+	defer close(h.Broadcasts)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.Serve(w, r, "chat", func(c *Client, msg Message) {
+			h.Broadcasts <- msg
+		})
+	}))
+	defer srv.Close()
+
+	first, second := dial(t, srv), dial(t, srv)
+	waitForClients(t, h, "chat", 2)
+
+	if err := websocket.JSON.Send(first, Message{Event: "said", Data: []byte(`"hello"`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, conn := range []*websocket.Conn{first, second} {
+		var msg Message
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			t.Fatal(err)
+		}
+
+		if msg.Channel != "chat" || msg.Event != "said" || string(msg.Data) != `"hello"` {
+			t.Errorf("unexpected message %+v", msg)
+		}
+	}
+
+	_ = first.Close()
+	waitForClients(t, h, "chat", 1)
+
+	if err := h.Broadcast("chat", "left", map[string]int{"clients": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	var msg Message
+	_ = second.SetReadDeadline(time.Now().Add(time.Second))
+	if err := websocket.JSON.Receive(second, &msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(msg.Data) != `{"clients":1}` {
+		t.Errorf("unexpected data %s", msg.Data)
+	}
+}
+
+func TestHub_CheckOrigin(t *testing.T) {
+	h := New(1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.Serve(w, r, "chat", nil)
+	}))
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	if _, err := websocket.Dial(url, "", "http://evil.example.com"); err == nil {
+		t.Error("expected connection from another origin to be refused")
+	}
+}